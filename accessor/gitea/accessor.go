@@ -126,6 +126,12 @@ const (
 	// CloseIssueCommentType is an IssueComment reflecting closing an issue
 	CloseIssueCommentType IssueCommentType = 2
 
+	// IssueRefIssueCommentType is an IssueComment reflecting a reference to the issue from the description of another issue
+	IssueRefIssueCommentType IssueCommentType = 3
+
+	// CommentRefIssueCommentType is an IssueComment reflecting a reference to the issue from a comment on another issue
+	CommentRefIssueCommentType IssueCommentType = 5
+
 	// LabelIssueCommentType is an IssueComment reflecting a label change
 	LabelIssueCommentType IssueCommentType = 7
 
@@ -155,8 +161,11 @@ type IssueComment struct {
 	OldTitle           string
 	Title              string `gorm:"column:new_title"`
 	Text               string `gorm:"column:content"`
-	CreatedTime        int64  `gorm:"column:created_unix"`
-	Time               int64  `gorm:"column:updated_unix"`
+	RefRepoID          int64
+	RefIssueID         int64
+	RefCommentID       int64
+	CreatedTime        int64 `gorm:"column:created_unix"`
+	Time               int64 `gorm:"column:updated_unix"`
 }
 
 func (IssueComment) TableName() string {
@@ -215,8 +224,21 @@ type Accessor interface {
 	// GetIssueID retrieves the id of the Gitea issue corresponding to a given index - returns NullID if no such issue.
 	GetIssueID(issueIndex int64) (int64, error)
 
-	// AddIssue adds a new issue to Gitea - returns id of created issue.
-	AddIssue(issue *Issue) (int64, error)
+	// GetMaxIssueIndex retrieves the highest issue index (including pull requests) in use in the repository - returns 0 if there are no issues.
+	GetMaxIssueIndex() (int64, error)
+
+	// GetIssue retrieves a given Gitea issue - returns nil if no such issue.
+	GetIssue(issueID int64) (*Issue, error)
+
+	// GetIssueCreatedTime retrieves the creation time of a given issue.
+	GetIssueCreatedTime(issueID int64) (int64, error)
+
+	// AddIssue adds a new issue to Gitea - returns id of issue and whether it was written (false if it already exists and is not being overwritten).
+	AddIssue(issue *Issue) (int64, bool, error)
+
+	// DeleteIssue deletes an issue from Gitea together with its comments, labels, assignees, participants and attachments and any cross-reference comments it made in other issues.
+	// The files of any deleted attachments are only removed when the transaction is committed.
+	DeleteIssue(issueID int64) error
 
 	// SetIssueUpdateTime sets the update time on a given Gitea issue.
 	SetIssueUpdateTime(issueID int64, updateTime int64) error
@@ -224,14 +246,20 @@ type Accessor interface {
 	// SetIssueClosedTime sets the date/time a given Gitea issue was closed.
 	SetIssueClosedTime(issueID int64, updateTime int64) error
 
-	// GetIssueURL retrieves a URL for viewing a given issue
-	GetIssueURL(issueID int64) string
+	// GetIssueURL retrieves a URL for viewing the issue with a given index
+	GetIssueURL(issueIndex int64) string
+
+	// GetIssueListURL retrieves a URL for viewing the list of issues selected by a given (URL-encoded) issue filter query
+	GetIssueListURL(query string) string
 
 	// UpdateIssueCommentCount updates the count of comments a given issue
 	UpdateIssueCommentCount(issueID int64) error
 
 	// UpdateIssueIndex updates the issue_index table after adding a new issue
-	UpdateIssueIndex(issueID, ticketID int64) error
+	UpdateIssueIndex(issueID, issueIndex int64) error
+
+	// ResetIssueIndex resets the issue_index table to the highest index of any remaining issue after issues have been deleted
+	ResetIssueIndex() error
 
 	// UpdateIssueDescription updates the description of an existing issue in Gitea
 	UpdateIssueDescription(issueID int64, issueDescription string) error
@@ -242,12 +270,21 @@ type Accessor interface {
 	// AddIssueAssignee adds an assignee to a Gitea issue
 	AddIssueAssignee(issueID int64, assigneeID int64) error
 
+	// GetIssueAssignees retrieves the user names of all assignees of a given issue
+	GetIssueAssignees(issueID int64) ([]string, error)
+
 	/*
 	 * Issue Attachments
 	 */
 	// GetIssueAttachmentUUID returns the UUID for a named attachment of a given issue - returns empty string if cannot find issue/attachment.
 	GetIssueAttachmentUUID(issueID int64, fileName string) (string, error)
 
+	// GetIssueAttachments retrieves all attachments of a given issue
+	GetIssueAttachments(issueID int64) ([]IssueAttachment, error)
+
+	// GetIssueAttachmentFileSize returns the size of the file stored for the attachment with a given UUID - returns -1 if there is no such file.
+	GetIssueAttachmentFileSize(uuid string) (int64, error)
+
 	// AddIssueAttachment adds a new attachment to an issue using the provided file - returns id of created attachment
 	AddIssueAttachment(issueID int64, attachment *IssueAttachment, filePath string) (int64, error)
 
@@ -257,23 +294,32 @@ type Accessor interface {
 	/*
 	 * Issue Comments
 	 */
-	// GetIssueCommentIDByTime retrieves the ID of the comment created at a given time for a given issue.
+	// GetIssueCommentIDByTime retrieves the ID of the comment created at a given time for a given issue - returns NullID if no such comment.
 	// Since different issue changes can happen at the same time, this tries to return the "comment" type
 	// change, or falls back to another type by increasing IssueCommentType.
 	GetIssueCommentIDByTime(issueID int64, createdTime int64) (int64, error)
 
-	// AddIssueComment adds a comment on a Gitea issue, returns id of created comment
-	AddIssueComment(issueID int64, comment *IssueComment) (int64, error)
+	// AddIssueComment adds a comment on a Gitea issue, returns id of comment and whether it was written (false if it already exists and is not being overwritten)
+	AddIssueComment(issueID int64, comment *IssueComment) (int64, bool, error)
+
+	// UpdateIssueCommentText updates the text of an existing Gitea issue comment
+	UpdateIssueCommentText(issueCommentID int64, text string) error
 
 	// GetIssueCommentURL retrieves the URL for viewing a Gitea comment for a given issue.
 	GetIssueCommentURL(issueNumber int64, commentID int64) string
 
+	// GetIssueCommentCount retrieves the number of comments of a given type on a given issue.
+	GetIssueCommentCount(issueID int64, commentType IssueCommentType) (int64, error)
+
 	/*
 	 * Issue Labels
 	 */
 	// AddIssueLabel adds an issue label to Gitea, returns issue label ID
 	AddIssueLabel(issueID int64, labelID int64) (int64, error)
 
+	// GetIssueLabels retrieves the names of all labels of a given issue
+	GetIssueLabels(issueID int64) ([]string, error)
+
 	// UpdateLabelIssueCounts updates issue counts for all labels.
 	UpdateLabelIssueCounts() error
 
@@ -289,6 +335,9 @@ type Accessor interface {
 	// AddIssueParticipant adds a user as a participant in a Gitea issue
 	AddIssueParticipant(issueID int64, userID int64) error
 
+	// AddIssueMentionedParticipant adds a user mentioned in a Gitea issue or one of its comments as a participant in the issue
+	AddIssueMentionedParticipant(issueID int64, userID int64) error
+
 	/*
 	 * Labels
 	 */
@@ -298,6 +347,9 @@ type Accessor interface {
 	// AddLabel adds a label to Gitea, returns label id.
 	AddLabel(label *Label) (int64, error)
 
+	// DeleteUnusedLabel deletes a named label provided it is not used by any issue or issue comment - returns true if the label was deleted
+	DeleteUnusedLabel(labelName string) (bool, error)
+
 	/*
 	 * Milestones
 	 */
@@ -307,6 +359,9 @@ type Accessor interface {
 	// AddMilestone adds a milestone to Gitea,  returns id of created milestone
 	AddMilestone(milestone *Milestone) (int64, error)
 
+	// DeleteUnusedMilestone deletes a named milestone provided it is not used by any issue or issue comment - returns true if the milestone was deleted
+	DeleteUnusedMilestone(milestoneName string) (bool, error)
+
 	// GetMilestoneURL gets the URL for accessing a given milestone
 	GetMilestoneURL(milestoneID int64) string
 
@@ -322,9 +377,33 @@ type Accessor interface {
 	// GetCommitURL retrieves the URL for viewing a given commit in the current repository
 	GetCommitURL(commitID string) string
 
+	// GetCompareURL retrieves the URL for viewing the changes between two commits in the current repository
+	GetCompareURL(fromCommitID string, toCommitID string) string
+
 	// GetSourceURL retrieves the URL for viewing the latest version of a source file on a given branch of the current repository
 	GetSourceURL(branchPath string, filePath string) string
 
+	// GetCommitSourceURL retrieves the URL for viewing a source file as of a given commit in the current repository
+	GetCommitSourceURL(commitID string, filePath string) string
+
+	// GetRawSourceURL retrieves the URL for downloading the latest version of a source file on a given branch of the current repository
+	GetRawSourceURL(branchPath string, filePath string) string
+
+	// GetRawCommitSourceURL retrieves the URL for downloading a source file as of a given commit in the current repository
+	GetRawCommitSourceURL(commitID string, filePath string) string
+
+	// GetSourceLogURL retrieves the URL for viewing the commit history of a source file or directory on a given branch of the current repository
+	GetSourceLogURL(branchPath string, filePath string) string
+
+	// GetRepoIssueURL retrieves the URL for viewing the issue with a given index in another repository owned by the current user
+	GetRepoIssueURL(repoName string, issueIndex int64) string
+
+	// GetRepoIssueReference retrieves the Gitea reference ("owner/repo#N") to the issue with a given index in another repository owned by the current user
+	GetRepoIssueReference(repoName string, issueIndex int64) string
+
+	// GetRepoWikiURL retrieves the URL for viewing a wiki page in another repository owned by the current user
+	GetRepoWikiURL(repoName string, pageName string) string
+
 	/*
 	 * Transactions
 	 * - a transaction is started on creation of the accessor
@@ -364,6 +443,9 @@ type Accessor interface {
 	// GetWikiFileURL returns a URL for viewing a file stored in the Gitea wiki repository.
 	GetWikiFileURL(relpath string) string
 
+	// GetWikiPageURL returns a URL for viewing a given Gitea wiki page.
+	GetWikiPageURL(pageName string) string
+
 	// CloneWiki creates a local clone of the wiki repo.
 	CloneWiki() error
 
@@ -377,6 +459,16 @@ type Accessor interface {
 	// If a previous commit of the wiki page is found containing the provided marker string then the page will only be written if an explicit override has been provided.
 	WriteWikiPage(pageName string, markdownText string, commitMarker string) (bool, error)
 
+	// GetWikiPageNames returns the names of all pages in the cloned wiki
+	GetWikiPageNames() ([]string, error)
+
+	// GetImportedWikiFiles returns the paths (relative to the root of the wiki repository) of all files in the cloned wiki
+	// whose every commit has a commit message containing the provided marker.
+	GetImportedWikiFiles(commitMarker string) ([]string, error)
+
+	// DeleteWikiFile deletes a file from the cloned wiki, staging the deletion for the next commit.
+	DeleteWikiFile(relPath string) error
+
 	// TranslateWikiPageName translates a Trac wiki page name into a Gitea one
 	TranslateWikiPageName(pageName string) string
 }
@@ -42,6 +42,9 @@ type DefaultAccessor struct {
 	overwrite     bool
 	pushWiki      bool
 	dbOnly        bool
+
+	// UUIDs of attachments whose files are to be deleted when the transaction is committed
+	deletedAttachmentUUIDs []string
 }
 
 func fetchConfig(configPath string) (*ini.File, error) {
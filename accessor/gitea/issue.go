@@ -10,6 +10,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/stevejefferson/trac2gitea/log"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GetIssueID retrieves the id of the Gitea issue corresponding to a given issue index - returns NullID if no such issue.
@@ -30,6 +31,41 @@ func (accessor *DefaultAccessor) GetIssueID(issueIndex int64) (int64, error) {
 	return id, nil
 }
 
+// getMaxIssueIndex retrieves the highest index of any issue (including pull requests) in the repository - returns 0 if there are no issues.
+func (accessor *DefaultAccessor) getMaxIssueIndex() (int64, error) {
+	var maxIndex int64
+	err := accessor.db.Model(&Issue{}).
+		Where("repo_id=?", accessor.repoID).
+		// Since index is a reserved word in some DBs
+		Select("COALESCE(MAX(?),0)", clause.Column{Name: "index"}).
+		Scan(&maxIndex).Error
+	if err != nil {
+		return 0, errors.Wrapf(err, "retrieving maximum issue index for repository %d", accessor.repoID)
+	}
+
+	return maxIndex, nil
+}
+
+// GetMaxIssueIndex retrieves the highest issue index (including pull requests) in use in the repository - returns 0 if there are no issues.
+func (accessor *DefaultAccessor) GetMaxIssueIndex() (int64, error) {
+	maxIndex, err := accessor.getMaxIssueIndex()
+	if err != nil {
+		return 0, err
+	}
+
+	// issue_index may have been advanced beyond the highest index of any remaining issue (e.g. if issues have been deleted)
+	var issueIndex IssueIndex
+	err = accessor.db.Where("group_id=?", accessor.repoID).Limit(1).Find(&issueIndex).Error
+	if err != nil {
+		return 0, errors.Wrapf(err, "retrieving issue index for repository %d", accessor.repoID)
+	}
+	if issueIndex.MaxIndex > maxIndex {
+		maxIndex = issueIndex.MaxIndex
+	}
+
+	return maxIndex, nil
+}
+
 // updateIssue updates an existing issue in Gitea
 func (accessor *DefaultAccessor) updateIssue(issueID int64, issue *Issue) error {
 	milestoneID, err := accessor.GetMilestoneID(issue.Milestone)
@@ -70,27 +106,113 @@ func (accessor *DefaultAccessor) insertIssue(issue *Issue) (int64, error) {
 	return issue.ID, nil
 }
 
-// AddIssue adds a new issue to Gitea.
-func (accessor *DefaultAccessor) AddIssue(issue *Issue) (int64, error) {
+// AddIssue adds a new issue to Gitea, returning the id of the issue and whether it was written (false if it already exists and is not being overwritten).
+func (accessor *DefaultAccessor) AddIssue(issue *Issue) (int64, bool, error) {
 	issueID, err := accessor.GetIssueID(issue.Index)
 	if err != nil {
-		return NullID, err
+		return NullID, false, err
 	}
 
 	if issueID == NullID {
-		return accessor.insertIssue(issue)
-	}
-
-	if accessor.overwrite {
-		err = accessor.updateIssue(issueID, issue)
+		issueID, err = accessor.insertIssue(issue)
 		if err != nil {
-			return NullID, err
+			return NullID, false, err
 		}
-	} else {
+		return issueID, true, nil
+	}
+
+	if !accessor.overwrite {
 		log.Info("issue %d already exists - ignored", issue.Index)
+		return issueID, false, nil
+	}
+
+	err = accessor.updateIssue(issueID, issue)
+	if err != nil {
+		return NullID, false, err
+	}
+	return issueID, true, nil
+}
+
+// GetIssue retrieves a given Gitea issue - returns nil if no such issue.
+func (accessor *DefaultAccessor) GetIssue(issueID int64) (*Issue, error) {
+	var issues []Issue
+	err := accessor.db.Where("id=?", issueID).Limit(1).Find(&issues).Error
+	if err != nil {
+		err = errors.Wrapf(err, "retrieving issue %d", issueID)
+		return nil, err
+	}
+	if len(issues) == 0 {
+		return nil, nil
+	}
+
+	return &issues[0], nil
+}
+
+// GetIssueCreatedTime retrieves the creation time of a given issue.
+func (accessor *DefaultAccessor) GetIssueCreatedTime(issueID int64) (int64, error) {
+	var createdTime int64
+	err := accessor.db.Model(&Issue{}).
+		Where("id=?", issueID).
+		Limit(1).
+		Pluck("created_unix", &createdTime).Error
+
+	if err != nil && err != gorm.ErrRecordNotFound {
+		err = errors.Wrapf(err, "retrieving creation time of issue %d", issueID)
+		return 0, err
+	}
+
+	return createdTime, nil
+}
+
+// deleteIssueRows deletes all rows of a given model belonging to a given issue
+func (accessor *DefaultAccessor) deleteIssueRows(issueID int64, model interface{}, description string) error {
+	if err := accessor.db.Where("issue_id=?", issueID).Delete(model).Error; err != nil {
+		return errors.Wrapf(err, "deleting %s of issue %d", description, issueID)
 	}
 
-	return issueID, nil
+	return nil
+}
+
+// DeleteIssue deletes an issue from Gitea together with its comments, labels, assignees, participants and attachments
+// and any cross-reference comments it made in other issues.
+// The files of any deleted attachments are only removed when the transaction is committed.
+func (accessor *DefaultAccessor) DeleteIssue(issueID int64) error {
+	var attachmentUUIDs []string
+	err := accessor.db.Model(&IssueAttachment{}).
+		Where("issue_id=?", issueID).
+		Pluck("uuid", &attachmentUUIDs).Error
+	if err != nil {
+		return errors.Wrapf(err, "retrieving attachments of issue %d", issueID)
+	}
+
+	if err = accessor.deleteIssueRows(issueID, &IssueAttachment{}, "attachments"); err != nil {
+		return err
+	}
+	if err = accessor.deleteIssueRows(issueID, &IssueComment{}, "comments"); err != nil {
+		return err
+	}
+	// cross-reference comments made by the issue in other issues would otherwise refer to a deleted issue
+	if err = accessor.db.Where("ref_issue_id=?", issueID).Delete(&IssueComment{}).Error; err != nil {
+		return errors.Wrapf(err, "deleting cross-references made by issue %d", issueID)
+	}
+	if err = accessor.deleteIssueRows(issueID, &IssueLabel{}, "labels"); err != nil {
+		return err
+	}
+	if err = accessor.deleteIssueRows(issueID, &IssueAssignee{}, "assignees"); err != nil {
+		return err
+	}
+	if err = accessor.deleteIssueRows(issueID, &IssueUser{}, "participants"); err != nil {
+		return err
+	}
+
+	if err = accessor.db.Delete(&Issue{}, issueID).Error; err != nil {
+		return errors.Wrapf(err, "deleting issue %d", issueID)
+	}
+
+	accessor.deletedAttachmentUUIDs = append(accessor.deletedAttachmentUUIDs, attachmentUUIDs...)
+	log.Debug("deleted issue %d with %d attachments", issueID, len(attachmentUUIDs))
+
+	return nil
 }
 
 // SetIssueClosedTime sets the date/time a given Gitea issue was closed.
@@ -119,10 +241,19 @@ func (accessor *DefaultAccessor) SetIssueUpdateTime(issueID int64, updateTime in
 	return nil
 }
 
-// GetIssueURL retrieves a URL for viewing a given issue
-func (accessor *DefaultAccessor) GetIssueURL(issueID int64) string {
+// GetIssueURL retrieves a URL for viewing the issue with a given index
+func (accessor *DefaultAccessor) GetIssueURL(issueIndex int64) string {
+	repoURL := accessor.getUserRepoURL()
+	return fmt.Sprintf("%s/issues/%d", repoURL, issueIndex)
+}
+
+// GetIssueListURL retrieves a URL for viewing the list of issues selected by a given (URL-encoded) issue filter query
+func (accessor *DefaultAccessor) GetIssueListURL(query string) string {
 	repoURL := accessor.getUserRepoURL()
-	return fmt.Sprintf("%s/issues/%d", repoURL, issueID)
+	if query == "" {
+		return fmt.Sprintf("%s/issues", repoURL)
+	}
+	return fmt.Sprintf("%s/issues?%s", repoURL, query)
 }
 
 // UpdateIssueCommentCount updates the count of comments a given issue
@@ -142,22 +273,40 @@ func (accessor *DefaultAccessor) UpdateIssueCommentCount(issueID int64) error {
 }
 
 // UpdateIssueIndex updates the issue_index table after adding a new issue
-func (accessor *DefaultAccessor) UpdateIssueIndex(issueID, ticketID int64) error {
-	var issueIndex IssueIndex
+func (accessor *DefaultAccessor) UpdateIssueIndex(issueID, issueIndex int64) error {
+	var maxIndex IssueIndex
 
 	// FIXME: Why is issueID passed in at all?
-	err := accessor.db.First(&issueIndex, accessor.repoID).Error
+	err := accessor.db.First(&maxIndex, accessor.repoID).Error
 	if err != nil && err == gorm.ErrRecordNotFound {
-		err = accessor.db.Create(&IssueIndex{RepoID: accessor.repoID, MaxIndex: ticketID}).Error
+		err = accessor.db.Create(&IssueIndex{RepoID: accessor.repoID, MaxIndex: issueIndex}).Error
 	} else if err == nil {
-		err = accessor.db.Model(&issueIndex).
-			Update("max_index", accessor.Greatest("max_index,?", ticketID)).
+		err = accessor.db.Model(&maxIndex).
+			Update("max_index", accessor.Greatest("max_index,?", issueIndex)).
 			Error
 	}
 
 	return err
 }
 
+// ResetIssueIndex resets the issue_index table to the highest index of any remaining issue after issues have been deleted
+func (accessor *DefaultAccessor) ResetIssueIndex() error {
+	maxIndex, err := accessor.getMaxIssueIndex()
+	if err != nil {
+		return err
+	}
+
+	if err = accessor.db.Model(&IssueIndex{}).
+		Where("group_id=?", accessor.repoID).
+		Update("max_index", maxIndex).
+		Error; err != nil {
+
+		return errors.Wrapf(err, "resetting issue index for repository %d", accessor.repoID)
+	}
+
+	return nil
+}
+
 // UpdateIssueDescription updates the description of an existing issue in Gitea
 func (accessor *DefaultAccessor) UpdateIssueDescription(issueID int64, issueDescription string) error {
 	if err := accessor.db.Model(&Issue{}).
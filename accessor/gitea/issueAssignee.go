@@ -77,3 +77,30 @@ func (accessor *DefaultAccessor) AddIssueAssignee(issueID int64, assigneeID int6
 
 	return nil
 }
+
+// GetIssueAssignees retrieves the user names of all assignees of a given issue
+func (accessor *DefaultAccessor) GetIssueAssignees(issueID int64) ([]string, error) {
+	var assigneeIDs []int64
+	err := accessor.db.Model(&IssueAssignee{}).
+		Where("issue_id=?", issueID).
+		Pluck("assignee_id", &assigneeIDs).Error
+	if err != nil {
+		err = errors.Wrapf(err, "retrieving assignees of issue %d", issueID)
+		return nil, err
+	}
+
+	assigneeNames := []string{}
+	if len(assigneeIDs) == 0 {
+		return assigneeNames, nil
+	}
+
+	err = accessor.db.Model(&User{}).
+		Where("id IN ?", assigneeIDs).
+		Pluck("name", &assigneeNames).Error
+	if err != nil {
+		err = errors.Wrapf(err, "retrieving names of assignees of issue %d", issueID)
+		return nil, err
+	}
+
+	return assigneeNames, nil
+}
@@ -41,8 +41,8 @@ func (accessor *DefaultAccessor) GetIssueAttachmentUUID(issueID int64, fileName
 	return uuid, nil
 }
 
-// getAttachmentPath returns the path at which to store an attachment with a given UUID
-func (accessor *DefaultAccessor) getAttachmentPath(UUID string) (string, error) {
+// getAttachmentDir returns the directory in which an attachment with a given UUID is stored
+func (accessor *DefaultAccessor) getAttachmentDir(UUID string) (string, error) {
 	attachmentsRootDir := accessor.GetStringConfig("attachment", "PATH")
 	if attachmentsRootDir == "" {
 		attachmentsRootDir = filepath.Join(accessor.rootDir, "data", "attachments")
@@ -54,7 +54,16 @@ func (accessor *DefaultAccessor) getAttachmentPath(UUID string) (string, error)
 
 	d1 := UUID[0:1]
 	d2 := UUID[1:2]
-	dir := filepath.Join(attachmentsRootDir, d1, d2)
+	return filepath.Join(attachmentsRootDir, d1, d2), nil
+}
+
+// getAttachmentPath returns the path at which to store an attachment with a given UUID
+func (accessor *DefaultAccessor) getAttachmentPath(UUID string) (string, error) {
+	dir, err := accessor.getAttachmentDir(UUID)
+	if err != nil {
+		return "", err
+	}
+
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return "", err
 	}
@@ -84,6 +93,21 @@ func (accessor *DefaultAccessor) deleteAttachment(UUID string) error {
 	return deleteFile(attachmentPath)
 }
 
+// deleteAttachmentFiles deletes the files of all attachments deleted during the current transaction
+func (accessor *DefaultAccessor) deleteAttachmentFiles() error {
+	for _, uuid := range accessor.deletedAttachmentUUIDs {
+		err := accessor.deleteAttachment(uuid)
+		if os.IsNotExist(err) {
+			log.Warn("cannot find file for deleted attachment %s", uuid)
+		} else if err != nil {
+			return errors.Wrapf(err, "deleting file for attachment %s", uuid)
+		}
+	}
+
+	accessor.deletedAttachmentUUIDs = nil
+	return nil
+}
+
 // updateIssueAttachment updates an existing issue attachment
 func (accessor *DefaultAccessor) updateIssueAttachment(issueAttachmentID int64, issueID int64, attachment *IssueAttachment, filePath string) error {
 	attachment.ID = issueAttachmentID
@@ -112,6 +136,37 @@ func (accessor *DefaultAccessor) insertIssueAttachment(issueID int64, attachment
 	return attachment.ID, nil
 }
 
+// GetIssueAttachments retrieves all attachments of a given issue
+func (accessor *DefaultAccessor) GetIssueAttachments(issueID int64) ([]IssueAttachment, error) {
+	var attachments []IssueAttachment
+	err := accessor.db.Where("issue_id=?", issueID).Find(&attachments).Error
+	if err != nil {
+		err = errors.Wrapf(err, "retrieving attachments of issue %d", issueID)
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// GetIssueAttachmentFileSize returns the size of the file stored for the attachment with a given UUID - returns -1 if there is no such file.
+func (accessor *DefaultAccessor) GetIssueAttachmentFileSize(uuid string) (int64, error) {
+	dir, err := accessor.getAttachmentDir(uuid)
+	if err != nil {
+		return -1, err
+	}
+
+	stat, err := os.Stat(filepath.Join(dir, uuid))
+	if os.IsNotExist(err) {
+		return -1, nil
+	}
+	if err != nil {
+		err = errors.Wrapf(err, "looking for file for attachment %s", uuid)
+		return -1, err
+	}
+
+	return stat.Size(), nil
+}
+
 // AddIssueAttachment adds a new attachment to an issue using the provided file - returns id of created attachment
 func (accessor *DefaultAccessor) AddIssueAttachment(issueID int64, attachment *IssueAttachment, filePath string) (int64, error) {
 	issueAttachmentID, issueAttachmentUUID, err := accessor.getIssueAttachmentIDandUUID(issueID, attachment.FileName)
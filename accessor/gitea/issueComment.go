@@ -12,7 +12,7 @@ import (
 	"github.com/stevejefferson/trac2gitea/log"
 )
 
-// GetIssueCommentIDByTime retrieves the ID of the comment created at a given time for a given issue.
+// GetIssueCommentIDByTime retrieves the ID of the comment created at a given time for a given issue - returns NullID if no such comment.
 // Since different issue changes can happen at the same time, this tries to return the "comment" type
 // change, or falls back to another type by increasing IssueCommentType.
 func (accessor *DefaultAccessor) GetIssueCommentIDByTime(issueID int64, createdTime int64) (int64, error) {
@@ -28,12 +28,11 @@ func (accessor *DefaultAccessor) GetIssueCommentIDByTime(issueID int64, createdT
 
 	if err != nil {
 		err = errors.Wrapf(err, "retrieving ids of comments created at \"%s\" for issue %d", time.Unix(createdTime, 0), issueID)
-		return -1, err
+		return NullID, err
 	}
 
 	if len(commentIDs) == 0 {
-		log.Error("could not find issue comment at %s for issue %d", time.Unix(createdTime, 0), issueID)
-		return -1, nil
+		return NullID, nil
 	}
 
 	return commentIDs[0], nil
@@ -44,6 +43,7 @@ func (accessor *DefaultAccessor) updateIssueComment(issueCommentID int64, issueI
 	comment.ID = issueCommentID
 	comment.IssueID = issueID
 	comment.CreatedTime = comment.Time
+	comment.RefRepoID = accessor.refRepoID(comment)
 
 	if err := accessor.db.Save(&comment).Error; err != nil {
 		return errors.Wrapf(err, "updating comment on issue %d timed at %s", issueID, time.Unix(comment.Time, 0))
@@ -58,6 +58,7 @@ func (accessor *DefaultAccessor) updateIssueComment(issueCommentID int64, issueI
 func (accessor *DefaultAccessor) insertIssueComment(issueID int64, comment *IssueComment) (int64, error) {
 	comment.IssueID = issueID
 	comment.CreatedTime = comment.Time
+	comment.RefRepoID = accessor.refRepoID(comment)
 
 	if err := accessor.db.Create(&comment).Error; err != nil {
 		err = errors.Wrapf(err, "adding comment \"%s\" for issue %d", comment.Text, issueID)
@@ -69,14 +70,28 @@ func (accessor *DefaultAccessor) insertIssueComment(issueID int64, comment *Issu
 	return comment.ID, nil
 }
 
+// refRepoID returns the repository of the issue referenced by a cross-reference comment - we only create cross-references between issues of the current repository
+func (accessor *DefaultAccessor) refRepoID(comment *IssueComment) int64 {
+	if comment.RefIssueID == NullID {
+		return NullID
+	}
+	return accessor.repoID
+}
+
 // findIssueComment checks for the existence and ID of a comment with the same timestamp and change type in the given issue
-func (accessor *DefaultAccessor) findIssueComment(issueID int64, createdTime int64, commentType IssueCommentType) (int64, error) {
+// - a cross-reference comment is instead identified by its referencing issue and comment: Gitea has only one for each, whenever it was made
+func (accessor *DefaultAccessor) findIssueComment(issueID int64, comment *IssueComment) (int64, error) {
+	createdTime := comment.CreatedTime
+	query := accessor.db.Model(&IssueComment{}).Select("id")
+	if comment.RefIssueID != NullID {
+		query = query.Where("issue_id=? AND type=? AND ref_issue_id=? AND ref_comment_id=?",
+			issueID, comment.CommentType, comment.RefIssueID, comment.RefCommentID)
+	} else {
+		query = query.Where("issue_id=? AND created_unix=? AND type=?", issueID, createdTime, comment.CommentType)
+	}
+
 	var commentIDs = []int64{}
-	err := accessor.db.Model(&IssueComment{}).
-		Select("id").
-		Where("issue_id=? AND created_unix=? AND type=?", issueID, createdTime, commentType).
-		Find(&commentIDs).
-		Error
+	err := query.Find(&commentIDs).Error
 
 	if err != nil {
 		err = errors.Wrapf(err, "retrieving ids of comments created at \"%s\" for issue %d", time.Unix(createdTime, 0), issueID)
@@ -90,28 +105,47 @@ func (accessor *DefaultAccessor) findIssueComment(issueID int64, createdTime int
 	return commentIDs[0], nil
 }
 
-// AddIssueComment adds a comment on a Gitea issue, returns id of created comment
-func (accessor *DefaultAccessor) AddIssueComment(issueID int64, comment *IssueComment) (int64, error) {
+// AddIssueComment adds a comment on a Gitea issue, returns id of comment and whether it was written (false if it already exists and is not being overwritten)
+func (accessor *DefaultAccessor) AddIssueComment(issueID int64, comment *IssueComment) (int64, bool, error) {
 	// Check whether a particular issue comment already exists (and hence whether we need to insert or update it).
-	issueCommentID, err := accessor.findIssueComment(issueID, comment.CreatedTime, comment.CommentType)
+	issueCommentID, err := accessor.findIssueComment(issueID, comment)
 	if err != nil {
-		return NullID, err
+		return NullID, false, err
 	}
 
 	if issueCommentID == -1 {
-		return accessor.insertIssueComment(issueID, comment)
-	}
-
-	if accessor.overwrite {
-		err := accessor.updateIssueComment(issueCommentID, issueID, comment)
+		issueCommentID, err = accessor.insertIssueComment(issueID, comment)
 		if err != nil {
-			return NullID, err
+			return NullID, false, err
 		}
-	} else {
+		return issueCommentID, true, nil
+	}
+
+	if !accessor.overwrite {
 		log.Info("issue %d already has comment timed at %s - ignored", issueID, time.Unix(comment.Time, 0))
+		return issueCommentID, false, nil
 	}
 
-	return issueCommentID, nil
+	err = accessor.updateIssueComment(issueCommentID, issueID, comment)
+	if err != nil {
+		return NullID, false, err
+	}
+	return issueCommentID, true, nil
+}
+
+// UpdateIssueCommentText updates the text of an existing Gitea issue comment
+func (accessor *DefaultAccessor) UpdateIssueCommentText(issueCommentID int64, text string) error {
+	if err := accessor.db.Model(&IssueComment{}).
+		Where("id=?", issueCommentID).
+		Update("content", text).
+		Error; err != nil {
+
+		return errors.Wrapf(err, "updating text of issue comment %d", issueCommentID)
+	}
+
+	log.Debug("updated text of issue comment %d", issueCommentID)
+
+	return nil
 }
 
 // GetIssueCommentURL retrieves the URL for viewing a Gitea comment for a given issue.
@@ -119,3 +153,17 @@ func (accessor *DefaultAccessor) GetIssueCommentURL(issueNumber int64, commentID
 	repoURL := accessor.getUserRepoURL()
 	return fmt.Sprintf("%s/issues/%d#issuecomment-%d", repoURL, issueNumber, commentID)
 }
+
+// GetIssueCommentCount retrieves the number of comments of a given type on a given issue.
+func (accessor *DefaultAccessor) GetIssueCommentCount(issueID int64, commentType IssueCommentType) (int64, error) {
+	var count int64
+	err := accessor.db.Model(&IssueComment{}).
+		Where("issue_id=? AND type=?", issueID, commentType).
+		Count(&count).Error
+	if err != nil {
+		err = errors.Wrapf(err, "counting comments of type %d for issue %d", commentType, issueID)
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -55,6 +55,33 @@ func (accessor *DefaultAccessor) AddIssueLabel(issueID int64, labelID int64) (in
 	return issueLabelID, nil
 }
 
+// GetIssueLabels retrieves the names of all labels of a given issue
+func (accessor *DefaultAccessor) GetIssueLabels(issueID int64) ([]string, error) {
+	var labelIDs []int64
+	err := accessor.db.Model(&IssueLabel{}).
+		Where("issue_id=?", issueID).
+		Pluck("label_id", &labelIDs).Error
+	if err != nil {
+		err = errors.Wrapf(err, "retrieving labels of issue %d", issueID)
+		return nil, err
+	}
+
+	labelNames := []string{}
+	if len(labelIDs) == 0 {
+		return labelNames, nil
+	}
+
+	err = accessor.db.Model(&Label{}).
+		Where("id IN ?", labelIDs).
+		Pluck("name", &labelNames).Error
+	if err != nil {
+		err = errors.Wrapf(err, "retrieving names of labels of issue %d", issueID)
+		return nil, err
+	}
+
+	return labelNames, nil
+}
+
 // UpdateLabelIssueCounts updates issue counts for all labels.
 func (accessor *DefaultAccessor) UpdateLabelIssueCounts() error {
 	err := accessor.db.Exec(`
@@ -42,8 +42,8 @@ func (accessor *DefaultAccessor) updateIssueParticipant(issueParticipantID int64
 }
 
 // insertIssueParticipant creates a new issue participant
-func (accessor *DefaultAccessor) insertIssueParticipant(issueID int64, userID int64) error {
-	issueUser := IssueUser{IssueId: issueID, UserId: userID, IsRead: true, IsMentioned: false}
+func (accessor *DefaultAccessor) insertIssueParticipant(issueID int64, userID int64, isMentioned bool) error {
+	issueUser := IssueUser{IssueId: issueID, UserId: userID, IsRead: true, IsMentioned: isMentioned}
 
 	if err := accessor.db.Create(&issueUser).Error; err != nil {
 		err = errors.Wrapf(err, "adding participant %d in issue %d", userID, issueID)
@@ -63,7 +63,7 @@ func (accessor *DefaultAccessor) AddIssueParticipant(issueID int64, userID int64
 	}
 
 	if issueParticipantID == NullID {
-		return accessor.insertIssueParticipant(issueID, userID)
+		return accessor.insertIssueParticipant(issueID, userID, false)
 	}
 
 	if accessor.overwrite {
@@ -77,3 +77,33 @@ func (accessor *DefaultAccessor) AddIssueParticipant(issueID int64, userID int64
 
 	return nil
 }
+
+// markIssueParticipantMentioned records that an existing issue participant is mentioned in the issue
+func (accessor *DefaultAccessor) markIssueParticipantMentioned(issueParticipantID int64, issueID int64, userID int64) error {
+	if err := accessor.db.Model(&IssueUser{}).
+		Where("id=?", issueParticipantID).
+		Update("is_mentioned", true).
+		Error; err != nil {
+
+		return errors.Wrapf(err, "marking participant %d in issue %d as mentioned", userID, issueID)
+	}
+
+	log.Debug("marked participant %d in issue %d as mentioned (id %d)", userID, issueID, issueParticipantID)
+
+	return nil
+}
+
+// AddIssueMentionedParticipant adds a user mentioned in a Gitea issue or one of its comments as a participant in the issue.
+// A user who is already a participant is marked as mentioned - this is done regardless of overwriting as a mention only adds to the participant.
+func (accessor *DefaultAccessor) AddIssueMentionedParticipant(issueID int64, userID int64) error {
+	issueParticipantID, err := accessor.getIssueParticipantID(issueID, userID)
+	if err != nil {
+		return err
+	}
+
+	if issueParticipantID == NullID {
+		return accessor.insertIssueParticipant(issueID, userID, true)
+	}
+
+	return accessor.markIssueParticipantMentioned(issueParticipantID, issueID, userID)
+}
@@ -76,3 +76,35 @@ func (accessor *DefaultAccessor) AddLabel(label *Label) (int64, error) {
 
 	return labelID, nil
 }
+
+// DeleteUnusedLabel deletes a named label provided it is not used by any issue or issue comment - returns true if the label was deleted
+func (accessor *DefaultAccessor) DeleteUnusedLabel(labelName string) (bool, error) {
+	labelID, err := accessor.GetLabelID(labelName)
+	if err != nil {
+		return false, err
+	}
+	if labelID == NullID {
+		return false, nil
+	}
+
+	var issueLabelCount, commentCount int64
+	err = accessor.db.Model(&IssueLabel{}).Where("label_id=?", labelID).Count(&issueLabelCount).Error
+	if err == nil {
+		err = accessor.db.Model(&IssueComment{}).Where("label_id=?", labelID).Count(&commentCount).Error
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "counting uses of label %s", labelName)
+	}
+	if issueLabelCount > 0 || commentCount > 0 {
+		log.Debug("label %s is still in use - not deleted", labelName)
+		return false, nil
+	}
+
+	if err = accessor.db.Delete(&Label{}, labelID).Error; err != nil {
+		return false, errors.Wrapf(err, "deleting label %s", labelName)
+	}
+
+	log.Debug("deleted label %s (id %d)", labelName, labelID)
+
+	return true, nil
+}
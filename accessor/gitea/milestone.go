@@ -84,3 +84,37 @@ func (accessor *DefaultAccessor) GetMilestoneURL(milestoneID int64) string {
 	repoURL := accessor.getUserRepoURL()
 	return fmt.Sprintf("%s/milestone/%d", repoURL, milestoneID)
 }
+
+// DeleteUnusedMilestone deletes a named milestone provided it is not used by any issue or issue comment - returns true if the milestone was deleted
+func (accessor *DefaultAccessor) DeleteUnusedMilestone(milestoneName string) (bool, error) {
+	milestoneID, err := accessor.GetMilestoneID(milestoneName)
+	if err != nil {
+		return false, err
+	}
+	if milestoneID == NullID {
+		return false, nil
+	}
+
+	var issueCount, commentCount int64
+	err = accessor.db.Model(&Issue{}).Where("milestone_id=?", milestoneID).Count(&issueCount).Error
+	if err == nil {
+		err = accessor.db.Model(&IssueComment{}).
+			Where("milestone_id=? OR old_milestone_id=?", milestoneID, milestoneID).
+			Count(&commentCount).Error
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "counting uses of milestone %s", milestoneName)
+	}
+	if issueCount > 0 || commentCount > 0 {
+		log.Debug("milestone %s is still in use - not deleted", milestoneName)
+		return false, nil
+	}
+
+	if err = accessor.db.Delete(&Milestone{}, milestoneID).Error; err != nil {
+		return false, errors.Wrapf(err, "deleting milestone %s", milestoneName)
+	}
+
+	log.Debug("deleted milestone %s (id %d)", milestoneName, milestoneID)
+
+	return true, nil
+}
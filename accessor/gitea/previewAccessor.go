@@ -0,0 +1,352 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package gitea
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stevejefferson/trac2gitea/log"
+)
+
+// PreviewAccessor is a lightweight implementation of the gitea Accessor interface which needs no Gitea instance.
+// It is used for previewing the conversion of Trac wiki text into markdown:
+// URLs are generated in the same form as for a real repository of the given user and name,
+// lookups return placeholder IDs and any attempt to modify Gitea fails.
+type PreviewAccessor struct {
+	// the URL methods of the default accessor only require the user and repository names
+	DefaultAccessor
+
+	// placeholder IDs allocated to named users, labels and milestones
+	placeholderIDs map[string]int64
+}
+
+// errPreviewOnly is returned by any attempt to modify Gitea through a preview accessor
+var errPreviewOnly = errors.New("cannot modify Gitea when previewing")
+
+// CreatePreviewAccessor returns a new Gitea preview accessor for a repository of the given user and name.
+func CreatePreviewAccessor(giteaUserName string, giteaRepoName string) *PreviewAccessor {
+	accessor := PreviewAccessor{
+		DefaultAccessor: DefaultAccessor{userName: giteaUserName, repoName: giteaRepoName},
+		placeholderIDs:  make(map[string]int64),
+	}
+	return &accessor
+}
+
+// placeholderID returns the placeholder ID of a named Gitea object, allocating IDs in order of first use so that the same name always has the same ID
+func (accessor *PreviewAccessor) placeholderID(kind string, name string) int64 {
+	key := kind + ":" + name
+	id, found := accessor.placeholderIDs[key]
+	if !found {
+		id = int64(len(accessor.placeholderIDs) + 1)
+		accessor.placeholderIDs[key] = id
+	}
+	return id
+}
+
+/*
+ * Config
+ */
+
+// GetStringConfig returns an empty string - there is no Gitea config when previewing.
+func (accessor *PreviewAccessor) GetStringConfig(sectionName string, configName string) string {
+	return ""
+}
+
+/*
+ * Issues
+ */
+
+// GetIssueID returns the issue index as the placeholder ID of the issue with that index.
+func (accessor *PreviewAccessor) GetIssueID(issueIndex int64) (int64, error) {
+	return issueIndex, nil
+}
+
+// GetMaxIssueIndex returns 0 - there are no existing issues when previewing.
+func (accessor *PreviewAccessor) GetMaxIssueIndex() (int64, error) {
+	return 0, nil
+}
+
+// GetIssue returns nil - there are no existing issues when previewing.
+func (accessor *PreviewAccessor) GetIssue(issueID int64) (*Issue, error) {
+	return nil, nil
+}
+
+// GetIssueCreatedTime returns 0 - there are no existing issues when previewing.
+func (accessor *PreviewAccessor) GetIssueCreatedTime(issueID int64) (int64, error) {
+	return 0, nil
+}
+
+// AddIssue fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) AddIssue(issue *Issue) (int64, bool, error) {
+	return NullID, false, errPreviewOnly
+}
+
+// DeleteIssue fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) DeleteIssue(issueID int64) error {
+	return errPreviewOnly
+}
+
+// SetIssueUpdateTime fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) SetIssueUpdateTime(issueID int64, updateTime int64) error {
+	return errPreviewOnly
+}
+
+// SetIssueClosedTime fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) SetIssueClosedTime(issueID int64, updateTime int64) error {
+	return errPreviewOnly
+}
+
+// UpdateIssueCommentCount fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) UpdateIssueCommentCount(issueID int64) error {
+	return errPreviewOnly
+}
+
+// UpdateIssueIndex fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) UpdateIssueIndex(issueID, issueIndex int64) error {
+	return errPreviewOnly
+}
+
+// ResetIssueIndex fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) ResetIssueIndex() error {
+	return errPreviewOnly
+}
+
+// UpdateIssueDescription fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) UpdateIssueDescription(issueID int64, issueDescription string) error {
+	return errPreviewOnly
+}
+
+/*
+ * Issue Assignees
+ */
+
+// AddIssueAssignee fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) AddIssueAssignee(issueID int64, assigneeID int64) error {
+	return errPreviewOnly
+}
+
+// GetIssueAssignees returns no assignees - there are no existing issues when previewing.
+func (accessor *PreviewAccessor) GetIssueAssignees(issueID int64) ([]string, error) {
+	return []string{}, nil
+}
+
+/*
+ * Issue Attachments
+ */
+
+// GetIssueAttachmentUUID returns the attachment file name as its placeholder UUID, so that previewed attachment URLs identify the attachment.
+func (accessor *PreviewAccessor) GetIssueAttachmentUUID(issueID int64, fileName string) (string, error) {
+	return fileName, nil
+}
+
+// GetIssueAttachments returns no attachments - there are no existing issues when previewing.
+func (accessor *PreviewAccessor) GetIssueAttachments(issueID int64) ([]IssueAttachment, error) {
+	return []IssueAttachment{}, nil
+}
+
+// GetIssueAttachmentFileSize returns 0 - there are no existing attachments when previewing.
+func (accessor *PreviewAccessor) GetIssueAttachmentFileSize(uuid string) (int64, error) {
+	return 0, nil
+}
+
+// AddIssueAttachment fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) AddIssueAttachment(issueID int64, attachment *IssueAttachment, filePath string) (int64, error) {
+	return NullID, errPreviewOnly
+}
+
+/*
+ * Issue Comments
+ */
+
+// GetIssueCommentIDByTime returns the comment time as the placeholder ID of the comment made at that time.
+func (accessor *PreviewAccessor) GetIssueCommentIDByTime(issueID int64, createdTime int64) (int64, error) {
+	return createdTime, nil
+}
+
+// AddIssueComment fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) AddIssueComment(issueID int64, comment *IssueComment) (int64, bool, error) {
+	return NullID, false, errPreviewOnly
+}
+
+// UpdateIssueCommentText fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) UpdateIssueCommentText(issueCommentID int64, text string) error {
+	return errPreviewOnly
+}
+
+// GetIssueCommentCount returns 0 - there are no existing issues when previewing.
+func (accessor *PreviewAccessor) GetIssueCommentCount(issueID int64, commentType IssueCommentType) (int64, error) {
+	return 0, nil
+}
+
+/*
+ * Issue Labels
+ */
+
+// AddIssueLabel fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) AddIssueLabel(issueID int64, labelID int64) (int64, error) {
+	return NullID, errPreviewOnly
+}
+
+// GetIssueLabels returns no labels - there are no existing issues when previewing.
+func (accessor *PreviewAccessor) GetIssueLabels(issueID int64) ([]string, error) {
+	return []string{}, nil
+}
+
+// UpdateLabelIssueCounts fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) UpdateLabelIssueCounts() error {
+	return errPreviewOnly
+}
+
+/*
+ * Issue Milestones
+ */
+
+// UpdateMilestoneIssueCounts fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) UpdateMilestoneIssueCounts() error {
+	return errPreviewOnly
+}
+
+/*
+ * Issue Participants
+ */
+
+// AddIssueParticipant fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) AddIssueParticipant(issueID int64, userID int64) error {
+	return errPreviewOnly
+}
+
+// AddIssueMentionedParticipant fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) AddIssueMentionedParticipant(issueID int64, userID int64) error {
+	return errPreviewOnly
+}
+
+/*
+ * Labels
+ */
+
+// GetLabelID returns a placeholder ID for a named label - every label is assumed to exist when previewing.
+func (accessor *PreviewAccessor) GetLabelID(labelName string) (int64, error) {
+	return accessor.placeholderID("label", labelName), nil
+}
+
+// AddLabel fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) AddLabel(label *Label) (int64, error) {
+	return NullID, errPreviewOnly
+}
+
+// DeleteUnusedLabel fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) DeleteUnusedLabel(labelName string) (bool, error) {
+	return false, errPreviewOnly
+}
+
+/*
+ * Milestones
+ */
+
+// GetMilestoneID returns a placeholder ID for a named milestone - every milestone is assumed to exist when previewing.
+func (accessor *PreviewAccessor) GetMilestoneID(name string) (int64, error) {
+	return accessor.placeholderID("milestone", name), nil
+}
+
+// AddMilestone fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) AddMilestone(milestone *Milestone) (int64, error) {
+	return NullID, errPreviewOnly
+}
+
+// DeleteUnusedMilestone fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) DeleteUnusedMilestone(milestoneName string) (bool, error) {
+	return false, errPreviewOnly
+}
+
+/*
+ * Repository
+ */
+
+// UpdateRepoIssueCounts fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) UpdateRepoIssueCounts() error {
+	return errPreviewOnly
+}
+
+// UpdateRepoMilestoneCounts fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) UpdateRepoMilestoneCounts() error {
+	return errPreviewOnly
+}
+
+/*
+ * Transactions
+ */
+
+// CommitTransaction does nothing - there is no transaction when previewing.
+func (accessor *PreviewAccessor) CommitTransaction() error {
+	return nil
+}
+
+// RollbackTransaction does nothing - there is no transaction when previewing.
+func (accessor *PreviewAccessor) RollbackTransaction() error {
+	return nil
+}
+
+/*
+ * Users
+ */
+
+// SetUserFullName fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) SetUserFullName(userName string, userFullName string) error {
+	return errPreviewOnly
+}
+
+// GetUserID returns a placeholder ID for a named user - every user is assumed to exist when previewing.
+func (accessor *PreviewAccessor) GetUserID(userName string) (int64, error) {
+	return accessor.placeholderID("user", userName), nil
+}
+
+// GetUserEMailAddress returns an empty string - there are no user details when previewing.
+func (accessor *PreviewAccessor) GetUserEMailAddress(userName string) (string, error) {
+	return "", nil
+}
+
+// MatchUser returns no match - there are no users to match when previewing.
+func (accessor *PreviewAccessor) MatchUser(userName string, userEmail string) (string, error) {
+	return "", nil
+}
+
+/*
+ * Wiki
+ */
+
+// CloneWiki fails - there is no wiki repository when previewing.
+func (accessor *PreviewAccessor) CloneWiki() error {
+	return errPreviewOnly
+}
+
+// CommitWikiToRepo fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) CommitWikiToRepo(author string, updateTime int64, message string) error {
+	return errPreviewOnly
+}
+
+// CopyFileToWiki does nothing - files referenced by the previewed text are not copied anywhere.
+func (accessor *PreviewAccessor) CopyFileToWiki(externalFilePath string, giteaWikiRelPath string) error {
+	log.Debug("preview: not copying %s to wiki file %s", externalFilePath, giteaWikiRelPath)
+	return nil
+}
+
+// WriteWikiPage fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) WriteWikiPage(pageName string, markdownText string, commitMarker string) (bool, error) {
+	return false, errPreviewOnly
+}
+
+// GetWikiPageNames returns no page names - there is no wiki repository when previewing.
+func (accessor *PreviewAccessor) GetWikiPageNames() ([]string, error) {
+	return []string{}, nil
+}
+
+// GetImportedWikiFiles returns no files - there is no wiki repository when previewing.
+func (accessor *PreviewAccessor) GetImportedWikiFiles(commitMarker string) ([]string, error) {
+	return []string{}, nil
+}
+
+// DeleteWikiFile fails - Gitea cannot be modified when previewing.
+func (accessor *PreviewAccessor) DeleteWikiFile(relPath string) error {
+	return errPreviewOnly
+}
@@ -86,8 +86,53 @@ func (accessor *DefaultAccessor) GetCommitURL(commitID string) string {
 	return fmt.Sprintf("%s/commit/%s", repoURL, commitID)
 }
 
+// GetCompareURL retrieves the URL for viewing the changes between two commits in the current repository
+func (accessor *DefaultAccessor) GetCompareURL(fromCommitID string, toCommitID string) string {
+	repoURL := accessor.getUserRepoURL()
+	return fmt.Sprintf("%s/compare/%s...%s", repoURL, fromCommitID, toCommitID)
+}
+
 // GetSourceURL retrieves the URL for viewing the latest version of a source file on a given branch of the current repository
 func (accessor *DefaultAccessor) GetSourceURL(branchPath string, filePath string) string {
 	repoURL := accessor.getUserRepoURL()
 	return fmt.Sprintf("%s/src/branch/%s/%s", repoURL, branchPath, filePath)
 }
+
+// GetCommitSourceURL retrieves the URL for viewing a source file as of a given commit in the current repository
+func (accessor *DefaultAccessor) GetCommitSourceURL(commitID string, filePath string) string {
+	repoURL := accessor.getUserRepoURL()
+	return fmt.Sprintf("%s/src/commit/%s/%s", repoURL, commitID, filePath)
+}
+
+// GetRawSourceURL retrieves the URL for downloading the latest version of a source file on a given branch of the current repository
+func (accessor *DefaultAccessor) GetRawSourceURL(branchPath string, filePath string) string {
+	repoURL := accessor.getUserRepoURL()
+	return fmt.Sprintf("%s/raw/branch/%s/%s", repoURL, branchPath, filePath)
+}
+
+// GetRawCommitSourceURL retrieves the URL for downloading a source file as of a given commit in the current repository
+func (accessor *DefaultAccessor) GetRawCommitSourceURL(commitID string, filePath string) string {
+	repoURL := accessor.getUserRepoURL()
+	return fmt.Sprintf("%s/raw/commit/%s/%s", repoURL, commitID, filePath)
+}
+
+// GetSourceLogURL retrieves the URL for viewing the commit history of a source file or directory on a given branch of the current repository
+func (accessor *DefaultAccessor) GetSourceLogURL(branchPath string, filePath string) string {
+	repoURL := accessor.getUserRepoURL()
+	return fmt.Sprintf("%s/commits/branch/%s/%s", repoURL, branchPath, filePath)
+}
+
+// GetRepoIssueURL retrieves the URL for viewing the issue with a given index in another repository owned by the current user
+func (accessor *DefaultAccessor) GetRepoIssueURL(repoName string, issueIndex int64) string {
+	return fmt.Sprintf("/%s/%s/issues/%d", accessor.userName, repoName, issueIndex)
+}
+
+// GetRepoIssueReference retrieves the Gitea reference ("owner/repo#N") to the issue with a given index in another repository owned by the current user
+func (accessor *DefaultAccessor) GetRepoIssueReference(repoName string, issueIndex int64) string {
+	return fmt.Sprintf("%s/%s#%d", accessor.userName, repoName, issueIndex)
+}
+
+// GetRepoWikiURL retrieves the URL for viewing a wiki page in another repository owned by the current user
+func (accessor *DefaultAccessor) GetRepoWikiURL(repoName string, pageName string) string {
+	return fmt.Sprintf("/%s/%s/wiki/%s", accessor.userName, repoName, pageName)
+}
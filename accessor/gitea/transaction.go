@@ -11,6 +11,11 @@ func (accessor *DefaultAccessor) CommitTransaction() error {
 		return err
 	}
 
+	err = accessor.deleteAttachmentFiles()
+	if err != nil {
+		return err
+	}
+
 	if !accessor.dbOnly {
 		return accessor.commitWikiRepo()
 	}
@@ -25,6 +30,9 @@ func (accessor *DefaultAccessor) RollbackTransaction() error {
 		return err
 	}
 
+	// attachments have not been deleted after all - leave their files alone
+	accessor.deletedAttachmentUUIDs = nil
+
 	if !accessor.dbOnly {
 		return accessor.rollbackWikiRepo()
 	}
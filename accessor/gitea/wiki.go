@@ -5,6 +5,7 @@
 package gitea
 
 import (
+	"fmt"
 	"os"
 	"path"
 	"path/filepath"
@@ -15,6 +16,7 @@ import (
 	"github.com/stevejefferson/trac2gitea/log"
 	"gopkg.in/src-d/go-git.v4"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
 	"gopkg.in/src-d/go-git.v4/plumbing/transport/http"
 )
 
@@ -43,6 +45,12 @@ func (accessor *DefaultAccessor) GetWikiFileURL(relpath string) string {
 	return "../raw/" + relpath
 }
 
+// GetWikiPageURL returns a URL for viewing a given Gitea wiki page.
+func (accessor *DefaultAccessor) GetWikiPageURL(pageName string) string {
+	repoURL := accessor.getUserRepoURL()
+	return fmt.Sprintf("%s/wiki/%s", repoURL, pageName)
+}
+
 // CloneWiki clones our wiki repo to the provided directory.
 func (accessor *DefaultAccessor) CloneWiki() error {
 	isBare := false
@@ -207,6 +215,123 @@ func (accessor *DefaultAccessor) WriteWikiPage(pageName string, markdownText str
 	return true, nil
 }
 
+// fileCommitsAllContain determines whether there are commits of the given wiki repository file and all of them have a commit message containing the provided string
+func (accessor *DefaultAccessor) fileCommitsAllContain(relPath string, commitString string) (bool, error) {
+	commitIter, err := accessor.wikiRepo.Log(&git.LogOptions{FileName: &relPath})
+	if err != nil {
+		err = errors.Wrapf(err, "retrieving git log for file %s", relPath)
+		return false, err
+	}
+
+	commitCount := 0
+	allContain := true
+	err = commitIter.ForEach(func(commit *object.Commit) error {
+		commitCount++
+		if !strings.Contains(commit.Message, commitString) {
+			allContain = false
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		err = errors.Wrapf(err, "reading git log for file %s", relPath)
+		return false, err
+	}
+
+	return commitCount > 0 && allContain, nil
+}
+
+// GetWikiPageNames returns the names of all pages in the cloned wiki
+func (accessor *DefaultAccessor) GetWikiPageNames() ([]string, error) {
+	pageNames := []string{}
+	err := filepath.Walk(accessor.wikiRepoDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			// skip git metadata and the directories in which we store non-page files
+			relPath, _ := filepath.Rel(accessor.wikiRepoDir, path)
+			if relPath == ".git" || relPath == "attachments" || relPath == "htdocs" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relPath, err := filepath.Rel(accessor.wikiRepoDir, path)
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(relPath, ".md") {
+			pageNames = append(pageNames, strings.TrimSuffix(filepath.ToSlash(relPath), ".md"))
+		}
+		return nil
+	})
+	if err != nil {
+		err = errors.Wrapf(err, "scanning cloned wiki %s", accessor.wikiRepoDir)
+		return nil, err
+	}
+
+	return pageNames, nil
+}
+
+// GetImportedWikiFiles returns the paths (relative to the root of the wiki repository) of all files in the cloned wiki
+// whose every commit has a commit message containing the provided marker.
+func (accessor *DefaultAccessor) GetImportedWikiFiles(commitMarker string) ([]string, error) {
+	var importedFiles []string
+	err := filepath.Walk(accessor.wikiRepoDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relPath, err := filepath.Rel(accessor.wikiRepoDir, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		imported, err := accessor.fileCommitsAllContain(relPath, commitMarker)
+		if err != nil {
+			return err
+		}
+		if imported {
+			importedFiles = append(importedFiles, relPath)
+		} else {
+			log.Debug("wiki file %s has commits not containing \"%s\"", relPath, commitMarker)
+		}
+		return nil
+	})
+	if err != nil {
+		err = errors.Wrapf(err, "scanning cloned wiki %s", accessor.wikiRepoDir)
+		return nil, err
+	}
+
+	return importedFiles, nil
+}
+
+// DeleteWikiFile deletes a file from the cloned wiki, staging the deletion for the next commit.
+// The path of the file is relative to the root of the wiki repository.
+func (accessor *DefaultAccessor) DeleteWikiFile(relPath string) error {
+	worktree, err := accessor.wikiRepo.Worktree()
+	if err != nil {
+		err = errors.Wrapf(err, "retrieving git work tree for cloned wiki")
+		return err
+	}
+
+	if _, err = worktree.Remove(relPath); err != nil {
+		err = errors.Wrapf(err, "removing file %s from git work tree", relPath)
+		return err
+	}
+
+	log.Debug("deleted wiki file %s", relPath)
+	return nil
+}
+
 // TranslateWikiPageName translates a Trac wiki page name into a Gitea one
 func (accessor *DefaultAccessor) TranslateWikiPageName(pageName string) string {
 	// special case: Trac "WikiStart" page is Gitea "Home" page...
@@ -45,6 +45,14 @@ type Ticket struct {
 	Updated        int64
 }
 
+// Report describes a Trac report.
+// The query is either SQL or, for a report defined as a saved ticket query, a 'query:...' Trac link.
+type Report struct {
+	ReportID int64
+	Title    string
+	Query    string
+}
+
 // TicketChangeType enumerates the types of ticket change we handle.
 type TicketChangeType string
 
@@ -119,6 +127,14 @@ type WikiAttachment struct {
 	FileName string
 }
 
+// InterWikiPrefix describes a Trac InterWiki prefix, as defined in the InterMapTxt wiki page or the '[interwiki]' section of the Trac config.
+// The URL may contain '$1', '$2' etc. placeholders for the parts of the link target, otherwise the target is appended to it.
+type InterWikiPrefix struct {
+	Prefix string
+	URL    string
+	Title  string
+}
+
 // NullID id used for Trac lookup failures
 // The Trac schema does not seem to use foreign key references so there is no specific null Trac id value
 // The value chosen here is therefore just one that will not occur in reality and is also simultaneously different from the Gitea one
@@ -139,6 +155,12 @@ type Accessor interface {
 	// GetStringConfig retrieves a value from the Trac config as a string.
 	GetStringConfig(sectionName string, configName string) string
 
+	// GetInterTracPrefixes retrieves the InterTrac prefixes defined in the Trac config, mapping each (lower-cased) prefix onto the name of the Trac environment it refers to.
+	GetInterTracPrefixes() map[string]string
+
+	// GetInterWikiPrefixes retrieves the InterWiki prefixes defined in the InterMapTxt wiki page and the Trac config, mapping each (lower-cased) prefix onto its definition.
+	GetInterWikiPrefixes() (map[string]*InterWikiPrefix, error)
+
 	/*
 	 * Milestones
 	 */
@@ -157,6 +179,12 @@ type Accessor interface {
 	// GetPriorities retrieves all priorities used in Trac tickets, passing each one to the provided "handler" function.
 	GetPriorities(handlerFn func(priority *Label) error) error
 
+	/*
+	 * Reports
+	 */
+	// GetReport retrieves the Trac report with a given ID, returns nil if there is no such report.
+	GetReport(reportID int64) (*Report, error)
+
 	/*
 	 * Resolutions
 	 */
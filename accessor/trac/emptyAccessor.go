@@ -0,0 +1,199 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package trac
+
+import "path/filepath"
+
+// EmptyAccessor is an implementation of the trac Accessor interface for a Trac environment containing no data.
+// It is used for previewing the conversion of Trac wiki text without a Trac environment on disk:
+// there is no config, so no InterWiki or InterTrac prefixes, and lookups of tickets, reports, wiki pages etc. find nothing.
+type EmptyAccessor struct {
+}
+
+// CreateEmptyAccessor returns a new empty Trac accessor.
+func CreateEmptyAccessor() *EmptyAccessor {
+	return &EmptyAccessor{}
+}
+
+/*
+ * Components
+ */
+
+// GetComponents retrieves no components - an empty Trac environment has none.
+func (accessor *EmptyAccessor) GetComponents(handlerFn func(component *Label) error) error {
+	return nil
+}
+
+/*
+ * Configuration
+ */
+
+// GetStringConfig returns an empty string - an empty Trac environment has no config.
+func (accessor *EmptyAccessor) GetStringConfig(sectionName string, configName string) string {
+	return ""
+}
+
+// GetInterTracPrefixes returns no InterTrac prefixes - an empty Trac environment has no config.
+func (accessor *EmptyAccessor) GetInterTracPrefixes() map[string]string {
+	return map[string]string{}
+}
+
+// GetInterWikiPrefixes returns no InterWiki prefixes - an empty Trac environment has no config or InterMapTxt page.
+func (accessor *EmptyAccessor) GetInterWikiPrefixes() (map[string]*InterWikiPrefix, error) {
+	return map[string]*InterWikiPrefix{}, nil
+}
+
+/*
+ * Milestones
+ */
+
+// GetMilestones retrieves no milestones - an empty Trac environment has none.
+func (accessor *EmptyAccessor) GetMilestones(handlerFn func(milestone *Milestone) error) error {
+	return nil
+}
+
+/*
+ * Paths
+ */
+
+// GetFullPath returns the path relative to the current directory - an empty Trac environment has no root directory.
+func (accessor *EmptyAccessor) GetFullPath(element ...string) string {
+	return filepath.Join(element...)
+}
+
+/*
+ * Priorities
+ */
+
+// GetPriorities retrieves no priorities - an empty Trac environment has none.
+func (accessor *EmptyAccessor) GetPriorities(handlerFn func(priority *Label) error) error {
+	return nil
+}
+
+/*
+ * Reports
+ */
+
+// GetReport returns nil - an empty Trac environment has no reports.
+func (accessor *EmptyAccessor) GetReport(reportID int64) (*Report, error) {
+	return nil, nil
+}
+
+/*
+ * Resolutions
+ */
+
+// GetResolutions retrieves no resolutions - an empty Trac environment has none.
+func (accessor *EmptyAccessor) GetResolutions(handlerFn func(resolution *Label) error) error {
+	return nil
+}
+
+/*
+ * Severities
+ */
+
+// GetSeverities retrieves no severities - an empty Trac environment has none.
+func (accessor *EmptyAccessor) GetSeverities(handlerFn func(severity *Label) error) error {
+	return nil
+}
+
+/*
+ * Tickets
+ */
+
+// GetTickets retrieves no tickets - an empty Trac environment has none.
+func (accessor *EmptyAccessor) GetTickets(handlerFn func(ticket *Ticket) error) error {
+	return nil
+}
+
+/*
+ * Ticket Changes
+ */
+
+// GetTicketChanges retrieves no ticket changes - an empty Trac environment has no tickets.
+func (accessor *EmptyAccessor) GetTicketChanges(ticketID int64, handlerFn func(change *TicketChange) error) error {
+	return nil
+}
+
+// GetTicketCommentTime returns 0, as for a comment which cannot be found - an empty Trac environment has no tickets.
+func (accessor *EmptyAccessor) GetTicketCommentTime(ticketID int64, changeNum int64) (int64, error) {
+	return 0, nil
+}
+
+/*
+ * Ticket Attachments
+ */
+
+// GetTicketAttachmentPath returns an empty path - an empty Trac environment has no attachments.
+func (accessor *EmptyAccessor) GetTicketAttachmentPath(attachment *TicketAttachment) string {
+	return ""
+}
+
+// GetTicketAttachments retrieves no attachments - an empty Trac environment has no tickets.
+func (accessor *EmptyAccessor) GetTicketAttachments(ticketID int64, handlerFn func(attachment *TicketAttachment) error) error {
+	return nil
+}
+
+/*
+ * Types
+ */
+
+// GetTypes retrieves no types - an empty Trac environment has none.
+func (accessor *EmptyAccessor) GetTypes(handlerFn func(tracType *Label) error) error {
+	return nil
+}
+
+/*
+ * Users
+ */
+
+// GetFullNames retrieves no full names - an empty Trac environment has no users.
+func (accessor *EmptyAccessor) GetFullNames(handlerFn func(userName string, fullName string) error) error {
+	return nil
+}
+
+// GetUsers retrieves no users - an empty Trac environment has none.
+func (accessor *EmptyAccessor) GetUsers(handlerFn func(user string) error) error {
+	return nil
+}
+
+/*
+ * Versions
+ */
+
+// GetVersions retrieves no versions - an empty Trac environment has none.
+func (accessor *EmptyAccessor) GetVersions(handlerFn func(version *Label) error) error {
+	return nil
+}
+
+/*
+ * Wiki
+ */
+
+// GetWikiPages retrieves no wiki pages - an empty Trac environment has none.
+func (accessor *EmptyAccessor) GetWikiPages(handlerFn func(page *WikiPage) error) error {
+	return nil
+}
+
+// GetWikiAttachmentPath returns an empty path - an empty Trac environment has no attachments.
+func (accessor *EmptyAccessor) GetWikiAttachmentPath(attachment *WikiAttachment) string {
+	return ""
+}
+
+// GetWikiAttachments retrieves no wiki attachments - an empty Trac environment has none.
+func (accessor *EmptyAccessor) GetWikiAttachments(handlerFn func(attachment *WikiAttachment) error) error {
+	return nil
+}
+
+// IsPredefinedPage returns true if the provided page name is one of Trac's predefined ones.
+func (accessor *EmptyAccessor) IsPredefinedPage(pageName string) bool {
+	for _, predefinedTracPage := range prefinedTracPages {
+		if pageName == predefinedTracPage {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,36 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package trac
+
+import "strings"
+
+// interTracSectionName is the name of the section of the Trac config holding InterTrac definitions
+const interTracSectionName = "intertrac"
+
+// GetInterTracPrefixes retrieves the InterTrac prefixes defined in the Trac config, mapping each (lower-cased) prefix onto the name of the Trac environment it refers to.
+func (accessor *DefaultAccessor) GetInterTracPrefixes() map[string]string {
+	prefixes := make(map[string]string)
+	section, err := accessor.config.GetSection(interTracSectionName)
+	if err != nil {
+		return prefixes
+	}
+
+	// InterTrac config consists of:
+	// - '<environment>.title', '<environment>.url' etc. entries defining an environment
+	// - '<alias> = <environment>' entries defining an alias for an environment
+	for _, key := range section.Keys() {
+		keyName := strings.ToLower(key.Name())
+		dotPos := strings.Index(keyName, ".")
+		if dotPos == -1 {
+			prefixes[keyName] = strings.ToLower(strings.TrimSpace(key.String()))
+			continue
+		}
+
+		environmentName := keyName[0:dotPos]
+		prefixes[environmentName] = environmentName
+	}
+
+	return prefixes
+}
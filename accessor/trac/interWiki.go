@@ -0,0 +1,87 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package trac
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// interWikiSectionName is the name of the section of the Trac config holding InterWiki definitions
+const interWikiSectionName = "interwiki"
+
+// interMapTxtPageName is the name of the Trac wiki page holding InterWiki definitions
+const interMapTxtPageName = "InterMapTxt"
+
+// regexp for an InterWiki definition in the InterMapTxt page: '<prefix> <url> [# <title>]': $1=prefix, $2=url, $3=title
+var interMapTxtDefinitionRegexp = regexp.MustCompile(`^([a-zA-Z][-a-zA-Z0-9+._]*)[ \t]+([^ \t]+)(?:[ \t]+#(.*))?`)
+
+// getInterMapTxt retrieves the text of the latest version of the InterMapTxt wiki page, returns an empty string if there is no such page.
+func (accessor *DefaultAccessor) getInterMapTxt() (string, error) {
+	var text string
+	err := accessor.db.QueryRow(`SELECT text FROM wiki WHERE name = $1 ORDER BY version DESC LIMIT 1`, interMapTxtPageName).Scan(&text)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		err = errors.Wrapf(err, "retrieving Trac wiki page %s", interMapTxtPageName)
+		return "", err
+	}
+
+	return text, nil
+}
+
+// GetInterWikiPrefixes retrieves the InterWiki prefixes defined in the InterMapTxt wiki page and the Trac config, mapping each (lower-cased) prefix onto its definition.
+// As in Trac, a prefix defined in the config takes precedence over one defined in the InterMapTxt page.
+func (accessor *DefaultAccessor) GetInterWikiPrefixes() (map[string]*InterWikiPrefix, error) {
+	prefixes := make(map[string]*InterWikiPrefix)
+
+	// the InterMapTxt definitions are those lines following a '----' line (up to any further '----' line) which look like definitions
+	text, err := accessor.getInterMapTxt()
+	if err != nil {
+		return nil, err
+	}
+	inMap := false
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(line, "----") {
+			inMap = !inMap
+			continue
+		}
+		if !inMap {
+			continue
+		}
+
+		if match := interMapTxtDefinitionRegexp.FindStringSubmatch(line); match != nil {
+			title := strings.TrimSpace(match[3])
+			if title == "" {
+				title = match[1]
+			}
+			prefixes[strings.ToLower(match[1])] = &InterWikiPrefix{Prefix: match[1], URL: strings.TrimSpace(match[2]), Title: title}
+		}
+	}
+
+	// config entries are of the form '<prefix> = <url> [<title>]'
+	section, err := accessor.config.GetSection(interWikiSectionName)
+	if err != nil {
+		return prefixes, nil
+	}
+	for _, key := range section.Keys() {
+		urlAndTitle := strings.SplitN(strings.TrimSpace(key.String()), " ", 2)
+		if urlAndTitle[0] == "" {
+			continue
+		}
+
+		title := key.Name()
+		if len(urlAndTitle) > 1 && strings.TrimSpace(urlAndTitle[1]) != "" {
+			title = strings.TrimSpace(urlAndTitle[1])
+		}
+		prefixes[strings.ToLower(key.Name())] = &InterWikiPrefix{Prefix: key.Name(), URL: urlAndTitle[0], Title: title}
+	}
+
+	return prefixes, nil
+}
@@ -0,0 +1,27 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package trac
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+// GetReport retrieves the Trac report with a given ID, returns nil if there is no such report.
+func (accessor *DefaultAccessor) GetReport(reportID int64) (*Report, error) {
+	var title string
+	var query string
+	err := accessor.db.QueryRow(`SELECT title, query FROM report WHERE id = $1`, reportID).Scan(&title, &query)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		err = errors.Wrapf(err, "retrieving Trac report %d", reportID)
+		return nil, err
+	}
+
+	return &Report{ReportID: reportID, Title: title, Query: query}, nil
+}
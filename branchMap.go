@@ -0,0 +1,57 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// readBranchMap reads the branch map (Subversion branch path -> git branch map) from the provided file.
+// Returns nil if no file was provided.
+func readBranchMap(mapFile string) (map[string]string, error) {
+	if mapFile == "" {
+		return nil, nil
+	}
+
+	fd, err := os.Open(mapFile)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	branchMap := make(map[string]string)
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		branchMapLine := scanner.Text()
+		if strings.Trim(branchMapLine, " ") == "" {
+			continue
+		}
+
+		equalsPos := strings.Index(branchMapLine, "=")
+		if equalsPos == -1 {
+			return nil, fmt.Errorf("badly formatted branch map file %s: expecting '=', found %s", mapFile, branchMapLine)
+		}
+
+		svnBranchPath := strings.Trim(branchMapLine[0:equalsPos], " /")
+		gitBranch := strings.Trim(branchMapLine[equalsPos+1:], " ")
+		if svnBranchPath == "" || gitBranch == "" {
+			return nil, fmt.Errorf("badly formatted branch map file %s: expecting '<svn-branch-path> = <git-branch>', found %s", mapFile, branchMapLine)
+		}
+		if _, found := branchMap[svnBranchPath]; found {
+			return nil, fmt.Errorf("branch map file %s: contains multiple entries for %s", mapFile, svnBranchPath)
+		}
+
+		branchMap[svnBranchPath] = gitBranch
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return branchMap, nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/stevejefferson/trac2gitea/importer"
+	"github.com/stevejefferson/trac2gitea/log"
+	"github.com/stevejefferson/trac2gitea/markdown"
+)
+
+// supported conversion report formats
+const (
+	jsonConversionReportFormat     = "json"
+	markdownConversionReportFormat = "markdown"
+)
+
+// isValidConversionReportFormat returns true if the provided conversion report format is supported
+func isValidConversionReportFormat(format string) bool {
+	return format == jsonConversionReportFormat || format == markdownConversionReportFormat
+}
+
+// markdownTableCell returns text escaped for use in a cell of a markdown table
+func markdownTableCell(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.ReplaceAll(text, "|", `\|`)
+}
+
+// markdownCodeSpan returns text as a markdown code span for use in a cell of a markdown table
+func markdownCodeSpan(text string) string {
+	text = markdownTableCell(text)
+	if strings.Contains(text, "`") {
+		return "`` " + text + " ``"
+	}
+	return "`" + text + "`"
+}
+
+// writeMarkdownDiagnosticRow writes a diagnostic as a row of a markdown table, preceded by any additional cells provided
+func writeMarkdownDiagnosticRow(builder *strings.Builder, diagnostic *markdown.Diagnostic, cells ...string) {
+	for _, cell := range cells {
+		builder.WriteString("| " + cell + " ")
+	}
+	builder.WriteString("| " + string(diagnostic.Kind) + " | " + markdownCodeSpan(diagnostic.Source) + " | " + markdownTableCell(diagnostic.Message) + " |\n")
+}
+
+// formatMarkdownConversionReport formats a conversion report as markdown
+func formatMarkdownConversionReport(report *importer.ConversionReport) string {
+	var builder strings.Builder
+	builder.WriteString("# Trac Conversion Report\n\n")
+	builder.WriteString(fmt.Sprintf("%d of %d converted wiki pages and %d of %d converted tickets contain Trac markup which could not be converted into markdown.\n",
+		len(report.WikiPages), report.WikiPagesConverted, len(report.Tickets), report.TicketsConverted))
+
+	if len(report.WikiPages) > 0 {
+		builder.WriteString("\n## Wiki Pages\n")
+	}
+	for _, page := range report.WikiPages {
+		builder.WriteString(fmt.Sprintf("\n### %s (version %d): %d problems\n\n", page.WikiPage, page.Version, len(page.Diagnostics)))
+		builder.WriteString("| Kind | Trac Text | Problem |\n| --- | --- | --- |\n")
+		for _, diagnostic := range page.Diagnostics {
+			writeMarkdownDiagnosticRow(&builder, &diagnostic)
+		}
+	}
+
+	if len(report.Tickets) > 0 {
+		builder.WriteString("\n## Tickets\n")
+	}
+	for _, ticket := range report.Tickets {
+		builder.WriteString(fmt.Sprintf("\n### Ticket %d (issue #%d): %d problems\n\n", ticket.TicketID, ticket.IssueIndex, len(ticket.Diagnostics)))
+		builder.WriteString("| Location | Kind | Trac Text | Problem |\n| --- | --- | --- | --- |\n")
+		for _, diagnostic := range ticket.Diagnostics {
+			location := "description"
+			if diagnostic.Comment != 0 {
+				location = fmt.Sprintf("comment:%d", diagnostic.Comment)
+			}
+			writeMarkdownDiagnosticRow(&builder, &diagnostic.Diagnostic, location)
+		}
+	}
+
+	return builder.String()
+}
+
+// writeConversionReport writes the report of the Trac markup which could not be converted into markdown into the provided file in the provided format
+func writeConversionReport(reportFile string, format string, report *importer.ConversionReport) error {
+	var reportText []byte
+	switch format {
+	case jsonConversionReportFormat:
+		reportJSON, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		reportText = append(reportJSON, '\n')
+	case markdownConversionReportFormat:
+		reportText = []byte(formatMarkdownConversionReport(report))
+	default:
+		return fmt.Errorf("unsupported conversion report format %s", format)
+	}
+
+	if err := os.WriteFile(reportFile, reportText, 0644); err != nil {
+		return err
+	}
+	log.Info("wrote conversion report to %s: %d wiki pages and %d tickets contain Trac markup which could not be converted",
+		reportFile, len(report.WikiPages), len(report.Tickets))
+	return nil
+}
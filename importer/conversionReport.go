@@ -0,0 +1,99 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package importer
+
+import (
+	"sort"
+
+	"github.com/stevejefferson/trac2gitea/accessor/trac"
+	"github.com/stevejefferson/trac2gitea/markdown"
+)
+
+// WikiPageDiagnostics lists the Trac markup which could not be converted into markdown in the latest imported version of a Trac wiki page
+type WikiPageDiagnostics struct {
+	WikiPage    string                `json:"wikiPage"`
+	Version     int64                 `json:"version"`
+	Diagnostics []markdown.Diagnostic `json:"diagnostics"`
+}
+
+// TicketTextDiagnostic is a diagnostic of the conversion of the description or a comment of a Trac ticket
+type TicketTextDiagnostic struct {
+	markdown.Diagnostic
+
+	// Comment is the number of the Trac comment containing the markup, or 0 if it is in the ticket description
+	Comment int64 `json:"comment,omitempty"`
+}
+
+// TicketDiagnostics lists the Trac markup which could not be converted into markdown in the description and comments of a Trac ticket
+type TicketDiagnostics struct {
+	TicketID    int64                  `json:"ticket"`
+	IssueIndex  int64                  `json:"issue"`
+	Diagnostics []TicketTextDiagnostic `json:"diagnostics"`
+}
+
+// ConversionReport lists, by wiki page and by ticket, the Trac markup which could not be converted into markdown during an import.
+// Only pages and tickets with diagnostics are listed, those with the most diagnostics first.
+type ConversionReport struct {
+	WikiPagesConverted int                    `json:"wikiPagesConverted"`
+	TicketsConverted   int                    `json:"ticketsConverted"`
+	WikiPages          []*WikiPageDiagnostics `json:"wikiPages"`
+	Tickets            []*TicketDiagnostics   `json:"tickets"`
+}
+
+// addWikiPageDiagnostics records the diagnostics of the conversion of a version of a Trac wiki page
+// - versions are converted in order so only the diagnostics of the latest version are kept
+func (importer *Importer) addWikiPageDiagnostics(page *trac.WikiPage, diagnostics []markdown.Diagnostic) {
+	importer.wikiPageDiagnostics[page.Name] = &WikiPageDiagnostics{WikiPage: page.Name, Version: page.Version, Diagnostics: diagnostics}
+}
+
+// addTicketTextDiagnostics records the diagnostics of the conversion of a ticket description or comment
+func (importer *Importer) addTicketTextDiagnostics(ticketText *ticketText, diagnostics []markdown.Diagnostic) {
+	ticketDiagnostics, found := importer.ticketDiagnostics[ticketText.ticketID]
+	if !found {
+		ticketDiagnostics = &TicketDiagnostics{
+			TicketID: ticketText.ticketID, IssueIndex: importer.issueIndex(ticketText.ticketID), Diagnostics: []TicketTextDiagnostic{}}
+		importer.ticketDiagnostics[ticketText.ticketID] = ticketDiagnostics
+	}
+
+	for _, diagnostic := range diagnostics {
+		ticketDiagnostics.Diagnostics = append(ticketDiagnostics.Diagnostics, TicketTextDiagnostic{Diagnostic: diagnostic, Comment: ticketText.commentNum})
+	}
+}
+
+// ConversionReport returns the report of the Trac markup which could not be converted into markdown in the wiki pages and tickets imported so far
+func (importer *Importer) ConversionReport() *ConversionReport {
+	report := ConversionReport{
+		WikiPagesConverted: len(importer.wikiPageDiagnostics),
+		TicketsConverted:   len(importer.ticketDiagnostics),
+		WikiPages:          []*WikiPageDiagnostics{},
+		Tickets:            []*TicketDiagnostics{},
+	}
+
+	for _, pageDiagnostics := range importer.wikiPageDiagnostics {
+		if len(pageDiagnostics.Diagnostics) > 0 {
+			report.WikiPages = append(report.WikiPages, pageDiagnostics)
+		}
+	}
+	sort.Slice(report.WikiPages, func(i, j int) bool {
+		if len(report.WikiPages[i].Diagnostics) != len(report.WikiPages[j].Diagnostics) {
+			return len(report.WikiPages[i].Diagnostics) > len(report.WikiPages[j].Diagnostics)
+		}
+		return report.WikiPages[i].WikiPage < report.WikiPages[j].WikiPage
+	})
+
+	for _, ticketDiagnostics := range importer.ticketDiagnostics {
+		if len(ticketDiagnostics.Diagnostics) > 0 {
+			report.Tickets = append(report.Tickets, ticketDiagnostics)
+		}
+	}
+	sort.Slice(report.Tickets, func(i, j int) bool {
+		if len(report.Tickets[i].Diagnostics) != len(report.Tickets[j].Diagnostics) {
+			return len(report.Tickets[i].Diagnostics) > len(report.Tickets[j].Diagnostics)
+		}
+		return report.Tickets[i].TicketID < report.Tickets[j].TicketID
+	})
+
+	return &report
+}
@@ -0,0 +1,89 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package importer_test
+
+import (
+	"testing"
+
+	"github.com/stevejefferson/trac2gitea/accessor/trac"
+	"github.com/stevejefferson/trac2gitea/importer"
+	"github.com/stevejefferson/trac2gitea/markdown"
+)
+
+var (
+	unconvertedMacroDiagnostic = markdown.Diagnostic{Kind: markdown.UnconvertedMacroDiagnostic, Source: "[[Foo]]", Message: "Trac macro [[Foo]] not converted"}
+	unresolvedLinkDiagnostic   = markdown.Diagnostic{Kind: markdown.UnresolvedLinkDiagnostic, Source: "milestone:bar", Message: "cannot find milestone \"bar\""}
+)
+
+func TestConversionReportOfMultiVersionWikiPages(t *testing.T) {
+	setUpWiki(t)
+	defer tearDown(t)
+
+	expectCloneWiki(t)
+	expectTracToReturnWikiPages(t, tracWikiPage1v1, tracWikiPage1v2, tracWikiPage2v1, tracWikiPage2v2)
+	expectTracToReturnWikiAttachments(t)
+	for _, page := range []struct {
+		tracPage  *trac.WikiPage
+		giteaPage string
+	}{{tracWikiPage1v1, giteaWikiPage1}, {tracWikiPage1v2, giteaWikiPage1}, {tracWikiPage2v1, giteaWikiPage2}, {tracWikiPage2v2, giteaWikiPage2}} {
+		expectToTestForPredefinedWikiPage(t, page.tracPage, false)
+		expectToTranslateWikiPageName(t, page.tracPage, page.giteaPage)
+	}
+
+	// only the latest version of each page counts: the problem in the first version of page 1 has been fixed, page 2 has gained some
+	expectToWriteGiteaWikiPage(t, tracWikiPage1v1, giteaWikiPage1, true, unconvertedMacroDiagnostic)
+	expectToCommitGiteaWikiPage(t, tracWikiPage1v1)
+	expectToWriteGiteaWikiPage(t, tracWikiPage1v2, giteaWikiPage1, true)
+	expectToCommitGiteaWikiPage(t, tracWikiPage1v2)
+	expectToWriteGiteaWikiPage(t, tracWikiPage2v1, giteaWikiPage2, true)
+	expectToCommitGiteaWikiPage(t, tracWikiPage2v1)
+	expectToWriteGiteaWikiPage(t, tracWikiPage2v2, giteaWikiPage2, true, unconvertedMacroDiagnostic, unresolvedLinkDiagnostic)
+	expectToCommitGiteaWikiPage(t, tracWikiPage2v2)
+
+	dataImporter.ImportWiki()
+
+	report := dataImporter.ConversionReport()
+	assertEquals(t, report.WikiPagesConverted, 2)
+	assertEquals(t, report.TicketsConverted, 0)
+	assertEquals(t, len(report.Tickets), 0)
+	assertEquals(t, len(report.WikiPages), 1)
+	assertEquals(t, report.WikiPages[0].WikiPage, tracWikiPage2v2.Name)
+	assertEquals(t, report.WikiPages[0].Version, tracWikiPage2v2.Version)
+	assertEquals(t, len(report.WikiPages[0].Diagnostics), 2)
+	assertEquals(t, report.WikiPages[0].Diagnostics[0], unconvertedMacroDiagnostic)
+	assertEquals(t, report.WikiPages[0].Diagnostics[1], unresolvedLinkDiagnostic)
+}
+
+func TestConversionReportOfTicketWithComments(t *testing.T) {
+	setUpTickets(t)
+	defer tearDown(t)
+
+	closedTicket.descriptionDiags = []markdown.Diagnostic{unresolvedLinkDiagnostic}
+	closedTicketComment2.commentNum = "1.2"
+	closedTicketComment2.diagnostics = []markdown.Diagnostic{unconvertedMacroDiagnostic}
+
+	expectTracTicketRetrievals(t, closedTicket)
+	expectAllTicketActions(t, closedTicket)
+	expectTracAttachmentRetrievals(t, closedTicket)
+	expectTracChangeRetrievals(t, closedTicket, closedTicketComment1, closedTicketComment2)
+	expectAllTicketCommentActions(t, closedTicket, closedTicketComment1)
+	expectAllTicketCommentActions(t, closedTicket, closedTicketComment2)
+	expectIssueUpdateTimeSetToLatestOf(t, closedTicket, closedTicketComment1, closedTicketComment2)
+	expectIssueCommentCountUpdate(t, closedTicket)
+	expectIssueCountUpdates(t)
+	expectDescriptionMarkdownConversion(t, closedTicket)
+	expectIssueDescriptionUpdates(t, closedTicket.issueID, closedTicket.descriptionMarkdown)
+
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
+
+	report := dataImporter.ConversionReport()
+	assertEquals(t, report.TicketsConverted, 1)
+	assertEquals(t, len(report.Tickets), 1)
+	assertEquals(t, report.Tickets[0].TicketID, closedTicket.ticketID)
+	assertEquals(t, report.Tickets[0].IssueIndex, closedTicket.issueIndex)
+	assertEquals(t, len(report.Tickets[0].Diagnostics), 2)
+	assertEquals(t, report.Tickets[0].Diagnostics[0], importer.TicketTextDiagnostic{Diagnostic: unresolvedLinkDiagnostic, Comment: 0})
+	assertEquals(t, report.Tickets[0].Diagnostics[1], importer.TicketTextDiagnostic{Diagnostic: unconvertedMacroDiagnostic, Comment: 2})
+}
@@ -22,6 +22,13 @@ type Importer struct {
 	markdownConverter  markdown.Converter
 	defaultAuthorID    int64
 	convertPredefineds bool
+	issueIndexes       map[int64]int64
+	ticketIssueIDs     map[int64]int64
+	ticketTexts        []ticketText
+
+	// diagnostics of the conversion of Trac wiki text into markdown, for the conversion report
+	wikiPageDiagnostics map[string]*WikiPageDiagnostics
+	ticketDiagnostics   map[int64]*TicketDiagnostics
 }
 
 // CreateImporter returns a new Trac to Gitea importer.
@@ -41,7 +48,8 @@ func CreateImporter(
 		return nil, errors.Errorf("Could not find default user, '%s'", dfltAuthor)
 	}
 
-	importer := Importer{tracAccessor: tAccessor, giteaAccessor: gAccessor, markdownConverter: converter, defaultAuthorID: dfltAuthorID, convertPredefineds: convertPredefs}
+	importer := Importer{tracAccessor: tAccessor, giteaAccessor: gAccessor, markdownConverter: converter, defaultAuthorID: dfltAuthorID, convertPredefineds: convertPredefs,
+		issueIndexes: make(map[int64]int64), ticketIssueIDs: make(map[int64]int64), wikiPageDiagnostics: make(map[string]*WikiPageDiagnostics), ticketDiagnostics: make(map[int64]*TicketDiagnostics)}
 
 	return &importer, nil
 }
@@ -0,0 +1,98 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package importer
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stevejefferson/trac2gitea/accessor/gitea"
+	"github.com/stevejefferson/trac2gitea/accessor/trac"
+	"github.com/stevejefferson/trac2gitea/log"
+)
+
+// IssueIndexMap returns the map of Trac ticket ID onto Gitea issue index used by the importer.
+// Any Trac ticket not in this map is imported into the Gitea issue whose index is the same as the ticket ID.
+// The map returned is the one used by the importer so any entries added to it will be used by subsequent imports.
+func (importer *Importer) IssueIndexMap() map[int64]int64 {
+	return importer.issueIndexes
+}
+
+// issueIndex returns the Gitea issue index for a given Trac ticket
+func (importer *Importer) issueIndex(ticketID int64) int64 {
+	if issueIndex, found := importer.issueIndexes[ticketID]; found {
+		return issueIndex
+	}
+
+	return ticketID
+}
+
+// AllocateIssueIndexes allocates a Gitea issue index to every Trac ticket which does not already appear in the issue index map.
+// If allocateNextFree is set, tickets are allocated the next free issue indexes in the Gitea repository (in ticket order),
+// otherwise each ticket is allocated the issue index of its ticket ID plus the provided offset.
+func (importer *Importer) AllocateIssueIndexes(offset int64, allocateNextFree bool) error {
+	var nextFreeIndex int64
+	if allocateNextFree {
+		maxIndex, err := importer.giteaAccessor.GetMaxIssueIndex()
+		if err != nil {
+			return err
+		}
+
+		// do not reuse any index previously allocated to a ticket - the issue may not have been created yet
+		for _, issueIndex := range importer.issueIndexes {
+			if issueIndex > maxIndex {
+				maxIndex = issueIndex
+			}
+		}
+		nextFreeIndex = maxIndex + 1
+	}
+
+	return importer.tracAccessor.GetTickets(func(ticket *trac.Ticket) error {
+		if _, found := importer.issueIndexes[ticket.TicketID]; found {
+			return nil
+		}
+
+		var issueIndex int64
+		if allocateNextFree {
+			issueIndex = nextFreeIndex
+			nextFreeIndex++
+		} else {
+			issueIndex = ticket.TicketID + offset
+		}
+
+		log.Debug("allocated Gitea issue index %d to Trac ticket %d", issueIndex, ticket.TicketID)
+		importer.issueIndexes[ticket.TicketID] = issueIndex
+		return nil
+	})
+}
+
+// CheckIssueIndexes checks that the Gitea issue index allocated to each Trac ticket is either unused or is that of the issue previously imported from the ticket,
+// returning an error if any is in use by some other issue - importing the ticket would otherwise silently skip it (or overwrite the other issue).
+// An issue is taken to have been imported from a ticket if its creation time matches that of the ticket.
+// Tickets imported into the issue whose index is their ticket ID are not checked: any existing issue at that index is treated as already imported.
+func (importer *Importer) CheckIssueIndexes() error {
+	return importer.tracAccessor.GetTickets(func(ticket *trac.Ticket) error {
+		issueIndex := importer.issueIndex(ticket.TicketID)
+		if issueIndex == ticket.TicketID {
+			return nil
+		}
+
+		issueID, err := importer.giteaAccessor.GetIssueID(issueIndex)
+		if err != nil {
+			return err
+		}
+		if issueID == gitea.NullID {
+			return nil
+		}
+
+		createdTime, err := importer.giteaAccessor.GetIssueCreatedTime(issueID)
+		if err != nil {
+			return err
+		}
+		if createdTime != ticket.Created {
+			return errors.Errorf("cannot import Trac ticket %d into Gitea issue %d: the issue already exists and was not imported from the ticket", ticket.TicketID, issueIndex)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,142 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package importer_test
+
+import (
+	"testing"
+
+	"github.com/stevejefferson/trac2gitea/accessor/gitea"
+)
+
+const issueIndexOffset int64 = 500
+
+func TestAllocateIssueIndexesWithOffset(t *testing.T) {
+	setUpTickets(t)
+	defer tearDown(t)
+
+	expectTracTicketRetrievals(t, closedTicket, openTicket)
+
+	err := dataImporter.AllocateIssueIndexes(issueIndexOffset, false)
+	assertEquals(t, err, nil)
+
+	issueIndexMap := dataImporter.IssueIndexMap()
+	assertEquals(t, len(issueIndexMap), 2)
+	assertEquals(t, issueIndexMap[closedTicket.ticketID], closedTicket.ticketID+issueIndexOffset)
+	assertEquals(t, issueIndexMap[openTicket.ticketID], openTicket.ticketID+issueIndexOffset)
+}
+
+func TestAllocateNextFreeIssueIndexes(t *testing.T) {
+	setUpTickets(t)
+	defer tearDown(t)
+
+	var maxIssueIndex int64 = 73
+	mockGiteaAccessor.
+		EXPECT().
+		GetMaxIssueIndex().
+		Return(maxIssueIndex, nil)
+
+	expectTracTicketRetrievals(t, closedTicket, openTicket)
+
+	err := dataImporter.AllocateIssueIndexes(0, true)
+	assertEquals(t, err, nil)
+
+	issueIndexMap := dataImporter.IssueIndexMap()
+	assertEquals(t, issueIndexMap[closedTicket.ticketID], maxIssueIndex+1)
+	assertEquals(t, issueIndexMap[openTicket.ticketID], maxIssueIndex+2)
+}
+
+func TestAllocateNextFreeIssueIndexesRetainsExistingMappings(t *testing.T) {
+	setUpTickets(t)
+	defer tearDown(t)
+
+	var maxIssueIndex int64 = 73
+	var existingIssueIndex int64 = 90
+	dataImporter.IssueIndexMap()[openTicket.ticketID] = existingIssueIndex
+
+	mockGiteaAccessor.
+		EXPECT().
+		GetMaxIssueIndex().
+		Return(maxIssueIndex, nil)
+
+	expectTracTicketRetrievals(t, closedTicket, openTicket)
+
+	err := dataImporter.AllocateIssueIndexes(0, true)
+	assertEquals(t, err, nil)
+
+	// previously-mapped ticket keeps its index, other tickets are allocated indexes beyond it
+	issueIndexMap := dataImporter.IssueIndexMap()
+	assertEquals(t, issueIndexMap[openTicket.ticketID], existingIssueIndex)
+	assertEquals(t, issueIndexMap[closedTicket.ticketID], existingIssueIndex+1)
+}
+
+func TestImportTicketWithOffsetIssueIndex(t *testing.T) {
+	setUpTickets(t)
+	defer tearDown(t)
+
+	closedTicket.issueIndex = closedTicket.ticketID + issueIndexOffset
+
+	// expect tickets to be retrieved from Trac twice: once for index allocation, once for import
+	expectTracTicketRetrievals(t, closedTicket)
+	expectTracTicketRetrievals(t, closedTicket)
+
+	// expect all actions for creating Gitea issue from Trac ticket - using the allocated issue index
+	expectAllTicketActions(t, closedTicket)
+	expectTracAttachmentRetrievals(t, closedTicket)
+	expectTracChangeRetrievals(t, closedTicket)
+	expectIssueUpdateTimeSetToLatestOf(t, closedTicket)
+	expectIssueCommentCountUpdate(t, closedTicket)
+	expectIssueCountUpdates(t)
+	expectDescriptionMarkdownConversion(t, closedTicket)
+	expectIssueDescriptionUpdates(t, closedTicket.issueID, closedTicket.descriptionMarkdown)
+
+	err := dataImporter.AllocateIssueIndexes(issueIndexOffset, false)
+	assertEquals(t, err, nil)
+
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
+}
+
+func TestCheckIssueIndexesAcceptsUnusedAndPreviouslyImportedIssues(t *testing.T) {
+	setUpTickets(t)
+	defer tearDown(t)
+
+	closedTicket.issueIndex = closedTicket.ticketID + issueIndexOffset
+	openTicket.issueIndex = openTicket.ticketID + issueIndexOffset
+	dataImporter.IssueIndexMap()[closedTicket.ticketID] = closedTicket.issueIndex
+	dataImporter.IssueIndexMap()[openTicket.ticketID] = openTicket.issueIndex
+
+	expectTracTicketRetrievals(t, closedTicket, openTicket)
+	expectIssueLookup(t, closedTicket, gitea.NullID)
+	expectIssueLookup(t, openTicket, openTicket.issueID)
+	expectIssueCreatedTimeLookup(t, openTicket, openTicket.created)
+
+	err := dataImporter.CheckIssueIndexes()
+	assertEquals(t, err, nil)
+}
+
+func TestCheckIssueIndexesRejectsClashingIssue(t *testing.T) {
+	setUpTickets(t)
+	defer tearDown(t)
+
+	closedTicket.issueIndex = closedTicket.ticketID + issueIndexOffset
+	dataImporter.IssueIndexMap()[closedTicket.ticketID] = closedTicket.issueIndex
+
+	expectTracTicketRetrievals(t, closedTicket)
+	expectIssueLookup(t, closedTicket, closedTicket.issueID)
+	expectIssueCreatedTimeLookup(t, closedTicket, closedTicket.created+1)
+
+	err := dataImporter.CheckIssueIndexes()
+	assertTrue(t, err != nil)
+}
+
+func TestCheckIssueIndexesIgnoresUnmappedTickets(t *testing.T) {
+	setUpTickets(t)
+	defer tearDown(t)
+
+	// no lookup of the issue at the ticket ID is expected
+	expectTracTicketRetrievals(t, closedTicket)
+
+	err := dataImporter.CheckIssueIndexes()
+	assertEquals(t, err, nil)
+}
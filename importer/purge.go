@@ -0,0 +1,139 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package importer
+
+import (
+	"time"
+
+	"github.com/stevejefferson/trac2gitea/accessor/gitea"
+	"github.com/stevejefferson/trac2gitea/accessor/trac"
+	"github.com/stevejefferson/trac2gitea/log"
+)
+
+// prefix of the Trac page version identifier in the commit message of every wiki commit created by importWikiPages
+const importedWikiCommitMarker = "[Imported from Trac: page "
+
+// author of the wiki commit removing imported wiki files
+const purgeWikiCommitAuthor = "trac2gitea"
+
+// PurgeIssues deletes the Gitea issues (and their comments, labels, assignees, participants and attachments) imported from Trac tickets.
+// An issue is only deleted if its creation time matches that of the Trac ticket - any other issue at the ticket's issue index was not imported from it.
+func (importer *Importer) PurgeIssues() error {
+	err := importer.tracAccessor.GetTickets(func(ticket *trac.Ticket) error {
+		issueIndex := importer.issueIndex(ticket.TicketID)
+		issueID, err := importer.giteaAccessor.GetIssueID(issueIndex)
+		if err != nil {
+			return err
+		}
+		if issueID == gitea.NullID {
+			log.Debug("no Gitea issue %d for Trac ticket %d - nothing to purge", issueIndex, ticket.TicketID)
+			return nil
+		}
+
+		createdTime, err := importer.giteaAccessor.GetIssueCreatedTime(issueID)
+		if err != nil {
+			return err
+		}
+		if createdTime != ticket.Created {
+			log.Warn("Gitea issue %d was not imported from Trac ticket %d - not purged", issueIndex, ticket.TicketID)
+			return nil
+		}
+
+		if err = importer.giteaAccessor.DeleteIssue(issueID); err != nil {
+			return err
+		}
+
+		log.Info("purged issue %d (imported from Trac ticket %d)", issueIndex, ticket.TicketID)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = importer.giteaAccessor.UpdateLabelIssueCounts(); err != nil {
+		return err
+	}
+	if err = importer.giteaAccessor.UpdateMilestoneIssueCounts(); err != nil {
+		return err
+	}
+	if err = importer.giteaAccessor.UpdateRepoIssueCounts(); err != nil {
+		return err
+	}
+
+	return importer.giteaAccessor.ResetIssueIndex()
+}
+
+// PurgeLabels deletes the Gitea labels named in the provided label maps, provided that they are no longer in use.
+func (importer *Importer) PurgeLabels(labelMaps ...map[string]string) error {
+	purgedLabels := make(map[string]bool)
+	for _, labelMap := range labelMaps {
+		for _, labelName := range labelMap {
+			if labelName == "" || purgedLabels[labelName] {
+				continue
+			}
+			purgedLabels[labelName] = true
+
+			deleted, err := importer.giteaAccessor.DeleteUnusedLabel(labelName)
+			if err != nil {
+				return err
+			}
+			if deleted {
+				log.Info("purged label %s", labelName)
+			}
+		}
+	}
+
+	return nil
+}
+
+// PurgeMilestones deletes the Gitea milestones imported from Trac milestones, provided that they are no longer in use.
+func (importer *Importer) PurgeMilestones() error {
+	err := importer.tracAccessor.GetMilestones(func(tracMilestone *trac.Milestone) error {
+		if tracMilestone.Name == "" {
+			return nil
+		}
+
+		deleted, err := importer.giteaAccessor.DeleteUnusedMilestone(tracMilestone.Name)
+		if err != nil {
+			return err
+		}
+		if deleted {
+			log.Info("purged milestone %s", tracMilestone.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return importer.giteaAccessor.UpdateRepoMilestoneCounts()
+}
+
+// PurgeWiki removes every file from the Gitea wiki repository which has only ever been committed by the importer.
+// Files with any other commits (e.g. pages edited in Gitea since the import) are left in place.
+func (importer *Importer) PurgeWiki() error {
+	err := importer.giteaAccessor.CloneWiki()
+	if err != nil {
+		return err
+	}
+
+	importedFiles, err := importer.giteaAccessor.GetImportedWikiFiles(importedWikiCommitMarker)
+	if err != nil {
+		return err
+	}
+	if len(importedFiles) == 0 {
+		log.Info("no imported wiki files found - wiki not purged")
+		return nil
+	}
+
+	for _, importedFile := range importedFiles {
+		if err = importer.giteaAccessor.DeleteWikiFile(importedFile); err != nil {
+			return err
+		}
+		log.Info("purged wiki file %s", importedFile)
+	}
+
+	return importer.giteaAccessor.CommitWikiToRepo(purgeWikiCommitAuthor, time.Now().Unix(), "Purged pages imported from Trac")
+}
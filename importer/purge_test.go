@@ -0,0 +1,155 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package importer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stevejefferson/trac2gitea/accessor/gitea"
+	"go.uber.org/mock/gomock"
+)
+
+func expectPurgeCountUpdates(t *testing.T) {
+	expectIssueCountUpdates(t)
+	mockGiteaAccessor.
+		EXPECT().
+		ResetIssueIndex().
+		Return(nil)
+}
+
+func expectIssueLookup(t *testing.T, ticket *TicketImport, issueID int64) {
+	mockGiteaAccessor.
+		EXPECT().
+		GetIssueID(gomock.Eq(ticket.issueIndex)).
+		Return(issueID, nil)
+}
+
+func expectIssueCreatedTimeLookup(t *testing.T, ticket *TicketImport, createdTime int64) {
+	mockGiteaAccessor.
+		EXPECT().
+		GetIssueCreatedTime(gomock.Eq(ticket.issueID)).
+		Return(createdTime, nil)
+}
+
+func expectIssueDeletion(t *testing.T, ticket *TicketImport) {
+	mockGiteaAccessor.
+		EXPECT().
+		DeleteIssue(gomock.Eq(ticket.issueID)).
+		Return(nil)
+}
+
+func TestPurgeImportedIssue(t *testing.T) {
+	setUpTickets(t)
+	defer tearDown(t)
+
+	expectTracTicketRetrievals(t, closedTicket)
+	expectIssueLookup(t, closedTicket, closedTicket.issueID)
+	expectIssueCreatedTimeLookup(t, closedTicket, closedTicket.created)
+	expectIssueDeletion(t, closedTicket)
+	expectPurgeCountUpdates(t)
+
+	err := dataImporter.PurgeIssues()
+	assertEquals(t, err, nil)
+}
+
+func TestPurgeRemappedIssue(t *testing.T) {
+	setUpTickets(t)
+	defer tearDown(t)
+
+	openTicket.issueIndex = openTicket.ticketID + 1000
+	dataImporter.IssueIndexMap()[openTicket.ticketID] = openTicket.issueIndex
+
+	expectTracTicketRetrievals(t, openTicket)
+	expectIssueLookup(t, openTicket, openTicket.issueID)
+	expectIssueCreatedTimeLookup(t, openTicket, openTicket.created)
+	expectIssueDeletion(t, openTicket)
+	expectPurgeCountUpdates(t)
+
+	err := dataImporter.PurgeIssues()
+	assertEquals(t, err, nil)
+}
+
+func TestPurgeSkipsMissingAndNonImportedIssues(t *testing.T) {
+	setUpTickets(t)
+	defer tearDown(t)
+
+	expectTracTicketRetrievals(t, closedTicket, openTicket)
+
+	// no Gitea issue for closed ticket
+	expectIssueLookup(t, closedTicket, gitea.NullID)
+
+	// Gitea issue at index of open ticket was created at a different time so was not imported from it
+	expectIssueLookup(t, openTicket, openTicket.issueID)
+	expectIssueCreatedTimeLookup(t, openTicket, openTicket.created+1)
+
+	expectPurgeCountUpdates(t)
+
+	err := dataImporter.PurgeIssues()
+	assertEquals(t, err, nil)
+}
+
+func TestPurgeLabels(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	componentMap := map[string]string{"comp1": "label1", "comp2": "label2", "comp3": ""}
+	typeMap := map[string]string{"type1": "label2", "type2": "label3"}
+
+	// each named label should be deleted (if unused) once only
+	mockGiteaAccessor.EXPECT().DeleteUnusedLabel(gomock.Eq("label1")).Return(true, nil)
+	mockGiteaAccessor.EXPECT().DeleteUnusedLabel(gomock.Eq("label2")).Return(false, nil)
+	mockGiteaAccessor.EXPECT().DeleteUnusedLabel(gomock.Eq("label3")).Return(true, nil)
+
+	err := dataImporter.PurgeLabels(componentMap, typeMap)
+	assertEquals(t, err, nil)
+}
+
+func TestPurgeMilestones(t *testing.T) {
+	setUpMilestones(t)
+	defer tearDown(t)
+
+	// unnamed milestone should be skipped
+	mockGiteaAccessor.EXPECT().DeleteUnusedMilestone(gomock.Eq(completedMilestoneName)).Return(true, nil)
+	mockGiteaAccessor.EXPECT().DeleteUnusedMilestone(gomock.Eq(uncompletedMilestoneName)).Return(false, nil)
+	mockGiteaAccessor.EXPECT().UpdateRepoMilestoneCounts().Return(nil)
+
+	err := dataImporter.PurgeMilestones()
+	assertEquals(t, err, nil)
+}
+
+func TestPurgeWiki(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	importedFiles := []string{"Page1.md", "attachments/Page1/attachment1.file"}
+
+	mockGiteaAccessor.EXPECT().CloneWiki().Return(nil)
+	mockGiteaAccessor.
+		EXPECT().
+		GetImportedWikiFiles(gomock.Any()).
+		DoAndReturn(func(commitMarker string) ([]string, error) {
+			assertTrue(t, strings.HasPrefix(commitMarker, "[Imported from Trac:"))
+			return importedFiles, nil
+		})
+	for _, importedFile := range importedFiles {
+		mockGiteaAccessor.EXPECT().DeleteWikiFile(gomock.Eq(importedFile)).Return(nil)
+	}
+	mockGiteaAccessor.EXPECT().CommitWikiToRepo(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+	err := dataImporter.PurgeWiki()
+	assertEquals(t, err, nil)
+}
+
+func TestPurgeWikiWithNoImportedFiles(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	mockGiteaAccessor.EXPECT().CloneWiki().Return(nil)
+	mockGiteaAccessor.EXPECT().GetImportedWikiFiles(gomock.Any()).Return(nil, nil)
+
+	err := dataImporter.PurgeWiki()
+	assertEquals(t, err, nil)
+}
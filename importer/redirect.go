@@ -0,0 +1,159 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package importer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stevejefferson/trac2gitea/accessor/gitea"
+	"github.com/stevejefferson/trac2gitea/accessor/trac"
+	"github.com/stevejefferson/trac2gitea/log"
+)
+
+// Redirect maps the path of a Trac URL (relative to the root of the Trac environment) onto the URL of the Gitea item imported from it.
+// Trac paths are unencoded and may contain a fragment (e.g. "/ticket/123#comment:4").
+type Redirect struct {
+	TracPath string
+	GiteaURL string
+}
+
+// giteaRootURL returns the root URL of the Gitea server, without a trailing slash
+func (importer *Importer) giteaRootURL() string {
+	return strings.TrimSuffix(importer.giteaAccessor.GetStringConfig("server", "ROOT_URL"), "/")
+}
+
+// generateTicketCommentRedirects generates a redirect for each comment of a Trac ticket onto its Gitea issue comment.
+func (importer *Importer) generateTicketCommentRedirects(rootURL string, ticket *trac.Ticket, issueIndex int64, issueID int64, handlerFn func(redirect *Redirect) error) error {
+	return importer.tracAccessor.GetTicketChanges(ticket.TicketID, func(change *trac.TicketChange) error {
+		if change.ChangeType != trac.TicketCommentChange || change.OldValue == "" {
+			return nil
+		}
+
+		// Trac records the comment number in the 'oldvalue' of a comment change - replies are recorded as "<parent>.<number>"
+		commentNum := change.OldValue[strings.LastIndex(change.OldValue, ".")+1:]
+
+		commentID, err := importer.giteaAccessor.GetIssueCommentIDByTime(issueID, change.Time)
+		if err != nil {
+			return err
+		}
+		if commentID == gitea.NullID {
+			log.Warn("cannot find Gitea comment for comment %s of Trac ticket %d - no redirect generated", commentNum, ticket.TicketID)
+			return nil
+		}
+
+		return handlerFn(&Redirect{
+			TracPath: fmt.Sprintf("/ticket/%d#comment:%s", ticket.TicketID, commentNum),
+			GiteaURL: rootURL + importer.giteaAccessor.GetIssueCommentURL(issueIndex, commentID),
+		})
+	})
+}
+
+// generateTicketAttachmentRedirects generates redirects for each attachment of a Trac ticket onto its Gitea issue attachment.
+func (importer *Importer) generateTicketAttachmentRedirects(rootURL string, ticket *trac.Ticket, issueID int64, handlerFn func(redirect *Redirect) error) error {
+	return importer.tracAccessor.GetTicketAttachments(ticket.TicketID, func(attachment *trac.TicketAttachment) error {
+		uuid, err := importer.giteaAccessor.GetIssueAttachmentUUID(issueID, attachment.FileName)
+		if err != nil {
+			return err
+		}
+		if uuid == "" {
+			log.Warn("cannot find Gitea attachment %s for Trac ticket %d - no redirect generated", attachment.FileName, ticket.TicketID)
+			return nil
+		}
+
+		giteaURL := rootURL + importer.giteaAccessor.GetIssueAttachmentURL(issueID, uuid)
+		for _, attachmentPathPrefix := range []string{"/attachment", "/raw-attachment"} {
+			err = handlerFn(&Redirect{
+				TracPath: fmt.Sprintf("%s/ticket/%d/%s", attachmentPathPrefix, ticket.TicketID, attachment.FileName),
+				GiteaURL: giteaURL,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GenerateTicketRedirects generates redirects for each Trac ticket, ticket comment and ticket attachment onto the Gitea issue, comment and attachment imported from it,
+// passing each redirect to the provided "handler" function.
+func (importer *Importer) GenerateTicketRedirects(handlerFn func(redirect *Redirect) error) error {
+	rootURL := importer.giteaRootURL()
+	return importer.tracAccessor.GetTickets(func(ticket *trac.Ticket) error {
+		issueIndex := importer.issueIndex(ticket.TicketID)
+		issueID, err := importer.giteaAccessor.GetIssueID(issueIndex)
+		if err != nil {
+			return err
+		}
+		if issueID == gitea.NullID {
+			log.Warn("cannot find Gitea issue %d for Trac ticket %d - no redirects generated", issueIndex, ticket.TicketID)
+			return nil
+		}
+
+		err = handlerFn(&Redirect{
+			TracPath: fmt.Sprintf("/ticket/%d", ticket.TicketID),
+			GiteaURL: rootURL + importer.giteaAccessor.GetIssueURL(issueIndex),
+		})
+		if err != nil {
+			return err
+		}
+
+		if err = importer.generateTicketCommentRedirects(rootURL, ticket, issueIndex, issueID, handlerFn); err != nil {
+			return err
+		}
+
+		return importer.generateTicketAttachmentRedirects(rootURL, ticket, issueID, handlerFn)
+	})
+}
+
+// GenerateMilestoneRedirects generates a redirect for each Trac milestone onto the Gitea milestone imported from it,
+// passing each redirect to the provided "handler" function.
+func (importer *Importer) GenerateMilestoneRedirects(handlerFn func(redirect *Redirect) error) error {
+	rootURL := importer.giteaRootURL()
+	return importer.tracAccessor.GetMilestones(func(milestone *trac.Milestone) error {
+		if milestone.Name == "" {
+			return nil
+		}
+
+		milestoneID, err := importer.giteaAccessor.GetMilestoneID(milestone.Name)
+		if err != nil {
+			return err
+		}
+		if milestoneID == gitea.NullID {
+			log.Warn("cannot find Gitea milestone %s - no redirect generated", milestone.Name)
+			return nil
+		}
+
+		return handlerFn(&Redirect{
+			TracPath: "/milestone/" + milestone.Name,
+			GiteaURL: rootURL + importer.giteaAccessor.GetMilestoneURL(milestoneID),
+		})
+	})
+}
+
+// GenerateWikiRedirects generates a redirect for each Trac wiki page onto the Gitea wiki page imported from it,
+// passing each redirect to the provided "handler" function.
+func (importer *Importer) GenerateWikiRedirects(handlerFn func(redirect *Redirect) error) error {
+	rootURL := importer.giteaRootURL()
+
+	// Trac returns every version of every page - only generate a redirect for the first version of each page
+	redirectedPages := make(map[string]bool)
+	return importer.tracAccessor.GetWikiPages(func(page *trac.WikiPage) error {
+		if redirectedPages[page.Name] {
+			return nil
+		}
+		redirectedPages[page.Name] = true
+
+		if !importer.convertPredefineds && importer.tracAccessor.IsPredefinedPage(page.Name) {
+			return nil
+		}
+
+		giteaPageName := importer.giteaAccessor.TranslateWikiPageName(page.Name)
+		return handlerFn(&Redirect{
+			TracPath: "/wiki/" + page.Name,
+			GiteaURL: rootURL + importer.giteaAccessor.GetWikiPageURL(giteaPageName),
+		})
+	})
+}
@@ -0,0 +1,165 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package importer_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stevejefferson/trac2gitea/accessor/gitea"
+	"github.com/stevejefferson/trac2gitea/accessor/trac"
+	"github.com/stevejefferson/trac2gitea/importer"
+	"go.uber.org/mock/gomock"
+)
+
+const (
+	redirectGiteaRootURL = "https://gitea.example.org"
+	redirectIssueURL     = "/org/repo/issues/123"
+	redirectCommentURL   = "/org/repo/issues/123#issuecomment-456"
+	redirectAttachURL    = "/org/repo/attachments/abcdef"
+	redirectMilestoneURL = "/org/repo/milestone/789"
+	redirectWikiPageURL  = "/org/repo/wiki/Gitea_Page1"
+)
+
+func expectGiteaRootURLRetrieval(t *testing.T) {
+	// return root URL with trailing slash as Gitea configs usually do
+	mockGiteaAccessor.
+		EXPECT().
+		GetStringConfig(gomock.Eq("server"), gomock.Eq("ROOT_URL")).
+		Return(redirectGiteaRootURL + "/")
+}
+
+func collectRedirects(t *testing.T, generateFn func(handlerFn func(redirect *importer.Redirect) error) error) map[string]string {
+	redirects := make(map[string]string)
+	err := generateFn(func(redirect *importer.Redirect) error {
+		redirects[redirect.TracPath] = redirect.GiteaURL
+		return nil
+	})
+	assertEquals(t, err, nil)
+	return redirects
+}
+
+func TestGenerateTicketRedirects(t *testing.T) {
+	setUpTickets(t)
+	defer tearDown(t)
+
+	expectGiteaRootURLRetrieval(t)
+	expectTracTicketRetrievals(t, closedTicket)
+	expectIssueLookup(t, closedTicket, closedTicket.issueID)
+	mockGiteaAccessor.EXPECT().GetIssueURL(gomock.Eq(closedTicket.issueIndex)).Return(redirectIssueURL)
+
+	// Trac records comment numbers in the old value of comment changes - replies have the form "<parent>.<number>"
+	var comment1Time int64 = 11111
+	var comment2Time int64 = 22222
+	var comment2ID int64 = 456
+	mockTracAccessor.
+		EXPECT().
+		GetTicketChanges(gomock.Eq(closedTicket.ticketID), gomock.Any()).
+		DoAndReturn(func(ticketID int64, handlerFn func(change *trac.TicketChange) error) error {
+			handlerFn(&trac.TicketChange{TicketID: ticketID, ChangeType: trac.TicketCommentChange, OldValue: "1", Time: comment1Time})
+			handlerFn(&trac.TicketChange{TicketID: ticketID, ChangeType: trac.TicketOwnerChange, OldValue: "someone", Time: comment1Time})
+			handlerFn(&trac.TicketChange{TicketID: ticketID, ChangeType: trac.TicketCommentChange, OldValue: "1.2", Time: comment2Time})
+			return nil
+		})
+
+	// first comment was not imported
+	mockGiteaAccessor.EXPECT().GetIssueCommentIDByTime(gomock.Eq(closedTicket.issueID), gomock.Eq(comment1Time)).Return(gitea.NullID, nil)
+	mockGiteaAccessor.EXPECT().GetIssueCommentIDByTime(gomock.Eq(closedTicket.issueID), gomock.Eq(comment2Time)).Return(comment2ID, nil)
+	mockGiteaAccessor.EXPECT().GetIssueCommentURL(gomock.Eq(closedTicket.issueIndex), gomock.Eq(comment2ID)).Return(redirectCommentURL)
+
+	expectTracAttachmentRetrievals(t, closedTicket, closedTicketAttachment1)
+	mockGiteaAccessor.
+		EXPECT().
+		GetIssueAttachmentUUID(gomock.Eq(closedTicket.issueID), gomock.Eq(closedTicketAttachment1.filename)).
+		Return("abcdef", nil)
+	mockGiteaAccessor.EXPECT().GetIssueAttachmentURL(gomock.Eq(closedTicket.issueID), gomock.Eq("abcdef")).Return(redirectAttachURL)
+
+	redirects := collectRedirects(t, dataImporter.GenerateTicketRedirects)
+
+	ticketPath := fmt.Sprintf("/ticket/%d", closedTicket.ticketID)
+	attachmentPath := fmt.Sprintf("/ticket/%d/%s", closedTicket.ticketID, closedTicketAttachment1.filename)
+	assertEquals(t, len(redirects), 4)
+	assertEquals(t, redirects[ticketPath], redirectGiteaRootURL+redirectIssueURL)
+	assertEquals(t, redirects[ticketPath+"#comment:2"], redirectGiteaRootURL+redirectCommentURL)
+	assertEquals(t, redirects["/attachment"+attachmentPath], redirectGiteaRootURL+redirectAttachURL)
+	assertEquals(t, redirects["/raw-attachment"+attachmentPath], redirectGiteaRootURL+redirectAttachURL)
+}
+
+func TestGenerateTicketRedirectsSkipsMissingIssue(t *testing.T) {
+	setUpTickets(t)
+	defer tearDown(t)
+
+	expectGiteaRootURLRetrieval(t)
+	expectTracTicketRetrievals(t, openTicket)
+	expectIssueLookup(t, openTicket, gitea.NullID)
+
+	redirects := collectRedirects(t, dataImporter.GenerateTicketRedirects)
+	assertEquals(t, len(redirects), 0)
+}
+
+func TestGenerateTicketRedirectsSkipsMissingComment(t *testing.T) {
+	setUpTickets(t)
+	defer tearDown(t)
+
+	expectGiteaRootURLRetrieval(t)
+	expectTracTicketRetrievals(t, closedTicket)
+	expectIssueLookup(t, closedTicket, closedTicket.issueID)
+	mockGiteaAccessor.EXPECT().GetIssueURL(gomock.Eq(closedTicket.issueIndex)).Return(redirectIssueURL)
+
+	var commentTime int64 = 33333
+	mockTracAccessor.
+		EXPECT().
+		GetTicketChanges(gomock.Eq(closedTicket.ticketID), gomock.Any()).
+		DoAndReturn(func(ticketID int64, handlerFn func(change *trac.TicketChange) error) error {
+			return handlerFn(&trac.TicketChange{TicketID: ticketID, ChangeType: trac.TicketCommentChange, OldValue: "3", Time: commentTime})
+		})
+
+	// no Gitea comment was created at the time of the Trac comment
+	mockGiteaAccessor.EXPECT().GetIssueCommentIDByTime(gomock.Eq(closedTicket.issueID), gomock.Eq(commentTime)).Return(gitea.NullID, nil)
+	expectTracAttachmentRetrievals(t, closedTicket)
+
+	redirects := collectRedirects(t, dataImporter.GenerateTicketRedirects)
+
+	ticketPath := fmt.Sprintf("/ticket/%d", closedTicket.ticketID)
+	assertEquals(t, len(redirects), 1)
+	assertEquals(t, redirects[ticketPath], redirectGiteaRootURL+redirectIssueURL)
+	_, found := redirects[ticketPath+"#comment:3"]
+	assertEquals(t, found, false)
+}
+
+func TestGenerateMilestoneRedirects(t *testing.T) {
+	setUpMilestones(t)
+	defer tearDown(t)
+
+	expectGiteaRootURLRetrieval(t)
+
+	// unnamed milestone should be skipped, uncompleted milestone has not been imported
+	var completedMilestoneID int64 = 789
+	mockGiteaAccessor.EXPECT().GetMilestoneID(gomock.Eq(completedMilestoneName)).Return(completedMilestoneID, nil)
+	mockGiteaAccessor.EXPECT().GetMilestoneID(gomock.Eq(uncompletedMilestoneName)).Return(gitea.NullID, nil)
+	mockGiteaAccessor.EXPECT().GetMilestoneURL(gomock.Eq(completedMilestoneID)).Return(redirectMilestoneURL)
+
+	redirects := collectRedirects(t, dataImporter.GenerateMilestoneRedirects)
+	assertEquals(t, len(redirects), 1)
+	assertEquals(t, redirects["/milestone/"+completedMilestoneName], redirectGiteaRootURL+redirectMilestoneURL)
+}
+
+func TestGenerateWikiRedirects(t *testing.T) {
+	setUpWiki(t)
+	defer tearDown(t)
+
+	expectGiteaRootURLRetrieval(t)
+
+	// only one redirect per page, predefined pages are skipped
+	expectTracToReturnWikiPages(t, tracWikiPage1v1, tracWikiPage1v2, tracWikiPage2v1)
+	expectToTestForPredefinedWikiPage(t, tracWikiPage1v1, false)
+	expectToTestForPredefinedWikiPage(t, tracWikiPage2v1, true)
+	expectToTranslateWikiPageName(t, tracWikiPage1v1, giteaWikiPage1)
+	mockGiteaAccessor.EXPECT().GetWikiPageURL(gomock.Eq(giteaWikiPage1)).Return(redirectWikiPageURL)
+
+	redirects := collectRedirects(t, dataImporter.GenerateWikiRedirects)
+	assertEquals(t, len(redirects), 1)
+	assertEquals(t, redirects["/wiki/"+tracWikiPage1], redirectGiteaRootURL+redirectWikiPageURL)
+}
@@ -9,6 +9,7 @@ import (
 
 	"go.uber.org/mock/gomock"
 	"github.com/stevejefferson/trac2gitea/accessor/trac"
+	"github.com/stevejefferson/trac2gitea/markdown"
 )
 
 /*
@@ -34,7 +35,10 @@ type TicketChangeImport struct {
 	summary        string
 	text           string
 	markdownText   string
+	diagnostics    []markdown.Diagnostic
+	commentNum     string
 	time           int64
+	existing       bool // issue comment already exists and is not overwritten
 }
 
 func tracTicketChangeLabelName(label *TicketLabelImport) string {
@@ -50,6 +54,7 @@ func createTracTicketChange(ticket *TicketImport, ticketChange *TicketChangeImpo
 	newValue := ""
 	switch ticketChange.tracChangeType {
 	case trac.TicketCommentChange:
+		oldValue = ticketChange.commentNum
 		newValue = ticketChange.text
 	case trac.TicketComponentChange:
 		fallthrough
@@ -76,26 +76,46 @@ func expectIssueCommentCreationForComment(t *testing.T, ticket *TicketImport, ti
 	mockGiteaAccessor.
 		EXPECT().
 		AddIssueComment(gomock.Eq(ticket.issueID), gomock.Any()).
-		DoAndReturn(func(issueID int64, issueComment *gitea.IssueComment) (int64, error) {
+		DoAndReturn(func(issueID int64, issueComment *gitea.IssueComment) (int64, bool, error) {
 			assertEquals(t, issueComment.CommentType, gitea.CommentIssueCommentType)
 			assertEquals(t, issueComment.AuthorID, ticketComment.author.giteaUserID)
-			assertEquals(t, issueComment.Text, ticketComment.markdownText)
+			assertEquals(t, issueComment.Text, "")
 			assertEquals(t, issueComment.Time, ticketComment.time)
-			return ticketComment.issueCommentID, nil
+			return ticketComment.issueCommentID, !ticketComment.existing, nil
 		})
 	if ticketComment.author.giteaUser != "" {
 		expectIssueParticipantToBeAdded(t, ticket, ticketComment.author)
 	}
 }
 
+func expectIssueCommentTextUpdate(t *testing.T, ticketComment *TicketChangeImport) {
+	mockGiteaAccessor.
+		EXPECT().
+		UpdateIssueCommentText(gomock.Eq(ticketComment.issueCommentID), gomock.Eq(ticketComment.markdownText)).
+		Return(nil)
+}
+
 func expectTicketCommentMarkdownConversion(t *testing.T, ticket *TicketImport, ticketComment *TicketChangeImport) {
+	// comment text may be embedded in other text (e.g. for attachments)
+	commentTextMatcher := gomock.Cond(func(text any) bool {
+		return strings.Contains(text.(string), ticketComment.text)
+	})
 	mockMarkdownConverter.
 		EXPECT().
-		TicketConvert(gomock.Eq(ticket.ticketID), gomock.Any()).
-		DoAndReturn(func(ticketID int64, text string) string {
-			assertTrue(t, strings.Contains(text, ticketComment.text))
-			return ticketComment.markdownText
-		})
+		TicketConvert(gomock.Eq(ticket.ticketID), commentTextMatcher).
+		Return(ticketComment.markdownText, ticketComment.diagnostics)
+
+	// expect to find no references to other tickets in comment
+	mockMarkdownConverter.
+		EXPECT().
+		TicketReferences(gomock.Eq(ticket.ticketID), commentTextMatcher).
+		Return([]int64{})
+
+	// expect to find no mentions of users in comment
+	mockMarkdownConverter.
+		EXPECT().
+		MentionedUsers(gomock.Eq(ticket.ticketID), commentTextMatcher).
+		Return([]string{})
 }
 
 func expectAllTicketCommentActions(t *testing.T, ticket *TicketImport, ticketComment *TicketChangeImport) {
@@ -107,4 +127,7 @@ func expectAllTicketCommentActions(t *testing.T, ticket *TicketImport, ticketCom
 
 	// expect retrieval/creation of issue comment for ticket comment
 	expectIssueCommentCreationForComment(t, ticket, ticketComment)
+
+	// expect issue comment text to be updated with converted text
+	expectIssueCommentTextUpdate(t, ticketComment)
 }
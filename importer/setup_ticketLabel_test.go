@@ -144,7 +144,7 @@ func expectIssueCommentCreationForLabelChange(t *testing.T, ticket *TicketImport
 	mockGiteaAccessor.
 		EXPECT().
 		AddIssueComment(gomock.Eq(ticket.issueID), gomock.Any()).
-		DoAndReturn(func(issueID int64, issueComment *gitea.IssueComment) (int64, error) {
+		DoAndReturn(func(issueID int64, issueComment *gitea.IssueComment) (int64, bool, error) {
 			assertEquals(t, issueComment.CommentType, gitea.LabelIssueCommentType)
 			assertEquals(t, issueComment.AuthorID, ticketLabelChange.author.giteaUserID)
 			assertEquals(t, issueComment.LabelID, label.giteaLabelID)
@@ -152,7 +152,7 @@ func expectIssueCommentCreationForLabelChange(t *testing.T, ticket *TicketImport
 				assertEquals(t, issueComment.Text, "1")
 			}
 			assertEquals(t, issueComment.Time, ticketLabelChange.time)
-			return ticketLabelChange.issueCommentID, nil
+			return ticketLabelChange.issueCommentID, true, nil
 		})
 
 	if ticketLabelChange.author.giteaUser != "" {
@@ -79,13 +79,13 @@ func expectIssueCommentCreationForMilestoneChange(t *testing.T, ticket *TicketIm
 	mockGiteaAccessor.
 		EXPECT().
 		AddIssueComment(gomock.Eq(ticket.issueID), gomock.Any()).
-		DoAndReturn(func(issueID int64, issueComment *gitea.IssueComment) (int64, error) {
+		DoAndReturn(func(issueID int64, issueComment *gitea.IssueComment) (int64, bool, error) {
 			assertEquals(t, issueComment.CommentType, gitea.MilestoneIssueCommentType)
 			assertEquals(t, issueComment.AuthorID, ticketMilestone.author.giteaUserID)
 			assertEquals(t, issueComment.OldMilestoneID, ticketMilestone.prevMilestone.milestoneID)
 			assertEquals(t, issueComment.MilestoneID, ticketMilestone.milestone.milestoneID)
 			assertEquals(t, issueComment.Time, ticketMilestone.time)
-			return ticketMilestone.issueCommentID, nil
+			return ticketMilestone.issueCommentID, true, nil
 		})
 
 	if ticketMilestone.author.giteaUser != "" {
@@ -74,13 +74,13 @@ func expectIssueCommentCreationForOwnershipChange(t *testing.T, ticket *TicketIm
 		mockGiteaAccessor.
 			EXPECT().
 			AddIssueComment(gomock.Eq(ticket.issueID), gomock.Any()).
-			DoAndReturn(func(issueID int64, issueComment *gitea.IssueComment) (int64, error) {
+			DoAndReturn(func(issueID int64, issueComment *gitea.IssueComment) (int64, bool, error) {
 				assertEquals(t, issueComment.CommentType, gitea.AssigneeIssueCommentType)
 				assertEquals(t, issueComment.AuthorID, ticketOwnership.author.giteaUserID)
 				assertEquals(t, issueComment.AssigneeID, ticketOwnership.prevOwner.giteaUserID)
 				assertEquals(t, issueComment.RemovedAssignee, true)
 				assertEquals(t, issueComment.Time, ticketOwnership.time)
-				return ticketOwnership.issueCommentID, nil
+				return ticketOwnership.issueCommentID, true, nil
 			})
 	}
 
@@ -88,13 +88,13 @@ func expectIssueCommentCreationForOwnershipChange(t *testing.T, ticket *TicketIm
 		mockGiteaAccessor.
 			EXPECT().
 			AddIssueComment(gomock.Eq(ticket.issueID), gomock.Any()).
-			DoAndReturn(func(issueID int64, issueComment *gitea.IssueComment) (int64, error) {
+			DoAndReturn(func(issueID int64, issueComment *gitea.IssueComment) (int64, bool, error) {
 				assertEquals(t, issueComment.CommentType, gitea.AssigneeIssueCommentType)
 				assertEquals(t, issueComment.AuthorID, ticketOwnership.author.giteaUserID)
 				assertEquals(t, issueComment.AssigneeID, ticketOwnership.owner.giteaUserID)
 				assertEquals(t, issueComment.RemovedAssignee, false)
 				assertEquals(t, issueComment.Time, ticketOwnership.time)
-				return ticketOwnership.issueCommentID, nil
+				return ticketOwnership.issueCommentID, true, nil
 			})
 	}
 
@@ -54,7 +54,7 @@ func expectIssueCommentCreationForStatusChange(t *testing.T, ticket *TicketImpor
 	mockGiteaAccessor.
 		EXPECT().
 		AddIssueComment(gomock.Eq(ticket.issueID), gomock.Any()).
-		DoAndReturn(func(issueID int64, issueComment *gitea.IssueComment) (int64, error) {
+		DoAndReturn(func(issueID int64, issueComment *gitea.IssueComment) (int64, bool, error) {
 			if ticketStatus.isClose {
 				assertEquals(t, issueComment.CommentType, gitea.CloseIssueCommentType)
 			} else {
@@ -62,7 +62,7 @@ func expectIssueCommentCreationForStatusChange(t *testing.T, ticket *TicketImpor
 			}
 			assertEquals(t, issueComment.AuthorID, ticketStatus.author.giteaUserID)
 			assertEquals(t, issueComment.Time, ticketStatus.time)
-			return ticketStatus.issueCommentID, nil
+			return ticketStatus.issueCommentID, true, nil
 		})
 	if ticketStatus.author.giteaUser != "" {
 		expectIssueParticipantToBeAdded(t, ticket, ticketStatus.author)
@@ -56,13 +56,13 @@ func expectIssueCommentCreationForSummaryChange(t *testing.T, ticket *TicketImpo
 	mockGiteaAccessor.
 		EXPECT().
 		AddIssueComment(gomock.Eq(ticket.issueID), gomock.Any()).
-		DoAndReturn(func(issueID int64, issueComment *gitea.IssueComment) (int64, error) {
+		DoAndReturn(func(issueID int64, issueComment *gitea.IssueComment) (int64, bool, error) {
 			assertEquals(t, issueComment.CommentType, gitea.TitleIssueCommentType)
 			assertEquals(t, issueComment.AuthorID, ticketSummary.author.giteaUserID)
 			assertEquals(t, issueComment.OldTitle, ticketSummary.prevSummary)
 			assertEquals(t, issueComment.Title, ticketSummary.summary)
 			assertEquals(t, issueComment.Time, ticketSummary.time)
-			return ticketSummary.issueCommentID, nil
+			return ticketSummary.issueCommentID, true, nil
 		})
 
 	if ticketSummary.author.giteaUser != "" {
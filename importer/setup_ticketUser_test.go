@@ -66,6 +66,13 @@ func expectIssueParticipantToBeAdded(t *testing.T, ticket *TicketImport, user *T
 		Return(nil)
 }
 
+func expectIssueMentionedParticipantToBeAdded(t *testing.T, ticket *TicketImport, user *TicketUserImport) {
+	mockGiteaAccessor.
+		EXPECT().
+		AddIssueMentionedParticipant(gomock.Eq(ticket.issueID), gomock.Eq(user.giteaUserID)).
+		Return(nil)
+}
+
 func expectIssueAssigneeToBeAdded(t *testing.T, ticket *TicketImport, user *TicketUserImport) {
 	mockGiteaAccessor.
 		EXPECT().
@@ -5,12 +5,12 @@
 package importer_test
 
 import (
-	"strings"
 	"testing"
 
 	"go.uber.org/mock/gomock"
 	"github.com/stevejefferson/trac2gitea/accessor/gitea"
 	"github.com/stevejefferson/trac2gitea/accessor/trac"
+	"github.com/stevejefferson/trac2gitea/markdown"
 )
 
 /*
@@ -57,7 +57,6 @@ var (
 	severityMap   map[string]string
 	typeMap       map[string]string
 	versionMap    map[string]string
-	revisionMap   map[string]string
 )
 
 func initMaps() {
@@ -125,9 +124,11 @@ func setUpTicketLabels(t *testing.T) {
 type TicketImport struct {
 	ticketID            int64
 	issueID             int64
+	issueIndex          int64
 	summary             string
 	description         string
 	descriptionMarkdown string
+	descriptionDiags    []markdown.Diagnostic
 	owner               *TicketUserImport
 	reporter            *TicketUserImport
 	milestoneName       string
@@ -141,6 +142,7 @@ type TicketImport struct {
 	status              string
 	created             int64
 	updated             int64
+	existing            bool // issue already exists and is not overwritten
 }
 
 func createTicketImport(
@@ -159,9 +161,11 @@ func createTicketImport(
 		status = "closed"
 	}
 
+	ticketID := allocateID()
 	return &TicketImport{
-		ticketID:            allocateID(),
+		ticketID:            ticketID,
 		issueID:             allocateID(),
+		issueIndex:          ticketID,
 		summary:             prefix + "-summary",
 		description:         prefix + "-description",
 		descriptionMarkdown: prefix + "-markdown",
@@ -250,7 +254,9 @@ func expectTracTicketRetrievals(t *testing.T, tickets ...*TicketImport) {
 		DoAndReturn(func(handlerFn func(ticket *trac.Ticket) error) error {
 			for _, ticket := range tickets {
 				tracTicket := createTracTicket(ticket)
-				handlerFn(tracTicket)
+				if err := handlerFn(tracTicket); err != nil {
+					return err
+				}
 			}
 			return nil
 		})
@@ -259,10 +265,47 @@ func expectTracTicketRetrievals(t *testing.T, tickets ...*TicketImport) {
 func expectDescriptionMarkdownConversion(t *testing.T, ticket *TicketImport) {
 	mockMarkdownConverter.
 		EXPECT().
-		TicketConvert(gomock.Eq(ticket.ticketID), gomock.Any()).
-		DoAndReturn(func(ticketID int64, text string) string {
-			assertTrue(t, strings.Contains(text, ticket.description))
-			return ticket.descriptionMarkdown
+		TicketConvert(gomock.Eq(ticket.ticketID), gomock.Eq(ticket.description)).
+		Return(ticket.descriptionMarkdown, ticket.descriptionDiags)
+
+	// expect to find no references to other tickets or mentions of users in description
+	expectDescriptionTicketReferences(t, ticket)
+	expectDescriptionMentions(t, ticket)
+}
+
+func expectDescriptionTicketReferences(t *testing.T, ticket *TicketImport, referencedTickets ...*TicketImport) {
+	referencedTicketIDs := []int64{}
+	for _, referencedTicket := range referencedTickets {
+		referencedTicketIDs = append(referencedTicketIDs, referencedTicket.ticketID)
+	}
+	mockMarkdownConverter.
+		EXPECT().
+		TicketReferences(gomock.Eq(ticket.ticketID), gomock.Eq(ticket.description)).
+		Return(referencedTicketIDs)
+}
+
+func expectDescriptionMentions(t *testing.T, ticket *TicketImport, mentionedUsers ...*TicketUserImport) {
+	mentionedGiteaUsers := []string{}
+	for _, mentionedUser := range mentionedUsers {
+		mentionedGiteaUsers = append(mentionedGiteaUsers, mentionedUser.giteaUser)
+	}
+	mockMarkdownConverter.
+		EXPECT().
+		MentionedUsers(gomock.Eq(ticket.ticketID), gomock.Eq(ticket.description)).
+		Return(mentionedGiteaUsers)
+}
+
+func expectIssueCrossReferenceCreation(t *testing.T, referencingTicket *TicketImport, referencedTicket *TicketImport) {
+	mockGiteaAccessor.
+		EXPECT().
+		AddIssueComment(gomock.Eq(referencedTicket.issueID), gomock.Any()).
+		DoAndReturn(func(issueID int64, issueComment *gitea.IssueComment) (int64, bool, error) {
+			assertEquals(t, issueComment.CommentType, gitea.IssueRefIssueCommentType)
+			assertEquals(t, issueComment.AuthorID, referencingTicket.reporter.giteaUserID)
+			assertEquals(t, issueComment.RefIssueID, referencingTicket.issueID)
+			assertEquals(t, issueComment.RefCommentID, gitea.NullID)
+			assertEquals(t, issueComment.Time, referencingTicket.created)
+			return allocateID(), true, nil
 		})
 }
 
@@ -276,8 +319,8 @@ func expectIssueCreation(t *testing.T, ticket *TicketImport) {
 	mockGiteaAccessor.
 		EXPECT().
 		AddIssue(gomock.Any()).
-		DoAndReturn(func(issue *gitea.Issue) (int64, error) {
-			assertEquals(t, issue.Index, ticket.ticketID)
+		DoAndReturn(func(issue *gitea.Issue) (int64, bool, error) {
+			assertEquals(t, issue.Index, ticket.issueIndex)
 			assertEquals(t, issue.Summary, ticket.summary)
 			assertEquals(t, issue.Description, "")
 			assertEquals(t, issue.OriginalAuthorID, gitea.NullID)
@@ -286,7 +329,7 @@ func expectIssueCreation(t *testing.T, ticket *TicketImport) {
 			assertEquals(t, issue.Milestone, ticket.milestoneName)
 			assertEquals(t, issue.Closed, ticket.closed)
 			assertEquals(t, issue.Created, ticket.created)
-			return ticket.issueID, nil
+			return ticket.issueID, !ticket.existing, nil
 		})
 
 	// reporter (or default user if no Gitea mapping) will always be set as issue participant
@@ -333,10 +376,10 @@ func expectIssueCountUpdates(t *testing.T) {
 		Return(nil)
 }
 
-func expectRepoIssueIndexUpdates(t *testing.T, issueID, ticketID int64) {
+func expectRepoIssueIndexUpdates(t *testing.T, issueID, issueIndex int64) {
 	mockGiteaAccessor.
 		EXPECT().
-		UpdateIssueIndex(issueID, ticketID).
+		UpdateIssueIndex(issueID, issueIndex).
 		Return(nil)
 }
 
@@ -371,7 +414,7 @@ func expectAllTicketActions(t *testing.T, ticket *TicketImport) {
 	expectIssueLabelCreation(t, ticket, ticket.versionLabel)
 
 	// expect the repo issue index to be updated
-	expectRepoIssueIndexUpdates(t, ticket.issueID, ticket.ticketID)
+	expectRepoIssueIndexUpdates(t, ticket.issueID, ticket.issueIndex)
 
 	// expect closed tickets to have their closed date/time set
 	if ticket.closed {
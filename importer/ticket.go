@@ -10,7 +10,7 @@ import (
 )
 
 // importTicket imports a Trac ticket as a Gitea issue, returning the id of the created issue or gitea.NullID if the issue was not created.
-func (importer *Importer) importTicket(ticket *trac.Ticket, closed bool, userMap, revisionMap map[string]string) (int64, error) {
+func (importer *Importer) importTicket(ticket *trac.Ticket, closed bool, userMap map[string]string) (int64, error) {
 	reporterID, err := importer.getUserID(ticket.Reporter, userMap)
 	if err != nil {
 		return gitea.NullID, err
@@ -30,14 +30,20 @@ func (importer *Importer) importTicket(ticket *trac.Ticket, closed bool, userMap
 		}
 	}
 
-	// Create the issue with empty description first
-	issue := gitea.Issue{Index: ticket.TicketID, Summary: ticket.Summary, ReporterID: reporterID,
+	// Create the issue with empty description first - the description is converted once all tickets have been imported
+	issue := gitea.Issue{Index: importer.issueIndex(ticket.TicketID), Summary: ticket.Summary, ReporterID: reporterID,
 		Milestone: ticket.MilestoneName, OriginalAuthorID: 0, OriginalAuthorName: originalAuthorName,
 		Closed: closed, Description: "", Created: ticket.Created, Updated: ticket.Updated}
-	issueID, err := importer.giteaAccessor.AddIssue(&issue)
+	issueID, written, err := importer.giteaAccessor.AddIssue(&issue)
 	if err != nil {
 		return gitea.NullID, err
 	}
+	importer.ticketIssueIDs[ticket.TicketID] = issueID
+
+	// an existing issue which is not being overwritten keeps its description
+	if written {
+		importer.addTicketDescription(ticket.TicketID, issueID, &issue, ticket.Description)
+	}
 
 	// if we have a Gitea user for the Trac ticket owner then assign the Gitea issue to that user
 	if ownerID != gitea.NullID {
@@ -64,10 +70,10 @@ func (importer *Importer) importTicket(ticket *trac.Ticket, closed bool, userMap
 
 // ImportTickets imports Trac tickets as Gitea issues.
 func (importer *Importer) ImportTickets(
-	userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap map[string]string) error {
+	userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap map[string]string) error {
 	err := importer.tracAccessor.GetTickets(func(ticket *trac.Ticket) error {
 		closed := (ticket.Status == string(trac.TicketStatusClosed))
-		issueID, err := importer.importTicket(ticket, closed, userMap, revisionMap)
+		issueID, err := importer.importTicket(ticket, closed, userMap)
 		if err != nil {
 			return err
 		}
@@ -105,13 +111,12 @@ func (importer *Importer) ImportTickets(
 			return err
 		}
 
-		lastUpdate, err := importer.importTicketAttachments(ticket.TicketID, issueID, ticket.Created, userMap, revisionMap)
+		lastUpdate, err := importer.importTicketAttachments(ticket.TicketID, issueID, ticket.Created, userMap)
 		if err != nil {
 			return err
 		}
 		lastUpdate, err = importer.importTicketChanges(ticket.TicketID, issueID, lastUpdate,
-			userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap,
-			revisionMap)
+			userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 		if err != nil {
 			return err
 		}
@@ -131,13 +136,7 @@ func (importer *Importer) ImportTickets(
 			return err
 		}
 
-		if err = importer.giteaAccessor.UpdateIssueIndex(issueID, ticket.TicketID); err != nil {
-			return err
-		}
-
-		// Update the issue description after creation - so link to itself can be resolved (e.g.: comment)
-		convertedDescription := importer.markdownConverter.TicketConvert(ticket.TicketID, ticket.Description)
-		if err = importer.giteaAccessor.UpdateIssueDescription(issueID, MapRevisions(convertedDescription, revisionMap)); err != nil {
+		if err = importer.giteaAccessor.UpdateIssueIndex(issueID, importer.issueIndex(ticket.TicketID)); err != nil {
 			return err
 		}
 		return nil
@@ -146,6 +145,11 @@ func (importer *Importer) ImportTickets(
 		return err
 	}
 
+	err = importer.convertTicketTexts()
+	if err != nil {
+		return err
+	}
+
 	err = importer.giteaAccessor.UpdateLabelIssueCounts()
 	if err != nil {
 		return err
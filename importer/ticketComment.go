@@ -10,20 +10,22 @@ import (
 )
 
 // importCommentIssueComment imports a Trac ticket comment into Gitea, returns id of created Gitea issue comment or NullID if cannot create comment
-func (importer *Importer) importCommentIssueComment(issueID int64, change *trac.TicketChange, userMap, revisionMap map[string]string) (int64, error) {
+func (importer *Importer) importCommentIssueComment(issueID int64, change *trac.TicketChange, userMap map[string]string) (int64, error) {
 	issueComment, err := importer.createIssueComment(issueID, change, userMap)
 	if err != nil {
 		return gitea.NullID, err
 	}
 
+	// create the comment with empty text: the text is converted once all tickets have been imported so links to any ticket or comment can be resolved
 	issueComment.CommentType = gitea.CommentIssueCommentType
-	issueComment.Text = importer.markdownConverter.TicketConvert(change.TicketID, change.NewValue)
-	issueComment.Text = MapRevisions(issueComment.Text, revisionMap)
-
-	issueCommentID, err := importer.giteaAccessor.AddIssueComment(issueID, issueComment)
+	issueCommentID, written, err := importer.giteaAccessor.AddIssueComment(issueID, issueComment)
 	if err != nil {
 		return gitea.NullID, err
 	}
+	if written {
+		// an existing comment which is not being overwritten keeps its text
+		importer.addTicketComment(issueID, issueCommentID, issueComment, change)
+	}
 
 	return issueCommentID, nil
 }
@@ -41,7 +41,7 @@ func TestImportTicketWithComments(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, closedTicket.issueID, closedTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportMultipleTicketsWithComments(t *testing.T) {
@@ -88,7 +88,7 @@ func TestImportMultipleTicketsWithComments(t *testing.T) {
 	expectIssueDescriptionUpdates(t, closedTicket.issueID, closedTicket.descriptionMarkdown)
 	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportTicketWithCommentButNoTracUser(t *testing.T) {
@@ -125,7 +125,7 @@ func TestImportTicketWithCommentButNoTracUser(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, noTracUserTicket.issueID, noTracUserTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportTicketWithCommentButUnmappedTracUser(t *testing.T) {
@@ -162,5 +162,46 @@ func TestImportTicketWithCommentButUnmappedTracUser(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, unmappedTracUserTicket.issueID, unmappedTracUserTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
+}
+
+func TestImportExistingTicketWithCommentsKeepsExistingText(t *testing.T) {
+	setUpTickets(t)
+	defer tearDown(t)
+
+	// issue and first comment were imported by a previous run and are not being overwritten, second comment is new
+	closedTicket.existing = true
+	closedTicketComment1.existing = true
+
+	// first thing to expect is retrieval of ticket from Trac
+	expectTracTicketRetrievals(t, closedTicket)
+
+	// expect all actions for creating Gitea issue from Trac ticket
+	expectAllTicketActions(t, closedTicket)
+
+	// expect trac to return us no attachments
+	expectTracAttachmentRetrievals(t, closedTicket)
+
+	// expect trac to return us comment changes
+	expectTracChangeRetrievals(t, closedTicket, closedTicketComment1, closedTicketComment2)
+
+	// expect existing issue comment to be found but its text not to be converted or updated
+	expectUserLookup(t, closedTicketComment1.author)
+	expectIssueCommentCreationForComment(t, closedTicket, closedTicketComment1)
+
+	// expect all actions for creating Gitea issue comment from new Trac ticket comment
+	expectAllTicketCommentActions(t, closedTicket, closedTicketComment2)
+
+	// expect issue update time to be updated
+	expectIssueUpdateTimeSetToLatestOf(t, closedTicket, closedTicketComment1, closedTicketComment2)
+
+	// expect issue comment count to be updated
+	expectIssueCommentCountUpdate(t, closedTicket)
+
+	// expect all issue counts to be updated
+	expectIssueCountUpdates(t)
+
+	// expect no conversion of ticket description or update of issue description
+
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
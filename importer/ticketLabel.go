@@ -49,7 +49,7 @@ func (importer *Importer) addLabelChangeIssueComment(issueID int64, change *trac
 		if isAdd {
 			issueComment.Text = "1"
 		}
-		issueCommentID, err = importer.giteaAccessor.AddIssueComment(issueID, issueComment)
+		issueCommentID, _, err = importer.giteaAccessor.AddIssueComment(issueID, issueComment)
 		if err != nil {
 			return gitea.NullID, err
 		}
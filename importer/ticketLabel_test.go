@@ -40,7 +40,7 @@ func TestImportTicketComponentAddition(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportTicketComponentAmend(t *testing.T) {
@@ -77,7 +77,7 @@ func TestImportTicketComponentAmend(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportTicketComponentRemoval(t *testing.T) {
@@ -114,7 +114,7 @@ func TestImportTicketComponentRemoval(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportTicketPriorityAddition(t *testing.T) {
@@ -151,7 +151,7 @@ func TestImportTicketPriorityAddition(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportTicketPriorityAmend(t *testing.T) {
@@ -188,7 +188,7 @@ func TestImportTicketPriorityAmend(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportTicketPriorityRemoval(t *testing.T) {
@@ -225,7 +225,7 @@ func TestImportTicketPriorityRemoval(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportTicketResolutionAddition(t *testing.T) {
@@ -262,7 +262,7 @@ func TestImportTicketResolutionAddition(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportTicketResolutionAmend(t *testing.T) {
@@ -299,7 +299,7 @@ func TestImportTicketResolutionAmend(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportTicketResolutionRemoval(t *testing.T) {
@@ -336,7 +336,7 @@ func TestImportTicketResolutionRemoval(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportTicketSeverityAddition(t *testing.T) {
@@ -373,7 +373,7 @@ func TestImportTicketSeverityAddition(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportTicketSeverityAmend(t *testing.T) {
@@ -410,7 +410,7 @@ func TestImportTicketSeverityAmend(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportTicketSeverityRemoval(t *testing.T) {
@@ -447,7 +447,7 @@ func TestImportTicketSeverityRemoval(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportTicketTypeAddition(t *testing.T) {
@@ -484,7 +484,7 @@ func TestImportTicketTypeAddition(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportTicketTypeAmend(t *testing.T) {
@@ -521,7 +521,7 @@ func TestImportTicketTypeAmend(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportTicketTypeRemoval(t *testing.T) {
@@ -558,7 +558,7 @@ func TestImportTicketTypeRemoval(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportTicketVersionAddition(t *testing.T) {
@@ -595,7 +595,7 @@ func TestImportTicketVersionAddition(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportTicketVersionAmend(t *testing.T) {
@@ -632,7 +632,7 @@ func TestImportTicketVersionAmend(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportTicketVersionRemoval(t *testing.T) {
@@ -669,5 +669,5 @@ func TestImportTicketVersionRemoval(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
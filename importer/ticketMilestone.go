@@ -43,7 +43,7 @@ func (importer *Importer) importMilestoneIssueComment(issueID int64, change *tra
 	issueComment.CommentType = gitea.MilestoneIssueCommentType
 	issueComment.OldMilestoneID = oldMilestoneID
 	issueComment.MilestoneID = milestoneID
-	issueCommentID, err := importer.giteaAccessor.AddIssueComment(issueID, issueComment)
+	issueCommentID, _, err := importer.giteaAccessor.AddIssueComment(issueID, issueComment)
 	if err != nil {
 		return gitea.NullID, err
 	}
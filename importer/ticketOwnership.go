@@ -51,7 +51,7 @@ func (importer *Importer) importOwnershipIssueComment(issueID int64, change *tra
 		// NOTE: The translation process is currently/ built around only returning
 		// one Gitea comment per Trac change so issueCommentID may get overwritten
 		// below
-		issueCommentID, err = importer.giteaAccessor.AddIssueComment(issueID, &removeOwnerComment)
+		issueCommentID, _, err = importer.giteaAccessor.AddIssueComment(issueID, &removeOwnerComment)
 
 		if err != nil {
 			return gitea.NullID, err
@@ -61,7 +61,7 @@ func (importer *Importer) importOwnershipIssueComment(issueID int64, change *tra
 	if assigneeID != gitea.NullID {
 		issueComment.AssigneeID = assigneeID
 		issueComment.RemovedAssignee = false
-		issueCommentID, err = importer.giteaAccessor.AddIssueComment(issueID, issueComment)
+		issueCommentID, _, err = importer.giteaAccessor.AddIssueComment(issueID, issueComment)
 		if err != nil {
 			return gitea.NullID, err
 		}
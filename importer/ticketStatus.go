@@ -27,7 +27,7 @@ func (importer *Importer) importStatusChangeIssueComment(issueID int64, change *
 	}
 
 	issueComment.CommentType = giteaCommentType
-	issueCommentID, err := importer.giteaAccessor.AddIssueComment(issueID, issueComment)
+	issueCommentID, _, err := importer.giteaAccessor.AddIssueComment(issueID, issueComment)
 	if err != nil {
 		return gitea.NullID, err
 	}
@@ -29,7 +29,7 @@ func (importer *Importer) importSummaryChangeIssueComment(issueID int64, change
 	issueComment.CommentType = gitea.TitleIssueCommentType
 	issueComment.OldTitle = prevSummary
 	issueComment.Title = summary
-	issueCommentID, err := importer.giteaAccessor.AddIssueComment(issueID, issueComment)
+	issueCommentID, _, err := importer.giteaAccessor.AddIssueComment(issueID, issueComment)
 	if err != nil {
 		return gitea.NullID, err
 	}
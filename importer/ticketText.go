@@ -0,0 +1,127 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package importer
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/stevejefferson/trac2gitea/accessor/gitea"
+	"github.com/stevejefferson/trac2gitea/accessor/trac"
+)
+
+// ticketText is the Trac wiki text of an imported ticket description or comment awaiting conversion into markdown
+type ticketText struct {
+	ticketID           int64
+	issueID            int64
+	issueCommentID     int64 // gitea.NullID for the ticket description
+	commentNum         int64 // Trac comment number, 0 for the ticket description
+	authorID           int64
+	originalAuthorName string
+	time               int64
+	text               string
+}
+
+// addTicketDescription records the description of a ticket for conversion into markdown once all tickets have been imported
+func (importer *Importer) addTicketDescription(ticketID int64, issueID int64, issue *gitea.Issue, description string) {
+	importer.ticketTexts = append(importer.ticketTexts, ticketText{ticketID: ticketID, issueID: issueID, issueCommentID: gitea.NullID,
+		authorID: issue.ReporterID, originalAuthorName: issue.OriginalAuthorName, time: issue.Created, text: description})
+}
+
+// addTicketComment records the text of a ticket comment for conversion into markdown once all tickets have been imported
+func (importer *Importer) addTicketComment(issueID int64, issueCommentID int64, issueComment *gitea.IssueComment, change *trac.TicketChange) {
+	importer.ticketTexts = append(importer.ticketTexts, ticketText{ticketID: change.TicketID, issueID: issueID, issueCommentID: issueCommentID,
+		commentNum: tracCommentNumber(change), authorID: issueComment.AuthorID, originalAuthorName: issueComment.OriginalAuthorName,
+		time: issueComment.Time, text: change.NewValue})
+}
+
+// tracCommentNumber returns the number of the Trac ticket comment made by a comment change, or 0 if it is not known
+// - Trac records the number as the old value of the change: '<number>' or, for a reply, '<replied-to-number>.<number>'
+func tracCommentNumber(change *trac.TicketChange) int64 {
+	commentNum, err := strconv.ParseInt(change.OldValue[strings.LastIndex(change.OldValue, ".")+1:], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return commentNum
+}
+
+// convertTicketTexts converts the Trac wiki text of each imported ticket description and comment into markdown.
+// This is done as a separate pass once every issue and comment has been created so that links to any ticket, comment or attachment can be resolved
+// - links to tickets later in the import would otherwise be left unconverted.
+func (importer *Importer) convertTicketTexts() error {
+	for _, ticketText := range importer.ticketTexts {
+		convertedText, diagnostics := importer.markdownConverter.TicketConvert(ticketText.ticketID, ticketText.text)
+		importer.addTicketTextDiagnostics(&ticketText, diagnostics)
+		if ticketText.issueCommentID == gitea.NullID {
+			if err := importer.giteaAccessor.UpdateIssueDescription(ticketText.issueID, convertedText); err != nil {
+				return err
+			}
+		} else {
+			if err := importer.giteaAccessor.UpdateIssueCommentText(ticketText.issueCommentID, convertedText); err != nil {
+				return err
+			}
+		}
+
+		if err := importer.addTicketCrossReferences(&ticketText); err != nil {
+			return err
+		}
+		if err := importer.addTicketMentions(&ticketText); err != nil {
+			return err
+		}
+	}
+
+	importer.ticketTexts = nil
+	return nil
+}
+
+// addTicketCrossReferences adds a cross-reference comment to the issue of each ticket referenced by a ticket description or comment
+// - this is the timeline event Gitea itself creates when an issue is referenced ("... referenced this issue")
+func (importer *Importer) addTicketCrossReferences(ticketText *ticketText) error {
+	commentType := gitea.IssueRefIssueCommentType
+	if ticketText.issueCommentID != gitea.NullID {
+		commentType = gitea.CommentRefIssueCommentType
+	}
+
+	for _, referencedTicketID := range importer.markdownConverter.TicketReferences(ticketText.ticketID, ticketText.text) {
+		referencedIssueID, found := importer.ticketIssueIDs[referencedTicketID]
+		if !found {
+			continue // no issue imported for ticket
+		}
+
+		issueComment := gitea.IssueComment{
+			CommentType:        commentType,
+			AuthorID:           ticketText.authorID,
+			OriginalAuthorName: ticketText.originalAuthorName,
+			RefIssueID:         ticketText.issueID,
+			RefCommentID:       ticketText.issueCommentID,
+			Time:               ticketText.time,
+		}
+		if _, _, err := importer.giteaAccessor.AddIssueComment(referencedIssueID, &issueComment); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addTicketMentions adds each Gitea user mentioned by a ticket description or comment as a mentioned participant in the ticket's issue
+// - as in Gitea, authors mentioning themselves are ignored
+func (importer *Importer) addTicketMentions(ticketText *ticketText) error {
+	for _, mentionedUser := range importer.markdownConverter.MentionedUsers(ticketText.ticketID, ticketText.text) {
+		userID, err := importer.giteaAccessor.GetUserID(mentionedUser)
+		if err != nil {
+			return err
+		}
+		if userID == gitea.NullID || (userID == ticketText.authorID && ticketText.originalAuthorName == "") {
+			continue
+		}
+
+		if err := importer.giteaAccessor.AddIssueMentionedParticipant(ticketText.issueID, userID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -6,6 +6,10 @@ package importer_test
 
 import (
 	"testing"
+
+	"github.com/stevejefferson/trac2gitea/accessor/trac"
+	"github.com/stevejefferson/trac2gitea/markdown"
+	"go.uber.org/mock/gomock"
 )
 
 func TestImportClosedTicketOnly(t *testing.T) {
@@ -39,7 +43,7 @@ func TestImportClosedTicketOnly(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, closedTicket.issueID, closedTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportOpenTicketOnly(t *testing.T) {
@@ -73,7 +77,7 @@ func TestImportOpenTicketOnly(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportMultipleTicketsOnly(t *testing.T) {
@@ -114,7 +118,7 @@ func TestImportMultipleTicketsOnly(t *testing.T) {
 	expectIssueDescriptionUpdates(t, closedTicket.issueID, closedTicket.descriptionMarkdown)
 	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportTicketWithNoTracUser(t *testing.T) {
@@ -148,7 +152,7 @@ func TestImportTicketWithNoTracUser(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, noTracUserTicket.issueID, noTracUserTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
 
 func TestImportTicketWithUnmappedTracUser(t *testing.T) {
@@ -182,5 +186,159 @@ func TestImportTicketWithUnmappedTracUser(t *testing.T) {
 	// expect to update Gitea issue description
 	expectIssueDescriptionUpdates(t, unmappedTracUserTicket.issueID, unmappedTracUserTicket.descriptionMarkdown)
 
-	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
+}
+
+func TestImportMultipleTicketsConvertsDescriptionsAfterAllTicketsImported(t *testing.T) {
+	setUpTickets(t)
+	defer tearDown(t)
+
+	// expect retrieval of tickets from Trac, noting when the last ticket has been handled
+	allTicketsImported := false
+	mockTracAccessor.
+		EXPECT().
+		GetTickets(gomock.Any()).
+		DoAndReturn(func(handlerFn func(ticket *trac.Ticket) error) error {
+			for _, ticket := range []*TicketImport{closedTicket, openTicket} {
+				handlerFn(createTracTicket(ticket))
+			}
+			allTicketsImported = true
+			return nil
+		})
+
+	// expect all actions for creating Gitea issue from Trac tickets
+	expectAllTicketActions(t, closedTicket)
+	expectAllTicketActions(t, openTicket)
+
+	// expect trac to return us no attachments
+	expectTracAttachmentRetrievals(t, closedTicket)
+	expectTracAttachmentRetrievals(t, openTicket)
+
+	// expect trac to return us no changes
+	expectTracChangeRetrievals(t, closedTicket)
+	expectTracChangeRetrievals(t, openTicket)
+
+	// expect issues update time to be updated
+	expectIssueUpdateTimeSetToLatestOf(t, closedTicket)
+	expectIssueUpdateTimeSetToLatestOf(t, openTicket)
+
+	// expect issue comment count to be updated
+	expectIssueCommentCountUpdate(t, closedTicket)
+	expectIssueCommentCountUpdate(t, openTicket)
+
+	// expect all issue counts to be updated
+	expectIssueCountUpdates(t)
+
+	// expect ticket descriptions to be converted to markdown only once every ticket has been imported
+	// - so that the description of the first ticket can link to the second
+	for _, ticket := range []*TicketImport{closedTicket, openTicket} {
+		ticket := ticket
+		mockMarkdownConverter.
+			EXPECT().
+			TicketConvert(gomock.Eq(ticket.ticketID), gomock.Eq(ticket.description)).
+			DoAndReturn(func(ticketID int64, text string) (string, []markdown.Diagnostic) {
+				assertTrue(t, allTicketsImported)
+				return ticket.descriptionMarkdown, nil
+			})
+		expectDescriptionTicketReferences(t, ticket)
+		expectDescriptionMentions(t, ticket)
+	}
+
+	// expect to update Gitea issue description
+	expectIssueDescriptionUpdates(t, closedTicket.issueID, closedTicket.descriptionMarkdown)
+	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
+
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
+}
+
+func TestImportTicketReferencingOtherTicket(t *testing.T) {
+	setUpTickets(t)
+	defer tearDown(t)
+
+	// first thing to expect is retrieval of tickets from Trac
+	expectTracTicketRetrievals(t, closedTicket, openTicket)
+
+	// expect all actions for creating Gitea issue from Trac tickets
+	expectAllTicketActions(t, closedTicket)
+	expectAllTicketActions(t, openTicket)
+
+	// expect trac to return us no attachments
+	expectTracAttachmentRetrievals(t, closedTicket)
+	expectTracAttachmentRetrievals(t, openTicket)
+
+	// expect trac to return us no changes
+	expectTracChangeRetrievals(t, closedTicket)
+	expectTracChangeRetrievals(t, openTicket)
+
+	// expect issues update time to be updated
+	expectIssueUpdateTimeSetToLatestOf(t, closedTicket)
+	expectIssueUpdateTimeSetToLatestOf(t, openTicket)
+
+	// expect issue comment count to be updated
+	expectIssueCommentCountUpdate(t, closedTicket)
+	expectIssueCommentCountUpdate(t, openTicket)
+
+	// expect all issue counts to be updated
+	expectIssueCountUpdates(t)
+
+	// expect to convert ticket descriptions to markdown - the closed ticket description references the open ticket
+	mockMarkdownConverter.
+		EXPECT().
+		TicketConvert(gomock.Eq(closedTicket.ticketID), gomock.Eq(closedTicket.description)).
+		Return(closedTicket.descriptionMarkdown, nil)
+	expectDescriptionTicketReferences(t, closedTicket, openTicket)
+	expectDescriptionMentions(t, closedTicket)
+	expectDescriptionMarkdownConversion(t, openTicket)
+
+	// expect to update Gitea issue description
+	expectIssueDescriptionUpdates(t, closedTicket.issueID, closedTicket.descriptionMarkdown)
+	expectIssueDescriptionUpdates(t, openTicket.issueID, openTicket.descriptionMarkdown)
+
+	// expect a cross-reference to the closed ticket's issue to be added to the open ticket's issue
+	expectIssueCrossReferenceCreation(t, closedTicket, openTicket)
+
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
+}
+
+func TestImportTicketMentioningUsers(t *testing.T) {
+	setUpTickets(t)
+	defer tearDown(t)
+
+	// first thing to expect is retrieval of ticket from Trac
+	expectTracTicketRetrievals(t, closedTicket)
+
+	// expect all actions for creating Gitea issue from Trac ticket
+	expectAllTicketActions(t, closedTicket)
+
+	// expect trac to return us no attachments
+	expectTracAttachmentRetrievals(t, closedTicket)
+
+	// expect trac to return us no changes
+	expectTracChangeRetrievals(t, closedTicket)
+
+	// expect issue update time to be updated
+	expectIssueUpdateTimeSetToLatestOf(t, closedTicket)
+
+	// expect issue comment count to be updated
+	expectIssueCommentCountUpdate(t, closedTicket)
+
+	// expect all issue counts to be updated
+	expectIssueCountUpdates(t)
+
+	// expect to convert ticket description to markdown - the description mentions another user and the ticket's own reporter
+	mockMarkdownConverter.
+		EXPECT().
+		TicketConvert(gomock.Eq(closedTicket.ticketID), gomock.Eq(closedTicket.description)).
+		Return(closedTicket.descriptionMarkdown, nil)
+	expectDescriptionTicketReferences(t, closedTicket)
+	expectDescriptionMentions(t, closedTicket, openTicketOwner, closedTicket.reporter)
+
+	// expect to update Gitea issue description
+	expectIssueDescriptionUpdates(t, closedTicket.issueID, closedTicket.descriptionMarkdown)
+
+	// expect the other user to be added as a mentioned participant - the reporter mentioning themselves is ignored
+	expectUserLookup(t, openTicketOwner)
+	expectIssueMentionedParticipantToBeAdded(t, closedTicket, openTicketOwner)
+
+	dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 }
@@ -0,0 +1,343 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package importer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stevejefferson/trac2gitea/accessor/gitea"
+	"github.com/stevejefferson/trac2gitea/accessor/trac"
+	"github.com/stevejefferson/trac2gitea/log"
+)
+
+// DiscrepancyKind identifies the type of a discrepancy found when verifying an import
+type DiscrepancyKind string
+
+const (
+	// MissingIssueDiscrepancy denotes a Trac ticket with no corresponding Gitea issue
+	MissingIssueDiscrepancy DiscrepancyKind = "missing-issue"
+
+	// IssueStateDiscrepancy denotes a Gitea issue whose open/closed state differs from that of its Trac ticket
+	IssueStateDiscrepancy DiscrepancyKind = "issue-state"
+
+	// CommentCountDiscrepancy denotes a Gitea issue with fewer comments than its Trac ticket
+	CommentCountDiscrepancy DiscrepancyKind = "comment-count"
+
+	// MissingAttachmentDiscrepancy denotes a Trac ticket attachment with no corresponding Gitea issue attachment
+	MissingAttachmentDiscrepancy DiscrepancyKind = "missing-attachment"
+
+	// AttachmentSizeDiscrepancy denotes a Gitea issue attachment whose size (or file size) differs from that of its Trac attachment
+	AttachmentSizeDiscrepancy DiscrepancyKind = "attachment-size"
+
+	// MissingLabelDiscrepancy denotes a label expected on a Gitea issue but not present
+	MissingLabelDiscrepancy DiscrepancyKind = "missing-label"
+
+	// MilestoneDiscrepancy denotes a Gitea issue which is not in the milestone of its Trac ticket
+	MilestoneDiscrepancy DiscrepancyKind = "milestone"
+
+	// MissingAssigneeDiscrepancy denotes a Gitea issue not assigned to the Gitea user corresponding to the owner of its Trac ticket
+	MissingAssigneeDiscrepancy DiscrepancyKind = "missing-assignee"
+
+	// WikiPageCountDiscrepancy denotes a difference between the number of Trac wiki pages and the number of Gitea wiki pages
+	WikiPageCountDiscrepancy DiscrepancyKind = "wiki-page-count"
+
+	// MissingWikiPageDiscrepancy denotes a Trac wiki page with no corresponding Gitea wiki page
+	MissingWikiPageDiscrepancy DiscrepancyKind = "missing-wiki-page"
+)
+
+// Discrepancy describes a single difference found between Trac and Gitea when verifying an import
+type Discrepancy struct {
+	Kind       DiscrepancyKind `json:"kind"`
+	TicketID   int64           `json:"ticket,omitempty"`
+	IssueIndex int64           `json:"issue,omitempty"`
+	WikiPage   string          `json:"wikiPage,omitempty"`
+	Item       string          `json:"item,omitempty"`
+	Expected   string          `json:"expected"`
+	Found      string          `json:"found"`
+}
+
+// VerificationReport is the result of verifying an import
+type VerificationReport struct {
+	TicketsChecked   int           `json:"ticketsChecked"`
+	WikiPagesChecked int           `json:"wikiPagesChecked"`
+	Discrepancies    []Discrepancy `json:"discrepancies"`
+}
+
+// CreateVerificationReport returns a new, empty, verification report
+func CreateVerificationReport() *VerificationReport {
+	return &VerificationReport{Discrepancies: []Discrepancy{}}
+}
+
+// addDiscrepancy records a discrepancy in the report
+func (report *VerificationReport) addDiscrepancy(discrepancy Discrepancy) {
+	switch {
+	case discrepancy.WikiPage != "":
+		log.Warn("wiki page %s: %s: expected %s, found %s",
+			discrepancy.WikiPage, discrepancy.Kind, discrepancy.Expected, discrepancy.Found)
+	case discrepancy.TicketID != 0:
+		log.Warn("ticket %d (issue %d): %s %s: expected %s, found %s",
+			discrepancy.TicketID, discrepancy.IssueIndex, discrepancy.Kind, discrepancy.Item, discrepancy.Expected, discrepancy.Found)
+	default:
+		log.Warn("%s: expected %s, found %s", discrepancy.Kind, discrepancy.Expected, discrepancy.Found)
+	}
+	report.Discrepancies = append(report.Discrepancies, discrepancy)
+}
+
+// addIssueDiscrepancy records a discrepancy between a Trac ticket and its Gitea issue in the report
+func (report *VerificationReport) addIssueDiscrepancy(kind DiscrepancyKind, ticketID, issueIndex int64, item string, expected, found interface{}) {
+	report.addDiscrepancy(Discrepancy{
+		Kind:       kind,
+		TicketID:   ticketID,
+		IssueIndex: issueIndex,
+		Item:       item,
+		Expected:   fmt.Sprint(expected),
+		Found:      fmt.Sprint(found),
+	})
+}
+
+// verifyIssueAttachments verifies that each Trac ticket attachment is present with the correct size in the Gitea issue
+func (importer *Importer) verifyIssueAttachments(report *VerificationReport, ticket *trac.Ticket, issueIndex int64, issueID int64) (int64, error) {
+	giteaAttachments, err := importer.giteaAccessor.GetIssueAttachments(issueID)
+	if err != nil {
+		return 0, err
+	}
+	giteaAttachmentsByName := make(map[string]gitea.IssueAttachment)
+	for _, giteaAttachment := range giteaAttachments {
+		giteaAttachmentsByName[giteaAttachment.FileName] = giteaAttachment
+	}
+
+	var tracAttachmentCount int64
+	err = importer.tracAccessor.GetTicketAttachments(ticket.TicketID, func(tracAttachment *trac.TicketAttachment) error {
+		tracAttachmentCount++
+		giteaAttachment, found := giteaAttachmentsByName[tracAttachment.FileName]
+		if !found {
+			report.addIssueDiscrepancy(MissingAttachmentDiscrepancy, ticket.TicketID, issueIndex, tracAttachment.FileName, "present", "missing")
+			return nil
+		}
+		if giteaAttachment.Size != tracAttachment.Size {
+			report.addIssueDiscrepancy(AttachmentSizeDiscrepancy, ticket.TicketID, issueIndex, tracAttachment.FileName, tracAttachment.Size, giteaAttachment.Size)
+		}
+
+		fileSize, err := importer.giteaAccessor.GetIssueAttachmentFileSize(giteaAttachment.UUID)
+		if err != nil {
+			return err
+		}
+		if fileSize == -1 {
+			report.addIssueDiscrepancy(MissingAttachmentDiscrepancy, ticket.TicketID, issueIndex, tracAttachment.FileName, "file "+giteaAttachment.UUID, "missing")
+		} else if fileSize != tracAttachment.Size {
+			report.addIssueDiscrepancy(AttachmentSizeDiscrepancy, ticket.TicketID, issueIndex, "file "+giteaAttachment.UUID, tracAttachment.Size, fileSize)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return tracAttachmentCount, nil
+}
+
+// verifyIssueComments verifies that the Gitea issue has a comment for each Trac ticket comment and attachment
+func (importer *Importer) verifyIssueComments(report *VerificationReport, ticket *trac.Ticket, issueIndex int64, issueID int64, tracAttachmentCount int64) error {
+	tracCommentCount := tracAttachmentCount // each attachment is imported with a comment
+	err := importer.tracAccessor.GetTicketChanges(ticket.TicketID, func(change *trac.TicketChange) error {
+		if change.ChangeType == trac.TicketCommentChange {
+			tracCommentCount++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	giteaCommentCount, err := importer.giteaAccessor.GetIssueCommentCount(issueID, gitea.CommentIssueCommentType)
+	if err != nil {
+		return err
+	}
+	if giteaCommentCount < tracCommentCount {
+		report.addIssueDiscrepancy(CommentCountDiscrepancy, ticket.TicketID, issueIndex, "", tracCommentCount, giteaCommentCount)
+	}
+
+	return nil
+}
+
+// verifyIssueLabels verifies that the Gitea issue has the labels mapped from each of the Trac ticket's label types
+func (importer *Importer) verifyIssueLabels(report *VerificationReport, ticket *trac.Ticket, issueIndex int64, issueID int64,
+	componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap map[string]string) error {
+	giteaLabelNames, err := importer.giteaAccessor.GetIssueLabels(issueID)
+	if err != nil {
+		return err
+	}
+	giteaLabels := make(map[string]bool)
+	for _, giteaLabelName := range giteaLabelNames {
+		giteaLabels[giteaLabelName] = true
+	}
+
+	tracLabels := []struct {
+		tracName string
+		labelMap map[string]string
+	}{
+		{ticket.ComponentName, componentMap},
+		{ticket.PriorityName, priorityMap},
+		{ticket.ResolutionName, resolutionMap},
+		{ticket.SeverityName, severityMap},
+		{ticket.TypeName, typeMap},
+		{ticket.VersionName, versionMap},
+	}
+	for _, tracLabel := range tracLabels {
+		if tracLabel.tracName == "" {
+			continue
+		}
+		expectedLabelName := tracLabel.labelMap[tracLabel.tracName]
+		if expectedLabelName != "" && !giteaLabels[expectedLabelName] {
+			report.addIssueDiscrepancy(MissingLabelDiscrepancy, ticket.TicketID, issueIndex, tracLabel.tracName, expectedLabelName, strings.Join(giteaLabelNames, ","))
+		}
+	}
+
+	return nil
+}
+
+// verifyIssueAssignee verifies that the Gitea issue is assigned to the Gitea user mapped from the Trac ticket owner
+func (importer *Importer) verifyIssueAssignee(report *VerificationReport, ticket *trac.Ticket, issueIndex int64, issueID int64, userMap map[string]string) error {
+	expectedAssignee := userMap[ticket.Owner]
+	if ticket.Owner == "" || expectedAssignee == "" {
+		return nil
+	}
+
+	assignees, err := importer.giteaAccessor.GetIssueAssignees(issueID)
+	if err != nil {
+		return err
+	}
+	for _, assignee := range assignees {
+		if assignee == expectedAssignee {
+			return nil
+		}
+	}
+
+	report.addIssueDiscrepancy(MissingAssigneeDiscrepancy, ticket.TicketID, issueIndex, ticket.Owner, expectedAssignee, strings.Join(assignees, ","))
+	return nil
+}
+
+// verifyTicket verifies the Gitea issue imported from a single Trac ticket
+func (importer *Importer) verifyTicket(report *VerificationReport, ticket *trac.Ticket,
+	userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap map[string]string) error {
+	issueIndex := importer.issueIndex(ticket.TicketID)
+	issueID, err := importer.giteaAccessor.GetIssueID(issueIndex)
+	if err != nil {
+		return err
+	}
+	if issueID == gitea.NullID {
+		report.addIssueDiscrepancy(MissingIssueDiscrepancy, ticket.TicketID, issueIndex, "", "present", "missing")
+		return nil
+	}
+
+	issue, err := importer.giteaAccessor.GetIssue(issueID)
+	if err != nil {
+		return err
+	}
+
+	closed := ticket.Status == string(trac.TicketStatusClosed)
+	if issue.Closed != closed {
+		report.addIssueDiscrepancy(IssueStateDiscrepancy, ticket.TicketID, issueIndex, "", issueStateName(closed), issueStateName(issue.Closed))
+	}
+
+	expectedMilestoneID := gitea.NullID
+	if ticket.MilestoneName != "" {
+		expectedMilestoneID, err = importer.giteaAccessor.GetMilestoneID(ticket.MilestoneName)
+		if err != nil {
+			return err
+		}
+	}
+	if issue.MilestoneID != expectedMilestoneID {
+		report.addIssueDiscrepancy(MilestoneDiscrepancy, ticket.TicketID, issueIndex, ticket.MilestoneName, expectedMilestoneID, issue.MilestoneID)
+	}
+
+	if err = importer.verifyIssueAssignee(report, ticket, issueIndex, issueID, userMap); err != nil {
+		return err
+	}
+
+	if err = importer.verifyIssueLabels(report, ticket, issueIndex, issueID, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap); err != nil {
+		return err
+	}
+
+	tracAttachmentCount, err := importer.verifyIssueAttachments(report, ticket, issueIndex, issueID)
+	if err != nil {
+		return err
+	}
+
+	return importer.verifyIssueComments(report, ticket, issueIndex, issueID, tracAttachmentCount)
+}
+
+// issueStateName returns the name of an issue state for reporting
+func issueStateName(closed bool) string {
+	if closed {
+		return "closed"
+	}
+	return "open"
+}
+
+// VerifyTickets compares each Trac ticket with the Gitea issue imported from it, recording any discrepancies in the provided report.
+func (importer *Importer) VerifyTickets(report *VerificationReport,
+	userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap map[string]string) error {
+	return importer.tracAccessor.GetTickets(func(ticket *trac.Ticket) error {
+		report.TicketsChecked++
+		return importer.verifyTicket(report, ticket, userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
+	})
+}
+
+// VerifyWiki compares the Trac wiki pages with the pages of the Gitea wiki, recording any discrepancies in the provided report.
+func (importer *Importer) VerifyWiki(report *VerificationReport) error {
+	err := importer.giteaAccessor.CloneWiki()
+	if err != nil {
+		return err
+	}
+
+	giteaPageNames, err := importer.giteaAccessor.GetWikiPageNames()
+	if err != nil {
+		return err
+	}
+	giteaPages := make(map[string]bool)
+	for _, giteaPageName := range giteaPageNames {
+		giteaPages[giteaPageName] = true
+	}
+
+	// Trac returns every version of every page - we are only interested in the pages themselves
+	tracPages := make(map[string]bool)
+	err = importer.tracAccessor.GetWikiPages(func(page *trac.WikiPage) error {
+		if !importer.convertPredefineds && importer.tracAccessor.IsPredefinedPage(page.Name) {
+			return nil
+		}
+		tracPages[page.Name] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	tracPageNames := make([]string, 0, len(tracPages))
+	for tracPageName := range tracPages {
+		tracPageNames = append(tracPageNames, tracPageName)
+	}
+	sort.Strings(tracPageNames)
+
+	for _, tracPageName := range tracPageNames {
+		report.WikiPagesChecked++
+		translatedPageName := importer.giteaAccessor.TranslateWikiPageName(tracPageName)
+		if !giteaPages[translatedPageName] {
+			report.addDiscrepancy(Discrepancy{Kind: MissingWikiPageDiscrepancy, WikiPage: tracPageName, Expected: translatedPageName, Found: "missing"})
+		}
+	}
+
+	if len(giteaPageNames) < len(tracPageNames) {
+		report.addDiscrepancy(Discrepancy{
+			Kind:     WikiPageCountDiscrepancy,
+			Expected: fmt.Sprint(len(tracPageNames)),
+			Found:    fmt.Sprint(len(giteaPageNames)),
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,217 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package importer_test
+
+import (
+	"testing"
+
+	"github.com/stevejefferson/trac2gitea/accessor/gitea"
+	"github.com/stevejefferson/trac2gitea/importer"
+	"go.uber.org/mock/gomock"
+)
+
+var verifiedMilestoneID int64 = 4321
+
+func expectIssueRetrieval(t *testing.T, ticket *TicketImport, closed bool, milestoneID int64) {
+	mockGiteaAccessor.
+		EXPECT().
+		GetIssue(gomock.Eq(ticket.issueID)).
+		Return(&gitea.Issue{ID: ticket.issueID, Index: ticket.issueIndex, Closed: closed, MilestoneID: milestoneID}, nil)
+}
+
+func expectVerifiedMilestoneLookup(t *testing.T, ticket *TicketImport) {
+	mockGiteaAccessor.
+		EXPECT().
+		GetMilestoneID(gomock.Eq(ticket.milestoneName)).
+		Return(verifiedMilestoneID, nil)
+}
+
+func expectIssueAssigneesRetrieval(t *testing.T, ticket *TicketImport, assignees ...string) {
+	mockGiteaAccessor.
+		EXPECT().
+		GetIssueAssignees(gomock.Eq(ticket.issueID)).
+		Return(assignees, nil)
+}
+
+func expectIssueLabelsRetrieval(t *testing.T, ticket *TicketImport, labels ...*TicketLabelImport) {
+	labelNames := []string{}
+	for _, label := range labels {
+		labelNames = append(labelNames, label.giteaLabelName)
+	}
+	mockGiteaAccessor.
+		EXPECT().
+		GetIssueLabels(gomock.Eq(ticket.issueID)).
+		Return(labelNames, nil)
+}
+
+func expectIssueAttachmentsRetrieval(t *testing.T, ticket *TicketImport, attachments ...gitea.IssueAttachment) {
+	mockGiteaAccessor.
+		EXPECT().
+		GetIssueAttachments(gomock.Eq(ticket.issueID)).
+		Return(attachments, nil)
+}
+
+func createGiteaIssueAttachment(ticket *TicketImport, ticketAttachment *TicketAttachmentImport) gitea.IssueAttachment {
+	return gitea.IssueAttachment{
+		ID:       ticketAttachment.issueAttachmentID,
+		UUID:     ticketAttachment.filename + "-uuid",
+		IssueID:  ticket.issueID,
+		FileName: ticketAttachment.filename,
+		Size:     ticketAttachment.size,
+	}
+}
+
+func expectIssueAttachmentFileSizeRetrieval(t *testing.T, ticketAttachment *TicketAttachmentImport, fileSize int64) {
+	mockGiteaAccessor.
+		EXPECT().
+		GetIssueAttachmentFileSize(gomock.Eq(ticketAttachment.filename+"-uuid")).
+		Return(fileSize, nil)
+}
+
+func expectIssueCommentCountRetrieval(t *testing.T, ticket *TicketImport, commentCount int64) {
+	mockGiteaAccessor.
+		EXPECT().
+		GetIssueCommentCount(gomock.Eq(ticket.issueID), gomock.Eq(gitea.CommentIssueCommentType)).
+		Return(commentCount, nil)
+}
+
+func verifyTickets(t *testing.T) *importer.VerificationReport {
+	report := importer.CreateVerificationReport()
+	err := dataImporter.VerifyTickets(report, userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
+	assertEquals(t, err, nil)
+	return report
+}
+
+func assertDiscrepancyKinds(t *testing.T, report *importer.VerificationReport, kinds ...importer.DiscrepancyKind) {
+	assertEquals(t, len(report.Discrepancies), len(kinds))
+	for i, kind := range kinds {
+		if i < len(report.Discrepancies) {
+			assertEquals(t, report.Discrepancies[i].Kind, kind)
+		}
+	}
+}
+
+func TestVerifyMatchingTicket(t *testing.T) {
+	setUpTickets(t)
+	defer tearDown(t)
+
+	expectTracTicketRetrievals(t, closedTicket)
+	expectIssueLookup(t, closedTicket, closedTicket.issueID)
+	expectIssueRetrieval(t, closedTicket, true, verifiedMilestoneID)
+	expectVerifiedMilestoneLookup(t, closedTicket)
+	expectIssueAssigneesRetrieval(t, closedTicket, closedTicket.owner.giteaUser)
+	expectIssueLabelsRetrieval(t, closedTicket,
+		componentLabel1, priorityLabel1, resolutionLabel1, severityLabel1, typeLabel1, versionLabel1)
+	expectIssueAttachmentsRetrieval(t, closedTicket, createGiteaIssueAttachment(closedTicket, closedTicketAttachment1))
+	expectTracAttachmentRetrievals(t, closedTicket, closedTicketAttachment1)
+	expectIssueAttachmentFileSizeRetrieval(t, closedTicketAttachment1, closedTicketAttachment1.size)
+	expectTracChangeRetrievals(t, closedTicket, closedTicketComment1, closedTicketComment2)
+	expectIssueCommentCountRetrieval(t, closedTicket, 3)
+
+	report := verifyTickets(t)
+	assertEquals(t, report.TicketsChecked, 1)
+	assertDiscrepancyKinds(t, report)
+}
+
+func TestVerifyMissingIssue(t *testing.T) {
+	setUpTickets(t)
+	defer tearDown(t)
+
+	expectTracTicketRetrievals(t, openTicket)
+	expectIssueLookup(t, openTicket, gitea.NullID)
+
+	report := verifyTickets(t)
+	assertEquals(t, report.TicketsChecked, 1)
+	assertDiscrepancyKinds(t, report, importer.MissingIssueDiscrepancy)
+	assertEquals(t, report.Discrepancies[0].TicketID, openTicket.ticketID)
+	assertEquals(t, report.Discrepancies[0].IssueIndex, openTicket.issueIndex)
+}
+
+func TestVerifyMismatchedTicket(t *testing.T) {
+	setUpTickets(t)
+	defer tearDown(t)
+
+	expectTracTicketRetrievals(t, closedTicket)
+	expectIssueLookup(t, closedTicket, closedTicket.issueID)
+
+	// issue is open, in no milestone, unassigned and missing its component label
+	expectIssueRetrieval(t, closedTicket, false, gitea.NullID)
+	expectVerifiedMilestoneLookup(t, closedTicket)
+	expectIssueAssigneesRetrieval(t, closedTicket)
+	expectIssueLabelsRetrieval(t, closedTicket,
+		priorityLabel1, resolutionLabel1, severityLabel1, typeLabel1, versionLabel1)
+
+	// first attachment is missing its file, second attachment is missing altogether
+	expectIssueAttachmentsRetrieval(t, closedTicket, createGiteaIssueAttachment(closedTicket, closedTicketAttachment1))
+	expectTracAttachmentRetrievals(t, closedTicket, closedTicketAttachment1, closedTicketAttachment2)
+	expectIssueAttachmentFileSizeRetrieval(t, closedTicketAttachment1, -1)
+
+	// only the first attachment comment has been imported
+	expectTracChangeRetrievals(t, closedTicket, closedTicketComment1)
+	expectIssueCommentCountRetrieval(t, closedTicket, 1)
+
+	report := verifyTickets(t)
+	assertDiscrepancyKinds(t, report,
+		importer.IssueStateDiscrepancy,
+		importer.MilestoneDiscrepancy,
+		importer.MissingAssigneeDiscrepancy,
+		importer.MissingLabelDiscrepancy,
+		importer.MissingAttachmentDiscrepancy,
+		importer.MissingAttachmentDiscrepancy,
+		importer.CommentCountDiscrepancy)
+	assertEquals(t, report.Discrepancies[1].Item, closedTicket.milestoneName)
+	assertEquals(t, report.Discrepancies[2].Expected, closedTicket.owner.giteaUser)
+	assertEquals(t, report.Discrepancies[3].Expected, componentLabel1.giteaLabelName)
+	assertEquals(t, report.Discrepancies[6].Expected, "3")
+	assertEquals(t, report.Discrepancies[6].Found, "1")
+}
+
+func TestVerifyAttachmentSizeMismatch(t *testing.T) {
+	setUpTickets(t)
+	defer tearDown(t)
+
+	expectTracTicketRetrievals(t, openTicket)
+	expectIssueLookup(t, openTicket, openTicket.issueID)
+	expectIssueRetrieval(t, openTicket, false, verifiedMilestoneID)
+	expectVerifiedMilestoneLookup(t, openTicket)
+	expectIssueAssigneesRetrieval(t, openTicket, openTicket.owner.giteaUser)
+	expectIssueLabelsRetrieval(t, openTicket,
+		componentLabel2, priorityLabel2, resolutionLabel2, severityLabel2, typeLabel2, versionLabel2)
+	expectIssueAttachmentsRetrieval(t, openTicket, createGiteaIssueAttachment(openTicket, openTicketAttachment1))
+	expectTracAttachmentRetrievals(t, openTicket, openTicketAttachment1)
+
+	// attachment file on disk is truncated
+	expectIssueAttachmentFileSizeRetrieval(t, openTicketAttachment1, openTicketAttachment1.size-1)
+	expectTracChangeRetrievals(t, openTicket)
+	expectIssueCommentCountRetrieval(t, openTicket, 1)
+
+	report := verifyTickets(t)
+	assertDiscrepancyKinds(t, report, importer.AttachmentSizeDiscrepancy)
+}
+
+func TestVerifyWiki(t *testing.T) {
+	setUpWiki(t)
+	defer tearDown(t)
+
+	expectCloneWiki(t)
+	mockGiteaAccessor.
+		EXPECT().
+		GetWikiPageNames().
+		Return([]string{giteaWikiPage1}, nil)
+	expectTracToReturnWikiPages(t, tracWikiPage1v1, tracWikiPage1v2, tracWikiPage2v1)
+	expectToTestForPredefinedWikiPage(t, tracWikiPage1v1, false)
+	expectToTestForPredefinedWikiPage(t, tracWikiPage1v2, false)
+	expectToTestForPredefinedWikiPage(t, tracWikiPage2v1, false)
+	expectToTranslateWikiPageName(t, tracWikiPage1v1, giteaWikiPage1)
+	expectToTranslateWikiPageName(t, tracWikiPage2v1, giteaWikiPage2)
+
+	report := importer.CreateVerificationReport()
+	err := dataImporter.VerifyWiki(report)
+	assertEquals(t, err, nil)
+
+	assertEquals(t, report.WikiPagesChecked, 2)
+	assertDiscrepancyKinds(t, report, importer.MissingWikiPageDiscrepancy, importer.WikiPageCountDiscrepancy)
+	assertEquals(t, report.Discrepancies[0].WikiPage, tracWikiPage2)
+}
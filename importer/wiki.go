@@ -35,7 +35,8 @@ func (importer *Importer) importWikiPages() {
 		translatedPageName := importer.giteaAccessor.TranslateWikiPageName(page.Name)
 
 		// convert and write wiki page
-		markdownText := importer.markdownConverter.WikiConvert(page.Name, page.Text)
+		markdownText, diagnostics := importer.markdownConverter.WikiConvert(page.Name, page.Text)
+		importer.addWikiPageDiagnostics(page, diagnostics)
 		written, err := importer.giteaAccessor.WriteWikiPage(translatedPageName, markdownText, tracPageVersionIdentifier)
 		if err != nil {
 			return err
@@ -11,6 +11,7 @@ import (
 
 	"go.uber.org/mock/gomock"
 	"github.com/stevejefferson/trac2gitea/accessor/trac"
+	"github.com/stevejefferson/trac2gitea/markdown"
 )
 
 const (
@@ -163,13 +164,14 @@ func expectToWriteGiteaWikiPage(
 	t *testing.T,
 	tracWikiPage *trac.WikiPage,
 	giteaWikiPage string,
-	pageWritten bool) {
+	pageWritten bool,
+	diagnostics ...markdown.Diagnostic) {
 	// expect to convert Trac page to markdown
 	markdownText := "trac wiki " + tracWikiPage.Text + "converted to markdown"
 	mockMarkdownConverter.
 		EXPECT().
 		WikiConvert(tracWikiPage.Name, tracWikiPage.Text).
-		Return(markdownText)
+		Return(markdownText, diagnostics)
 
 	// expect to write translated page to Gitea, returning provided status
 	mockGiteaAccessor.
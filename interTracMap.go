@@ -0,0 +1,72 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// interTracEnvironment describes a Trac environment listed in the InterTrac map
+type interTracEnvironment struct {
+	name            string
+	giteaRepo       string
+	tracRootDir     string
+	revisionMapFile string
+}
+
+// readInterTracMap reads the InterTrac map (Trac environment name -> Gitea repository map) from the provided file,
+// also returning the details of each environment listed. Returns nil if no file was provided.
+func readInterTracMap(mapFile string) (map[string]string, []interTracEnvironment, error) {
+	if mapFile == "" {
+		return nil, nil, nil
+	}
+
+	fd, err := os.Open(mapFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer fd.Close()
+
+	interTracMap := make(map[string]string)
+	var environments []interTracEnvironment
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		interTracMapLine := scanner.Text()
+		if strings.Trim(interTracMapLine, " ") == "" {
+			continue
+		}
+
+		equalsPos := strings.Index(interTracMapLine, "=")
+		if equalsPos == -1 {
+			return nil, nil, fmt.Errorf("badly formatted InterTrac map file %s: expecting '=', found %s", mapFile, interTracMapLine)
+		}
+
+		environmentName := strings.Trim(interTracMapLine[0:equalsPos], " ")
+		environmentFields := strings.Fields(interTracMapLine[equalsPos+1:])
+		if environmentName == "" || len(environmentFields) == 0 || len(environmentFields) > 3 {
+			return nil, nil, fmt.Errorf("badly formatted InterTrac map file %s: expecting '<trac-environment> = <gitea-repo> [<trac-root> [<revision-map>]]', found %s", mapFile, interTracMapLine)
+		}
+
+		environment := interTracEnvironment{name: environmentName, giteaRepo: environmentFields[0]}
+		if len(environmentFields) > 1 {
+			environment.tracRootDir = environmentFields[1]
+		}
+		if len(environmentFields) > 2 {
+			environment.revisionMapFile = environmentFields[2]
+		}
+
+		interTracMap[environmentName] = environment.giteaRepo
+		environments = append(environments, environment)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return interTracMap, environments, nil
+}
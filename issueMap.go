@@ -0,0 +1,85 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// readIssueMap reads the issue map (Trac ticket ID -> Gitea issue index map) from the provided file.
+// Returns an empty map if no file was provided or if the file does not exist yet.
+func readIssueMap(mapFile string) (map[int64]int64, error) {
+	issueMap := make(map[int64]int64)
+	if mapFile == "" {
+		return issueMap, nil
+	}
+
+	fd, err := os.Open(mapFile)
+	if os.IsNotExist(err) {
+		return issueMap, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		issueMapLine := scanner.Text()
+		if strings.Trim(issueMapLine, " ") == "" {
+			continue
+		}
+
+		equalsPos := strings.Index(issueMapLine, "=")
+		if equalsPos == -1 {
+			return nil, fmt.Errorf("badly formatted issue map file %s: found line %s", mapFile, issueMapLine)
+		}
+
+		ticketID, err := strconv.ParseInt(strings.Trim(issueMapLine[0:equalsPos], " "), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("badly formatted issue map file %s: invalid ticket ID in line %s", mapFile, issueMapLine)
+		}
+		issueIndex, err := strconv.ParseInt(strings.Trim(issueMapLine[equalsPos+1:], " "), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("badly formatted issue map file %s: invalid issue index in line %s", mapFile, issueMapLine)
+		}
+
+		issueMap[ticketID] = issueIndex
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return issueMap, nil
+}
+
+// writeIssueMapToFile writes the issue map to the provided file in ticket ID order
+func writeIssueMapToFile(mapFile string, issueMap map[int64]int64) error {
+	fd, err := os.Create(mapFile)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	ticketIDs := make([]int64, 0, len(issueMap))
+	for ticketID := range issueMap {
+		ticketIDs = append(ticketIDs, ticketID)
+	}
+	sort.Slice(ticketIDs, func(i, j int) bool { return ticketIDs[i] < ticketIDs[j] })
+
+	for _, ticketID := range ticketIDs {
+		if _, err := fmt.Fprintf(fd, "%d = %d\n", ticketID, issueMap[ticketID]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
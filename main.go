@@ -5,8 +5,10 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/stevejefferson/trac2gitea/importer"
 	"github.com/stevejefferson/trac2gitea/markdown"
@@ -24,6 +26,12 @@ var overwrite bool
 var verbose bool
 var wikiConvertPredefineds bool
 var generateMaps bool
+var interTracImport bool
+var issueNextFree bool
+var purge bool
+var purgePreview bool
+var verify bool
+var issueIndexOffset int64
 var tracRootDir string
 var giteaRootDir string
 var giteaMainConfigPath string
@@ -35,6 +43,14 @@ var userMapOutputFile string
 var labelMapInputFile string
 var labelMapOutputFile string
 var revisionMapFile string
+var interTracMapFile string
+var branchMapFile string
+var issueMapFile string
+var verifyReportFile string
+var redirectMapFile string
+var redirectMapFormat string
+var conversionReportFile string
+var conversionReportFormat string
 var giteaWikiRepoURL string
 var giteaWikiRepoToken string
 var giteaWikiRepoDir string
@@ -54,6 +70,39 @@ func parseArgs() {
 		"directory into which to checkout (clone) wiki repository - defaults to cwd")
 	wikiConvertPredefinedsParam := pflag.Bool("wiki-convert-predefined", false,
 		"convert Trac predefined wiki pages - by default we skip these")
+	interTracMapParam := pflag.String("intertrac-map", "",
+		"file mapping Trac InterTrac environment names onto Gitea repositories owned by <gitea-org> - InterTrac links to these environments are converted into links to the repositories")
+	branchMapParam := pflag.String("branch-map", "",
+		"file mapping Subversion branch paths onto git branch names - used for Trac source links outside the standard 'trunk' and 'branches/<name>' layout")
+	interTracImportParam := pflag.Bool("intertrac-import", false,
+		"after importing <trac-root>, also import each Trac environment in the InterTrac map which has a Trac root into its Gitea repository")
+
+	issueOffsetParam := pflag.Int64("issue-offset", 0,
+		"import each Trac ticket into the Gitea issue whose index is the ticket ID plus this offset")
+	issueNextFreeParam := pflag.Bool("issue-next-free", false,
+		"import Trac tickets into the next free Gitea issue indexes in the repository rather than using the ticket ID as the issue index")
+	issueMapParam := pflag.String("issue-map", "",
+		"file into which to write the mapping of Trac ticket IDs onto Gitea issue indexes - if the file already exists, the mappings in it are reused")
+
+	purgeParam := pflag.Bool("purge", false,
+		"remove all data imported by a previous conversion (issues, comments, attachments, labels, milestones and wiki pages) rather than importing")
+	purgePreviewParam := pflag.Bool("purge-preview", false,
+		"report what --purge would remove without removing anything")
+
+	verifyParam := pflag.Bool("verify", false,
+		"compare the data imported by a previous conversion with the Trac data rather than importing - exits with an error if any discrepancies are found")
+	verifyReportParam := pflag.String("verify-report", "",
+		"file into which to write the JSON verification report - defaults to stdout (implies --verify)")
+
+	redirectMapParam := pflag.String("redirect-map", "",
+		"file into which to write rules redirecting the URLs of Trac tickets, comments, attachments, milestones and wiki pages to the imported Gitea data")
+	redirectFormatParam := pflag.String("redirect-format", csvRedirectFormat,
+		"format of redirect map: one of "+nginxRedirectFormat+", "+apacheRedirectFormat+" or "+csvRedirectFormat)
+
+	conversionReportParam := pflag.String("conversion-report", "",
+		"file into which to write a report, by wiki page and ticket, of the Trac markup which could not be converted into markdown")
+	conversionReportFormatParam := pflag.String("conversion-report-format", jsonConversionReportFormat,
+		"format of conversion report: one of "+jsonConversionReportFormat+" or "+markdownConversionReportFormat)
 
 	generateMapsParam := pflag.Bool("generate-maps", false,
 		"generate default user/label mappings into provided map files (note: no conversion will be performed in this case)")
@@ -84,10 +133,57 @@ func parseArgs() {
 	wikiOnly = *wikiOnlyParam
 	wikiPush = !*wikiNoPushParam
 	generateMaps = *generateMapsParam
+	interTracMapFile = *interTracMapParam
+	interTracImport = *interTracImportParam
+	branchMapFile = *branchMapParam
+	issueIndexOffset = *issueOffsetParam
+	issueNextFree = *issueNextFreeParam
+	issueMapFile = *issueMapParam
+	purgePreview = *purgePreviewParam
+	purge = *purgeParam || purgePreview
+	verifyReportFile = *verifyReportParam
+	verify = *verifyParam || verifyReportFile != ""
+	redirectMapFile = *redirectMapParam
+	redirectMapFormat = *redirectFormatParam
+	conversionReportFile = *conversionReportParam
+	conversionReportFormat = *conversionReportFormatParam
 
 	if dbOnly && wikiOnly {
 		log.Fatal("cannot generate only database AND only wiki!")
 	}
+	if issueNextFree && issueIndexOffset != 0 {
+		log.Fatal("cannot both offset issue indexes AND allocate next free issue indexes!")
+	}
+	if purge && generateMaps {
+		log.Fatal("cannot both purge AND generate maps!")
+	}
+	if purge && issueNextFree && issueMapFile == "" {
+		log.Fatal("cannot purge issues allocated to next free issue indexes without an issue map!")
+	}
+	if verify && (purge || generateMaps) {
+		log.Fatal("cannot verify AND either purge or generate maps!")
+	}
+	if verify && issueNextFree && issueMapFile == "" {
+		log.Fatal("cannot verify issues allocated to next free issue indexes without an issue map!")
+	}
+	if verify && interTracImport {
+		log.Fatal("cannot verify InterTrac environments - verify each environment separately!")
+	}
+	if redirectMapFile != "" && (purge || generateMaps) {
+		log.Fatal("cannot generate a redirect map AND either purge or generate maps!")
+	}
+	if !isValidRedirectFormat(redirectMapFormat) {
+		log.Fatal("unsupported redirect map format %s!", redirectMapFormat)
+	}
+	if conversionReportFile != "" && (purge || verify || generateMaps) {
+		log.Fatal("cannot generate a conversion report AND either purge, verify or generate maps!")
+	}
+	if !isValidConversionReportFormat(conversionReportFormat) {
+		log.Fatal("unsupported conversion report format %s!", conversionReportFormat)
+	}
+	if interTracImport && interTracMapFile == "" {
+		log.Fatal("cannot import InterTrac environments without an InterTrac map!")
+	}
 	wikiConvertPredefineds = *wikiConvertPredefinedsParam
 	giteaWikiRepoURL = *wikiURLParam
 	giteaWikiRepoToken = *wikiTokenParam
@@ -131,7 +227,7 @@ func parseArgs() {
 }
 
 // importData imports the non-wiki Trac data.
-func importData(dataImporter *importer.Importer, userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap map[string]string) error {
+func importData(dataImporter *importer.Importer, userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap map[string]string) error {
 	var err error
 	if err = dataImporter.ImportFullNames(); err != nil {
 		return err
@@ -157,17 +253,24 @@ func importData(dataImporter *importer.Importer, userMap, componentMap, priority
 	if err = dataImporter.ImportMilestones(); err != nil {
 		return err
 	}
-	if err = dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap); err != nil {
+	if err = dataImporter.AllocateIssueIndexes(issueIndexOffset, issueNextFree); err != nil {
+		return err
+	}
+	if err = dataImporter.CheckIssueIndexes(); err != nil {
+		return err
+	}
+	if err = dataImporter.ImportTickets(userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-// performImport performs the actual import
-func performImport(dataImporter *importer.Importer, userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap map[string]string) error {
+// performImport performs the actual import, writing a redirect map for the imported data if a redirect map file is provided
+func performImport(dataImporter *importer.Importer, redirectMapFile string,
+	userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap map[string]string) error {
 	if !wikiOnly {
-		if err := importData(dataImporter, userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap); err != nil {
+		if err := importData(dataImporter, userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap); err != nil {
 			dataImporter.RollbackImport()
 			return err
 		}
@@ -180,86 +283,433 @@ func performImport(dataImporter *importer.Importer, userMap, componentMap, prior
 		}
 	}
 
+	// redirects must be generated before committing as they are generated from the data within the import transaction
+	if redirectMapFile != "" {
+		if err := writeRedirectMapToFile(redirectMapFile, redirectMapFormat, dataImporter); err != nil {
+			dataImporter.RollbackImport()
+			return err
+		}
+	}
+
 	return dataImporter.CommitImport()
 }
 
-// createImporter creates and configures the importer
-func createImporter() (*importer.Importer, error) {
-	tracAccessor, err := trac.CreateDefaultAccessor(tracRootDir)
+// purgeData purges the non-wiki data imported by a previous import.
+func purgeData(dataImporter *importer.Importer, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap map[string]string) error {
+	var err error
+	if err = dataImporter.AllocateIssueIndexes(issueIndexOffset, false); err != nil {
+		return err
+	}
+	if err = dataImporter.PurgeIssues(); err != nil {
+		return err
+	}
+	if err = dataImporter.PurgeMilestones(); err != nil {
+		return err
+	}
+	if err = dataImporter.PurgeLabels(componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// performPurge performs the purge of previously-imported data - if only previewing the purge, all changes are rolled back
+func performPurge(dataImporter *importer.Importer, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap map[string]string) error {
+	if purgePreview {
+		log.Info("previewing purge - no changes will be made")
+	}
+
+	if !wikiOnly {
+		if err := purgeData(dataImporter, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap); err != nil {
+			dataImporter.RollbackImport()
+			return err
+		}
+	}
+
+	if !dbOnly {
+		if err := dataImporter.PurgeWiki(); err != nil {
+			dataImporter.RollbackImport()
+			return err
+		}
+	}
+
+	if purgePreview {
+		log.Info("purge preview only - discarding changes")
+		return dataImporter.RollbackImport()
+	}
+
+	return dataImporter.CommitImport()
+}
+
+// performVerify compares the data imported by a previous import with the Trac data, writing a report of any discrepancies
+// (and a redirect map for the imported data if a redirect map file is provided).
+// The Gitea database is never modified so the transaction is always rolled back.
+func performVerify(dataImporter *importer.Importer, redirectMapFile string, userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap map[string]string) error {
+	report := importer.CreateVerificationReport()
+
+	if !wikiOnly {
+		if err := dataImporter.AllocateIssueIndexes(issueIndexOffset, false); err != nil {
+			dataImporter.RollbackImport()
+			return err
+		}
+		if err := dataImporter.VerifyTickets(report, userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap); err != nil {
+			dataImporter.RollbackImport()
+			return err
+		}
+	}
+
+	if !dbOnly {
+		if err := dataImporter.VerifyWiki(report); err != nil {
+			dataImporter.RollbackImport()
+			return err
+		}
+	}
+
+	if redirectMapFile != "" {
+		if err := writeRedirectMapToFile(redirectMapFile, redirectMapFormat, dataImporter); err != nil {
+			dataImporter.RollbackImport()
+			return err
+		}
+	}
+
+	if err := dataImporter.RollbackImport(); err != nil {
+		return err
+	}
+
+	if err := writeVerificationReport(verifyReportFile, report); err != nil {
+		return err
+	}
+
+	log.Info("verified %d tickets and %d wiki pages", report.TicketsChecked, report.WikiPagesChecked)
+	if len(report.Discrepancies) > 0 {
+		return fmt.Errorf("verification found %d discrepancies", len(report.Discrepancies))
+	}
+
+	return nil
+}
+
+// writeVerificationReport writes the verification report as JSON to the provided file or, if no file is provided, to stdout
+func writeVerificationReport(reportFile string, report *importer.VerificationReport) error {
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	reportJSON = append(reportJSON, '\n')
+
+	if reportFile == "" {
+		_, err = os.Stdout.Write(reportJSON)
+		return err
+	}
+
+	if err = os.WriteFile(reportFile, reportJSON, 0644); err != nil {
+		return err
+	}
+	log.Info("wrote verification report to %s", reportFile)
+	return nil
+}
+
+// createImporter creates and configures the importer, and the markdown converter it uses, for importing a given Trac environment into a given Gitea repository
+func createImporter(tracRoot, repo, wikiURL, wikiDir string, interTracMap map[string]string, interTracIssueIndexMaps map[string]map[int64]int64) (*importer.Importer, *markdown.DefaultConverter, error) {
+	tracAccessor, err := trac.CreateDefaultAccessor(tracRoot)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	giteaAccessor, err := gitea.CreateDefaultAccessor(
-		giteaRootDir, giteaMainConfigPath, giteaOrg, giteaRepo, giteaWikiRepoURL, giteaWikiRepoToken, giteaWikiRepoDir, overwrite, wikiPush, dbOnly)
+		giteaRootDir, giteaMainConfigPath, giteaOrg, repo, wikiURL, giteaWikiRepoToken, wikiDir, overwrite, wikiPush, dbOnly)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	markdownConverter := markdown.CreateDefaultConverter(tracAccessor, giteaAccessor)
+	markdownConverter.SetConvertPredefineds(wikiConvertPredefineds)
+	if interTracMap != nil {
+		markdownConverter.SetInterTracMap(interTracMap)
+		markdownConverter.SetInterTracIssueIndexMaps(interTracIssueIndexMaps)
+	}
+	if err = markdownConverter.LoadInterWikiMap(); err != nil {
+		return nil, nil, err
+	}
 
 	dataImporter, err := importer.CreateImporter(tracAccessor, giteaAccessor, markdownConverter, giteaDefaultUser, wikiConvertPredefineds)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	markdownConverter.SetIssueIndexMap(dataImporter.IssueIndexMap())
 
-	return dataImporter, nil
+	return dataImporter, markdownConverter, nil
 }
 
-func main() {
-	parseArgs()
-
-	var logLevel = log.INFO
-	if verbose {
-		logLevel = log.TRACE
-	}
-	log.SetLevel(logLevel)
-
-	dataImporter, err := createImporter()
+// migrateEnvironment migrates a given Trac environment into a given Gitea repository
+// (or, if we are only generating maps, generates the maps for the environment, or if purging or verifying, purges or verifies the previous migration of the environment).
+// The issue index map of the repository is added to the InterTrac issue index maps so that environments migrated later can link to its issues.
+func migrateEnvironment(tracRoot, repo, wikiURL, wikiDir, issueMapFile, redirectMapFile, conversionReportFile, revisionMapFile string,
+	interTracMap map[string]string, interTracIssueIndexMaps map[string]map[int64]int64) error {
+	dataImporter, markdownConverter, err := createImporter(tracRoot, repo, wikiURL, wikiDir, interTracMap, interTracIssueIndexMaps)
 	if err != nil {
-		log.Fatal("%+v", err)
-		return
+		return err
 	}
 
 	userMap, err := readUserMap(userMapInputFile, dataImporter)
 	if err != nil {
-		log.Fatal("%+v", err)
-		return
+		return err
 	}
 
 	componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, err := readLabelMaps(labelMapInputFile, dataImporter)
 	if err != nil {
-		log.Fatal("%+v", err)
-		return
+		return err
 	}
 
+	markdownConverter.SetUserMap(userMap)
+	markdownConverter.SetLabelMaps(componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
+
 	if generateMaps {
 		// note: no need to commit or rollback transaction here - nothing has been imported yet
 		if userMapOutputFile != "" {
 			if err = writeUserMapToFile(userMapOutputFile, userMap); err != nil {
-				log.Fatal("%+v", err)
-				return
+				return err
 			}
 			log.Info("wrote user map to %s", userMapOutputFile)
 		}
 		if labelMapOutputFile != "" {
 			if err = writeLabelMapsToFile(labelMapOutputFile, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap); err != nil {
-				log.Fatal("%+v", err)
-				return
+				return err
 			}
 			log.Info("wrote label map to %s", labelMapOutputFile)
 		}
 
-		return
+		return nil
+	}
+
+	issueMap, err := readIssueMap(issueMapFile)
+	if err != nil {
+		return err
+	}
+	issueIndexMap := dataImporter.IssueIndexMap()
+	for ticketID, issueIndex := range issueMap {
+		issueIndexMap[ticketID] = issueIndex
+	}
+	for ticketID, issueIndex := range interTracIssueIndexMaps[repo] {
+		issueIndexMap[ticketID] = issueIndex
+	}
+	if interTracIssueIndexMaps != nil {
+		interTracIssueIndexMaps[repo] = issueIndexMap
+	}
+
+	if purge {
+		return performPurge(dataImporter, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
+	}
+	if verify {
+		return performVerify(dataImporter, redirectMapFile, userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
 	}
 
 	revisionMap, err := readRevisionMap(revisionMapFile)
+	if err != nil {
+		return err
+	}
+	markdownConverter.SetRevisionMap(revisionMap)
+
+	branchMap, err := readBranchMap(branchMapFile)
+	if err != nil {
+		return err
+	}
+	markdownConverter.SetBranchMap(branchMap)
+
+	err = performImport(dataImporter, redirectMapFile, userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
+	if err != nil {
+		return err
+	}
+	reportUnresolvedAnchors(markdownConverter)
+
+	if conversionReportFile != "" {
+		if err = writeConversionReport(conversionReportFile, conversionReportFormat, dataImporter.ConversionReport()); err != nil {
+			return err
+		}
+	}
+
+	if issueMapFile != "" && !wikiOnly {
+		if err = writeIssueMapToFile(issueMapFile, issueIndexMap); err != nil {
+			return err
+		}
+		log.Info("wrote issue map to %s", issueMapFile)
+	}
+
+	return nil
+}
+
+// reportUnresolvedAnchors reports the wiki page anchors referenced by converted Trac links which could not be found
+func reportUnresolvedAnchors(markdownConverter *markdown.DefaultConverter) {
+	unresolvedAnchors := markdownConverter.UnresolvedAnchors()
+	if len(unresolvedAnchors) == 0 {
+		return
+	}
+
+	log.Warn("%d converted links refer to wiki page anchors which could not be found:", len(unresolvedAnchors))
+	for _, anchor := range unresolvedAnchors {
+		referrer := "wiki page " + anchor.ReferringWikiPage
+		if anchor.TicketID != trac.NullID {
+			referrer = fmt.Sprintf("ticket %d", anchor.TicketID)
+		}
+		log.Warn("  %s#%s (link \"%s\" in %s)", anchor.WikiPage, anchor.Anchor, anchor.Link, referrer)
+	}
+}
+
+// interTracWikiDir returns the directory into which to clone the wiki of a Gitea repository imported from an InterTrac environment
+func interTracWikiDir(repo string) string {
+	if giteaWikiRepoDir == "" {
+		return "" // use default directory based on repository name
+	}
+
+	return filepath.Join(filepath.Dir(giteaWikiRepoDir), repo+".wiki")
+}
+
+// interTracIssueMapFile returns the file into which to write the issue map of a Gitea repository imported from an InterTrac environment
+func interTracIssueMapFile(repo string) string {
+	if issueMapFile == "" {
+		return ""
+	}
+
+	return issueMapFile + "." + repo
+}
+
+// interTracRedirectMapFile returns the file into which to write the redirect map of a Gitea repository imported from an InterTrac environment
+func interTracRedirectMapFile(repo string) string {
+	if redirectMapFile == "" {
+		return ""
+	}
+
+	return redirectMapFile + "." + repo
+}
+
+// interTracConversionReportFile returns the file into which to write the conversion report of a Gitea repository imported from an InterTrac environment
+func interTracConversionReportFile(repo string) string {
+	if conversionReportFile == "" {
+		return ""
+	}
+
+	return conversionReportFile + "." + repo
+}
+
+// isImportedInterTracEnvironment returns true if an InterTrac environment is to be imported in this run (after the main environment)
+func isImportedInterTracEnvironment(environment interTracEnvironment) bool {
+	return interTracImport && !generateMaps && environment.tracRootDir != "" && environment.giteaRepo != giteaRepo
+}
+
+// allocateInterTracIssueIndexes allocates the Gitea issue indexes of the tickets of an InterTrac environment which has not been imported yet,
+// adding them to the provided issue index map.
+// This allows links to its tickets from environments imported before it to be converted - the environment's own import then keeps these allocations.
+func allocateInterTracIssueIndexes(environment interTracEnvironment, issueIndexMap map[int64]int64) error {
+	tracAccessor, err := trac.CreateDefaultAccessor(environment.tracRootDir)
+	if err != nil {
+		return err
+	}
+	giteaAccessor, err := gitea.CreateDefaultAccessor(
+		giteaRootDir, giteaMainConfigPath, giteaOrg, environment.giteaRepo, "", giteaWikiRepoToken, "", overwrite, false, true)
+	if err != nil {
+		return err
+	}
+	dataImporter, err := importer.CreateImporter(tracAccessor, giteaAccessor, nil, giteaDefaultUser, wikiConvertPredefineds)
+	if err != nil {
+		return err
+	}
+
+	allocatedIndexMap := dataImporter.IssueIndexMap()
+	for ticketID, issueIndex := range issueIndexMap {
+		allocatedIndexMap[ticketID] = issueIndex
+	}
+	if err = dataImporter.AllocateIssueIndexes(issueIndexOffset, issueNextFree); err != nil {
+		dataImporter.RollbackImport()
+		return err
+	}
+	if err = dataImporter.CheckIssueIndexes(); err != nil {
+		dataImporter.RollbackImport()
+		return err
+	}
+	for ticketID, issueIndex := range allocatedIndexMap {
+		issueIndexMap[ticketID] = issueIndex
+	}
+
+	// nothing has been changed in Gitea
+	return dataImporter.RollbackImport()
+}
+
+// readInterTracIssueIndexMaps returns the map of Trac ticket ID onto Gitea issue index for the Gitea repository of each InterTrac environment.
+// These are read from the issue map file of each environment, with issue indexes being allocated to the tickets of any environment to be imported in this run.
+func readInterTracIssueIndexMaps(environments []interTracEnvironment) (map[string]map[int64]int64, error) {
+	if environments == nil {
+		return nil, nil
+	}
+
+	interTracIssueIndexMaps := make(map[string]map[int64]int64)
+	for _, environment := range environments {
+		if environment.giteaRepo == giteaRepo {
+			continue
+		}
+
+		issueIndexMap, err := readIssueMap(interTracIssueMapFile(environment.giteaRepo))
+		if err != nil {
+			return nil, err
+		}
+		if isImportedInterTracEnvironment(environment) && !purge && !verify && !wikiOnly {
+			if err = allocateInterTracIssueIndexes(environment, issueIndexMap); err != nil {
+				return nil, err
+			}
+		}
+		interTracIssueIndexMaps[environment.giteaRepo] = issueIndexMap
+	}
+
+	return interTracIssueIndexMaps, nil
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == previewCommand {
+		if err := runPreview(os.Args[2:]); err != nil {
+			log.Fatal("%+v", err)
+		}
+		return
+	}
+
+	parseArgs()
+
+	var logLevel = log.INFO
+	if verbose {
+		logLevel = log.TRACE
+	}
+	log.SetLevel(logLevel)
+
+	interTracMap, interTracEnvironments, err := readInterTracMap(interTracMapFile)
 	if err != nil {
 		log.Fatal("%+v", err)
 		return
 	}
 
-	err = performImport(dataImporter, userMap, componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, revisionMap)
+	interTracIssueIndexMaps, err := readInterTracIssueIndexMaps(interTracEnvironments)
 	if err != nil {
 		log.Fatal("%+v", err)
 		return
 	}
+
+	err = migrateEnvironment(tracRootDir, giteaRepo, giteaWikiRepoURL, giteaWikiRepoDir, issueMapFile, redirectMapFile, conversionReportFile, revisionMapFile,
+		interTracMap, interTracIssueIndexMaps)
+	if err != nil {
+		log.Fatal("%+v", err)
+		return
+	}
+
+	if !interTracImport || generateMaps {
+		return
+	}
+
+	for _, environment := range interTracEnvironments {
+		if !isImportedInterTracEnvironment(environment) {
+			continue
+		}
+
+		log.Info("importing InterTrac environment %s from %s into repository %s", environment.name, environment.tracRootDir, environment.giteaRepo)
+		err = migrateEnvironment(environment.tracRootDir, environment.giteaRepo, "", interTracWikiDir(environment.giteaRepo),
+			interTracIssueMapFile(environment.giteaRepo), interTracRedirectMapFile(environment.giteaRepo),
+			interTracConversionReportFile(environment.giteaRepo), environment.revisionMapFile, interTracMap, interTracIssueIndexMaps)
+		if err != nil {
+			log.Fatal("%+v", err)
+			return
+		}
+	}
 }
@@ -4,22 +4,33 @@
 
 package markdown
 
-import "regexp"
+import (
+	"regexp"
+	"strings"
+)
 
 // regexp for a Trac anchor: $1=anchor $2=anchor text
-var anchorRegexp = regexp.MustCompile(`(?m)\[=#([[:alnum:]?/:@\-._\~!$&'()*+,;=]+)(?: +([^\]\n]+))?\]`)
+var anchorRegexp = regexp.MustCompile(`^\[=#([[:alnum:]?/:@\-._\~!$&'()*+,;=]+)(?: +([^\]]+))?\]`)
 
-// convertAnchors converts Trac '[=#name...]' anchors
-// additionally Trac supports anchors on headings - these are dealt with in the heading conversion
-func (converter *DefaultConverter) convertAnchors(in string) string {
-	out := in
-	out = anchorRegexp.ReplaceAllStringFunc(out, func(match string) string {
-		anchorName := anchorRegexp.ReplaceAllString(match, `$1`)
-		anchorLabel := anchorRegexp.ReplaceAllString(match, `$2`)
+// anchorInline is a Trac '[=#name...]' anchor
+// additionally Trac supports anchors on headings - these are dealt with as part of the heading
+type anchorInline struct {
+	name  string
+	label []inline
+}
+
+func (parser *inlineParser) matchAnchor(s string) (inline, int) {
+	match := anchorRegexp.FindStringSubmatch(s)
+	if match == nil {
+		return nil, 0
+	}
 
-		// there is no agreed markdown for anchors however raw HTML works
-		return "<a name=\"" + anchorName + "\">" + anchorLabel + "</a>"
-	})
+	return &anchorInline{name: match[1], label: parser.converter.parseLinkText(match[2])}, len(match[0])
+}
 
-	return out
+func (renderer *renderer) writeAnchor(builder *strings.Builder, node *anchorInline) {
+	// there is no agreed markdown for anchors however raw HTML works
+	builder.WriteString("<a name=\"" + node.name + "\">")
+	renderer.writeInlines(builder, node.label)
+	builder.WriteString("</a>")
 }
@@ -10,13 +10,13 @@ func TestUnlabelledAnchor(t *testing.T) {
 	setUp(t)
 	defer tearDown(t)
 
-	conversion := converter.WikiConvert(wikiPage, leadingText+"[=#anchor-name]"+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"[=#anchor-name]"+trailingText)
 	assertEquals(t, conversion, leadingText+"<a name=\"anchor-name\"></a>"+trailingText)
 }
 func TestLabelledAnchor(t *testing.T) {
 	setUp(t)
 	defer tearDown(t)
 
-	conversion := converter.WikiConvert(wikiPage, leadingText+"[=#anchor-name anchor label]"+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"[=#anchor-name anchor label]"+trailingText)
 	assertEquals(t, conversion, leadingText+"<a name=\"anchor-name\">anchor label</a>"+trailingText)
 }
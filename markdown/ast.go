@@ -0,0 +1,87 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+// Trac wiki text is parsed into an abstract syntax tree consisting of a sequence of blocks,
+// each block representing one or more complete lines of wiki text (a heading, a list, a table, a code block etc.).
+// The text within a block is represented by a sequence of inlines (plain text, links, font styles etc.).
+// The node types for each construct are declared alongside its parsing and rendering.
+
+// block is a node of the abstract syntax tree representing one or more complete lines of Trac wiki text
+type block interface{}
+
+// inline is a node of the abstract syntax tree representing a span of text within a line of Trac wiki text
+type inline interface{}
+
+// blankBlock is a blank line
+type blankBlock struct{}
+
+// textInline is literal text
+type textInline struct {
+	text string
+}
+
+// walkInlines calls a function for each inline in a sequence of blocks, including those nested inside other blocks and inlines
+// - the text of code blocks and processors which are not parsed into inlines is not visited
+func walkInlines(blocks []block, visit func(node inline)) {
+	for _, node := range blocks {
+		switch node := node.(type) {
+		case *paragraphBlock:
+			walkLineInlines(node.lines, visit)
+		case *headingBlock:
+			walkInlineSequence(node.content, visit)
+		case *listBlock:
+			walkListItemInlines(node.items, visit)
+		case *definitionBlock:
+			walkInlineSequence(node.term, visit)
+			walkLineInlines(node.description, visit)
+		case *blockQuoteBlock:
+			walkLineInlines(node.lines, visit)
+		case *htmlSpanBlock:
+			walkLineInlines(node.lines, visit)
+		case *htmlTagBlock:
+			walkInlines(node.blocks, visit)
+		case *commitTicketReferenceBlock:
+			walkInlines(node.blocks, visit)
+		case *tableBlock:
+			for _, row := range node.rows {
+				for _, cell := range row.cells {
+					walkInlineSequence(cell.content, visit)
+					walkInlines(cell.blocks, visit)
+				}
+			}
+		}
+	}
+}
+
+func walkListItemInlines(items []*listItem, visit func(node inline)) {
+	for _, item := range items {
+		walkInlineSequence(item.content, visit)
+		for _, continuation := range item.continuation {
+			walkInlineSequence(continuation.content, visit)
+		}
+		walkListItemInlines(item.children, visit)
+	}
+}
+
+func walkLineInlines(lines [][]inline, visit func(node inline)) {
+	for _, line := range lines {
+		walkInlineSequence(line, visit)
+	}
+}
+
+func walkInlineSequence(inlines []inline, visit func(node inline)) {
+	for _, node := range inlines {
+		visit(node)
+		switch node := node.(type) {
+		case *styledInline:
+			walkInlineSequence(node.content, visit)
+		case *anchorInline:
+			walkInlineSequence(node.label, visit)
+		case *linkInline:
+			walkInlineSequence(node.text, visit)
+		}
+	}
+}
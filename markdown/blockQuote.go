@@ -4,10 +4,25 @@
 
 package markdown
 
-import "regexp"
+import "strings"
 
-var blockQuoteRegexp = regexp.MustCompile(`(?m)^  ([[:alpha:]].*)$`)
+// blockQuoteBlock is a block of indented text, which Trac displays as a block quote
+type blockQuoteBlock struct {
+	lines [][]inline
+}
+
+func (parser *blockParser) parseBlockQuote() block {
+	blockQuote := blockQuoteBlock{}
+	for line, ok := parser.currentLine(); ok && isIndented(line) && !isBlockStart(line); line, ok = parser.currentLine() {
+		blockQuote.lines = append(blockQuote.lines, parser.converter.parseInlines(strings.TrimSpace(line)))
+		parser.pos++
+	}
+
+	return &blockQuote
+}
 
-func (converter *DefaultConverter) convertBlockQuotes(in string) string {
-	return blockQuoteRegexp.ReplaceAllString(in, "> $1")
+func (renderer *renderer) renderBlockQuote(blockQuote *blockQuoteBlock) {
+	for _, line := range blockQuote.lines {
+		renderer.addLine("> " + renderer.renderLine(line))
+	}
 }
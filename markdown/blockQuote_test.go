@@ -15,7 +15,7 @@ func TestBlockQuote(t *testing.T) {
 	setUp(t)
 	defer tearDown(t)
 
-	conversion := converter.WikiConvert(
+	conversion, _ := converter.WikiConvert(
 		wikiPage,
 		leadingText+"\n"+
 			"  "+line1+
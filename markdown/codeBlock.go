@@ -5,167 +5,322 @@
 package markdown
 
 import (
-	"regexp"
 	"strings"
 
 	"github.com/stevejefferson/trac2gitea/log"
 )
 
-// Code blocks are disguised with {@{@{...}@}@} in order to avoid Trac syntax conversion inside
-var nonCodeBlockRegexp = regexp.MustCompile(`(?m)(?:}@}@}$|\A)(?s)(.+?)(?:^{@{@{|\z)`)
-
-var singleLineCodeBlockRegexp = regexp.MustCompile(`{@{@{([^\n]+?)}@}@}`)
-
-var codeBlockBoundaryRegexp = regexp.MustCompile(`{{{|}}}`)
-var tracProcessorRegexp = regexp.MustCompile(`^\s*#!(\w[^\n]*)?`)
-
 // We support block-style HTML tags, for which we add an empty line between the tags
 // and the content which might be Markdown
-var htmlTags = []string{"div", "td", "th", "tr", "table"}
+var htmlTags = []string{"div", "section"}
 
 var codeLangs = []string{"c", "c++", "ps1", "php", "py", "sh", "cpp", "pl"}
 var langMap = map[string]string{"c++": "cpp"}
 
-// Parse code blocks delimited by triple curly brackets, converting them to raw HTML if applicable
-// Else, convert them to disguised code block boundaries allowing us to preserve their contents
-// See WikiProcessors for the Trac syntax details
-func (converter *DefaultConverter) parseCodeBlocks(in string, accumulated []string) string {
+// codeBlock is a block of code (or of the output of a Trac processor for which we have no equivalent) rendered as a markdown code block
+type codeBlock struct {
+	info  string
+	lines []string
 
-	// This function receives a remaining `in` text and a list of currently opened code blocks
+	// processor is the Trac processor for which we have no equivalent, if any
+	processor string
+}
 
-	// Do nothing if there are no curly braces in the remaining text. There shouldn't remain any pending triple brackets.
-	if !codeBlockBoundaryRegexp.MatchString(in) {
-		if len(accumulated) > 0 {
-			log.Error("Parsing issue during markdown conversion of code blocks. Some opening triple brackets are not closed.")
-		}
+// htmlBlock is the contents of a Trac '#!html' processor which is passed through as raw HTML
+type htmlBlock struct {
+	lines []string
+}
 
-		return in
-	}
+// commentBlock is the contents of a Trac '#!comment' processor
+type commentBlock struct {
+	lines []string
+}
 
-	// Split the text around the next boundary.
-	surroundings := codeBlockBoundaryRegexp.Split(in, 2)
-	beforeBoundary := surroundings[0]
-	afterBoundary := surroundings[1]
-	convertedBoundary := ""
-	newAccumulated := accumulated
+// htmlTagBlock is the contents of a Trac processor such as '#!div' which corresponds to an HTML tag containing further wiki text
+type htmlTagBlock struct {
+	tag        string
+	attributes []htmlAttribute
+	blocks     []block
 
-	// Deal with a new block opening
-	if codeBlockBoundaryRegexp.FindString(in) == "{{{" {
-		// Default values for unknown processors as well as simple {{{
-		convertedBoundary = "{@{@{"
-		blockType := ""
-		tracProcessor := ""
+	// tableProcessor is the Trac '#!table' processor whose content could not be parsed into table rows and cells, if any
+	tableProcessor string
+}
 
-		// Identify the processor if there is one after the opening
-		if match := tracProcessorRegexp.FindStringSubmatch(afterBoundary); match != nil {
-			tracProcessor = match[1]
-			afterBoundary = tracProcessorRegexp.Split(afterBoundary, 2)[1]
-		}
-		// get rid of CommitTicketReference processors
-		if strings.HasPrefix(tracProcessor, "CommitTicketReference") {
-			tracProcessor = ""
-		}
-		// (in which case it will be kept after the converted opening by default)
-		if tracProcessor != "" {
-			convertedBoundary += "#!" + tracProcessor
-		}
+// htmlSpanBlock is the contents of a Trac '#!span' processor: lines of wiki text within an HTML span
+type htmlSpanBlock struct {
+	attributes []htmlAttribute
+	lines      [][]inline
+}
 
-		// If the processor is a known language, do not keep the #! mark
-		// Convert the lang to the supported gitea version if needed
-		for _, codeLang := range codeLangs {
-			if strings.TrimSpace(tracProcessor) == codeLang {
-				lang := codeLang
-				if fixedLang, found := langMap[lang]; found {
-					lang = fixedLang
-				}
-				convertedBoundary = "{@{@{" + lang
-			}
-		}
+// codeInline is a span of code within a line
+type codeInline struct {
+	code string
+}
 
-		// If it is a supported html tag, replace the opening by a <tag>
-		// followed by an empty line
-		for _, tag := range htmlTags {
-			if strings.HasPrefix(tracProcessor, tag) {
-				blockType = tag
-				convertedBoundary = "<" + tracProcessor + ">\n"
+// isCodeBlockStart returns true if a (trimmed) line opens a multi-line Trac '{{{' block
+func isCodeBlockStart(trimmedLine string) bool {
+	return strings.HasPrefix(trimmedLine, "{{{") && !strings.Contains(trimmedLine[len("{{{"):], "}}}")
+}
+
+// isCodeBlockEnd returns true if a (trimmed) line closes a multi-line Trac '{{{' block
+func isCodeBlockEnd(trimmedLine string) bool {
+	return strings.HasPrefix(trimmedLine, "}}}")
+}
+
+// parseCodeBlock parses a Trac '{{{...}}}' block, including any Trac processor ('#!<processor>') for the block.
+// See WikiProcessors for the Trac syntax details
+func (parser *blockParser) parseCodeBlock() block {
+	processor, content := parser.readCodeBlock()
+	return parser.createProcessorBlock(processor, content)
+}
+
+// codeBlockProcessorName returns the name of the Trac processor of the '{{{' block starting at the current line, if any
+func (parser *blockParser) codeBlockProcessorName() string {
+	line, _ := parser.currentLine()
+	trimmedLine := strings.TrimSpace(line)
+	if !isCodeBlockStart(trimmedLine) {
+		return ""
+	}
+
+	// the processor can either follow the opening '{{{' or be on the following line
+	processorLine := strings.TrimSpace(trimmedLine[len("{{{"):])
+	if processorLine == "" && parser.pos+1 < len(parser.lines) {
+		processorLine = strings.TrimSpace(parser.lines[parser.pos+1])
+	}
+	if !strings.HasPrefix(processorLine, "#!") {
+		return ""
+	}
+	if processorFields := strings.Fields(processorLine[len("#!"):]); len(processorFields) > 0 {
+		return processorFields[0]
+	}
+	return ""
+}
+
+// readCodeBlock reads a Trac '{{{...}}}' block, returning its processor (including any processor parameters) and its lines of content
+func (parser *blockParser) readCodeBlock() (string, []string) {
+	// the processor can either follow the opening '{{{' or be on the following line
+	processor := ""
+	content := []string{}
+	afterBoundary := strings.TrimSpace(strings.TrimSpace(parser.lines[parser.pos])[len("{{{"):])
+	parser.pos++
+	if strings.HasPrefix(afterBoundary, "#!") {
+		processor = strings.TrimSpace(afterBoundary[len("#!"):])
+	} else if afterBoundary != "" {
+		content = append(content, afterBoundary)
+	} else if line, ok := parser.currentLine(); ok && strings.HasPrefix(strings.TrimSpace(line), "#!") {
+		processor = strings.TrimSpace(strings.TrimSpace(line)[len("#!"):])
+		parser.pos++
+	}
+
+	// collect lines up to the matching '}}}' - there may be further blocks nested inside this one
+	nestingLevel := 0
+	closed := false
+	for line, ok := parser.currentLine(); ok; line, ok = parser.currentLine() {
+		trimmedLine := strings.TrimSpace(line)
+		if isCodeBlockEnd(trimmedLine) {
+			if nestingLevel == 0 {
+				closed = true
+
+				// treat anything following the closing '}}}' as a line of its own
+				if afterBoundary := strings.TrimSpace(trimmedLine[len("}}}"):]); afterBoundary != "" {
+					parser.lines[parser.pos] = afterBoundary
+				} else {
+					parser.pos++
+				}
 				break
 			}
+			nestingLevel--
+		} else if isCodeBlockStart(trimmedLine) {
+			nestingLevel++
 		}
 
-		// If it is a comment, replace the opening by the start of an HTML comment
-		if strings.HasPrefix(tracProcessor, "comment") || strings.HasPrefix(tracProcessor, "htmlcomment") {
-			blockType = "comment"
-			convertedBoundary = "<!---"
-		}
+		content = append(content, line)
+		parser.pos++
+	}
+	if !closed {
+		log.Warn("Trac code block opened with '{{{' is not closed")
 
-		// If it is a #!html processor, replace the block by an empty line to preserve functionality
-		// in Markdown (this must be checked after #!htmlcomment)
-		if strings.HasPrefix(tracProcessor, "html") {
-			blockType = "html"
-			convertedBoundary = ""
+		// leave any trailing blank line outside the block
+		if len(content) > 0 && strings.TrimSpace(content[len(content)-1]) == "" {
+			content = content[:len(content)-1]
+			parser.pos--
 		}
+	}
+
+	return processor, content
+}
 
-		// Remember that we opened a new block
-		newAccumulated = append(accumulated, blockType)
+// createProcessorBlock creates the block for the contents of a Trac '{{{...}}}' block according to its processor
+func (parser *blockParser) createProcessorBlock(processor string, content []string) block {
+	processorName := ""
+	if processorFields := strings.Fields(processor); len(processorFields) > 0 {
+		processorName = processorFields[0]
 	}
 
-	// Deal with a block closing
-	if codeBlockBoundaryRegexp.FindString(in) == "}}}" {
-		// We are closing the lastly opened block. If we are not inside one, do not convert or disguise these braces.
-		if len(accumulated) == 0 {
-			return beforeBoundary + "}}}" + converter.parseCodeBlocks(afterBoundary, accumulated)
+	// if the content of the processor can be converted into markdown, do that but keep the block we would otherwise produce in case the conversion fails
+	if _, found := processorConverters[processorName]; found {
+		fallback := parser.createUnconvertedProcessorBlock(processorName, processor, content)
+		if code, ok := fallback.(*codeBlock); ok {
+			// any failure to convert the content is reported when rendering the converted processor
+			code.processor = ""
 		}
-		blockType := accumulated[len(accumulated)-1]
+		return &convertedProcessorBlock{
+			processorName: processorName,
+			content:       content,
+			fallback:      fallback,
+		}
+	}
 
-		// By default, this will become a closing triple bracket
-		convertedBoundary = "}@}@}"
+	return parser.createUnconvertedProcessorBlock(processorName, processor, content)
+}
 
-		// If we are closing a supported html tag, replace the closing by an empty line and a </tag>
-		for _, tag := range htmlTags {
-			if blockType == tag {
-				convertedBoundary = "\n</" + tag + ">"
-			}
+// createUnconvertedProcessorBlock creates the block for the contents of a Trac '{{{...}}}' block whose content cannot be converted into markdown by a processorConverter
+func (parser *blockParser) createUnconvertedProcessorBlock(processorName string, processor string, content []string) block {
+	// if it is a supported html tag, the contents are wiki text
+	for _, tag := range htmlTags {
+		if strings.ToLower(processorName) == tag {
+			return &htmlTagBlock{tag: tag, attributes: parseHTMLAttributes(processorParameters(processor)), blocks: parser.converter.parseLines(content)}
+		}
+	}
+
+	switch processorName {
+	case "":
+		return &codeBlock{lines: content}
+	case "table":
+		return parser.converter.parseTableProcessor(processor, content)
+	case "comment", "htmlcomment":
+		return &commentBlock{lines: content}
+	case "html":
+		return &htmlBlock{lines: content}
+	case "span":
+		span := htmlSpanBlock{attributes: parseHTMLAttributes(processorParameters(processor))}
+		for _, line := range content {
+			span.lines = append(span.lines, parser.converter.parseInlines(line))
 		}
+		return &span
+	case "CommitTicketReference":
+		return parser.parseCommitTicketReference(processor, content)
+	}
 
-		// If we are closing a comment, replace the closing by the end of an HTML comment
-		if blockType == "comment" {
-			convertedBoundary = "-->"
+	// if the processor is a known language, convert the lang to the supported gitea version if needed
+	for _, codeLang := range codeLangs {
+		if processorName == codeLang {
+			lang := codeLang
+			if fixedLang, found := langMap[lang]; found {
+				lang = fixedLang
+			}
+			return &codeBlock{info: lang, lines: content}
 		}
+	}
+
+	// otherwise keep the processor after the opening of the code block
+	return &codeBlock{info: "#!" + processor, lines: content, processor: processor}
+}
+
+// processorParameters returns the parameters following the name of a Trac processor
+func processorParameters(processor string) string {
+	processor = strings.TrimSpace(processor)
+	if end := strings.IndexAny(processor, " \t"); end != -1 {
+		return strings.TrimSpace(processor[end:])
+	}
+	return ""
+}
 
-		// If we are closing a #!html processor, just remove the block marks
-		if blockType == "html" {
-			convertedBoundary = ""
+// codeFence returns a markdown code fence suitable for enclosing some lines of code
+// - the fence must be longer than any sequence of backticks at the start of a line of the code
+func codeFence(lines []string) string {
+	fence := "```"
+	for _, line := range lines {
+		trimmedLine := strings.TrimLeft(line, " \t")
+		for strings.HasPrefix(trimmedLine, fence) {
+			fence = fence + "`"
 		}
+	}
+	return fence
+}
 
-		// This block is now closed
-		newAccumulated = accumulated[:len(accumulated)-1]
+func (renderer *renderer) renderCodeBlock(code *codeBlock) {
+	if code.processor != "" {
+		renderer.converter.warn(UnconvertedProcessorDiagnostic, "#!"+code.processor,
+			"Trac '#!%s' processor is not supported, leaving its content in a code block", code.processor)
 	}
 
-	// Continue parsing the rest of the text using recursion
-	// This is guaranteed to end as `afterBoundary` is strictly shorter than `in`
-	return beforeBoundary + convertedBoundary + converter.parseCodeBlocks(afterBoundary, newAccumulated)
+	fence := codeFence(code.lines)
+	renderer.addLine(fence + code.info)
+	for _, line := range code.lines {
+		renderer.addLine(line)
+	}
+	renderer.addLine(fence)
+}
+
+func (renderer *renderer) renderHTMLBlock(html *htmlBlock) {
+	// replace the block by an empty line to preserve functionality in markdown
+	renderer.addLine("")
+	for _, line := range html.lines {
+		renderer.addLine(line)
+	}
+	renderer.addLine("")
+}
+
+func (renderer *renderer) renderCommentBlock(comment *commentBlock) {
+	renderer.addLine("<!---")
+	for _, line := range comment.lines {
+		renderer.addLine(line)
+	}
+	renderer.addLine("-->")
 }
 
-func (converter *DefaultConverter) convertCodeBlocks(in string) string {
+func (renderer *renderer) renderHTMLTagBlock(htmlTag *htmlTagBlock) {
+	if htmlTag.tableProcessor != "" {
+		renderer.converter.warn(UnconvertedTableDiagnostic, "#!"+htmlTag.tableProcessor,
+			"content of Trac '#!%s' processor is not made up of table rows and cells, converting it as wiki text within an HTML table", htmlTag.tableProcessor)
+	}
 
-	// convert all {{{...}}} to html or to disguised triple brackets, depending on
-	// specified Trac processors (see WikiProcessors)
-	acc := []string{}
-	return converter.parseCodeBlocks(in, acc)
+	// markdown is only recognised inside HTML tags if separated from them by empty lines
+	renderer.addLine(htmlStartTag(htmlTag.tag, htmlTag.attributes))
+	renderer.addLine("")
+	renderer.renderBlocks(htmlTag.blocks)
+	renderer.addLine("")
+	renderer.addLine("</" + htmlTag.tag + ">")
 }
 
-func (converter *DefaultConverter) undisguiseCodeBlocks(in string) string {
+func (renderer *renderer) renderHTMLSpanBlock(span *htmlSpanBlock) {
+	// a span is an inline element so its lines of text are run together within a single line of markdown
+	renderedLines := []string{}
+	for _, line := range span.lines {
+		if renderedLine := strings.TrimSpace(renderer.renderInlines(line)); renderedLine != "" {
+			renderedLines = append(renderedLines, renderedLine)
+		}
+	}
+	renderer.addLine(htmlStartTag("span", span.attributes) + strings.Join(renderedLines, " ") + "</span>")
+}
 
-	// convert single line {{{...}}} to `...`
-	out := singleLineCodeBlockRegexp.ReplaceAllString(in, "`$1`")
+// matchInlineCode matches a single-line Trac '{{{...}}}' code span at the start of a string
+func matchInlineCode(s string) (inline, int) {
+	if !strings.HasPrefix(s, "{{{") {
+		return nil, 0
+	}
 
-	// convert other brackets to ```
-	out = strings.ReplaceAll(out, "{@{@{", "```")
-	out = strings.ReplaceAll(out, "}@}@}", "```")
+	end := strings.Index(s[len("{{{"):], "}}}")
+	if end == -1 {
+		return nil, 0
+	}
+	return &codeInline{code: s[len("{{{") : len("{{{")+end]}, end + len("{{{}}}")
+}
 
-	return out
+// matchBacktickCode matches a Trac '`...`' code span at the start of a string
+func matchBacktickCode(s string) (inline, int) {
+	end := strings.IndexByte(s[1:], '`')
+	if end <= 0 {
+		return nil, 0
+	}
+	return &codeInline{code: s[1 : 1+end]}, end + 2
 }
 
-func (converter *DefaultConverter) convertNonCodeBlocks(in string, convertFn func(string) string) string {
-	return nonCodeBlockRegexp.ReplaceAllStringFunc(in, convertFn)
+func renderCodeInline(code *codeInline) string {
+	// code containing backticks needs a longer delimiter
+	if strings.Contains(code.code, "`") {
+		return "`` " + code.code + " ``"
+	}
+	return "`" + code.code + "`"
 }
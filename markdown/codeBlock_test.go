@@ -16,7 +16,7 @@ func TestSingleLineCodeBlock(t *testing.T) {
 
 	code := "this is some code"
 
-	conversion := converter.WikiConvert(wikiPage, leadingText+"{{{"+code+"}}}"+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"{{{"+code+"}}}"+trailingText)
 	assertEquals(t, conversion, leadingText+"`"+code+"`"+trailingText)
 }
 
@@ -27,7 +27,7 @@ func TestMultiLineCodeBlock(t *testing.T) {
 	codeLine1 := "this is some code\n"
 	codeLine2 := "this is more code\n"
 
-	conversion := converter.WikiConvert(
+	conversion, _ := converter.WikiConvert(
 		wikiPage,
 		leadingText+"\n"+
 			"{{{#!processor\n"+
@@ -50,7 +50,7 @@ func TestNoProcessorBlock(t *testing.T) {
 
 	contents := "this is some text\n"
 
-	conversion := converter.WikiConvert(
+	conversion, _ := converter.WikiConvert(
 		wikiPage,
 		leadingText+"\n"+
 			"{{{\n"+
@@ -71,7 +71,7 @@ func TestNewLineProcessorBlock(t *testing.T) {
 
 	contents := "this is some text\n"
 
-	conversion := converter.WikiConvert(
+	conversion, _ := converter.WikiConvert(
 		wikiPage,
 		leadingText+"\n"+
 			"{{{\n"+
@@ -93,7 +93,28 @@ func TestHTMLBlock(t *testing.T) {
 
 	contents := "<strong style=\"color: grey\">This is some raw HTML</strong>\n"
 
-	conversion := converter.WikiConvert(
+	conversion, _ := converter.WikiConvert(
+		wikiPage,
+		leadingText+"\n"+
+			"{{{#!html\n"+
+			contents+
+			"}}}\n"+
+			trailingText)
+	assertEquals(t, conversion,
+		leadingText+"\n"+
+			"\n"+
+			"**This is some raw HTML**\n"+
+			"\n"+
+			trailingText)
+}
+
+func TestUnconvertibleHTMLBlock(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	contents := "<form action=\"/search\"><input name=\"q\"></form>\n"
+
+	conversion, _ := converter.WikiConvert(
 		wikiPage,
 		leadingText+"\n"+
 			"{{{#!html\n"+
@@ -114,7 +135,7 @@ func TestHTMLTag(t *testing.T) {
 
 	contents := "this is some text\n"
 
-	conversion := converter.WikiConvert(
+	conversion, _ := converter.WikiConvert(
 		wikiPage,
 		leadingText+"\n"+
 			"{{{#!table\n"+
@@ -137,7 +158,7 @@ func TestHTMLTagWithSingleParam(t *testing.T) {
 
 	contents := "this is some text\n"
 
-	conversion := converter.WikiConvert(
+	conversion, _ := converter.WikiConvert(
 		wikiPage,
 		leadingText+"\n"+
 			"{{{#!div id=\"test\"\n"+
@@ -160,7 +181,7 @@ func TestHTMLTagWithMultipleParams(t *testing.T) {
 
 	contents := "this is some text\n"
 
-	conversion := converter.WikiConvert(
+	conversion, _ := converter.WikiConvert(
 		wikiPage,
 		leadingText+"\n"+
 			"{{{#!div class=\"test\" style=\"color: red; font-size: 90%\"\n"+
@@ -169,7 +190,7 @@ func TestHTMLTagWithMultipleParams(t *testing.T) {
 			trailingText)
 	assertEquals(t, conversion,
 		leadingText+"\n"+
-			"<div class=\"test\" style=\"color: red; font-size: 90%\">\n"+
+			"<div style=\"color: red\">\n"+
 			"\n"+
 			contents+
 			"\n"+
@@ -177,6 +198,51 @@ func TestHTMLTagWithMultipleParams(t *testing.T) {
 			trailingText)
 }
 
+func TestNestedHTMLTags(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(
+		wikiPage,
+		leadingText+"\n"+
+			"{{{#!div title=\"outer\"\n"+
+			"{{{#!Section\n"+
+			"some '''bold''' text\n"+
+			"}}}\n"+
+			"}}}\n"+
+			trailingText)
+	assertEquals(t, conversion,
+		leadingText+"\n"+
+			"<div title=\"outer\">\n"+
+			"\n"+
+			"<section>\n"+
+			"\n"+
+			"some **bold** text\n"+
+			"\n"+
+			"</section>\n"+
+			"\n"+
+			"</div>\n"+
+			trailingText)
+}
+
+func TestSpanProcessor(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(
+		wikiPage,
+		leadingText+"\n"+
+			"{{{#!span class=\"note\" style=\"background: yellow\"\n"+
+			"some ''italic''\n"+
+			"text\n"+
+			"}}}\n"+
+			trailingText)
+	assertEquals(t, conversion,
+		leadingText+"\n"+
+			"<span style=\"background-color: yellow\">some *italic* text</span>\n"+
+			trailingText)
+}
+
 func TestNestedHTML(t *testing.T) {
 	setUp(t)
 	defer tearDown(t)
@@ -186,7 +252,7 @@ func TestNestedHTML(t *testing.T) {
 	content1 := "Content 1\n"
 	content2 := "Content 2\n"
 
-	conversion := converter.WikiConvert(
+	conversion, _ := converter.WikiConvert(
 		wikiPage,
 		leadingText+"\n"+
 			"{{{#!table\n"+
@@ -211,9 +277,7 @@ func TestNestedHTML(t *testing.T) {
 	assertEquals(t, conversion,
 		leadingText+"\n"+
 			"<table>\n"+
-			"\n"+
 			"<tr>\n"+
-			"\n"+
 			"<th>\n"+
 			"\n"+
 			head1+
@@ -224,10 +288,8 @@ func TestNestedHTML(t *testing.T) {
 			content1+
 			"\n"+
 			"</td>\n"+
-			"\n"+
 			"</tr>\n"+
 			"<tr>\n"+
-			"\n"+
 			"<th>\n"+
 			"\n"+
 			head2+
@@ -238,9 +300,7 @@ func TestNestedHTML(t *testing.T) {
 			content2+
 			"\n"+
 			"</td>\n"+
-			"\n"+
 			"</tr>\n"+
-			"\n"+
 			"</table>\n"+
 			trailingText)
 }
@@ -251,7 +311,7 @@ func TestHTMLComment(t *testing.T) {
 
 	contents := "this is some text\n"
 
-	conversion := converter.WikiConvert(
+	conversion, _ := converter.WikiConvert(
 		wikiPage,
 		leadingText+"\n"+
 			"{{{#!comment\n"+
@@ -270,21 +330,56 @@ func TestCodeBlockWithCommitTicketReference(t *testing.T) {
 	setUp(t)
 	defer tearDown(t)
 
-	codeLine1 := "#!CommitTicketReference repository=\"\" revision=\"4574\"\n"
-	codeLine2 := "Remove CommitTicketReference\n"
+	const commitID = "0123456789abcdef0123456789abcdef01234567"
+	const commitURL = "http://example.com/commit/" + commitID
+	converter.SetRevisionMap(map[string]string{"r4574": commitID})
+	mockGiteaAccessor.
+		EXPECT().
+		GetCommitURL(gomock.Eq(commitID)).
+		Return(commitURL)
 
-	conversion := converter.WikiConvert(
+	conversion, _ := converter.TicketConvert(
+		ticketID,
+		leadingText+"\n"+
+			"\n"+
+			"In [4574]:\n"+
+			"{{{\n"+
+			"#!CommitTicketReference repository=\"\" revision=\"4574\"\n"+
+			"Fix the ''thing''\n"+
+			"\n"+
+			"Closes #1\n"+
+			"}}}\n"+
+			trailingText)
+	assertEquals(t, conversion,
+		leadingText+"\n"+
+			"\n"+
+			"Referenced in commit ["+commitID+"]("+commitURL+")\n"+
+			"\n"+
+			"> Fix the *thing*\n"+
+			">\n"+
+			"> Closes #1\n"+
+			"\n"+
+			trailingText)
+}
+
+func TestCodeBlockWithUnmappedCommitTicketReference(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(
 		wikiPage,
 		leadingText+"\n"+
-			"{{{"+codeLine1+
-			codeLine2+
+			"{{{#!CommitTicketReference repository=\"\" revision=\"4574\"\n"+
+			"Fix the thing\n"+
 			"}}}\n"+
 			trailingText)
 	assertEquals(t, conversion,
 		leadingText+"\n"+
-			"```\n"+
-			codeLine2+
-			"```\n"+
+			"\n"+
+			"Referenced in Trac revision 4574\n"+
+			"\n"+
+			"> Fix the thing\n"+
+			"\n"+
 			trailingText)
 }
 
@@ -295,7 +390,7 @@ func TestCodeBlockWithLanguage(t *testing.T) {
 	codeLine1 := "#!cpp\n"
 	codeLine2 := "This is some C++\n"
 
-	conversion := converter.WikiConvert(
+	conversion, _ := converter.WikiConvert(
 		wikiPage,
 		leadingText+"\n"+
 			"{{{"+codeLine1+
@@ -318,7 +413,7 @@ func TestCodeBlockWithMappedLanguage(t *testing.T) {
 	codeLine2 := "This is some C++\n"
 
 	// NOTE: We also check \n after {{{ here
-	conversion := converter.WikiConvert(
+	conversion, _ := converter.WikiConvert(
 		wikiPage,
 		leadingText+"\n"+
 			"{{{\n"+codeLine1+
@@ -342,7 +437,7 @@ func TestNoConversionInsideCodeBlock(t *testing.T) {
 	codeLine3 := "- bullet point\n"
 	codeLine4 := "== Trac-style Subheading\n"
 
-	conversion := converter.WikiConvert(
+	conversion, _ := converter.WikiConvert(
 		wikiPage,
 		leadingText+"\n"+
 			"{{{#!processor\n"+
@@ -383,7 +478,7 @@ func TestConversionInsideHTMLBlock(t *testing.T) {
 	mdLine3 := "- bullet point\n"
 	mdLine4 := "## Trac-style Subheading\n"
 
-	conversion := converter.WikiConvert(
+	conversion, _ := converter.WikiConvert(
 		wikiPage,
 		leadingText+"\n"+
 			"{{{#!td\n"+
@@ -395,6 +490,8 @@ func TestConversionInsideHTMLBlock(t *testing.T) {
 			trailingText)
 	assertEquals(t, conversion,
 		leadingText+"\n"+
+			"<table>\n"+
+			"<tr>\n"+
 			"<td>\n"+
 			"\n"+
 			mdLine1+
@@ -403,5 +500,87 @@ func TestConversionInsideHTMLBlock(t *testing.T) {
 			mdLine4+
 			"\n"+
 			"</td>\n"+
+			"</tr>\n"+
+			"</table>\n"+
+			trailingText)
+}
+
+func TestRSTBlock(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(
+		wikiPage,
+		leadingText+"\n"+
+			"{{{#!rst\n"+
+			"Heading\n"+
+			"=======\n"+
+			"\n"+
+			"Some *emphasised* text and a `link <http://www.example.com>`_.\n"+
+			"}}}\n"+
 			trailingText)
+	assertEquals(t, conversion,
+		leadingText+"\n"+
+			"\n"+
+			"# Heading\n"+
+			"\n"+
+			"Some *emphasised* text and a [link](http://www.example.com).\n"+
+			"\n"+
+			trailingText)
+}
+
+func TestUnconvertibleRSTBlock(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	contents := ".. unknown-directive:: argument\n"
+
+	conversion, _ := converter.WikiConvert(
+		wikiPage,
+		leadingText+"\n"+
+			"{{{#!rst\n"+
+			contents+
+			"}}}\n"+
+			trailingText)
+	assertEquals(t, conversion,
+		leadingText+"\n"+
+			"```#!rst\n"+
+			contents+
+			"```\n"+
+			trailingText)
+}
+
+func verifyDivStyle(t *testing.T, tracStyle string, markdownDiv string) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(
+		wikiPage,
+		leadingText+"\n"+
+			"{{{#!div style=\""+tracStyle+"\"\n"+
+			"some text\n"+
+			"}}}\n"+
+			trailingText)
+	assertEquals(t, conversion,
+		leadingText+"\n"+
+			markdownDiv+"\n"+
+			"\n"+
+			"some text\n"+
+			"\n"+
+			"</div>\n"+
+			trailingText)
+}
+
+func TestDivProcessorDropsBackgroundURL(t *testing.T) {
+	verifyDivStyle(t, "background: url(javascript:alert(1))", "<div>")
+	verifyDivStyle(t, "color: red; background-color: URL (foo.png)", "<div style=\"color: red\">")
+	verifyDivStyle(t, "color: expression(alert(1))", "<div>")
+}
+
+func TestDivProcessorBackgroundShorthand(t *testing.T) {
+	verifyDivStyle(t, "background: none", "<div>")
+	verifyDivStyle(t, "background: inherit", "<div style=\"background-color: inherit\">")
+	verifyDivStyle(t, "background: #ffe", "<div style=\"background-color: #ffe\">")
+	verifyDivStyle(t, "background: rgb(255, 255, 224)", "<div style=\"background-color: rgb(255, 255, 224)\">")
+	verifyDivStyle(t, "background: yellow no-repeat", "<div>")
 }
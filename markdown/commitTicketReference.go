@@ -0,0 +1,82 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+// regexp for the 'In [<changeset>]:' line which Trac's commit hook writes before a '#!CommitTicketReference' processor
+var commitTicketReferenceIntroRegexp = regexp.MustCompile(`^\s*In \[[^\]]+\]:\s*$`)
+
+// commitTicketReferenceBlock is the contents of a Trac '#!CommitTicketReference' processor:
+// the message of a commit referencing a ticket, added as a ticket comment by Trac's commit hook
+type commitTicketReferenceBlock struct {
+	repository string
+	revision   string
+	blocks     []block
+}
+
+// parseCommitTicketReference parses the content of a Trac '#!CommitTicketReference repository="<repo>" revision="<rev>"' processor
+func (parser *blockParser) parseCommitTicketReference(processor string, content []string) block {
+	reference := commitTicketReferenceBlock{blocks: parser.converter.parseLines(content)}
+	for _, attribute := range parseHTMLAttributes(processorParameters(processor)) {
+		switch attribute.name {
+		case "repository":
+			reference.repository = attribute.value
+		case "revision":
+			reference.revision = attribute.value
+		}
+	}
+	return &reference
+}
+
+// isCommitTicketReferenceIntro returns true if a block is the 'In [<changeset>]:' paragraph introducing a '#!CommitTicketReference' processor
+// - we replace this with a link to the commit so it can be dropped
+func isCommitTicketReferenceIntro(node block) bool {
+	paragraph, ok := node.(*paragraphBlock)
+	if !ok || len(paragraph.lines) != 1 {
+		return false
+	}
+
+	// the changeset link will have been parsed so reconstruct the original text
+	var builder strings.Builder
+	for _, node := range paragraph.lines[0] {
+		switch node := node.(type) {
+		case *textInline:
+			builder.WriteString(node.text)
+		case *linkInline:
+			builder.WriteString(node.source)
+		default:
+			return false
+		}
+	}
+	return commitTicketReferenceIntroRegexp.MatchString(builder.String())
+}
+
+// renderCommitTicketReference renders a Trac '#!CommitTicketReference' processor as a link to the referencing commit followed by the quoted commit message
+func (renderer *renderer) renderCommitTicketReference(reference *commitTicketReferenceBlock) {
+	renderer.addBlankLineSeparator()
+	if commitID := renderer.converter.commitID(reference.revision); commitID != "" {
+		renderer.addLine("Referenced in commit [" + commitID + "](" + renderer.converter.giteaAccessor.GetCommitURL(commitID) + ")")
+	} else {
+		renderer.addLine("Referenced in Trac revision " + reference.revision)
+	}
+	renderer.addLine("")
+
+	// render the commit message in place then quote it
+	messageStart := len(renderer.lines)
+	renderer.renderBlocks(reference.blocks)
+	for len(renderer.lines) > messageStart && renderer.lines[len(renderer.lines)-1] == "" {
+		renderer.lines = renderer.lines[:len(renderer.lines)-1]
+	}
+	for index := messageStart; index < len(renderer.lines); index++ {
+		renderer.lines[index] = strings.TrimRight("> "+renderer.lines[index], " ")
+	}
+
+	// prevent any following text being taken as a continuation of the quote
+	renderer.addLine("")
+}
@@ -6,9 +6,17 @@ package markdown
 
 // Converter is the interface for Trac markdown to Gitea markdown conversions
 type Converter interface {
-	// TicketConvert converts a comment/description string associated with a Trac ticket to Gitea markdown
-	TicketConvert(ticketID int64, in string) string
+	// TicketConvert converts a comment/description string associated with a Trac ticket to Gitea markdown,
+	// returning diagnostics of any markup which could not be converted
+	TicketConvert(ticketID int64, in string) (string, []Diagnostic)
 
-	// WikiConvert converts a comment/description string associated with a Trac wiki page to Gitea markdown
-	WikiConvert(wikiPage string, in string) string
+	// TicketReferences returns the IDs of the Trac tickets referenced by ticket links in a comment/description string associated with a Trac ticket
+	TicketReferences(ticketID int64, in string) []int64
+
+	// MentionedUsers returns the Gitea users mentioned by Trac user names in a comment/description string associated with a Trac ticket
+	MentionedUsers(ticketID int64, in string) []string
+
+	// WikiConvert converts a comment/description string associated with a Trac wiki page to Gitea markdown,
+	// returning diagnostics of any markup which could not be converted
+	WikiConvert(wikiPage string, in string) (string, []Diagnostic)
 }
@@ -5,6 +5,8 @@
 package markdown
 
 import (
+	"strings"
+
 	"github.com/stevejefferson/trac2gitea/accessor/gitea"
 	"github.com/stevejefferson/trac2gitea/accessor/trac"
 )
@@ -20,69 +22,54 @@ func CreateDefaultConverter(tracAccessor trac.Accessor, giteaAccessor gitea.Acce
 // 1. for ticket comments - in which case ticketID != NullID and wikiAccessor == nil
 // 2. for wiki imports - in which case ticketID == NullID and wikiAccessor != nil
 type DefaultConverter struct {
-	tracAccessor  trac.Accessor
-	giteaAccessor gitea.Accessor
+	tracAccessor          trac.Accessor
+	giteaAccessor         gitea.Accessor
+	interTracRepos        map[string]string
+	interTracIssueIndexes map[string]map[int64]int64
+	interWikiPrefixes     map[string]*trac.InterWikiPrefix
+	issueIndexes          map[int64]int64
+	convertPredefineds    bool
+	userMap               map[string]string
+	labelMaps             map[string]map[string]string
+	revisionMap           map[string]string
+	branchMap             map[string]string
+	wikiPages             map[string]*trac.WikiPage
+	pageAnchors           map[string]map[string]string
+	unresolvedAnchors     []UnresolvedAnchor
+	tickets               []*trac.Ticket
+
+	// diagnostics collects the diagnostics of the conversion in progress - nil if no conversion is in progress
+	diagnostics *[]Diagnostic
 }
 
-func (converter *DefaultConverter) convertNonCodeBlockText(ticketID int64, wikiPage string, in string) string {
-	out := in
-
-	// remove TOC special tags
-	out = converter.removeTOC(out)
-
-	// do simple one-line constructs first
-	out = converter.convertLinks(ticketID, wikiPage, out)
-	out = converter.convertAnchors(out)
-	out = converter.convertEscapes(out)
-	out = converter.convertLists(out)
-	out = converter.convertDefinitionLists(out)
-	out = converter.convertHeadings(out)
-
-	// font styles require links to be disguised
-	out = converter.disguiseLinks(out)
-	out = converter.convertFontStyles(out)
-	out = converter.undisguiseLinks(out)
-
-	// now do potentially more complex constructs
-	out = converter.convertBlockQuotes(out)
-	out = converter.convertTables(out)
-
-	// do paragraphs last because this results in the insertion of newlines
-	// - this can upset other regexps which assume everything on a single line
-	out = converter.convertParagraphs(out)
-
-	return out
-}
-
-func (converter *DefaultConverter) convert(ticketID int64, wikiPage string, in string) string {
-	out := in
+// convert converts Trac wiki text associated with either a ticket or a wiki page into markdown, returning diagnostics of any markup which could not be converted
+// - the text is parsed into an abstract syntax tree which is then rendered as markdown
+func (converter *DefaultConverter) convert(ticketID int64, wikiPage string, in string) (string, []Diagnostic) {
+	diagnostics := []Diagnostic{}
+	converter.diagnostics = &diagnostics
+	defer func() { converter.diagnostics = nil }()
 
 	// ensure we have Unix EOLs
-	out = converter.convertEOL(out)
-
-	// convert Trac "code blocks"
-	// - some of them are converted to HTML tags
-	// - others are actual code blocks, inside which the Trac syntax must not be converted - mark
-	// those with custom brackets
-	out = converter.convertCodeBlocks(out)
-
-	// perform conversions on text not in a code block using the ticket-specific link conversion
-	out = converter.convertNonCodeBlocks(out, func(in string) string {
-		return converter.convertNonCodeBlockText(ticketID, wikiPage, in)
-	})
-
-	// finally, replace custom brackets with markdown code block boundaries
-	out = converter.undisguiseCodeBlocks(out)
-
-	return out
+	out := converter.convertEOL(in)
+
+	blocks := converter.parse(out)
+	if wikiPage != "" {
+		// the anchors of the page being converted take precedence over those of any earlier version of the page
+		converter.setWikiPageAnchors(wikiPage, pageAnchors(blocks))
+	}
+	renderer := renderer{converter: converter, ticketID: ticketID, wikiPage: wikiPage, headings: collectHeadings(blocks)}
+	renderer.renderBlocks(blocks)
+	return strings.Join(renderer.lines, "\n"), diagnostics
 }
 
-// TicketConvert converts a comment/description string associated with a Trac ticket to Gitea markdown
-func (converter *DefaultConverter) TicketConvert(ticketID int64, in string) string {
+// TicketConvert converts a comment/description string associated with a Trac ticket to Gitea markdown,
+// returning diagnostics of any markup which could not be converted
+func (converter *DefaultConverter) TicketConvert(ticketID int64, in string) (string, []Diagnostic) {
 	return converter.convert(ticketID, "", in)
 }
 
-// WikiConvert converts a comment/description string associated with a Trac wiki page to Gitea markdown
-func (converter *DefaultConverter) WikiConvert(wikiPage string, in string) string {
+// WikiConvert converts a comment/description string associated with a Trac wiki page to Gitea markdown,
+// returning diagnostics of any markup which could not be converted
+func (converter *DefaultConverter) WikiConvert(wikiPage string, in string) (string, []Diagnostic) {
 	return converter.convert(trac.NullID, wikiPage, in)
 }
@@ -4,10 +4,46 @@
 
 package markdown
 
-import "regexp"
+import (
+	"regexp"
+	"strings"
+)
 
-var definitionListRegexp = regexp.MustCompile(`(?m)^ ([^:]+)\:\:`)
+// regexp for the first line of a Trac definition: $1=term, $2=any definition on the same line
+var definitionRegexp = regexp.MustCompile(`^[[:blank:]]+([^:]+)::(.*)$`)
 
-func (converter *DefaultConverter) convertDefinitionLists(in string) string {
-	return definitionListRegexp.ReplaceAllString(in, "*$1*  \n")
+// definitionBlock is a Trac ' <term>:: <definition>' definition
+type definitionBlock struct {
+	term        []inline
+	description [][]inline
+}
+
+func isDefinition(line string) bool {
+	return definitionRegexp.MatchString(line)
+}
+
+// parseDefinition parses a Trac definition - the definition may follow the term on the same line and continue on subsequent indented lines
+func (parser *blockParser) parseDefinition() block {
+	match := definitionRegexp.FindStringSubmatch(parser.lines[parser.pos])
+	parser.pos++
+
+	definition := definitionBlock{term: parser.converter.parseInlines(strings.TrimSpace(match[1]))}
+	if description := strings.TrimSpace(match[2]); description != "" {
+		definition.description = append(definition.description, parser.converter.parseInlines(description))
+	}
+
+	for line, ok := parser.currentLine(); ok && isIndented(line) && !isBlockStart(line); line, ok = parser.currentLine() {
+		definition.description = append(definition.description, parser.converter.parseInlines(strings.TrimSpace(line)))
+		parser.pos++
+	}
+
+	return &definition
+}
+
+func (renderer *renderer) renderDefinition(definition *definitionBlock) {
+	// markdown has no definition lists so use an emphasised term followed by a line break
+	renderer.addLine("*" + renderer.renderInlines(definition.term) + "*  ")
+	for _, line := range definition.description {
+		renderer.addLine(renderer.renderLine(line))
+	}
 }
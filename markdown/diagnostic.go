@@ -0,0 +1,62 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"fmt"
+
+	"github.com/stevejefferson/trac2gitea/log"
+)
+
+// DiagnosticKind identifies the type of Trac markup which could not be (fully) converted into markdown
+type DiagnosticKind string
+
+const (
+	// UnconvertedMacroDiagnostic denotes a Trac macro which could not be converted and is flagged by an HTML comment
+	UnconvertedMacroDiagnostic DiagnosticKind = "unconverted-macro"
+
+	// UnresolvedLinkDiagnostic denotes a Trac link which could not be converted and is left as Trac text
+	UnresolvedLinkDiagnostic DiagnosticKind = "unresolved-link"
+
+	// UnresolvedAnchorDiagnostic denotes an anchor referenced by a Trac link which could not be found on the referenced wiki page
+	UnresolvedAnchorDiagnostic DiagnosticKind = "unresolved-anchor"
+
+	// UnconvertedProcessorDiagnostic denotes a Trac '#!<processor>' block whose content is left unconverted
+	UnconvertedProcessorDiagnostic DiagnosticKind = "unconverted-processor"
+
+	// UnconvertedTableDiagnostic denotes a Trac '#!table' processor whose content is not made up of table rows and cells
+	UnconvertedTableDiagnostic DiagnosticKind = "unconverted-table"
+
+	// DroppedImageOptionDiagnostic denotes an option of a Trac image macro which Gitea cannot represent
+	DroppedImageOptionDiagnostic DiagnosticKind = "dropped-image-option"
+
+	// StaticTicketQueryDiagnostic denotes a Trac ticket query which is converted into the static results of the query at the time of conversion
+	StaticTicketQueryDiagnostic DiagnosticKind = "static-ticket-query"
+)
+
+// Diagnostic describes a piece of Trac markup found when converting some Trac wiki text which could not be (fully) converted into markdown
+type Diagnostic struct {
+	Kind    DiagnosticKind `json:"kind"`
+	Source  string         `json:"source"`
+	Message string         `json:"message"`
+}
+
+// warn logs a warning about a piece of Trac markup which could not be (fully) converted,
+// recording it as a diagnostic of the conversion in progress (if any)
+func (converter *DefaultConverter) warn(kind DiagnosticKind, source string, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	log.Warn("%s", message)
+	if converter.diagnostics != nil {
+		*converter.diagnostics = append(*converter.diagnostics, Diagnostic{Kind: kind, Source: source, Message: message})
+	}
+}
+
+// diagnosticCount returns the number of diagnostics recorded so far by the conversion in progress
+func (converter *DefaultConverter) diagnosticCount() int {
+	if converter.diagnostics == nil {
+		return 0
+	}
+	return len(*converter.diagnostics)
+}
@@ -0,0 +1,141 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stevejefferson/trac2gitea/accessor/gitea"
+	"github.com/stevejefferson/trac2gitea/markdown"
+	"go.uber.org/mock/gomock"
+)
+
+func assertDiagnostics(t *testing.T, diagnostics []markdown.Diagnostic, expectedDiagnostics ...markdown.Diagnostic) {
+	assertEquals(t, len(diagnostics), len(expectedDiagnostics))
+	for index := 0; index < len(diagnostics) && index < len(expectedDiagnostics); index++ {
+		assertEquals(t, diagnostics[index], expectedDiagnostics[index])
+	}
+}
+
+func TestNoDiagnostics(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	_, diagnostics := converter.WikiConvert(wikiPage, leadingText+" '''bold''' "+trailingText)
+	assertDiagnostics(t, diagnostics)
+}
+
+func TestUnconvertedMacroDiagnostic(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	_, diagnostics := converter.WikiConvert(wikiPage, leadingText+" [[FooBar(x)]] "+trailingText)
+	assertDiagnostics(t, diagnostics, markdown.Diagnostic{
+		Kind: markdown.UnconvertedMacroDiagnostic, Source: "[[FooBar(x)]]", Message: "Trac macro [[FooBar(x)]] not converted"})
+}
+
+func TestUnconvertedProcessorDiagnostic(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	_, diagnostics := converter.WikiConvert(wikiPage, "{{{#!graphviz\ndigraph G {}\n}}}")
+	assertDiagnostics(t, diagnostics, markdown.Diagnostic{
+		Kind: markdown.UnconvertedProcessorDiagnostic, Source: "#!graphviz",
+		Message: "Trac '#!graphviz' processor is not supported, leaving its content in a code block"})
+}
+
+func TestUnconvertedTableDiagnostic(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	_, diagnostics := converter.WikiConvert(wikiPage, "{{{#!table\n||cell||\nsome text\n}}}")
+	assertDiagnostics(t, diagnostics, markdown.Diagnostic{
+		Kind: markdown.UnconvertedTableDiagnostic, Source: "#!table",
+		Message: "content of Trac '#!table' processor is not made up of table rows and cells, converting it as wiki text within an HTML table"})
+}
+
+func TestDroppedImageOptionDiagnostic(t *testing.T) {
+	setUpLocalImage(t)
+	defer tearDown(t)
+
+	_, diagnostics := converter.WikiConvert(wikiPage, "[[Image("+attachmentName+", 10em)]]")
+	assertDiagnostics(t, diagnostics, markdown.Diagnostic{
+		Kind: markdown.DroppedImageOptionDiagnostic, Source: "[[Image(" + attachmentName + ", 10em)]]",
+		Message: "dropping size \"10em\" of Trac image macro \"[[Image(" + attachmentName + ", 10em)]]\": only pixel and percentage sizes are supported"})
+}
+
+func TestDroppedImageKeywordOptionDiagnostic(t *testing.T) {
+	setUpLocalImage(t)
+	defer tearDown(t)
+
+	_, diagnostics := converter.WikiConvert(wikiPage, "[[Image("+attachmentName+", border=1)]]")
+	assertDiagnostics(t, diagnostics, markdown.Diagnostic{
+		Kind: markdown.DroppedImageOptionDiagnostic, Source: "[[Image(" + attachmentName + ", border=1)]]",
+		Message: "dropping unsupported option \"border\" of Trac image macro \"[[Image(" + attachmentName + ", border=1)]]\""})
+}
+
+func TestUnresolvedLinkDiagnostic(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	expectToRetrieveMilestoneID(t, "missing", gitea.NullID)
+
+	_, diagnostics := converter.TicketConvert(ticketID, leadingText+" milestone:missing "+trailingText)
+	assertDiagnostics(t, diagnostics, markdown.Diagnostic{
+		Kind: markdown.UnresolvedLinkDiagnostic, Source: "milestone:missing",
+		Message: "cannot find milestone \"missing\" referenced by Trac link \"milestone:missing\""})
+}
+
+func TestUnresolvedTicketCommentLinkDiagnostic(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	mockGiteaAccessor.
+		EXPECT().
+		GetIssueID(gomock.Eq(ticketID)).
+		Return(issueID, nil)
+	mockTracAccessor.
+		EXPECT().
+		GetTicketCommentTime(gomock.Eq(ticketID), gomock.Eq(tracCommentNum)).
+		Return(commentTime, nil)
+	mockGiteaAccessor.
+		EXPECT().
+		GetIssueCommentIDByTime(gomock.Eq(issueID), gomock.Eq(commentTime)).
+		Return(gitea.NullID, nil)
+
+	conversion, diagnostics := converter.TicketConvert(ticketID, leadingText+" comment:"+tracCommentNumStr+" "+trailingText)
+	assertEquals(t, conversion, leadingText+" comment:"+tracCommentNumStr+" "+trailingText)
+	assertDiagnostics(t, diagnostics, markdown.Diagnostic{
+		Kind: markdown.UnresolvedLinkDiagnostic, Source: "comment:" + tracCommentNumStr,
+		Message: "cannot find Gitea comment for comment " + tracCommentNumStr + " of ticket " + ticketIDStr + " referenced by Trac link \"comment:" + tracCommentNumStr + "\""})
+}
+
+func TestUnresolvedLinkDiagnosticWithoutReason(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	mockTracAccessor.
+		EXPECT().
+		GetReport(int64(9)).
+		Return(nil, errors.New("no database"))
+
+	_, diagnostics := converter.WikiConvert(wikiPage, leadingText+" report:9 "+trailingText)
+	assertDiagnostics(t, diagnostics, markdown.Diagnostic{
+		Kind: markdown.UnresolvedLinkDiagnostic, Source: "report:9", Message: "cannot convert Trac link \"report:9\""})
+}
+
+func TestDiagnosticsOnlyCoverTheirOwnConversion(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	_, diagnostics := converter.WikiConvert(wikiPage, "[[FooBar(1)]] and [[BarFoo(2)]]")
+	assertDiagnostics(t, diagnostics,
+		markdown.Diagnostic{Kind: markdown.UnconvertedMacroDiagnostic, Source: "[[FooBar(1)]]", Message: "Trac macro [[FooBar(1)]] not converted"},
+		markdown.Diagnostic{Kind: markdown.UnconvertedMacroDiagnostic, Source: "[[BarFoo(2)]]", Message: "Trac macro [[BarFoo(2)]] not converted"})
+
+	_, diagnostics = converter.TicketConvert(ticketID, leadingText)
+	assertDiagnostics(t, diagnostics)
+}
@@ -4,12 +4,137 @@
 
 package markdown
 
-import "regexp"
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
 
-var escapeRegexp = regexp.MustCompile(`\!((?:[[:upper:]][[:lower:]]+){2,})`)
+// matchEscape matches a Trac '!' escape at a given position of the text being parsed.
+// An escaped construct (link, font style, code etc.) is output as literal text without the '!'
+// - a '!' which does not precede any such construct is just literal text.
+func (parser *inlineParser) matchEscape(pos int) (inline, int) {
+	if pos+1 >= len(parser.text) {
+		return nil, 0
+	}
 
-// convertEscapes converts Trac markdown escapes to Markdown
-// - this must be run after convertLinks otherwise it will convert non-links into something that will be recognised as a link
-func (converter *DefaultConverter) convertEscapes(in string) string {
-	return escapeRegexp.ReplaceAllString(in, "$1")
+	node, length := parser.matchAt(pos+1, true)
+	if node == nil {
+		return nil, 0
+	}
+
+	return &textInline{text: parser.text[pos+1 : pos+1+length]}, length + 1
+}
+
+// Markdown escaping:
+//	Literal text which means nothing special in Trac can still be interpreted as markdown (emphasis, HTML tags, headings, lists etc.)
+//	so any markdown punctuation which could change the meaning of literal text is backslash-escaped when the text is rendered.
+//	Escaping is only applied to literal text: the markdown generated for any parsed Trac construct is never escaped.
+
+// regexp for an HTML entity or character reference at the start of a string
+var htmlEntityRegexp = regexp.MustCompile(`^&(?:#[[:digit:]]{1,7}|#[xX][[:xdigit:]]{1,6}|[[:alpha:]][[:alnum:]]*);`)
+
+// regexps for literal text at the start of a line which markdown would interpret as the start of a block:
+// ATX headings, bullet list items, ordered list items ($1=number) and thematic breaks or setext heading underlines ($1=first character)
+var (
+	headingLineStartRegexp       = regexp.MustCompile(`^#{1,6}(?:[ \t]|$)`)
+	bulletListLineStartRegexp    = regexp.MustCompile(`^[-+*](?:[ \t]|$)`)
+	orderedListLineStartRegexp   = regexp.MustCompile(`^([[:digit:]]{1,9})[.)](?:[ \t]|$)`)
+	ruleOrUnderlineLineRegexp    = regexp.MustCompile(`^(?:(?:-[ \t]*)+|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,}|=+[ \t]*)$`)
+	fencedCodeLineStartRegexp    = regexp.MustCompile("^(?:```|~~~)")
+	markdownLineStartPunctuation = "#-+*_=`~"
+)
+
+// isMarkdownPunctuation returns true if a character is ASCII punctuation, which can be backslash-escaped in markdown
+func isMarkdownPunctuation(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) != -1
+}
+
+// isSpaceOrEdge returns true if the character at a given position of a string is whitespace or lies outside the string
+func isSpaceOrEdge(s string, pos int) bool {
+	return pos < 0 || pos >= len(s) || s[pos] == ' ' || s[pos] == '\t'
+}
+
+// delimiterRun returns the start and end of the run of identical characters containing a given position of a string
+func delimiterRun(s string, pos int) (int, int) {
+	start, end := pos, pos+1
+	for start > 0 && s[start-1] == s[pos] {
+		start--
+	}
+	for end < len(s) && s[end] == s[pos] {
+		end++
+	}
+	return start, end
+}
+
+// isAlphanumericAt returns true if the character at a given position of a string is an ASCII letter or digit
+func isAlphanumericAt(s string, pos int) bool {
+	return pos >= 0 && pos < len(s) && s[pos] < utf8.RuneSelf && isAlphanumeric(rune(s[pos]))
+}
+
+// needsEscape returns true if the markdown punctuation character at a given position of some literal text needs escaping
+func needsEscape(text string, pos int) bool {
+	switch text[pos] {
+	case '\\':
+		// a backslash escapes any following punctuation and is a hard line break at the end of a line
+		return pos+1 == len(text) || isMarkdownPunctuation(text[pos+1])
+	case '`':
+		return true
+	case '*':
+		// a run of '*'s surrounded by whitespace cannot delimit emphasis
+		start, end := delimiterRun(text, pos)
+		return !(isSpaceOrEdge(text, start-1) && isSpaceOrEdge(text, end))
+	case '_':
+		// nor can a run of '_'s surrounded by whitespace or within a word
+		start, end := delimiterRun(text, pos)
+		return !(isAlphanumericAt(text, start-1) && isAlphanumericAt(text, end)) &&
+			!(isSpaceOrEdge(text, start-1) && isSpaceOrEdge(text, end))
+	case '~':
+		return (pos > 0 && text[pos-1] == '~') || (pos+1 < len(text) && text[pos+1] == '~')
+	case '<':
+		// '<' starts an HTML tag, comment or autolink if followed by a letter, '/', '!' or '?'
+		return pos+1 < len(text) && (isAlphanumericAt(text, pos+1) || strings.IndexByte("/!?", text[pos+1]) != -1)
+	case '&':
+		return htmlEntityRegexp.MatchString(text[pos:])
+	case '[':
+		// only a '[' followed by '](' or ']:' can form a markdown link
+		closePos := strings.IndexByte(text[pos:], ']')
+		return closePos != -1 && pos+closePos+1 < len(text) && strings.IndexByte("(:[", text[pos+closePos+1]) != -1
+	}
+	return false
+}
+
+// escapeMarkdown backslash-escapes any markdown punctuation in some literal text which could otherwise be interpreted as markdown
+func escapeMarkdown(text string) string {
+	var builder strings.Builder
+	for pos := 0; pos < len(text); pos++ {
+		if needsEscape(text, pos) {
+			builder.WriteByte('\\')
+		}
+		builder.WriteByte(text[pos])
+	}
+	return builder.String()
+}
+
+// escapeLineStart backslash-escapes literal text at the start of a line which markdown would interpret as the start of a block (a heading, list etc.)
+// - the text has already been escaped by escapeMarkdown
+func escapeLineStart(text string) string {
+	trimmedText := strings.TrimLeft(text, " \t")
+	indentation := text[:len(text)-len(trimmedText)]
+	if trimmedText == "" || strings.IndexByte(markdownLineStartPunctuation, trimmedText[0]) == -1 && !orderedListLineStartRegexp.MatchString(trimmedText) {
+		return text
+	}
+
+	switch {
+	case orderedListLineStartRegexp.MatchString(trimmedText):
+		// escape the list delimiter following the number
+		numberLength := len(orderedListLineStartRegexp.FindStringSubmatch(trimmedText)[1])
+		return indentation + trimmedText[:numberLength] + `\` + trimmedText[numberLength:]
+	case headingLineStartRegexp.MatchString(trimmedText),
+		bulletListLineStartRegexp.MatchString(trimmedText),
+		ruleOrUnderlineLineRegexp.MatchString(trimmedText),
+		fencedCodeLineStartRegexp.MatchString(trimmedText):
+		return indentation + `\` + trimmedText
+	}
+	return text
 }
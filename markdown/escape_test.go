@@ -12,6 +12,69 @@ func TestEscape(t *testing.T) {
 
 	escaped := "NotWikiLinkInTrac"
 
-	conversion := converter.WikiConvert(wikiPage, leadingText+"!"+escaped+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"!"+escaped+trailingText)
 	assertEquals(t, conversion, leadingText+escaped+trailingText)
 }
+
+func TestMarkdownEmphasisCharactersEscaped(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+" call(*args) with _private and ~~tildes "+trailingText)
+	assertEquals(t, conversion, leadingText+" call(\\*args) with \\_private and \\~\\~tildes "+trailingText)
+}
+
+func TestNonEmphasisCharactersNotEscaped(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	text := leadingText + " 2 * 3 and snake_case_name and ~/path " + trailingText
+	conversion, _ := converter.WikiConvert(wikiPage, text)
+	assertEquals(t, conversion, text)
+}
+
+func TestHTMLLookalikesEscaped(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+" <Foo> and &amp; but not 1 < 2 or R&D "+trailingText)
+	assertEquals(t, conversion, leadingText+" \\<Foo> and \\&amp; but not 1 < 2 or R&D "+trailingText)
+}
+
+func TestBackslashEscaped(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+" C:\\*.txt and C:\\Temp \\")
+	assertEquals(t, conversion, leadingText+" C:\\\\\\*.txt and C:\\Temp \\\\")
+}
+
+func TestMarkdownBlockStartsEscaped(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage,
+		leadingText+"\n"+
+			"# not a heading\n"+
+			"2) not a list\n"+
+			"+ not a list\n"+
+			"---\n"+
+			"===\n"+
+			"#123 is not a heading\n")
+	assertEquals(t, conversion,
+		leadingText+"\n"+
+			"\\# not a heading\n"+
+			"2\\) not a list\n"+
+			"\\+ not a list\n"+
+			"\\---\n"+
+			"\\===\n"+
+			"#123 is not a heading\n")
+}
+
+func TestGeneratedMarkdownNotEscaped(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+" '''bold''' and ''italic'' and `code_with_*stars*` "+trailingText)
+	assertEquals(t, conversion, leadingText+" **bold** and *italic* and `code_with_*stars*` "+trailingText)
+}
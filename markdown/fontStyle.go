@@ -5,35 +5,146 @@
 package markdown
 
 import (
-	"regexp"
+	"strings"
 )
 
-// regexps for trac-supported font styles
-var singleQuoteBoldRegexp = regexp.MustCompile(`'''([^\n]+?)'''`)
-var singleQuoteItalicRegexp = regexp.MustCompile(`''([^\n]+?)''`)
-var singleQuoteBoldItalicRegexp = regexp.MustCompile(`'''''([^\n]+?)'''''`)
-var doubleAsteriskBoldRegexp = regexp.MustCompile(`\*\*([^\n]+?)\*\*`)
-var doubleSlashItalicRegexp = regexp.MustCompile(`//([^\n]+?)//`)
-var underlineRegexp = regexp.MustCompile(`__([^\n]+?)__`)
-
-// regexps for trac font styles for which we have no mappings
-//var wikiCreoleStyleRegexp = regexp.MustCompile(`\*\*//!([^\n]+?)//\*\*`)
-//var strikethroughRegexp = regexp.MustCompile(`~~([^\n]+?)~~`)
-//var superscriptRegexp = regexp.MustCompile(`\^([^\n]+?)\^`)
-//var subscriptRegexp = regexp.MustCompile(`,,([^\n]+?),,`)
-
-// markdown replacement strings
-var emphasisReplacementStr = `*$1*`
-var strongReplacementStr = `**$1**`
-
-func (converter *DefaultConverter) convertFontStyles(in string) string {
-	out := in
-	out = singleQuoteBoldItalicRegexp.ReplaceAllString(out, strongReplacementStr)
-	out = singleQuoteBoldRegexp.ReplaceAllString(out, strongReplacementStr)
-	out = singleQuoteItalicRegexp.ReplaceAllString(out, emphasisReplacementStr)
-	out = doubleAsteriskBoldRegexp.ReplaceAllString(out, strongReplacementStr)
-	out = doubleSlashItalicRegexp.ReplaceAllString(out, emphasisReplacementStr)
-	out = underlineRegexp.ReplaceAllString(out, emphasisReplacementStr)
-
-	return out
+// fontStyle is a Trac font style
+type fontStyle int
+
+const (
+	boldItalicStyle fontStyle = iota
+	singleQuoteBoldStyle
+	singleQuoteItalicStyle
+	doubleAsteriskBoldStyle
+	doubleSlashItalicStyle
+	underlineStyle
+	strikethroughStyle
+	superscriptStyle
+	subscriptStyle
+)
+
+// fontStyleDelimiters are the Trac font style delimiters
+// - these are matched in order so any delimiter which is a prefix of another must appear after it
+var fontStyleDelimiters = []struct {
+	delimiter string
+	style     fontStyle
+}{
+	{delimiter: "'''''", style: boldItalicStyle},
+	{delimiter: "'''", style: singleQuoteBoldStyle},
+	{delimiter: "''", style: singleQuoteItalicStyle},
+	{delimiter: "**", style: doubleAsteriskBoldStyle},
+	{delimiter: "//", style: doubleSlashItalicStyle},
+	{delimiter: "__", style: underlineStyle},
+	{delimiter: "~~", style: strikethroughStyle},
+	{delimiter: "^", style: superscriptStyle},
+	{delimiter: ",,", style: subscriptStyle},
+}
+
+// markdown delimiters for each Trac font style
+// - markdown has no underline so we use emphasis instead
+var markdownFontStyleDelimiters = map[fontStyle]string{
+	boldItalicStyle:         "**",
+	singleQuoteBoldStyle:    "**",
+	singleQuoteItalicStyle:  "*",
+	doubleAsteriskBoldStyle: "**",
+	doubleSlashItalicStyle:  "*",
+	underlineStyle:          "*",
+	strikethroughStyle:      "~~",
+}
+
+// HTML elements for Trac font styles which markdown has no syntax for
+var htmlFontStyleElements = map[fontStyle]string{
+	superscriptStyle: "sup",
+	subscriptStyle:   "sub",
+}
+
+// fontStyleDelimiterInline is a Trac font style delimiter
+// - these only exist until delimiters are paired up by nestFontStyles
+type fontStyleDelimiterInline struct {
+	style     fontStyle
+	delimiter string
+}
+
+// styledInline is text in a given font style
+type styledInline struct {
+	style   fontStyle
+	content []inline
+}
+
+func matchFontStyleDelimiter(s string) (inline, int) {
+	for _, fontStyleDelimiter := range fontStyleDelimiters {
+		if strings.HasPrefix(s, fontStyleDelimiter.delimiter) {
+			return &fontStyleDelimiterInline{style: fontStyleDelimiter.style, delimiter: fontStyleDelimiter.delimiter}, len(fontStyleDelimiter.delimiter)
+		}
+	}
+
+	return nil, 0
+}
+
+// nestFontStyles pairs up the font style delimiters in a sequence of inlines, nesting the inlines between each pair inside a styled inline.
+// Any delimiter that cannot be paired is treated as literal text.
+func nestFontStyles(inlines []inline) []inline {
+	type styleScope struct {
+		opener  *fontStyleDelimiterInline
+		content []inline
+	}
+
+	// scope 0 is the top level, every other scope has been opened by a delimiter
+	scopes := []*styleScope{{}}
+
+	// closeUnpaired closes all scopes above a given one, treating their opening delimiters as literal text
+	closeUnpaired := func(scopeIndex int) {
+		for len(scopes)-1 > scopeIndex {
+			unpaired := scopes[len(scopes)-1]
+			scopes = scopes[:len(scopes)-1]
+			parent := scopes[len(scopes)-1]
+			parent.content = append(parent.content, &textInline{text: unpaired.opener.delimiter})
+			parent.content = append(parent.content, unpaired.content...)
+		}
+	}
+
+	for _, node := range inlines {
+		delimiter, isDelimiter := node.(*fontStyleDelimiterInline)
+		if !isDelimiter {
+			topScope := scopes[len(scopes)-1]
+			topScope.content = append(topScope.content, node)
+			continue
+		}
+
+		openerIndex := -1
+		for scopeIndex := len(scopes) - 1; scopeIndex > 0; scopeIndex-- {
+			if scopes[scopeIndex].opener.style == delimiter.style {
+				openerIndex = scopeIndex
+				break
+			}
+		}
+
+		if openerIndex == -1 {
+			scopes = append(scopes, &styleScope{opener: delimiter})
+			continue
+		}
+
+		closeUnpaired(openerIndex)
+		closed := scopes[openerIndex]
+		scopes = scopes[:openerIndex]
+		parent := scopes[openerIndex-1]
+		parent.content = append(parent.content, &styledInline{style: delimiter.style, content: closed.content})
+	}
+
+	closeUnpaired(0)
+	return scopes[0].content
+}
+
+func (renderer *renderer) writeStyledInline(builder *strings.Builder, node *styledInline) {
+	if element, found := htmlFontStyleElements[node.style]; found {
+		builder.WriteString("<" + element + ">")
+		renderer.writeInlines(builder, node.content)
+		builder.WriteString("</" + element + ">")
+		return
+	}
+
+	markdownDelimiter := markdownFontStyleDelimiters[node.style]
+	builder.WriteString(markdownDelimiter)
+	renderer.writeInlines(builder, node.content)
+	builder.WriteString(markdownDelimiter)
 }
@@ -14,41 +14,105 @@ func TestTripleSingleQuoteBold(t *testing.T) {
 	setUp(t)
 	defer tearDown(t)
 
-	conversion := converter.WikiConvert(wikiPage, leadingText+"'''"+highlightedText+"'''"+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"'''"+highlightedText+"'''"+trailingText)
 	assertEquals(t, conversion, leadingText+"**"+highlightedText+"**"+trailingText)
 }
 func TestDoubleSingleQuoteItalic(t *testing.T) {
 	setUp(t)
 	defer tearDown(t)
 
-	conversion := converter.WikiConvert(wikiPage, leadingText+"''"+highlightedText+"''"+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"''"+highlightedText+"''"+trailingText)
 	assertEquals(t, conversion, leadingText+"*"+highlightedText+"*"+trailingText)
 }
 func TestFiveSingleQuoteBoldItalic(t *testing.T) {
 	setUp(t)
 	defer tearDown(t)
 
-	conversion := converter.WikiConvert(wikiPage, leadingText+"'''''"+highlightedText+"'''''"+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"'''''"+highlightedText+"'''''"+trailingText)
 	assertEquals(t, conversion, leadingText+"**"+highlightedText+"**"+trailingText)
 }
 func TestDoubleAsteriskBold(t *testing.T) {
 	setUp(t)
 	defer tearDown(t)
 
-	conversion := converter.WikiConvert(wikiPage, leadingText+"**"+highlightedText+"**"+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"**"+highlightedText+"**"+trailingText)
 	assertEquals(t, conversion, leadingText+"**"+highlightedText+"**"+trailingText)
 }
 func TestDoubleSlashItalic(t *testing.T) {
 	setUp(t)
 	defer tearDown(t)
 
-	conversion := converter.WikiConvert(wikiPage, leadingText+"//"+highlightedText+"//"+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"//"+highlightedText+"//"+trailingText)
 	assertEquals(t, conversion, leadingText+"*"+highlightedText+"*"+trailingText)
 }
 func TestUnderline(t *testing.T) {
 	setUp(t)
 	defer tearDown(t)
 
-	conversion := converter.WikiConvert(wikiPage, leadingText+"__"+highlightedText+"__"+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"__"+highlightedText+"__"+trailingText)
 	assertEquals(t, conversion, leadingText+"*"+highlightedText+"*"+trailingText)
 }
+
+func TestStrikethrough(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.TicketConvert(ticketID, "Workaround: ~~restart the server~~ no longer needed")
+	assertEquals(t, conversion, "Workaround: ~~restart the server~~ no longer needed")
+}
+
+func TestSuperscript(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.TicketConvert(ticketID, "The lookup is O(n^2^) for large tables")
+	assertEquals(t, conversion, "The lookup is O(n<sup>2</sup>) for large tables")
+}
+
+func TestSubscript(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.TicketConvert(ticketID, "The CO,,2,, sensor reading is wrong")
+	assertEquals(t, conversion, "The CO<sub>2</sub> sensor reading is wrong")
+}
+
+func TestEscapedStrikethroughSuperscriptAndSubscript(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.TicketConvert(ticketID, "!~~kept~~ and !^kept^ and !,,kept,,")
+	assertEquals(t, conversion, "\\~\\~kept\\~\\~ and ^kept^ and ,,kept,,")
+}
+
+func TestUnpairedStrikethroughSuperscriptAndSubscriptDelimiters(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.TicketConvert(ticketID, "Press Ctrl+^ to switch, or use a,,b syntax")
+	assertEquals(t, conversion, "Press Ctrl+^ to switch, or use a,,b syntax")
+}
+
+func TestFontStylesNotConvertedInCode(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.TicketConvert(ticketID, "Use {{{x ^= mask^2^}}} or `path,,name,,` or {{{~~tmp~~}}}")
+	assertEquals(t, conversion, "Use `x ^= mask^2^` or `path,,name,,` or `~~tmp~~`")
+}
+
+func TestFontStylesNotConvertedInURL(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.TicketConvert(ticketID, "See http://www.example.com/~~old~~/a,,b,,c or http://a.com/x^y^z for ~~details~~")
+	assertEquals(t, conversion, "See <http://www.example.com/~~old~~/a,,b,,c> or <http://a.com/x^y^z> for ~~details~~")
+}
+
+func TestMonospaceInBold(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.TicketConvert(ticketID, "'''{{{make install}}} fails''' and **`make test` passes**")
+	assertEquals(t, conversion, "**`make install` fails** and **`make test` passes**")
+}
@@ -0,0 +1,284 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown_test
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stevejefferson/trac2gitea/accessor/gitea"
+	"github.com/stevejefferson/trac2gitea/accessor/mock_gitea"
+	"github.com/stevejefferson/trac2gitea/accessor/mock_trac"
+	"github.com/stevejefferson/trac2gitea/accessor/trac"
+	"github.com/stevejefferson/trac2gitea/markdown"
+	"go.uber.org/mock/gomock"
+)
+
+// The golden corpus consists of pairs of files in testdata/golden: '<name>.trac' containing Trac wiki text and '<name>.md' containing the expected markdown.
+// Files whose name starts with "ticket-" are converted as ticket text, all others as text of the wiki page goldenWikiPage.
+// Run 'go test -run TestGoldenCorpus -update' to regenerate the '.md' files after a deliberate change to the conversion.
+var updateGolden = flag.Bool("update", false, "update golden markdown files")
+
+const (
+	goldenDir      = "testdata/golden"
+	goldenTicketID = int64(42)
+	goldenWikiPage = "GoldenPage"
+)
+
+// goldenWikiPages are the Trac wiki pages (in time order) available to macros such as '[[TitleIndex]]' and '[[Include]]'
+var goldenWikiPages = []*trac.WikiPage{
+	{Name: "GuideIntro", Text: "Introduction to the ''guide''.\n[[Image(intro.png)]]\n", Version: 1, UpdateTime: 1577880000},
+	{Name: "GuideSetup", Text: "= Setup =\nRun `make`.\n", Version: 1, UpdateTime: 1577966400},
+	{Name: "GuideIntro", Text: "Introduction to the '''guide''', see GuideSetup.\n[[Image(intro.png)]]\n", Version: 2, UpdateTime: 1578052800},
+	{Name: "TracGuide", Text: "Predefined page.\n", Version: 1, UpdateTime: 1578139200},
+	{Name: "Recursive", Text: "Before\n[[Include(Recursive)]]\nAfter\n", Version: 1, UpdateTime: 1578225600},
+}
+
+// goldenTickets are the Trac tickets available to '[[TicketQuery]]' macros
+var goldenTickets = []*trac.Ticket{
+	{TicketID: 1, Summary: "Crash on start", Owner: "alice", Reporter: "bob", MilestoneName: "1.2", ComponentName: "ui", PriorityName: "major", TypeName: "defect", Status: "new"},
+	{TicketID: 2, Summary: "Add | separator", Owner: "bob", Reporter: "alice", MilestoneName: "1.2", ComponentName: "core", PriorityName: "minor", TypeName: "enhancement", Status: "closed"},
+	{TicketID: 3, Summary: "Slow rendering", Owner: "alice", Reporter: "carol", MilestoneName: "1.3", ComponentName: "ui", PriorityName: "major", TypeName: "defect", Status: "assigned"},
+}
+
+// goldenReports are the Trac reports available to 'report:' links
+var goldenReports = []*trac.Report{
+	{ReportID: 1, Title: "Active Tickets", Query: "SELECT ..."},
+	{ReportID: 7, Title: "My Tickets", Query: "SELECT ..."},
+	{ReportID: 9, Title: "UI Tickets", Query: "query:?component=ui&order=priority"},
+	{ReportID: 10, Title: "Custom", Query: "SELECT ..."},
+}
+
+// createGoldenConverter creates a converter whose accessors return predictable values for any lookup
+func createGoldenConverter(ctrl *gomock.Controller) *markdown.DefaultConverter {
+	tracAccessor := mock_trac.NewMockAccessor(ctrl)
+	giteaAccessor := mock_gitea.NewMockAccessor(ctrl)
+
+	tracAccessor.EXPECT().GetFullPath(gomock.Any()).DoAndReturn(func(element ...string) string {
+		return "/trac/" + strings.Join(element, "/")
+	}).AnyTimes()
+	tracAccessor.EXPECT().GetFullPath(gomock.Any(), gomock.Any()).DoAndReturn(func(element ...string) string {
+		return "/trac/" + strings.Join(element, "/")
+	}).AnyTimes()
+	tracAccessor.EXPECT().GetTicketCommentTime(gomock.Any(), gomock.Any()).DoAndReturn(func(ticketID int64, commentNum int64) (int64, error) {
+		return ticketID*1000 + commentNum, nil
+	}).AnyTimes()
+	tracAccessor.EXPECT().GetInterTracPrefixes().Return(map[string]string{"ot": "othertrac"}).AnyTimes()
+	tracAccessor.EXPECT().GetInterWikiPrefixes().Return(map[string]*trac.InterWikiPrefix{
+		"pep":           {Prefix: "PEP", URL: "http://www.python.org/peps/pep-$1.html", Title: "Python Enhancement Proposal $1"},
+		"trac":          {Prefix: "Trac", URL: "http://trac.edgewall.org/intertrac/", Title: "The Trac Project"},
+		"meatball":      {Prefix: "MeatBall", URL: "http://meatballwiki.org/wiki/", Title: "MeatBall"},
+		"tracchangeset": {Prefix: "tracchangeset", URL: "http://trac.edgewall.org/changeset/$1/$2", Title: "Changeset $1/$2 in Trac"},
+		"jira":          {Prefix: "JIRA", URL: "https://jira.example.com/browse/$1?focus=true", Title: "JIRA issue $1"},
+	}, nil).AnyTimes()
+	tracAccessor.EXPECT().GetWikiPages(gomock.Any()).DoAndReturn(func(handlerFn func(page *trac.WikiPage) error) error {
+		for _, page := range goldenWikiPages {
+			if err := handlerFn(page); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).AnyTimes()
+	tracAccessor.EXPECT().GetTickets(gomock.Any()).DoAndReturn(func(handlerFn func(ticket *trac.Ticket) error) error {
+		for _, ticket := range goldenTickets {
+			if err := handlerFn(ticket); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).AnyTimes()
+	tracAccessor.EXPECT().GetReport(gomock.Any()).DoAndReturn(func(reportID int64) (*trac.Report, error) {
+		for _, report := range goldenReports {
+			if report.ReportID == reportID {
+				return report, nil
+			}
+		}
+		return nil, nil
+	}).AnyTimes()
+	tracAccessor.EXPECT().IsPredefinedPage(gomock.Any()).DoAndReturn(func(pageName string) bool {
+		return strings.HasPrefix(pageName, "Trac")
+	}).AnyTimes()
+
+	giteaAccessor.EXPECT().GetIssueID(gomock.Any()).DoAndReturn(func(issueIndex int64) (int64, error) {
+		return issueIndex + 100, nil
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetIssueURL(gomock.Any()).DoAndReturn(func(issueIndex int64) string {
+		return fmt.Sprintf("/org/repo/issues/%d", issueIndex)
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetIssueCommentIDByTime(gomock.Any(), gomock.Any()).DoAndReturn(func(issueID int64, createdTime int64) (int64, error) {
+		return createdTime + 5000, nil
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetIssueCommentURL(gomock.Any(), gomock.Any()).DoAndReturn(func(issueIndex int64, commentID int64) string {
+		return fmt.Sprintf("/org/repo/issues/%d#issuecomment-%d", issueIndex, commentID)
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetIssueAttachmentUUID(gomock.Any(), gomock.Any()).DoAndReturn(func(issueID int64, fileName string) (string, error) {
+		return fmt.Sprintf("uuid-%d-%s", issueID, fileName), nil
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetIssueAttachmentURL(gomock.Any(), gomock.Any()).DoAndReturn(func(issueID int64, uuid string) string {
+		return "/attachments/" + uuid
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetIssueListURL(gomock.Any()).DoAndReturn(func(query string) string {
+		return "/org/repo/issues?" + query
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetLabelID(gomock.Any()).DoAndReturn(func(labelName string) (int64, error) {
+		if labelName == "unmapped" {
+			return gitea.NullID, nil
+		}
+		return int64(len(labelName)), nil
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetUserID(gomock.Any()).DoAndReturn(func(userName string) (int64, error) {
+		return int64(len(userName)) + 1000, nil
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetMilestoneID(gomock.Any()).Return(int64(7), nil).AnyTimes()
+	giteaAccessor.EXPECT().GetMilestoneURL(gomock.Any()).DoAndReturn(func(milestoneID int64) string {
+		return fmt.Sprintf("/org/repo/milestone/%d", milestoneID)
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetCommitURL(gomock.Any()).DoAndReturn(func(commitID string) string {
+		return "/org/repo/commit/" + commitID
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetCompareURL(gomock.Any(), gomock.Any()).DoAndReturn(func(fromCommitID string, toCommitID string) string {
+		return "/org/repo/compare/" + fromCommitID + "..." + toCommitID
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetSourceURL(gomock.Any(), gomock.Any()).DoAndReturn(func(branchPath string, filePath string) string {
+		return "/org/repo/src/branch/" + branchPath + "/" + filePath
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetCommitSourceURL(gomock.Any(), gomock.Any()).DoAndReturn(func(commitID string, filePath string) string {
+		return "/org/repo/src/commit/" + commitID + "/" + filePath
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetRawSourceURL(gomock.Any(), gomock.Any()).DoAndReturn(func(branchPath string, filePath string) string {
+		return "/org/repo/raw/branch/" + branchPath + "/" + filePath
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetRawCommitSourceURL(gomock.Any(), gomock.Any()).DoAndReturn(func(commitID string, filePath string) string {
+		return "/org/repo/raw/commit/" + commitID + "/" + filePath
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetSourceLogURL(gomock.Any(), gomock.Any()).DoAndReturn(func(branchPath string, filePath string) string {
+		return "/org/repo/commits/branch/" + branchPath + "/" + filePath
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetRepoIssueURL(gomock.Any(), gomock.Any()).DoAndReturn(func(repoName string, issueIndex int64) string {
+		return fmt.Sprintf("/org/%s/issues/%d", repoName, issueIndex)
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetRepoIssueReference(gomock.Any(), gomock.Any()).DoAndReturn(func(repoName string, issueIndex int64) string {
+		return fmt.Sprintf("org/%s#%d", repoName, issueIndex)
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetRepoWikiURL(gomock.Any(), gomock.Any()).DoAndReturn(func(repoName string, pageName string) string {
+		return fmt.Sprintf("/org/%s/wiki/%s", repoName, pageName)
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetWikiPageURL(gomock.Any()).DoAndReturn(func(pageName string) string {
+		return "/org/repo/wiki/" + pageName
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetWikiAttachmentRelPath(gomock.Any(), gomock.Any()).DoAndReturn(func(pageName string, filename string) string {
+		return "attachments/" + pageName + "/" + filename
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetWikiHtdocRelPath(gomock.Any()).DoAndReturn(func(filename string) string {
+		return "htdocs/" + filename
+	}).AnyTimes()
+	giteaAccessor.EXPECT().GetWikiFileURL(gomock.Any()).DoAndReturn(func(relpath string) string {
+		return "../raw/" + relpath
+	}).AnyTimes()
+	giteaAccessor.EXPECT().CopyFileToWiki(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	giteaAccessor.EXPECT().TranslateWikiPageName(gomock.Any()).DoAndReturn(func(pageName string) string {
+		return "Gitea" + pageName
+	}).AnyTimes()
+
+	goldenConverter := markdown.CreateDefaultConverter(tracAccessor, giteaAccessor)
+	goldenConverter.SetInterTracMap(map[string]string{"othertrac": "other-repo"})
+	goldenConverter.LoadInterWikiMap()
+	goldenConverter.SetUserMap(map[string]string{"alice": "alice", "bob": "robert", "carol": ""})
+	goldenConverter.SetBranchMap(map[string]string{"branches/1.x": "release-1.x"})
+	goldenConverter.SetRevisionMap(map[string]string{"r123": "0123456789abcdef0123456789abcdef01234567", "r130": "fedcba9876543210fedcba9876543210fedcba98"})
+	goldenConverter.SetLabelMaps(
+		map[string]string{"ui": "ui", "core": "core", "docs": "unmapped"},
+		map[string]string{"major": "major", "minor": ""},
+		nil, nil,
+		map[string]string{"defect": "bug", "enhancement": "feature"},
+		nil)
+	return goldenConverter
+}
+
+// convertGolden converts the Trac text of a golden file as either ticket or wiki text depending on the file name
+func convertGolden(goldenConverter *markdown.DefaultConverter, name string, in string) string {
+	if strings.HasPrefix(name, "ticket-") {
+		conversion, _ := goldenConverter.TicketConvert(goldenTicketID, in)
+		return conversion
+	}
+	conversion, _ := goldenConverter.WikiConvert(goldenWikiPage, in)
+	return conversion
+}
+
+// goldenFile is the Trac text of a golden corpus file
+type goldenFile struct {
+	name     string
+	tracText string
+}
+
+func readGoldenCorpus(t testing.TB) []goldenFile {
+	tracFiles, err := filepath.Glob(filepath.Join(goldenDir, "*.trac"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tracFiles) == 0 {
+		t.Fatalf("no golden files found in %s", goldenDir)
+	}
+
+	corpus := []goldenFile{}
+	for _, tracFile := range tracFiles {
+		tracText, err := os.ReadFile(tracFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		corpus = append(corpus, goldenFile{name: strings.TrimSuffix(filepath.Base(tracFile), ".trac"), tracText: string(tracText)})
+	}
+	return corpus
+}
+
+func TestGoldenCorpus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	goldenConverter := createGoldenConverter(ctrl)
+
+	for _, golden := range readGoldenCorpus(t) {
+		markdownFile := filepath.Join(goldenDir, golden.name+".md")
+		got := convertGolden(goldenConverter, golden.name, golden.tracText)
+
+		if *updateGolden {
+			if err := os.WriteFile(markdownFile, []byte(got), 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+
+		expected, err := os.ReadFile(markdownFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != string(expected) {
+			t.Errorf("conversion of %s.trac does not match %s:\n--- expected ---\n%s\n--- got ---\n%s", golden.name, markdownFile, expected, got)
+		}
+	}
+}
+
+// BenchmarkWikiConvert measures the conversion of the whole golden corpus as a single wiki page
+func BenchmarkWikiConvert(b *testing.B) {
+	ctrl := gomock.NewController(b)
+	defer ctrl.Finish()
+	goldenConverter := createGoldenConverter(ctrl)
+
+	var builder strings.Builder
+	for _, golden := range readGoldenCorpus(b) {
+		builder.WriteString(golden.tracText)
+		builder.WriteString("\n")
+	}
+	in := builder.String()
+
+	b.SetBytes(int64(len(in)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		goldenConverter.WikiConvert(goldenWikiPage, in)
+	}
+}
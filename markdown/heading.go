@@ -9,62 +9,36 @@ import (
 	"strings"
 )
 
-const maxHeadingLevel = 6
-
-var headingRegexps [maxHeadingLevel]*regexp.Regexp
-var headingAnchorRegexp = regexp.MustCompile(`(?m)^ +#([^[:space:]]+)`)
-
-func compileRegexps() {
-	tracHeadingDelimiter := ""
-	for headingLevel := 0; headingLevel < maxHeadingLevel; headingLevel++ {
-		tracHeadingDelimiter = tracHeadingDelimiter + "="
-
-		// construct regexp for trac heading - this captures:
-		// $1 = heading level delimiter (which we transform into the equivalent markdown delimiter)
-		// $2 = heading text
-		// $3 = any trailing text on line which may contain an optional Trac heading anchor
-		// note: the trailing sequence of '='s  on trac headings turns out to be optional
-		headingRegexpStr := `(?m)^(` + tracHeadingDelimiter + `) *([^=\n]+)(?:` + tracHeadingDelimiter + `)?(.*)$`
-		headingRegexps[headingLevel] = regexp.MustCompile(headingRegexpStr)
-	}
+// regexp for a trac heading: $1=heading level delimiter, $2=heading text, $3=optional heading anchor
+// note: the trailing sequence of '='s on trac headings turns out to be optional
+var headingRegexp = regexp.MustCompile(`^\s*(={1,6})\s+(.*?)(?:\s*=+)?(?:\s+#([^[:space:]]+))?\s*$`)
+
+// headingBlock is a Trac heading
+type headingBlock struct {
+	level   int
+	text    string
+	content []inline
+	anchor  string
 }
 
-func init() {
-	// pre-compile array of regexps - one for each level of trac heading
-	compileRegexps()
+func isHeading(line string) bool {
+	return headingRegexp.MatchString(line)
 }
 
-func (converter *DefaultConverter) convertHeadings(in string) string {
-	// iterate through all heading levels starting from longest (doing shortest first risks premature regexp matches)
-	out := in
-	for headingLevel := maxHeadingLevel - 1; headingLevel >= 0; headingLevel-- {
-		headingRegexp := headingRegexps[headingLevel]
-		out = headingRegexp.ReplaceAllStringFunc(out, func(match string) string {
-			// turn trac delimiter into a markdown delimiter - e.g. '===' => '###'
-			tracDelimiter := headingRegexp.ReplaceAllString(match, `$1`)
-			markdownDelimiter := strings.Replace(tracDelimiter, "=", "#", -1)
+func (parser *blockParser) parseHeading() block {
+	match := headingRegexp.FindStringSubmatch(parser.lines[parser.pos])
+	parser.pos++
 
-			// extract text of heading
-			headingText := headingRegexp.ReplaceAllString(match, `$2`)
-			headingText = strings.Trim(headingText, " ")
-
-			// examine any trailing text for presence of a Trac heading anchor
-			anchor := ""
-			trailingText := headingRegexp.ReplaceAllString(match, `$3`)
-			if headingAnchorRegexp.MatchString(trailingText) {
-				// if Trac anchor is the same as the "hyphenated" heading then this is the same as the implicit markdown heading anchor
-				// so we don't need to embed an explicit anchor
-				anchorName := headingAnchorRegexp.ReplaceAllString(trailingText, `$1`)
-				hyphenatedHeading := strings.Replace(headingText, " ", "-", -1)
-				if hyphenatedHeading != anchorName {
-					// Trac anchor does not match markdown implicit anchor - the best we can do is insert a raw HTML anchor
-					anchor = "<a name=\"" + anchorName + "\"></a>"
-				}
-			}
-
-			return markdownDelimiter + " " + anchor + headingText
-		})
+	return &headingBlock{
+		level:   len(match[1]),
+		text:    match[2],
+		content: parser.converter.parseInlines(match[2]),
+		anchor:  match[3],
 	}
+}
 
-	return out
+// renderHeading renders a heading - any Trac anchor is dropped as links to it are converted into links to the anchor Gitea generates for the heading
+func (renderer *renderer) renderHeading(heading *headingBlock) {
+	markdownDelimiter := strings.Repeat("#", heading.level)
+	renderer.addLine(markdownDelimiter + " " + renderer.renderInlines(heading.content))
 }
@@ -0,0 +1,155 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// regexp for the characters Trac removes from the text of a heading to generate its anchor
+var tracAnchorRemovedCharsRegexp = regexp.MustCompile(`[^\pL\pN_:.\-]+`)
+
+// UnresolvedAnchor is an anchor referenced by a converted Trac link which could not be found on the referenced wiki page
+type UnresolvedAnchor struct {
+	WikiPage string
+	Anchor   string
+	Link     string
+
+	// the link was found in the text of either a ticket (in which case TicketID != NullID) or a wiki page
+	TicketID          int64
+	ReferringWikiPage string
+}
+
+// tracHeadingAnchor returns the anchor which Trac generates for a heading with the given text
+func tracHeadingAnchor(text string) string {
+	anchor := tracAnchorRemovedCharsRegexp.ReplaceAllString(text, "")
+	firstRune, _ := utf8.DecodeRuneInString(anchor)
+	if anchor == "" || unicode.IsDigit(firstRune) || firstRune == '.' || firstRune == '-' {
+		// Trac anchors must start with a letter
+		anchor = "a" + anchor
+	}
+	return anchor
+}
+
+// markdownHeadingAnchor returns the anchor which Gitea generates for a markdown heading with the given text:
+// runs of characters other than letters and digits are replaced by a single '-' and the result is lowercased
+func markdownHeadingAnchor(text string) string {
+	runes := []rune{}
+	needsDash := false
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			if needsDash && len(runes) > 0 {
+				runes = append(runes, '-')
+			}
+			needsDash = false
+			runes = append(runes, unicode.ToLower(r))
+		default:
+			needsDash = true
+		}
+	}
+	if len(runes) == 0 {
+		return "heading"
+	}
+	return string(runes)
+}
+
+// markdownHeadingAnchors returns the anchors which Gitea generates for a sequence of converted headings
+// - Gitea distinguishes repeated anchors by appending '-1', '-2' etc.
+func markdownHeadingAnchors(headings []*headingBlock) []string {
+	anchors := []string{}
+	used := make(map[string]bool)
+	for _, heading := range headings {
+		anchor := markdownHeadingAnchor(plainText(heading.content))
+		for i := 1; used[anchor]; i++ {
+			anchor = markdownHeadingAnchor(plainText(heading.content)) + "-" + strconv.Itoa(i)
+		}
+		used[anchor] = true
+		anchors = append(anchors, anchor)
+	}
+	return anchors
+}
+
+// pageAnchors returns a map of the Trac anchors of a wiki page onto the equivalent anchors in the converted page.
+// This covers both heading anchors (explicit or generated by Trac) and '[=#name]' anchors.
+func pageAnchors(blocks []block) map[string]string {
+	anchors := make(map[string]string)
+
+	headings := collectHeadings(blocks)
+	markdownAnchors := markdownHeadingAnchors(headings)
+	for index, heading := range headings {
+		// Trac distinguishes repeated anchors by appending '1', '2' etc.
+		anchor := heading.anchor
+		if anchor == "" {
+			anchor = tracHeadingAnchor(plainText(heading.content))
+		}
+		baseAnchor := anchor
+		for i := 1; anchors[anchor] != ""; i++ {
+			anchor = baseAnchor + strconv.Itoa(i)
+		}
+		anchors[anchor] = markdownAnchors[index]
+	}
+
+	// '[=#name]' anchors are converted into HTML anchors of the same name
+	walkInlines(blocks, func(node inline) {
+		if anchorNode, ok := node.(*anchorInline); ok {
+			anchors[anchorNode.name] = anchorNode.name
+		}
+	})
+
+	return anchors
+}
+
+// wikiPageAnchors returns the map of Trac anchors onto converted anchors for a given wiki page, or nil if there is no such page
+func (converter *DefaultConverter) wikiPageAnchors(wikiPage string) map[string]string {
+	if anchors, found := converter.pageAnchors[wikiPage]; found {
+		return anchors
+	}
+
+	page, found := converter.latestWikiPages()[wikiPage]
+	if !found {
+		return nil
+	}
+
+	anchors := pageAnchors(converter.parse(converter.convertEOL(page.Text)))
+	converter.setWikiPageAnchors(wikiPage, anchors)
+	return anchors
+}
+
+func (converter *DefaultConverter) setWikiPageAnchors(wikiPage string, anchors map[string]string) {
+	if converter.pageAnchors == nil {
+		converter.pageAnchors = make(map[string]map[string]string)
+	}
+	converter.pageAnchors[wikiPage] = anchors
+}
+
+// convertAnchor converts a Trac anchor on a wiki page referenced by a link in the text of a given ticket or wiki page into the equivalent anchor in the converted page.
+// An anchor which cannot be found on the page is left unchanged and recorded as unresolved.
+func (converter *DefaultConverter) convertAnchor(ticketID int64, referringWikiPage string, wikiPage string, anchor string, link *tracLink) string {
+	if convertedAnchor, found := converter.wikiPageAnchors(wikiPage)[anchor]; found {
+		return convertedAnchor
+	}
+
+	converter.warn(UnresolvedAnchorDiagnostic, link.source,
+		"cannot find anchor \"%s\" on wiki page %s referenced by Trac link \"%s\"", anchor, wikiPage, link.source)
+	unresolvedAnchor := UnresolvedAnchor{
+		WikiPage: wikiPage, Anchor: anchor, Link: link.source, TicketID: ticketID, ReferringWikiPage: referringWikiPage}
+	for _, existingAnchor := range converter.unresolvedAnchors {
+		if existingAnchor == unresolvedAnchor {
+			// each version of a wiki page is converted so the same link may be found more than once
+			return anchor
+		}
+	}
+	converter.unresolvedAnchors = append(converter.unresolvedAnchors, unresolvedAnchor)
+	return anchor
+}
+
+// UnresolvedAnchors returns the wiki page anchors referenced by converted Trac links which could not be found
+func (converter *DefaultConverter) UnresolvedAnchors() []UnresolvedAnchor {
+	return converter.unresolvedAnchors
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown_test
+
+import (
+	"testing"
+
+	"github.com/stevejefferson/trac2gitea/accessor/trac"
+	"github.com/stevejefferson/trac2gitea/markdown"
+)
+
+func TestLinkToGeneratedHeadingAnchorOnSamePage(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, "== Some Heading ==\nSee [#SomeHeading] and [#SomeHeading the heading].")
+	assertEquals(t, conversion, "## Some Heading\nSee [#some-heading](#some-heading) and [the heading](#some-heading).")
+}
+
+func TestLinkToExplicitHeadingAnchorOnSamePage(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, "== Some Heading == #custom-id\nSee [[#custom-id|the heading]].")
+	assertEquals(t, conversion, "## Some Heading\nSee [the heading](#some-heading).")
+}
+
+func TestLinkToRepeatedHeadingAnchors(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	// Trac appends '1', '2' etc. to repeated anchors, Gitea appends '-1', '-2' etc.
+	conversion, _ := converter.WikiConvert(wikiPage, "= Notes =\n= Notes =\n= Notes =\n[#Notes] [#Notes1] [#Notes2]")
+	assertEquals(t, conversion, "# Notes\n# Notes\n# Notes\n[#notes](#notes) [#notes-1](#notes-1) [#notes-2](#notes-2)")
+}
+
+func TestLinkToHeadingAnchorStartingWithDigit(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, "== 1.2 Upgrading, Step-by-Step! ==\nSee [#a1.2UpgradingStep-by-Step].")
+	assertEquals(t, conversion, "## 1.2 Upgrading, Step-by-Step!\nSee [#1-2-upgrading-step-by-step](#1-2-upgrading-step-by-step).")
+}
+
+func TestLinkToExplicitAnchorOnSamePage(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, "[=#here]Somewhere\nSee [#here there].")
+	assertEquals(t, conversion, "<a name=\"here\"></a>Somewhere\nSee [there](#here).")
+}
+
+func TestLinkToHeadingAnchorOnOtherPage(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	expectTracToReturnWikiPages(t,
+		&trac.WikiPage{Name: otherPageName, Version: 1, Text: "= Old Heading ="},
+		&trac.WikiPage{Name: otherPageName, Version: 2, Text: "= Introduction =\n== Getting Started == #start\n"})
+	expectToTranslateWikiPageName(t, otherPageName, giteaOtherPageName)
+	expectToTranslateWikiPageName(t, otherPageName, giteaOtherPageName)
+
+	conversion, _ := converter.WikiConvert(wikiPage, "wiki:"+otherPageName+"#Introduction and [wiki:"+otherPageName+"#start started]")
+	assertEquals(t, conversion, "["+giteaOtherPageName+"#introduction]("+giteaOtherPageName+"#introduction) and [started]("+giteaOtherPageName+"#getting-started)")
+}
+
+func TestUnresolvedAnchor(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, "== Some Heading ==\nSee [#Missing].")
+	assertEquals(t, conversion, "## Some Heading\nSee [#Missing](#Missing).")
+
+	unresolvedAnchors := converter.UnresolvedAnchors()
+	assertEquals(t, len(unresolvedAnchors), 1)
+	assertEquals(t, unresolvedAnchors[0], markdown.UnresolvedAnchor{WikiPage: wikiPage, Anchor: "Missing", Link: "#Missing", TicketID: trac.NullID, ReferringWikiPage: wikiPage})
+}
+
+func TestLinkToAnchorFromTicketNotConverted(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.TicketConvert(ticketID, "See [#SomeHeading].")
+	assertEquals(t, conversion, "See [#SomeHeading].")
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/stevejefferson/trac2gitea/log"
+)
+
+// regexp for a '<name>=<value>' attribute of a Trac processor or macro, the value being optionally quoted
+var htmlAttributeRegexp = regexp.MustCompile(`([\w-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|(\S+))`)
+
+// HTML attributes which Gitea's HTML sanitiser leaves in place - anything else is stripped from the rendered markdown
+var giteaAllowedAttributes = []string{
+	"align", "border", "cellpadding", "cellspacing", "colspan", "dir", "headers", "height",
+	"id", "lang", "nowrap", "rowspan", "scope", "span", "title", "valign", "width",
+}
+
+// attributes which Gitea's HTML sanitiser allows on specific elements only
+var giteaAllowedElementAttributes = map[string][]string{
+	"a":   {"href"},
+	"img": {"src", "alt"},
+}
+
+// elements on which Gitea's HTML sanitiser allows a restricted 'style' attribute
+var giteaStyledElements = []string{"div", "span", "p", "tr", "th", "td"}
+
+// style properties which Gitea's HTML sanitiser allows
+var giteaAllowedStyleProperties = []string{"color", "background-color"}
+
+// regexp for a CSS colour value - a name, '#<hex>', 'rgb(...)' or 'hsl(...)' (and their 'a' variants)
+var cssColorRegexp = regexp.MustCompile(`^(?i:[[:alpha:]]+|#[[:xdigit:]]+|(?:rgb|hsl)a?\([^()]*\))$`)
+
+// CSS functions which can run script or fetch content - any value containing them is dropped
+var cssUnsafeFunctions = []string{"url(", "expression("}
+
+// htmlAttribute is an attribute of an HTML element
+type htmlAttribute struct {
+	name  string
+	value string
+}
+
+// parseHTMLAttributes parses Trac processor parameters or macro keyword arguments of the form '<name>=<value> ...' into HTML attributes
+func parseHTMLAttributes(parameters string) []htmlAttribute {
+	attributes := []htmlAttribute{}
+	for _, match := range htmlAttributeRegexp.FindAllStringSubmatch(parameters, -1) {
+		attributes = append(attributes, htmlAttribute{name: strings.ToLower(match[1]), value: match[2] + match[3] + match[4]})
+	}
+	return attributes
+}
+
+// isCSSColor returns true if a CSS value is a colour
+// - 'none' is a valid 'background' value but not a colour
+func isCSSColor(value string) bool {
+	return cssColorRegexp.MatchString(value) && !strings.EqualFold(value, "none")
+}
+
+// isUnsafeCSSValue returns true if a CSS value uses a function which can run script or fetch content
+func isUnsafeCSSValue(value string) bool {
+	lowerValue := strings.ToLower(strings.ReplaceAll(value, " ", ""))
+	for _, function := range cssUnsafeFunctions {
+		if strings.Contains(lowerValue, function) {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeStyle returns the subset of the CSS declarations of an HTML 'style' attribute which Gitea allows
+// - a 'background' shorthand consisting of just a colour is converted into the equivalent 'background-color'
+func sanitizeStyle(style string) string {
+	declarations := []string{}
+	for _, declaration := range strings.Split(style, ";") {
+		nameAndValue := strings.SplitN(declaration, ":", 2)
+		if len(nameAndValue) != 2 {
+			continue
+		}
+		name := strings.ToLower(strings.TrimSpace(nameAndValue[0]))
+		value := strings.TrimSpace(nameAndValue[1])
+		if isUnsafeCSSValue(value) {
+			log.Debug("dropping CSS property \"%s\" with unsafe value \"%s\"", name, value)
+			continue
+		}
+		if name == "background" && isCSSColor(value) {
+			name = "background-color"
+		}
+		if containsString(giteaAllowedStyleProperties, name) {
+			declarations = append(declarations, name+": "+value)
+		} else {
+			log.Debug("dropping CSS property \"%s\" which Gitea does not support", name)
+		}
+	}
+	return strings.Join(declarations, "; ")
+}
+
+// sanitizeHTMLAttributes removes any attributes of an HTML element which Gitea would strip, and any parts of a style attribute which it would strip
+func sanitizeHTMLAttributes(tag string, attributes []htmlAttribute) []htmlAttribute {
+	sanitizedAttributes := []htmlAttribute{}
+	for _, attribute := range attributes {
+		switch {
+		case attribute.name == "style" && containsString(giteaStyledElements, tag):
+			if style := sanitizeStyle(attribute.value); style != "" {
+				sanitizedAttributes = append(sanitizedAttributes, htmlAttribute{name: "style", value: style})
+			}
+		case containsString(giteaAllowedAttributes, attribute.name), containsString(giteaAllowedElementAttributes[tag], attribute.name):
+			sanitizedAttributes = append(sanitizedAttributes, attribute)
+		default:
+			log.Debug("dropping HTML attribute \"%s\" of <%s> which Gitea does not support", attribute.name, tag)
+		}
+	}
+	return sanitizedAttributes
+}
+
+// htmlStartTag returns the HTML start tag for an element with the given attributes, omitting any attributes which Gitea would strip
+func htmlStartTag(tag string, attributes []htmlAttribute) string {
+	var builder strings.Builder
+	builder.WriteString("<" + tag)
+	for _, attribute := range sanitizeHTMLAttributes(tag, attributes) {
+		builder.WriteString(" " + attribute.name + "=\"" + strings.ReplaceAll(attribute.value, "\"", "&quot;") + "\"")
+	}
+	builder.WriteString(">")
+	return builder.String()
+}
+
+// containsString returns true if a slice of strings contains a given string
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
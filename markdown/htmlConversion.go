@@ -0,0 +1,443 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTML constructs which we convert into markdown:
+// - paragraphs, headings, horizontal rules, block quotes, preformatted text and line breaks
+// - bulleted and numbered lists
+// - tables without spanned cells or block content
+// - bold, italic, underlined, struck-through, code, superscript and subscript text
+// - links, anchors and images
+// Any other element, or any attribute which markdown cannot represent, causes the conversion to fail.
+
+// regexp for a run of HTML whitespace
+var htmlWhitespaceRegexp = regexp.MustCompile(`[ \t\r\n\f]+`)
+
+// markdown characters which must be escaped in converted HTML text
+var htmlTextEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", "&lt;")
+
+// markdown delimiters for HTML inline elements
+var htmlInlineDelimiters = map[atom.Atom]string{
+	atom.B: "**", atom.Strong: "**",
+	atom.I: "*", atom.Em: "*", atom.Cite: "*", atom.U: "*", atom.Ins: "*",
+	atom.S: "~~", atom.Strike: "~~", atom.Del: "~~",
+}
+
+// HTML attributes which can be ignored when converting an element into markdown
+var htmlIgnoredAttributes = []string{"class", "style", "title", "id", "lang", "dir"}
+
+// convertHTML converts the content of a Trac '#!html' processor into markdown
+func convertHTML(renderer *renderer, content []string) ([]string, error) {
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(strings.Join(content, "\n")), context)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing HTML")
+	}
+
+	blocks, err := convertHTMLBlocks(nodes)
+	if err != nil {
+		return nil, err
+	}
+	return joinHTMLBlocks(blocks, ""), nil
+}
+
+// joinHTMLBlocks joins converted blocks of markdown lines, separating them by the given separator line
+func joinHTMLBlocks(blocks [][]string, separator string) []string {
+	lines := []string{}
+	for index, block := range blocks {
+		if index > 0 {
+			lines = append(lines, separator)
+		}
+		lines = append(lines, block...)
+	}
+	return lines
+}
+
+// checkHTMLAttributes returns an error if an HTML element has any attributes other than the given ones which markdown cannot represent
+func checkHTMLAttributes(node *html.Node, allowedAttributes ...string) error {
+	for _, attribute := range node.Attr {
+		if !containsString(allowedAttributes, attribute.Key) && !containsString(htmlIgnoredAttributes, attribute.Key) {
+			return errors.Errorf("unsupported attribute \"%s\" of HTML element <%s>", attribute.Key, node.Data)
+		}
+	}
+	return nil
+}
+
+// htmlAttributeValue returns the value of an attribute of an HTML element
+func htmlAttributeValue(node *html.Node, key string) string {
+	for _, attribute := range node.Attr {
+		if attribute.Key == key {
+			return attribute.Val
+		}
+	}
+	return ""
+}
+
+// childNodes returns the children of an HTML node
+func childNodes(node *html.Node) []*html.Node {
+	children := []*html.Node{}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		children = append(children, child)
+	}
+	return children
+}
+
+// isHTMLBlockElement returns true if an HTML node is an element which we convert into a markdown block
+func isHTMLBlockElement(node *html.Node) bool {
+	if node.Type != html.ElementNode {
+		return false
+	}
+	switch node.DataAtom {
+	case atom.P, atom.Div, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Hr, atom.Blockquote, atom.Pre,
+		atom.Ul, atom.Ol, atom.Table:
+		return true
+	}
+	return false
+}
+
+// convertHTMLBlocks converts a sequence of HTML nodes into blocks of markdown lines
+// - consecutive inline nodes make up a paragraph
+func convertHTMLBlocks(nodes []*html.Node) ([][]string, error) {
+	blocks := [][]string{}
+	paragraph := []*html.Node{}
+	flushParagraph := func() error {
+		text, err := convertHTMLInlines(paragraph)
+		paragraph = []*html.Node{}
+		if err != nil {
+			return err
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			blocks = append(blocks, []string{text})
+		}
+		return nil
+	}
+
+	for _, node := range nodes {
+		if node.Type == html.CommentNode {
+			continue
+		}
+		if !isHTMLBlockElement(node) {
+			paragraph = append(paragraph, node)
+			continue
+		}
+
+		if err := flushParagraph(); err != nil {
+			return nil, err
+		}
+		block, err := convertHTMLBlock(node)
+		if err != nil {
+			return nil, err
+		}
+		if len(block) > 0 {
+			blocks = append(blocks, block)
+		}
+	}
+	if err := flushParagraph(); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// convertHTMLBlock converts an HTML block element into lines of markdown
+func convertHTMLBlock(node *html.Node) ([]string, error) {
+	switch node.DataAtom {
+	case atom.Hr:
+		return []string{"----"}, checkHTMLAttributes(node)
+	case atom.Pre:
+		if err := checkHTMLAttributes(node); err != nil {
+			return nil, err
+		}
+		code := strings.Split(strings.TrimSuffix(strings.TrimPrefix(htmlText(node), "\n"), "\n"), "\n")
+		fence := codeFence(code)
+		return append(append([]string{fence}, code...), fence), nil
+	case atom.Table:
+		return convertHTMLTable(node)
+	case atom.Ul, atom.Ol:
+		return convertHTMLList(node)
+	}
+
+	if err := checkHTMLAttributes(node); err != nil {
+		return nil, err
+	}
+	switch node.DataAtom {
+	case atom.P:
+		text, err := convertHTMLInlines(childNodes(node))
+		return []string{strings.TrimSpace(text)}, err
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level, _ := strconv.Atoi(node.Data[1:])
+		text, err := convertHTMLInlines(childNodes(node))
+		return []string{strings.Repeat("#", level) + " " + strings.TrimSpace(text)}, err
+	case atom.Blockquote:
+		blocks, err := convertHTMLBlocks(childNodes(node))
+		if err != nil {
+			return nil, err
+		}
+		return prefixLines(joinHTMLBlocks(blocks, ""), "> ", "> "), nil
+	}
+
+	// a div just groups its content
+	blocks, err := convertHTMLBlocks(childNodes(node))
+	return joinHTMLBlocks(blocks, ""), err
+}
+
+// convertHTMLList converts an HTML list into lines of markdown
+func convertHTMLList(list *html.Node) ([]string, error) {
+	if err := checkHTMLAttributes(list); err != nil {
+		return nil, err
+	}
+
+	lines := []string{}
+	itemNumber := 0
+	for _, item := range childNodes(list) {
+		if item.Type == html.TextNode && strings.TrimSpace(item.Data) == "" || item.Type == html.CommentNode {
+			continue
+		}
+		if item.Type != html.ElementNode || item.DataAtom != atom.Li {
+			return nil, errors.Errorf("unsupported content of HTML list <%s>", list.Data)
+		}
+		if err := checkHTMLAttributes(item); err != nil {
+			return nil, err
+		}
+
+		marker := "* "
+		if list.DataAtom == atom.Ol {
+			itemNumber++
+			marker = strconv.Itoa(itemNumber) + ". "
+		}
+
+		// the content of a list item is kept together with no blank lines so that the list remains "tight"
+		blocks, err := convertHTMLBlocks(childNodes(item))
+		if err != nil {
+			return nil, err
+		}
+		itemLines := []string{}
+		for _, block := range blocks {
+			itemLines = append(itemLines, block...)
+		}
+		if len(itemLines) == 0 {
+			itemLines = []string{""}
+		}
+		lines = append(lines, prefixLines(itemLines, marker, strings.Repeat(" ", len(marker)))...)
+	}
+	return lines, nil
+}
+
+// convertHTMLTable converts an HTML table into a markdown table
+func convertHTMLTable(table *html.Node) ([]string, error) {
+	if err := checkHTMLAttributes(table, "border", "cellpadding", "cellspacing", "width"); err != nil {
+		return nil, err
+	}
+
+	// collect the rows, which may be grouped into a header, body and footer
+	rows := []*html.Node{}
+	var collectRows func(node *html.Node) error
+	collectRows = func(node *html.Node) error {
+		for _, child := range childNodes(node) {
+			switch {
+			case child.Type == html.TextNode && strings.TrimSpace(child.Data) == "", child.Type == html.CommentNode:
+			case child.DataAtom == atom.Thead || child.DataAtom == atom.Tbody || child.DataAtom == atom.Tfoot:
+				if err := collectRows(child); err != nil {
+					return err
+				}
+			case child.DataAtom == atom.Tr:
+				rows = append(rows, child)
+			default:
+				return errors.Errorf("unsupported content <%s> of HTML table", child.Data)
+			}
+		}
+		return nil
+	}
+	if err := collectRows(table); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	cellRows := [][]string{}
+	firstRowIsHeader := true
+	columnCount := 0
+	for rowIndex, row := range rows {
+		if err := checkHTMLAttributes(row); err != nil {
+			return nil, err
+		}
+		cells := []string{}
+		for _, cell := range childNodes(row) {
+			if cell.Type == html.TextNode && strings.TrimSpace(cell.Data) == "" || cell.Type == html.CommentNode {
+				continue
+			}
+			if cell.DataAtom != atom.Td && cell.DataAtom != atom.Th {
+				return nil, errors.Errorf("unsupported content <%s> of HTML table row", cell.Data)
+			}
+			if err := checkHTMLAttributes(cell, "align", "valign", "width"); err != nil {
+				return nil, err
+			}
+			if rowIndex == 0 && cell.DataAtom != atom.Th {
+				firstRowIsHeader = false
+			}
+			for _, child := range childNodes(cell) {
+				if isHTMLBlockElement(child) {
+					return nil, errors.Errorf("unsupported block content <%s> in HTML table cell", child.Data)
+				}
+			}
+			text, err := convertHTMLInlines(childNodes(cell))
+			if err != nil {
+				return nil, err
+			}
+			cells = append(cells, escapeTableCell(strings.TrimSpace(text)))
+		}
+		if len(cells) > columnCount {
+			columnCount = len(cells)
+		}
+		cellRows = append(cellRows, cells)
+	}
+
+	markdownRow := func(cells []string) string {
+		row := "|"
+		for column := 0; column < columnCount; column++ {
+			cellText := " "
+			if column < len(cells) && cells[column] != "" {
+				cellText = cells[column]
+			}
+			row = row + cellText + "|"
+		}
+		return row
+	}
+
+	lines := []string{}
+	if firstRowIsHeader {
+		lines = append(lines, markdownRow(cellRows[0]))
+		cellRows = cellRows[1:]
+	} else {
+		lines = append(lines, markdownRow(nil))
+	}
+	lines = append(lines, "|"+strings.Repeat("---|", columnCount))
+	for _, cells := range cellRows {
+		lines = append(lines, markdownRow(cells))
+	}
+	return lines, nil
+}
+
+// htmlText returns the raw text content of an HTML node
+func htmlText(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+	var builder strings.Builder
+	for _, child := range childNodes(node) {
+		if child.DataAtom == atom.Br {
+			builder.WriteString("\n")
+			continue
+		}
+		builder.WriteString(htmlText(child))
+	}
+	return builder.String()
+}
+
+// convertHTMLInlines converts a sequence of inline HTML nodes into a line of markdown
+func convertHTMLInlines(nodes []*html.Node) (string, error) {
+	var builder strings.Builder
+	for _, node := range nodes {
+		if err := writeHTMLInline(&builder, node); err != nil {
+			return "", err
+		}
+	}
+	return builder.String(), nil
+}
+
+// writeHTMLInline writes the markdown for an inline HTML node
+func writeHTMLInline(builder *strings.Builder, node *html.Node) error {
+	switch node.Type {
+	case html.TextNode:
+		builder.WriteString(htmlTextEscaper.Replace(htmlWhitespaceRegexp.ReplaceAllString(node.Data, " ")))
+		return nil
+	case html.CommentNode:
+		return nil
+	case html.ElementNode:
+	default:
+		return errors.Errorf("unsupported HTML content \"%s\"", node.Data)
+	}
+
+	if isHTMLBlockElement(node) {
+		return errors.Errorf("unsupported block element <%s> within HTML text", node.Data)
+	}
+
+	switch node.DataAtom {
+	case atom.A:
+		if err := checkHTMLAttributes(node, "href", "name"); err != nil {
+			return err
+		}
+		text, err := convertHTMLInlines(childNodes(node))
+		if err != nil {
+			return err
+		}
+		if name := htmlAttributeValue(node, "name"); name != "" {
+			builder.WriteString("<a name=\"" + name + "\"></a>")
+		}
+		if href := htmlAttributeValue(node, "href"); href != "" {
+			builder.WriteString("[" + text + "](" + href + ")")
+		} else {
+			builder.WriteString(text)
+		}
+		return nil
+	case atom.Img:
+		if err := checkHTMLAttributes(node, "src", "alt"); err != nil {
+			return err
+		}
+		builder.WriteString("![" + htmlAttributeValue(node, "alt") + "](" + htmlAttributeValue(node, "src") + ")")
+		return nil
+	case atom.Br:
+		writePageBreak(builder)
+		return nil
+	}
+
+	if err := checkHTMLAttributes(node); err != nil {
+		return err
+	}
+	switch node.DataAtom {
+	case atom.Code, atom.Tt, atom.Kbd, atom.Samp:
+		builder.WriteString(renderCodeInline(&codeInline{code: htmlWhitespaceRegexp.ReplaceAllString(htmlText(node), " ")}))
+		return nil
+	case atom.Sup, atom.Sub:
+		text, err := convertHTMLInlines(childNodes(node))
+		builder.WriteString("<" + node.Data + ">" + text + "</" + node.Data + ">")
+		return err
+	case atom.Span, atom.Font, atom.Small, atom.Big:
+		// these only affect presentation so we just keep their content
+		text, err := convertHTMLInlines(childNodes(node))
+		builder.WriteString(text)
+		return err
+	}
+
+	delimiter, found := htmlInlineDelimiters[node.DataAtom]
+	if !found {
+		return errors.Errorf("unsupported HTML element <%s>", node.Data)
+	}
+	text, err := convertHTMLInlines(childNodes(node))
+	if err != nil {
+		return err
+	}
+
+	// markdown delimiters must be adjacent to the text they enclose
+	trimmedText := strings.TrimSpace(text)
+	if trimmedText == "" {
+		builder.WriteString(text)
+		return nil
+	}
+	leadingSpace := text[:len(text)-len(strings.TrimLeft(text, " "))]
+	trailingSpace := text[len(strings.TrimRight(text, " ")):]
+	builder.WriteString(leadingSpace + delimiter + trimmedText + delimiter + trailingSpace)
+	return nil
+}
@@ -0,0 +1,221 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/stevejefferson/trac2gitea/accessor/trac"
+)
+
+// regexp for local filenames used in Images (not explicit enough to be handled as attachment: or htdocs: links)
+// must match exactly
+var localFileLinkRegexp = regexp.MustCompile(`^[[:alnum:]-._,]+\.[[:alpha:]]+$`)
+
+// regexp for Image targets which are attachments of an explicit wiki page or ticket: 'wiki:<page>:<file>', 'ticket:<ticketID>:<file>' and '#<ticketID>:<file>'
+// $1=wiki page, $2=ticketID (ticket: form), $3=ticketID (# form), $4=file
+var imageAttachmentRegexp = regexp.MustCompile(`^(?:wiki:([^:]+)|ticket:([[:digit:]]+)|#([[:digit:]]+)):([[:alnum:]\-._,]+\.[[:alpha:]]+)$`)
+
+// regexp for Image targets which are attachments of a wiki page given without a 'wiki:' prefix: '<page>:<file>'
+// $1=wiki page, $2=file
+var imagePageAttachmentRegexp = regexp.MustCompile(`^([[:alpha:]][[:alnum:]\-._/]*):([[:alnum:]\-._,]+\.[[:alpha:]]+)$`)
+
+// regexp for an Image size - a number, optionally followed by 'px' or '%': $1=number, $2=unit
+var imageSizeRegexp = regexp.MustCompile(`^([[:digit:]]+)(px|%)?$`)
+
+// regexp for an Image size given in some other unit (which HTML attributes do not support)
+var imageOtherSizeRegexp = regexp.MustCompile(`^[[:digit:]]+(?:\.[[:digit:]]+)?[[:alpha:]]+$`)
+
+// Image alignments which can be expressed with an HTML 'align' attribute
+var imageAlignments = []string{"left", "right", "top", "bottom", "middle"}
+
+// imageOptions are the options of a Trac '[[Image(...)]]' macro which we can convert
+type imageOptions struct {
+	width  string
+	height string
+	align  string
+	alt    string
+	title  string
+	link   string
+}
+
+// resolveImageAttachmentURL resolves an Image target which is an attachment of a given wiki page or ticket into a URL
+func (renderer *renderer) resolveImageAttachmentURL(image string, wikiPage string, ticketID int64, file string) string {
+	link := tracLink{kind: attachmentLink, source: image, target: file, wikiPage: wikiPage, ticketID: ticketID}
+	url, _, resolved := renderer.resolveLink(&link)
+	if !resolved {
+		return image
+	}
+	return url
+}
+
+// resolveImageURL resolves the image argument of a Trac '[[Image(...)]]' macro into a URL
+func (renderer *renderer) resolveImageURL(image string) string {
+	if localFileLinkRegexp.MatchString(image) {
+		// a local filename in a ticket is an attachment of that ticket...
+		if renderer.ticketID != trac.NullID {
+			return renderer.resolveImageAttachmentURL(image, "", renderer.ticketID, image)
+		}
+
+		// ...otherwise it is an attachment of the current wiki page
+		return renderer.converter.giteaAccessor.GetWikiAttachmentRelPath(renderer.wikiPage, image)
+	}
+
+	// 'wiki:<page>:<file>' looks like a wiki link so must be checked before any other Trac link
+	if match := imageAttachmentRegexp.FindStringSubmatch(image); match != nil {
+		ticketID := trac.NullID
+		if match[2]+match[3] != "" {
+			ticketID = parseInt64(match[2] + match[3])
+		}
+		return renderer.resolveImageAttachmentURL(image, match[1], ticketID, match[4])
+	}
+
+	if link := renderer.converter.parseLinkTarget(image); link != nil {
+		// an image from the repository must be referenced by its raw content, not the Gitea page displaying it
+		if link.kind == sourceLink {
+			link.kind = exportLink
+			link.anchor = ""
+		}
+		if url, _, resolved := renderer.resolveLink(link); resolved {
+			return url
+		}
+		return image
+	}
+
+	if match := imagePageAttachmentRegexp.FindStringSubmatch(image); match != nil {
+		return renderer.resolveImageAttachmentURL(image, match[1], trac.NullID, match[2])
+	}
+
+	return image
+}
+
+// parseImageSize parses a Trac Image width or height into the value of an HTML 'width' or 'height' attribute
+func parseImageSize(size string) (string, bool) {
+	match := imageSizeRegexp.FindStringSubmatch(size)
+	if match == nil {
+		return "", false
+	}
+	if match[2] == "%" {
+		return match[1] + "%", true
+	}
+	return match[1], true
+}
+
+// parseImageAlignment parses a Trac Image alignment into the value of an HTML 'align' attribute
+func parseImageAlignment(align string) (string, bool) {
+	align = strings.ToLower(align)
+	if containsString(imageAlignments, align) {
+		return align, true
+	}
+	return "", false
+}
+
+// parseImageOptions parses the options of a Trac '[[Image(<image>,<option>,...)]]' macro
+// - options which cannot be represented in Gitea (e.g. borders, margins and CSS classes) are dropped
+func (converter *DefaultConverter) parseImageOptions(macro *macroInline, args *macroArgs) *imageOptions {
+	options := imageOptions{}
+	for index := 1; index < len(args.positional); index++ {
+		arg := args.positional[index]
+		if size, ok := parseImageSize(arg); ok {
+			options.width = size
+		} else if align, ok := parseImageAlignment(arg); ok {
+			options.align = align
+		} else if arg == "nolink" || arg == "inline" || arg == "" {
+			// Gitea does not link images to anything by default and has no special treatment for SVG images
+		} else if imageOtherSizeRegexp.MatchString(arg) {
+			converter.warn(DroppedImageOptionDiagnostic, macro.tracText(), "dropping size \"%s\" of Trac image macro \"%s\": only pixel and percentage sizes are supported", arg, macro.tracText())
+		} else {
+			converter.warn(DroppedImageOptionDiagnostic, macro.tracText(), "dropping unsupported option \"%s\" of Trac image macro \"%s\"", arg, macro.tracText())
+		}
+	}
+
+	for _, keywordArg := range args.keywords {
+		keyword := strings.ToLower(keywordArg.keyword)
+		value := keywordArg.value
+		switch keyword {
+		case "width", "height":
+			size, ok := parseImageSize(value)
+			if !ok {
+				converter.warn(DroppedImageOptionDiagnostic, macro.tracText(), "dropping %s \"%s\" of Trac image macro \"%s\": only pixel and percentage sizes are supported", keyword, value, macro.tracText())
+			} else if keyword == "width" {
+				options.width = size
+			} else {
+				options.height = size
+			}
+		case "align", "valign":
+			if align, ok := parseImageAlignment(value); ok {
+				options.align = align
+			} else {
+				converter.warn(DroppedImageOptionDiagnostic, macro.tracText(), "dropping alignment \"%s\" of Trac image macro \"%s\": Gitea does not support it", value, macro.tracText())
+			}
+		case "alt":
+			options.alt = value
+		case "title":
+			options.title = value
+		case "link":
+			options.link = value
+		default:
+			converter.warn(DroppedImageOptionDiagnostic, macro.tracText(), "dropping unsupported option \"%s\" of Trac image macro \"%s\"", keyword, macro.tracText())
+		}
+	}
+
+	return &options
+}
+
+// writeMarkdownImage writes an image as a markdown image, optionally wrapped in a link
+func writeMarkdownImage(builder *strings.Builder, imageURL string, linkURL string, options *imageOptions) {
+	image := "![" + escapeMarkdown(options.alt) + "](" + imageURL
+	if options.title != "" {
+		image = image + " \"" + strings.ReplaceAll(options.title, "\"", "\\\"") + "\""
+	}
+	image = image + ")"
+
+	if linkURL == "" {
+		builder.WriteString(image)
+		return
+	}
+	builder.WriteString("[" + image + "](" + linkURL + ")")
+}
+
+// writeHTMLImage writes an image as an HTML <img> element, optionally wrapped in a link - this is required where the image has a size or alignment
+func writeHTMLImage(builder *strings.Builder, imageURL string, linkURL string, options *imageOptions) {
+	attributes := []htmlAttribute{{name: "src", value: imageURL}, {name: "alt", value: options.alt}}
+	for _, attribute := range []htmlAttribute{
+		{name: "title", value: options.title},
+		{name: "width", value: options.width},
+		{name: "height", value: options.height},
+		{name: "align", value: options.align},
+	} {
+		if attribute.value != "" {
+			attributes = append(attributes, attribute)
+		}
+	}
+
+	if linkURL == "" {
+		builder.WriteString(htmlStartTag("img", attributes))
+		return
+	}
+	builder.WriteString(htmlStartTag("a", []htmlAttribute{{name: "href", value: linkURL}}) + htmlStartTag("img", attributes) + "</a>")
+}
+
+// writeImageMacro writes the markdown for a Trac '[[Image(<image>,<option>,...)]]' macro
+// - images with a size or alignment are written as HTML, anything else is written as a markdown image
+func writeImageMacro(renderer *renderer, builder *strings.Builder, macro *macroInline) {
+	args := macro.parseArgs()
+	imageURL := renderer.resolveImageURL(args.arg(0))
+	options := renderer.converter.parseImageOptions(macro, args)
+
+	linkURL := ""
+	if options.link != "" {
+		linkURL = renderer.resolveURL(options.link)
+	}
+
+	if options.width == "" && options.height == "" && options.align == "" {
+		writeMarkdownImage(builder, imageURL, linkURL, options)
+		return
+	}
+	writeHTMLImage(builder, imageURL, linkURL, options)
+}
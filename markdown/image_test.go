@@ -0,0 +1,98 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown_test
+
+import (
+	"testing"
+
+	"go.uber.org/mock/gomock"
+)
+
+func setUpLocalImage(t *testing.T) {
+	setUp(t)
+
+	// expect call to get relative path of attachment within wiki repo
+	mockGiteaAccessor.
+		EXPECT().
+		GetWikiAttachmentRelPath(gomock.Eq(wikiPage), gomock.Eq(attachmentName)).
+		Return(attachmentWikiRelPath)
+}
+
+func verifyLocalImage(t *testing.T, tracImageArgs string, markdownImage string) {
+	setUpLocalImage(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"[[Image("+attachmentName+tracImageArgs+")]]"+trailingText)
+	assertEquals(t, conversion, leadingText+markdownImage+trailingText)
+}
+
+func TestLocalImageInWiki(t *testing.T) {
+	verifyLocalImage(t, "", "![]("+attachmentWikiRelPath+")")
+}
+
+func TestLocalImageInTicket(t *testing.T) {
+	verifyImageLinkTypes(t, setUpImplicitTicketAttachmentLink, tearDown, ticketConvert, attachmentName, ticketAttachmentURL)
+}
+
+func TestExplicitTicketAttachmentImage(t *testing.T) {
+	verifyImageLinkTypes(t, setUpExplicitTicketAttachmentLink, tearDown, wikiConvert, "ticket:"+otherTicketIDStr+":"+attachmentName, ticketAttachmentURL)
+}
+
+func TestTicketNumberAttachmentImage(t *testing.T) {
+	verifyImageLinkTypes(t, setUpExplicitTicketAttachmentLink, tearDown, wikiConvert, "#"+otherTicketIDStr+":"+attachmentName, ticketAttachmentURL)
+}
+
+func TestExplicitWikiAttachmentImage(t *testing.T) {
+	verifyImageLinkTypes(t, setUpExplicitWikiAttachmentLink, tearDown, ticketConvert, "wiki:"+otherWikiPage+":"+attachmentName, attachmentWikiURL)
+}
+
+func TestWikiPageAttachmentImage(t *testing.T) {
+	verifyImageLinkTypes(t, setUpExplicitWikiAttachmentLink, tearDown, ticketConvert, otherWikiPage+":"+attachmentName, attachmentWikiURL)
+}
+
+func TestImageWithPixelSize(t *testing.T) {
+	verifyLocalImage(t, ", 120px", `<img src="`+attachmentWikiRelPath+`" alt="" width="120">`)
+}
+
+func TestImageWithPercentageSize(t *testing.T) {
+	verifyLocalImage(t, ", 25%", `<img src="`+attachmentWikiRelPath+`" alt="" width="25%">`)
+}
+
+func TestImageWithWidthAndHeight(t *testing.T) {
+	verifyLocalImage(t, ", width=200px, height=100", `<img src="`+attachmentWikiRelPath+`" alt="" width="200" height="100">`)
+}
+
+func TestImageWithAlignment(t *testing.T) {
+	verifyLocalImage(t, ", right", `<img src="`+attachmentWikiRelPath+`" alt="" align="right">`)
+	verifyLocalImage(t, ", align=top", `<img src="`+attachmentWikiRelPath+`" alt="" align="top">`)
+}
+
+func TestImageWithTitleAndAltText(t *testing.T) {
+	verifyLocalImage(t, ", title=Some \"title\", alt=Some text", `![Some text](`+attachmentWikiRelPath+` "Some \"title\"")`)
+	verifyLocalImage(t, ", 50%, title=Some \"title\", alt=Some text", `<img src="`+attachmentWikiRelPath+`" alt="Some text" title="Some &quot;title&quot;" width="50%">`)
+}
+
+func TestImageWithSizeAndLink(t *testing.T) {
+	verifyLocalImage(t, ", 50%, link="+additionalImageLink, `<a href="`+additionalImageLink+`"><img src="`+attachmentWikiRelPath+`" alt="" width="50%"></a>`)
+}
+
+func TestImageDropsUnsupportedOptions(t *testing.T) {
+	verifyLocalImage(t, ", 10em, center, nolink, border=2, margin=5, class=shadow, width=3em", "![]("+attachmentWikiRelPath+")")
+}
+
+func TestImageInTicketWithWikiLink(t *testing.T) {
+	setUpWikiLink(t)
+	defer tearDown(t)
+
+	// an HTML link in an issue is not resolved relative to the repository so the wiki page is linked to by its full URL
+	wikiPageURL := "/org/repo/wiki/" + transformedWikiPageName
+	mockGiteaAccessor.
+		EXPECT().
+		GetWikiPageURL(gomock.Eq(transformedWikiPageName)).
+		Return(wikiPageURL)
+
+	conversion, _ := converter.TicketConvert(ticketID, leadingText+"[[Image("+httpLink+", 50%, link=wiki:"+wikiPageName+")]]"+trailingText)
+	assertEquals(t, conversion, leadingText+`<a href="`+wikiPageURL+`"><img src="`+httpLink+`" alt="" width="50%"></a>`+trailingText)
+}
@@ -0,0 +1,50 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"strings"
+
+	"github.com/stevejefferson/trac2gitea/accessor/trac"
+	"github.com/stevejefferson/trac2gitea/log"
+)
+
+// renderIncludeMacro renders a Trac '[[Include(<page>)]]' macro by converting the text of the included wiki page in place of the macro.
+// Only wiki pages can be included - inclusion of other resources (source files, URLs etc.) is flagged as unconverted.
+func renderIncludeMacro(renderer *renderer, macro *macroInline) {
+	pageName := strings.TrimPrefix(macro.parseArgs().arg(0), "wiki:")
+	if pageName == "" || strings.Contains(pageName, ":") {
+		renderer.renderUnconvertedMacro(macro, "not converted: only wiki pages can be included")
+		return
+	}
+
+	page, found := renderer.converter.latestWikiPages()[pageName]
+	if !found {
+		renderer.renderUnconvertedMacro(macro, "not converted: there is no such wiki page")
+		return
+	}
+
+	for _, includedPage := range renderer.includedPages {
+		if includedPage == pageName {
+			renderer.renderUnconvertedMacro(macro, "not converted: the wiki page includes itself")
+			return
+		}
+	}
+
+	log.Debug("including Trac wiki page %s in converted text", pageName)
+
+	// links within the included text are relative to the included page
+	includingPage := renderer.wikiPage
+	if renderer.ticketID == trac.NullID {
+		renderer.wikiPage = pageName
+	}
+	renderer.includedPages = append(renderer.includedPages, pageName)
+
+	renderer.addBlankLineSeparator()
+	renderer.renderBlocks(renderer.converter.parse(renderer.converter.convertEOL(page.Text)))
+
+	renderer.includedPages = renderer.includedPages[:len(renderer.includedPages)-1]
+	renderer.wikiPage = includingPage
+}
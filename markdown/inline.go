@@ -0,0 +1,98 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// inlineParser parses a single line of Trac wiki text into inlines
+type inlineParser struct {
+	converter  *DefaultConverter
+	text       string
+	inLinkText bool
+	nodes      []inline
+}
+
+// parseInlines parses a single line of Trac wiki text into a sequence of inlines
+func (converter *DefaultConverter) parseInlines(text string) []inline {
+	parser := inlineParser{converter: converter, text: text}
+	parser.parse()
+	return nestFontStyles(parser.nodes)
+}
+
+func (parser *inlineParser) parse() {
+	textStart := 0
+	pos := 0
+	for pos < len(parser.text) {
+		node, length := parser.matchAt(pos, false)
+		if node == nil {
+			pos++
+			continue
+		}
+
+		parser.addText(parser.text[textStart:pos])
+		parser.nodes = append(parser.nodes, node)
+		pos += length
+		textStart = pos
+	}
+	parser.addText(parser.text[textStart:])
+}
+
+// addText adds a span of literal text to the parsed inlines
+func (parser *inlineParser) addText(text string) {
+	if text != "" {
+		parser.nodes = append(parser.nodes, &textInline{text: text})
+	}
+}
+
+// matchAt attempts to match an inline construct at a given position of the text being parsed, returning the parsed inline and its length.
+// If the construct has been escaped with a Trac '!' we only need to know the extent of the construct and not whether it is at the start of a word.
+// Returns a nil inline if there is no construct at the position.
+func (parser *inlineParser) matchAt(pos int, escaped bool) (inline, int) {
+	s := parser.text[pos:]
+	switch s[0] {
+	case '!':
+		if !escaped {
+			return parser.matchEscape(pos)
+		}
+	case '{':
+		if node, length := matchInlineCode(s); node != nil {
+			return node, length
+		}
+		return parser.matchReportShorthand(s)
+	case '`':
+		return matchBacktickCode(s)
+	case '[':
+		return parser.matchBracket(s)
+	case '\'', '*', '/', '_', '~', '^', ',':
+		return matchFontStyleDelimiter(s)
+	default:
+		if pos == 0 && s[0] == 'R' {
+			if node, length := parser.matchReplyPreamble(s); node != nil {
+				return node, length
+			}
+		}
+		if s[0] < utf8.RuneSelf && isAlphanumeric(rune(s[0])) {
+			var prevRune rune
+			if pos > 0 && !escaped {
+				prevRune, _ = utf8.DecodeLastRuneInString(parser.text[:pos])
+			}
+			if node, length := parser.matchUnbracketedLink(s, prevRune); node != nil {
+				return node, length
+			}
+			// Trac user names are converted into mentions only if they are not part of a link
+			return parser.matchMention(pos, prevRune)
+		}
+	}
+
+	return nil, 0
+}
+
+// isAlphanumeric returns true if a character is a letter or a digit
+func isAlphanumeric(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// regexp for trac InterTrac '<prefix>:ticket:<ticketID>', '<prefix>:#<ticketID>' and '<prefix>:wiki:<page>#<anchor>' links:
+// $1=prefix, $2=ticketID, $3=page, $4=anchor
+var interTracLinkRegexp = regexp.MustCompile(
+	`^([[:alpha:]][[:alnum:]\-_]*):` +
+		`(?:` +
+		`(?:(?:ticket:|#)([[:digit:]]+))|` +
+		`(?:wiki:([[:alnum:]:\-._&'/]*[[:alnum:]])(?:#([[:alnum:]?/:@\-._\~!$&'*+,;=]+))?)` +
+		`)`)
+
+// SetInterTracMap provides the converter with a map of Trac environment name onto the Gitea repository (owned by the same user) into which that environment has been imported.
+// The environment names used here are those defined in the '[intertrac]' section of the Trac config - any InterTrac aliases for the environment will also be recognised.
+func (converter *DefaultConverter) SetInterTracMap(interTracMap map[string]string) {
+	converter.interTracRepos = make(map[string]string)
+	for environmentName, repoName := range interTracMap {
+		converter.interTracRepos[strings.ToLower(environmentName)] = repoName
+	}
+
+	for prefix, environmentName := range converter.tracAccessor.GetInterTracPrefixes() {
+		if repoName, found := converter.interTracRepos[environmentName]; found {
+			converter.interTracRepos[prefix] = repoName
+		}
+	}
+}
+
+// matchInterTracLink matches an InterTrac link at the start of a string, returning the link and its length or a nil link if there is no link.
+// Only links with an InterTrac prefix we know about are matched - anything else could be part of some other text.
+func (converter *DefaultConverter) matchInterTracLink(s string, unbracketed bool) (*tracLink, int) {
+	if len(converter.interTracRepos) == 0 {
+		return nil, 0
+	}
+
+	match := matchWithoutTrailingPunctuation(interTracLinkRegexp, s, unbracketed)
+	if match == nil {
+		return nil, 0
+	}
+	prefix := submatch(s, match, 1)
+	if _, found := converter.interTracRepos[strings.ToLower(prefix)]; !found {
+		return nil, 0
+	}
+
+	if ticketIDStr := submatch(s, match, 2); ticketIDStr != "" {
+		return &tracLink{kind: interTracTicketLink, source: s[:match[1]], interTracPrefix: prefix, ticketID: parseInt64(ticketIDStr)}, match[1]
+	}
+	return &tracLink{kind: interTracWikiLink, source: s[:match[1]], interTracPrefix: prefix, target: submatch(s, match, 3), anchor: submatch(s, match, 4)}, match[1]
+}
+
+func (converter *DefaultConverter) resolveInterTracLink(link *tracLink) (string, string, bool) {
+	repoName := converter.interTracRepos[strings.ToLower(link.interTracPrefix)]
+
+	// use the InterTrac reference as the default link text
+	if link.kind == interTracTicketLink {
+		linkText := link.interTracPrefix + ":#" + strconv.FormatInt(link.ticketID, 10)
+		return converter.giteaAccessor.GetRepoIssueURL(repoName, converter.interTracIssueIndex(repoName, link.ticketID)), linkText, true
+	}
+
+	translatedPageName := converter.giteaAccessor.TranslateWikiPageName(link.target)
+	var suffix string
+	if link.anchor != "" {
+		suffix = "#" + link.anchor
+	}
+	linkText := link.interTracPrefix + ":" + translatedPageName + suffix
+	return converter.giteaAccessor.GetRepoWikiURL(repoName, translatedPageName) + suffix, linkText, true
+}
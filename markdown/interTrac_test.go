@@ -0,0 +1,169 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown_test
+
+import (
+	"testing"
+
+	"go.uber.org/mock/gomock"
+)
+
+const (
+	interTracEnvironment    = "othertrac"
+	interTracAlias          = "ot"
+	interTracRepo           = "other-repo"
+	interTracTicketID       = int64(4321)
+	interTracTicketIDStr    = "4321"
+	interTracIssueURL       = "url-of-issue-in-other-repo"
+	interTracIssueReference = "user/other-repo#4321"
+	interTracWikiURL        = "url-of-wiki-page-in-other-repo"
+)
+
+func setUpInterTrac(t *testing.T) {
+	setUp(t)
+
+	// expect converter to retrieve InterTrac prefixes from Trac config
+	mockTracAccessor.
+		EXPECT().
+		GetInterTracPrefixes().
+		Return(map[string]string{interTracEnvironment: interTracEnvironment, interTracAlias: interTracEnvironment})
+
+	converter.SetInterTracMap(map[string]string{interTracEnvironment: interTracRepo})
+}
+
+func setUpInterTracTicketLink(t *testing.T) {
+	setUpInterTrac(t)
+
+	// expect call to lookup URL of issue in other repository
+	mockGiteaAccessor.
+		EXPECT().
+		GetRepoIssueURL(gomock.Eq(interTracRepo), gomock.Eq(interTracTicketID)).
+		Return(interTracIssueURL)
+
+	// expect call to lookup Gitea reference to issue in other repository where the link has no text
+	mockGiteaAccessor.
+		EXPECT().
+		GetRepoIssueReference(gomock.Eq(interTracRepo), gomock.Eq(interTracTicketID)).
+		Return(interTracIssueReference).
+		AnyTimes()
+}
+
+func TestInterTracTicketLink(t *testing.T) {
+	verifyAllTicketLinkTypes(
+		t,
+		setUpInterTracTicketLink,
+		tearDown,
+		wikiConvert,
+		interTracEnvironment+":ticket:"+interTracTicketIDStr,
+		interTracIssueURL,
+		interTracEnvironment+":#"+interTracTicketIDStr,
+		interTracIssueReference)
+}
+
+func TestInterTracHashTicketLink(t *testing.T) {
+	verifyAllTicketLinkTypes(
+		t,
+		setUpInterTracTicketLink,
+		tearDown,
+		ticketConvert,
+		interTracEnvironment+":#"+interTracTicketIDStr,
+		interTracIssueURL,
+		interTracEnvironment+":#"+interTracTicketIDStr,
+		interTracIssueReference)
+}
+
+func TestInterTracAliasTicketLink(t *testing.T) {
+	verifyAllTicketLinkTypes(
+		t,
+		setUpInterTracTicketLink,
+		tearDown,
+		wikiConvert,
+		interTracAlias+":ticket:"+interTracTicketIDStr,
+		interTracIssueURL,
+		interTracAlias+":#"+interTracTicketIDStr,
+		interTracIssueReference)
+}
+
+const (
+	interTracIssueIndex          = int64(99)
+	interTracIndexIssueReference = "user/other-repo#99"
+)
+
+func setUpInterTracTicketLinkWithIssueIndexMap(t *testing.T) {
+	setUpInterTrac(t)
+
+	converter.SetInterTracIssueIndexMaps(map[string]map[int64]int64{interTracRepo: {interTracTicketID: interTracIssueIndex}})
+
+	// expect call to lookup URL of issue in other repository using the issue index mapped from the ticket ID
+	mockGiteaAccessor.
+		EXPECT().
+		GetRepoIssueURL(gomock.Eq(interTracRepo), gomock.Eq(interTracIssueIndex)).
+		Return(interTracIssueURL)
+
+	mockGiteaAccessor.
+		EXPECT().
+		GetRepoIssueReference(gomock.Eq(interTracRepo), gomock.Eq(interTracIssueIndex)).
+		Return(interTracIndexIssueReference).
+		AnyTimes()
+}
+
+func TestInterTracTicketLinkWithIssueIndexMap(t *testing.T) {
+	verifyAllTicketLinkTypes(
+		t,
+		setUpInterTracTicketLinkWithIssueIndexMap,
+		tearDown,
+		wikiConvert,
+		interTracEnvironment+":ticket:"+interTracTicketIDStr,
+		interTracIssueURL,
+		interTracEnvironment+":#"+interTracTicketIDStr,
+		interTracIndexIssueReference)
+}
+
+func setUpInterTracWikiLink(t *testing.T) {
+	setUpInterTrac(t)
+
+	// expect call to translate name of wiki page
+	mockGiteaAccessor.
+		EXPECT().
+		TranslateWikiPageName(gomock.Eq(wikiPageName)).
+		Return(transformedWikiPageName)
+
+	// expect call to lookup URL of wiki page in other repository
+	mockGiteaAccessor.
+		EXPECT().
+		GetRepoWikiURL(gomock.Eq(interTracRepo), gomock.Eq(transformedWikiPageName)).
+		Return(interTracWikiURL)
+}
+
+func TestInterTracWikiLink(t *testing.T) {
+	verifyAllLinkTypes(
+		t,
+		setUpInterTracWikiLink,
+		tearDown,
+		wikiConvert,
+		interTracEnvironment+":wiki:"+wikiPageName,
+		interTracWikiURL,
+		interTracEnvironment+":"+transformedWikiPageName)
+}
+
+func TestInterTracWikiLinkWithAnchor(t *testing.T) {
+	verifyAllLinkTypes(
+		t,
+		setUpInterTracWikiLink,
+		tearDown,
+		wikiConvert,
+		interTracEnvironment+":wiki:"+wikiPageName+"#"+wikiPageAnchor,
+		interTracWikiURL+"#"+wikiPageAnchor,
+		interTracEnvironment+":"+transformedWikiPageName+"#"+wikiPageAnchor)
+}
+
+func TestUnknownInterTracPrefix(t *testing.T) {
+	setUpInterTrac(t)
+	defer tearDown(t)
+
+	unknownLink := "unknowntrac:#" + interTracTicketIDStr
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+" "+unknownLink+" "+trailingText)
+	assertEquals(t, conversion, leadingText+" "+unknownLink+" "+trailingText)
+}
@@ -0,0 +1,119 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+// regexp for trac InterWiki '<prefix>:<target>' and '<prefix>:"<target>"' links: $1=prefix, $2=quoted target, $3=unquoted target
+var interWikiLinkRegexp = regexp.MustCompile(`^([[:alpha:]][[:alnum:]\-+._]*):(?:"([^"]+)"|([^\s"<>\[\]|]+))`)
+
+// regexp for a '$<n>' placeholder in an InterWiki URL
+var interWikiArgRegexp = regexp.MustCompile(`\$[[:digit:]]`)
+
+// LoadInterWikiMap loads the InterWiki prefixes defined in Trac, so that links using these prefixes can be expanded into URLs.
+func (converter *DefaultConverter) LoadInterWikiMap() error {
+	interWikiPrefixes, err := converter.tracAccessor.GetInterWikiPrefixes()
+	if err != nil {
+		return err
+	}
+
+	converter.interWikiPrefixes = interWikiPrefixes
+	return nil
+}
+
+// matchInterWikiLink matches an InterWiki link at the start of a string, returning the link and its length or a nil link if there is no link.
+// Only links with an InterWiki prefix defined in Trac are matched - anything else could be part of some other text.
+func (converter *DefaultConverter) matchInterWikiLink(s string, unbracketed bool) (*tracLink, int) {
+	if len(converter.interWikiPrefixes) == 0 {
+		return nil, 0
+	}
+
+	// the closing quote of a quoted target is part of the link, not trailing punctuation
+	match := interWikiLinkRegexp.FindStringSubmatchIndex(s)
+	if match != nil && match[4] == -1 {
+		match = matchWithoutTrailingPunctuation(interWikiLinkRegexp, s, unbracketed)
+	}
+	if match == nil {
+		return nil, 0
+	}
+	prefix := submatch(s, match, 1)
+	if _, found := converter.interWikiPrefixes[strings.ToLower(prefix)]; !found {
+		return nil, 0
+	}
+
+	return &tracLink{kind: interWikiLink, source: s[:match[1]], interWikiPrefix: prefix, target: submatch(s, match, 2) + submatch(s, match, 3)}, match[1]
+}
+
+// splitURL splits a URL into its path, query (including any leading '?') and fragment (including any leading '#')
+func splitURL(url string) (string, string, string) {
+	fragment := ""
+	if hashPos := strings.Index(url, "#"); hashPos != -1 {
+		url, fragment = url[:hashPos], url[hashPos:]
+	}
+	query := ""
+	if queryPos := strings.Index(url, "?"); queryPos != -1 {
+		url, query = url[:queryPos], url[queryPos:]
+	}
+	return url, query, fragment
+}
+
+// expandInterWikiArgs replaces the '$<n>' placeholders in an InterWiki URL with the corresponding parts of a link target
+func expandInterWikiArgs(url string, args []string) string {
+	return interWikiArgRegexp.ReplaceAllStringFunc(url, func(placeholder string) string {
+		argNum := int(placeholder[1] - '0')
+		if argNum > 0 && argNum <= len(args) {
+			return args[argNum-1]
+		}
+		return ""
+	})
+}
+
+// expandInterWikiURL expands an InterWiki URL for a link target following Trac's rules:
+// the target is split at ':'s into as many parts as there are placeholders and each part substituted for its placeholder,
+// or the target is appended to the URL if it has no placeholders; any query or fragment of the target is merged into the URL.
+func expandInterWikiURL(url string, target string) string {
+	maxArgNum := 0
+	for _, placeholder := range interWikiArgRegexp.FindAllString(url, -1) {
+		if argNum := int(placeholder[1] - '0'); argNum > maxArgNum {
+			maxArgNum = argNum
+		}
+	}
+
+	targetPath, targetQuery, targetFragment := splitURL(target)
+	args := []string{targetPath}
+	if maxArgNum > 0 {
+		args = strings.SplitN(targetPath, ":", maxArgNum)
+	}
+	expandedURL := expandInterWikiArgs(url, args)
+	if expandedURL == url {
+		expandedURL = url + args[0]
+	}
+
+	path, query, fragment := splitURL(expandedURL)
+	switch {
+	case targetQuery != "" && query != "":
+		query = query + "&" + targetQuery[1:]
+	case targetQuery != "":
+		query = targetQuery
+	}
+	if targetFragment != "" {
+		fragment = targetFragment
+	}
+	return path + query + fragment
+}
+
+// resolveInterWikiLink resolves an InterWiki link into a URL using the URL defined for its prefix
+func (converter *DefaultConverter) resolveInterWikiLink(link *tracLink) (string, string, bool) {
+	interWikiPrefix := converter.interWikiPrefixes[strings.ToLower(link.interWikiPrefix)]
+
+	// spaces can only appear in quoted targets but are not valid in a markdown link URL
+	target := strings.ReplaceAll(link.target, " ", "%20")
+
+	// use the InterWiki reference as the default link text
+	return expandInterWikiURL(interWikiPrefix.URL, target), link.source, true
+}
@@ -0,0 +1,113 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown_test
+
+import (
+	"testing"
+
+	"github.com/stevejefferson/trac2gitea/accessor/trac"
+)
+
+const (
+	interWikiPrefix          = "PEP"
+	interWikiURL             = "http://www.example.com/peps/pep-$1.html"
+	interWikiAppendPrefix    = "MeatBall"
+	interWikiAppendURL       = "http://www.example.com/wiki/"
+	interWikiTwoArgPrefix    = "tracchangeset"
+	interWikiTwoArgURL       = "http://www.example.com/changeset/$1/$2"
+	interWikiQueryPrefix     = "jira"
+	interWikiQueryURL        = "http://www.example.com/browse/$1?focus=true#details"
+	interWikiTarget          = "0008"
+	interWikiCamelCaseTarget = "FrontPage"
+)
+
+func setUpInterWiki(t *testing.T) {
+	setUp(t)
+
+	// expect converter to retrieve InterWiki prefixes from Trac
+	mockTracAccessor.
+		EXPECT().
+		GetInterWikiPrefixes().
+		Return(map[string]*trac.InterWikiPrefix{
+			"pep":           {Prefix: interWikiPrefix, URL: interWikiURL},
+			"meatball":      {Prefix: interWikiAppendPrefix, URL: interWikiAppendURL},
+			"tracchangeset": {Prefix: interWikiTwoArgPrefix, URL: interWikiTwoArgURL},
+			"jira":          {Prefix: interWikiQueryPrefix, URL: interWikiQueryURL},
+		}, nil)
+
+	converter.LoadInterWikiMap()
+}
+
+func TestInterWikiLink(t *testing.T) {
+	verifyAllLinkTypes(
+		t,
+		setUpInterWiki,
+		tearDown,
+		wikiConvert,
+		interWikiPrefix+":"+interWikiTarget,
+		"http://www.example.com/peps/pep-0008.html",
+		interWikiPrefix+":"+interWikiTarget)
+}
+
+func TestInterWikiLinkPrefixIsCaseInsensitive(t *testing.T) {
+	verifyAllLinkTypes(
+		t,
+		setUpInterWiki,
+		tearDown,
+		ticketConvert,
+		"pep:"+interWikiTarget,
+		"http://www.example.com/peps/pep-0008.html",
+		"pep:"+interWikiTarget)
+}
+
+func TestInterWikiLinkWithCamelCasePrefix(t *testing.T) {
+	verifyAllLinkTypes(
+		t,
+		setUpInterWiki,
+		tearDown,
+		wikiConvert,
+		interWikiAppendPrefix+":"+interWikiCamelCaseTarget,
+		interWikiAppendURL+interWikiCamelCaseTarget,
+		interWikiAppendPrefix+":"+interWikiCamelCaseTarget)
+}
+
+func TestInterWikiLinkWithMultipleArguments(t *testing.T) {
+	verifyAllLinkTypes(
+		t,
+		setUpInterWiki,
+		tearDown,
+		wikiConvert,
+		interWikiTwoArgPrefix+":1234:trunk",
+		"http://www.example.com/changeset/1234/trunk",
+		interWikiTwoArgPrefix+":1234:trunk")
+}
+
+func TestInterWikiLinkWithQueryAndFragment(t *testing.T) {
+	verifyAllLinkTypes(
+		t,
+		setUpInterWiki,
+		tearDown,
+		wikiConvert,
+		interWikiQueryPrefix+":ABC-12?page=comments#c3",
+		"http://www.example.com/browse/ABC-12?focus=true&page=comments#c3",
+		interWikiQueryPrefix+":ABC-12?page=comments#c3")
+}
+
+func TestInterWikiLinkWithQuotedTarget(t *testing.T) {
+	quotedLink := interWikiAppendPrefix + ":\"Front Page\""
+	quotedURL := interWikiAppendURL + "Front%20Page"
+	verifyLink(t, setUpInterWiki, tearDown, wikiConvert, tracPlainLink(quotedLink), markdownLinkWithText(quotedURL, quotedLink), false)
+	verifyLink(t, setUpInterWiki, tearDown, wikiConvert, tracDoubleBracketLink(quotedLink), markdownLinkWithText(quotedURL, quotedLink), true)
+	verifyLink(t, setUpInterWiki, tearDown, wikiConvert, tracDoubleBracketLinkWithText(quotedLink, linkText), markdownLinkWithText(quotedURL, linkText), false)
+}
+
+func TestUnknownInterWikiPrefix(t *testing.T) {
+	setUpInterWiki(t)
+	defer tearDown(t)
+
+	unknownLink := "unknownwiki:" + interWikiTarget
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+" "+unknownLink+" "+trailingText)
+	assertEquals(t, conversion, leadingText+" "+unknownLink+" "+trailingText)
+}
@@ -0,0 +1,37 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+// SetIssueIndexMap provides the converter with the map of Trac ticket ID onto the index of the Gitea issue into which that ticket is imported.
+// Links to any ticket not in the map are converted into links to the Gitea issue whose index is the same as the ticket ID.
+// The map is not copied so any entries added to it after this call will also be used.
+func (converter *DefaultConverter) SetIssueIndexMap(issueIndexMap map[int64]int64) {
+	converter.issueIndexes = issueIndexMap
+}
+
+// issueIndex returns the index of the Gitea issue corresponding to a given Trac ticket
+func (converter *DefaultConverter) issueIndex(ticketID int64) int64 {
+	if issueIndex, found := converter.issueIndexes[ticketID]; found {
+		return issueIndex
+	}
+
+	return ticketID
+}
+
+// SetInterTracIssueIndexMaps provides the converter with the map of Trac ticket ID onto Gitea issue index for the Gitea repository of each InterTrac environment.
+// Links to any ticket of an environment not in its map are converted into links to the Gitea issue whose index is the same as the ticket ID.
+// The maps are not copied so any entries (or repositories) added to them after this call will also be used.
+func (converter *DefaultConverter) SetInterTracIssueIndexMaps(issueIndexMaps map[string]map[int64]int64) {
+	converter.interTracIssueIndexes = issueIndexMaps
+}
+
+// interTracIssueIndex returns the index of the Gitea issue corresponding to a given Trac ticket of the InterTrac environment imported into a given Gitea repository
+func (converter *DefaultConverter) interTracIssueIndex(repoName string, ticketID int64) int64 {
+	if issueIndex, found := converter.interTracIssueIndexes[repoName][ticketID]; found {
+		return issueIndex
+	}
+
+	return ticketID
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown_test
+
+import (
+	"strconv"
+	"testing"
+
+	"go.uber.org/mock/gomock"
+)
+
+const (
+	remappedIssueIndex int64 = 8765
+)
+
+func setUpRemappedTicketLink(t *testing.T) {
+	setUp(t)
+
+	converter.SetIssueIndexMap(map[int64]int64{ticketID: remappedIssueIndex})
+
+	// expect call to lookup gitea issue for remapped trac ticket
+	mockGiteaAccessor.
+		EXPECT().
+		GetIssueID(gomock.Eq(remappedIssueIndex)).
+		Return(issueID, nil)
+}
+
+func setUpRemappedTicketOnlyLink(t *testing.T) {
+	setUpRemappedTicketLink(t)
+
+	// expect call to lookup gitea issue URL using remapped issue index
+	mockGiteaAccessor.
+		EXPECT().
+		GetIssueURL(gomock.Eq(remappedIssueIndex)).
+		Return(issueURL)
+}
+
+func TestRemappedTicketLink(t *testing.T) {
+	verifyAllTicketLinkTypes(
+		t,
+		setUpRemappedTicketOnlyLink,
+		tearDown,
+		wikiConvert,
+		"ticket:"+ticketIDStr,
+		issueURL,
+		issueURL,
+		"#"+strconv.FormatInt(remappedIssueIndex, 10))
+}
+
+func setUpRemappedTicketCommentLink(t *testing.T) {
+	setUpRemappedTicketLink(t)
+
+	// expect a call to lookup text of trac comment using original ticket ID
+	mockTracAccessor.
+		EXPECT().
+		GetTicketCommentTime(gomock.Eq(ticketID), gomock.Eq(tracCommentNum)).
+		Return(commentTime, nil)
+
+	// expect call to lookup gitea ID for trac comment
+	mockGiteaAccessor.
+		EXPECT().
+		GetIssueCommentIDByTime(gomock.Eq(issueID), gomock.Eq(commentTime)).
+		Return(commentID, nil)
+
+	// expect call to lookup URL of gitea comment using remapped issue index
+	mockGiteaAccessor.
+		EXPECT().
+		GetIssueCommentURL(gomock.Eq(remappedIssueIndex), gomock.Eq(commentID)).
+		Return(commentURL)
+}
+
+func TestRemappedTicketCommentLink(t *testing.T) {
+	verifyAllLinkTypes(
+		t,
+		setUpRemappedTicketCommentLink,
+		tearDown,
+		ticketConvert,
+		"comment:"+tracCommentNumStr,
+		commentURL,
+		"comment:"+commentIDStr)
+}
+
+func setUpRemappedTicketAttachmentLink(t *testing.T) {
+	setUpRemappedTicketLink(t)
+
+	// expect call to get UUID of attachment
+	mockGiteaAccessor.
+		EXPECT().
+		GetIssueAttachmentUUID(gomock.Eq(issueID), gomock.Eq(attachmentName)).
+		Return(ticketAttachmentUUID, nil)
+
+	// expect call to lookup URL for attachment file
+	mockGiteaAccessor.
+		EXPECT().
+		GetIssueAttachmentURL(gomock.Eq(issueID), gomock.Eq(ticketAttachmentUUID)).
+		Return(ticketAttachmentURL)
+}
+
+func TestRemappedTicketAttachmentLink(t *testing.T) {
+	verifyAllLinkTypes(
+		t,
+		setUpRemappedTicketAttachmentLink,
+		tearDown,
+		ticketConvert,
+		"attachment:"+attachmentName,
+		ticketAttachmentURL,
+		"attachment:"+attachmentName)
+}
@@ -8,484 +8,707 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/stevejefferson/trac2gitea/accessor/gitea"
 	"github.com/stevejefferson/trac2gitea/accessor/trac"
 	"github.com/stevejefferson/trac2gitea/log"
 )
 
+// Trac link regexps: these are all anchored to the start of the text at the current parse position
 var (
-	// regexp for trac '[[Image(<image>...,link=<link>)]]': $1=image, $2=link
-	doubleBracketImageLinkRegexp = regexp.MustCompile(`\[\[Image\(([^,)]+)(?:, *link=([[:alnum:]\-._~:/?#@!$&'"(*+;%=]+))?[^\]]*\]\]`)
+	// regexp for trac '[<link>]' and '[<link> <text>]' - a quoted part of the link may contain spaces: $1=link, $2=text
+	singleBracketLinkRegexp = regexp.MustCompile(`^\[([[:alpha:]#](?:"[^"\]]*"|[^ \]])*)(?: +([^\]]+))?\]`)
 
-	// regexp for trac '[[<link>]]' and '[[<link>|<text>]]': $1=link, $2=text
-	doubleBracketLinkRegexp = regexp.MustCompile(`\[\[([[:alpha:]][^|\]]*)(?:\|([^\]]+))?\]\]`)
+	// regexp for trac '[[...]]': $1=contents
+	doubleBracketRegexp = regexp.MustCompile(`^\[\[([^\]]*)\]\]`)
 
-	// regexp for trac '[<link>]' and '[<link> <text>]': $1=link, $2=text
-	// note: trac image and double bracket links are processed before this so we do not need to exclude a leading '[' in the regexp
-	singleBracketLinkRegexp = regexp.MustCompile(`\[([[:alpha:]][^ \]]*)(?: +([^\]]+))?\]`)
+	// regexp for contents of trac '[[<link>]]' and '[[<link>|<text>]]': $1=link, $2=text
+	doubleBracketLinkRegexp = regexp.MustCompile(`^([[:alpha:]#][^|]*)(?:\|(.+))?$`)
 
 	// regexp for 'http://...' and 'https://...' links
-	httpLinkRegexp = regexp.MustCompile(`https?://[[:alnum:]\-._~:/?#@!$&'"()*+,;%=]*[[:alnum:]/]`)
+	httpLinkRegexp = regexp.MustCompile(`^https?://[[:alnum:]\-._~:/?#@!$&'"()*+,;%=^]*[[:alnum:]/]`)
 
 	// regexp for trac 'htdocs:<link>': $1=link
-	htdocsLinkRegexp = regexp.MustCompile(`htdocs:([[:alnum:]\-._~:/?#@!$&'"()*+,;%=]+)`)
-
-	// regexp for local filenames used in Images (not explicit enough to be handled as attachment: or htdocs: links)
-	// must match exactly
-	localFileLinkRegexp = regexp.MustCompile(`^[[:alnum:]-._,]+\.[[:alpha:]]+$`)
+	htdocsLinkRegexp = regexp.MustCompile(`^htdocs:([[:alnum:]\-._~:/?#@!$&'"()*+,;%=]+)`)
 
 	// regexp for a trac 'comment:<commentNum>' and 'comment:<commentNum>:ticket:<ticketID>' link: $1=commentNum, $2=ticketID
-	ticketCommentLinkRegexp = regexp.MustCompile(`(.{0,1})comment:([[:digit:]]+)(?::ticket:([[:digit:]]+))?`)
+	ticketCommentLinkRegexp = regexp.MustCompile(`^comment:([[:digit:]]+)(?::ticket:([[:digit:]]+))?`)
 
 	// regexp for a trac 'milestone:<milestoneName>' link: $1=milestoneName
-	milestoneLinkRegexp = regexp.MustCompile(`(.{0,1})milestone:([[:alnum:]\-._~:/?#@!$&'"()*+,;%=]+)`)
+	milestoneLinkRegexp = regexp.MustCompile(`^milestone:([[:alnum:]\-._~:/?#@!$&'"()*+,;%=]+)`)
 
 	// regexp for a trac 'attachment:<file>', 'attachment:<file>:wiki:<pageName>' and 'attachment:<file>:ticket:<ticketID>' links: $1=file, $2=pageName, $3=ticketID
 	attachmentLinkRegexp = regexp.MustCompile(
-		`(.{0,1})attachment:([[:alnum:]\-._~/?#@!$&'"()*+,;%=]+)` +
+		`^attachment:([[:alnum:]\-._~/?#@!$&'"()*+,;%=]+)` +
 			`(?:` +
 			`(?::wiki:((?:[[:upper:]][[:lower:]]*)+))|` +
 			`(?::ticket:([[:digit:]]+))` +
 			`)?`)
 
-	// regexp for a trac 'changeset:<changesetID>' link: $1=commitID
-	changesetLinkRegexp = regexp.MustCompile(`changeset:"([[:xdigit:]]+)/[^"]+"`)
+	// regexp for a trac 'changeset:<revision>', 'changeset:<revision>/<path>' and 'changeset:"<revision>/<repository>"' link: $1=quoted revision, $2=unquoted revision
+	changesetLinkRegexp = regexp.MustCompile(`^changeset:(?:"([[:xdigit:]]+)(?:/[^"]*)?"|([[:xdigit:]]+)(?:/[[:alnum:]\-._~/]*[[:alnum:]])?)`)
+
+	// regexp for a trac 'r<revision>' and 'r<revision>:<revision>' link: $1=revision, $2=end revision
+	revisionLinkRegexp = regexp.MustCompile(`^r([[:digit:]]+)(?:[:-]r?([[:digit:]]+))?`)
+
+	// regexp for a trac '[<revision>]' and '[<revision>:<revision>]' changeset link: $1=revision, $2=end revision
+	revisionBracketLinkRegexp = regexp.MustCompile(`^\[([[:xdigit:]]+)(?::([[:xdigit:]]+))?(?:/[^\]\s]*)?\]`)
+
+	// regexp for a trac 'log:<path>@<revision>' and 'log:<path>@<revision>:<revision>' link: $1=revision, $2=end revision
+	logLinkRegexp = regexp.MustCompile(`^log:[^@\s\]]*@([[:xdigit:]]+)(?:[:-]([[:xdigit:]]+))?`)
+
+	// regexp for a trac 'diff:<path>@<revision>:<revision>' link: $1=revision, $2=end revision
+	diffLinkRegexp = regexp.MustCompile(`^diff:[^@\s\]]*@([[:xdigit:]]+):([[:xdigit:]]+)`)
+
+	// regexp for a trac 'query:<query>' link: $1=query
+	queryLinkRegexp = regexp.MustCompile(`^query:(\??[[:alnum:]\-._~:/?#@!$&'"()*+,;%=|^]*)`)
 
-	// regexp for a trac 'source:<sourcePath>' link: $1=sourcePath
-	sourceLinkRegexp = regexp.MustCompile(`source:"[^/]+/([^"]+)"`)
+	// regexp for a trac 'report:<reportID>' link: $1=reportID
+	reportLinkRegexp = regexp.MustCompile(`^report:([[:digit:]]+)`)
 
-	// regexp for a trac 'ticket:<ticketID>' link: $1=ticketID
-	ticketLinkRegexp = regexp.MustCompile(`ticket:([[:digit:]]+)`)
+	// regexp for a trac '{<reportID>}' report link: $1=reportID
+	reportShorthandLinkRegexp = regexp.MustCompile(`^\{([[:digit:]]+)\}`)
+
+	// regexp for a trac 'ticket:<ticketID>' and 'ticket:<ticketID>#comment:<commentNum>' link: $1=ticketID, $2=commentNum
+	ticketLinkRegexp = regexp.MustCompile(`^ticket:([[:digit:]]+)(?:#comment:([[:digit:]]+))?`)
+
+	// regexp for trac '#<anchor>' links to an anchor on the current wiki page (only recognised in brackets): $1=anchor
+	pageAnchorLinkRegexp = regexp.MustCompile(`^#([[:alnum:]?/:@\-._\~!$&'*+,;=]+)$`)
 
 	// regexp for trac 'wiki:<page>#<anchor>' links: $1=page $2=anchor
 	// note: page does not need to be in proper CamelCase in this variant, but its last character should be alphanumeric
-	wikiLinkRegexp = regexp.MustCompile(`wiki:([[:alnum:]:\-._&']*[[:alnum:]])(?:#([[:alnum:]?/:@\-._\~!$&'*+,;=]+))?`)
+	wikiLinkRegexp = regexp.MustCompile(`^wiki:([[:alnum:]:\-._&'/]*[[:alnum:]])(?:#([[:alnum:]?/:@\-._\~!$&'*+,;=]+))?`)
 
-	// regexp for trac '<CamelCase>#anchor' wiki links: $1=leading char, $2=CamelCase $3=anchor
-	// note: leading char (if any) must be a space or ']'
-	//       - a space constitutes a "start of word" for an "standalone", unbracketted CamelCase link,
-	//       - a zero-width space \u200B might be marking a removed opening square bracket from trac syntax
-	//       - a ']' constitutes the end of the link comment after conversion of the various trac bracketting syntaxes above
-	wikiCamelCaseLinkRegexp = regexp.MustCompile(`([[:space:]\x{200B}\]]|\A)((?:[[:upper:]][[:lower:]]+){2,})(?:#([[:alnum:]?/:@\-._\~!$&'()*+,;=]+))?`)
+	// regexp for trac '<CamelCase>#anchor' wiki links: $1=CamelCase $2=anchor
+	wikiCamelCaseLinkRegexp = regexp.MustCompile(`^((?:[[:upper:]][[:lower:]]+){2,})(?:#([[:alnum:]?/:@\-._\~!$&'()*+,;=]+))?`)
+)
 
-	// regexp for recognising a "marked" link with no accompanying text: $1=leading char, $2=link
-	noTextMarkedLinkRegexp = regexp.MustCompile(`([^\]])\(@@([^@]+)@@\)`)
+// characters which, if preceding an unbracketed Trac link, indicate that the link text is part of something else (a path, a qualified name etc.) rather than a link
+// - preceding alphanumerics always indicate this, CamelCase words are more likely to be part of something else
+const nonLinkPrecedingChars = "/:"
+const nonCamelCaseLinkPrecedingChars = "/:.#@?~&=-_"
+
+// sentence punctuation which is not considered to be part of an unbracketed link when it appears at the end of the link
+const trailingLinkPunctuation = ".,;:!?'\")"
+
+// tracLinkKind is the kind of a Trac link
+type tracLinkKind int
+
+const (
+	httpLink tracLinkKind = iota
+	htdocsLink
+	interTracTicketLink
+	interTracWikiLink
+	interWikiLink
+	ticketCommentLink
+	milestoneLink
+	attachmentLink
+	changesetLink
+	revisionRangeLink
+	sourceLink
+	exportLink
+	sourceLogLink
+	queryLink
+	reportLink
+	ticketLink
+	wikiLink
+	camelCaseLink
+	pageAnchorLink
+)
 
-	// regexp for recognising a "marked" link: $1=link
-	markedLinkRegexp = regexp.MustCompile(`\(@@([^@]+)@@\)`)
+// tracLink is a parsed (but not yet resolved) Trac link
+type tracLink struct {
+	kind tracLinkKind
 
-	// zero-width space for separating debracketted links from trailing text, until they are converted to proper markdown
-	zeroWidthSpace = "\u200B"
-)
+	// source is the original text of the link
+	source string
 
-// Link resolution functions:
+	// target is the URL, htdocs path, page name, milestone name, attachment name, revision, source file path, ticket query or report ID (depending on kind)
+	target string
+
+	// endTarget is the end revision of a revision range link
+	endTarget string
+
+	// branch and revision are the git branch and optional Trac revision of a source link
+	branch   string
+	revision string
+
+	// anchor is an optional anchor within a wiki page or source file
+	anchor string
+
+	// ticketID is the ticket referenced by a ticket, comment or attachment link - NullID if this is the current ticket
+	ticketID int64
 
-//	These are responsible for extracting link information from its appropriate Trac link regexp and preparing that link for conversion to markdown.
-//	The portion of the returned text corresponding to the link itself (as opposed to any extraneous characters that may have been hoovered up by the regexp)
-//	should be "marked" using the markLink() function to identify it for later processing.
+	// commentNum is the ticket comment referenced by a comment link
+	commentNum int64
 
-func (converter *DefaultConverter) resolveHTTPLink(link string) string {
-	return markLink(link)
+	// wikiPage is the wiki page of an attachment link - empty if this is the current page
+	wikiPage string
+
+	// interTracPrefix is the InterTrac prefix of InterTrac links
+	interTracPrefix string
+
+	// interWikiPrefix is the InterWiki prefix of InterWiki links
+	interWikiPrefix string
 }
 
-func (converter *DefaultConverter) resolveHtdocsLink(link string) string {
-	// any htdocs file needs copying from trac htdocs directory to an equivalent wiki subdirectory
-	htdocPath := htdocsLinkRegexp.ReplaceAllString(link, `$1`)
-	tracHtdocPath := converter.tracAccessor.GetFullPath("htdocs", htdocPath)
-	wikiHtdocRelPath := converter.giteaAccessor.GetWikiHtdocRelPath(htdocPath)
-	converter.giteaAccessor.CopyFileToWiki(tracHtdocPath, wikiHtdocRelPath)
-	wikiHtdocURL := converter.giteaAccessor.GetWikiFileURL(wikiHtdocRelPath)
-	return markLink(wikiHtdocURL)
+// linkInline is a Trac link with optional link text
+type linkInline struct {
+	link *tracLink
+	text []inline
+
+	// source is the original text of the entire link construct, output if the link cannot be resolved
+	source string
 }
 
-func (converter *DefaultConverter) resolveTicketCommentLink(ticketID int64, link string) string {
-	leadingChar := ticketCommentLinkRegexp.ReplaceAllString(link, `$1`)
-	commentNumStr := ticketCommentLinkRegexp.ReplaceAllString(link, `$2`)
-	var commentNum int64
-	commentNum, err := strconv.ParseInt(commentNumStr, 10, 64)
+// parseInt64 parses a string of digits already validated by a regexp
+func parseInt64(digits string) int64 {
+	value, err := strconv.ParseInt(digits, 10, 64)
 	if err != nil {
-		log.Warn("found invalid Trac ticket comment number %s", commentNum)
-		return link
+		log.Warn("found invalid number %s in Trac link", digits)
+		return trac.NullID
+	}
+	return value
+}
+
+// matchTracLink matches a Trac link at the start of a string, returning the link and its length or a nil link if there is no link.
+// If the link is unbracketed, any trailing sentence punctuation is excluded from those links whose syntax would otherwise include it.
+func (converter *DefaultConverter) matchTracLink(s string, unbracketed bool) (*tracLink, int) {
+	if link, length := converter.matchInterTracLink(s, unbracketed); link != nil {
+		return link, length
+	}
+
+	switch s[0] {
+	case 'h':
+		if match := httpLinkRegexp.FindString(s); match != "" {
+			return &tracLink{kind: httpLink, source: match, target: match}, len(match)
+		}
+		if match := matchWithoutTrailingPunctuation(htdocsLinkRegexp, s, unbracketed); match != nil {
+			return &tracLink{kind: htdocsLink, source: s[:match[1]], target: s[match[2]:match[3]]}, match[1]
+		}
+	case 'c':
+		if match := ticketCommentLinkRegexp.FindStringSubmatch(s); match != nil {
+			link := tracLink{kind: ticketCommentLink, source: match[0], commentNum: parseInt64(match[1]), ticketID: trac.NullID}
+			if match[2] != "" {
+				link.ticketID = parseInt64(match[2])
+			}
+			return &link, len(match[0])
+		}
+		if match := changesetLinkRegexp.FindStringSubmatch(s); match != nil {
+			return &tracLink{kind: changesetLink, source: match[0], target: match[1] + match[2]}, len(match[0])
+		}
+	case 'd':
+		if match := diffLinkRegexp.FindStringSubmatch(s); match != nil {
+			return &tracLink{kind: revisionRangeLink, source: match[0], target: match[1], endTarget: match[2]}, len(match[0])
+		}
+	case 'l':
+		if match := logLinkRegexp.FindStringSubmatch(s); match != nil {
+			return revisionLink(match[0], match[1], match[2]), len(match[0])
+		}
+		if link, length := converter.matchSourceLink(s, unbracketed); link != nil {
+			return link, length
+		}
+	case 'b', 'e':
+		if link, length := converter.matchSourceLink(s, unbracketed); link != nil {
+			return link, length
+		}
+	case 'm':
+		if match := matchWithoutTrailingPunctuation(milestoneLinkRegexp, s, unbracketed); match != nil {
+			return &tracLink{kind: milestoneLink, source: s[:match[1]], target: s[match[2]:match[3]]}, match[1]
+		}
+	case 'a':
+		if match := matchWithoutTrailingPunctuation(attachmentLinkRegexp, s, unbracketed); match != nil {
+			link := tracLink{kind: attachmentLink, source: s[:match[1]], target: s[match[2]:match[3]], ticketID: trac.NullID}
+			link.wikiPage = submatch(s, match, 2)
+			if ticketIDStr := submatch(s, match, 3); ticketIDStr != "" {
+				link.ticketID = parseInt64(ticketIDStr)
+			}
+			return &link, match[1]
+		}
+	case 'q':
+		if match := matchWithoutTrailingPunctuation(queryLinkRegexp, s, unbracketed); match != nil {
+			return &tracLink{kind: queryLink, source: s[:match[1]], target: s[match[2]:match[3]]}, match[1]
+		}
+	case 'r':
+		if match := reportLinkRegexp.FindStringSubmatch(s); match != nil {
+			return &tracLink{kind: reportLink, source: match[0], target: match[1]}, len(match[0])
+		}
+		if match := revisionLinkRegexp.FindStringSubmatch(s); match != nil {
+			// a revision must not be immediately followed by further alphanumerics (e.g. 'r2d2')
+			if nextRune, _ := utf8.DecodeRuneInString(s[len(match[0]):]); isAlphanumeric(nextRune) {
+				return nil, 0
+			}
+			return revisionLink(match[0], match[1], match[2]), len(match[0])
+		}
+	case 's':
+		if link, length := converter.matchSourceLink(s, unbracketed); link != nil {
+			return link, length
+		}
+	case 't':
+		if match := ticketLinkRegexp.FindStringSubmatch(s); match != nil {
+			if match[2] != "" {
+				return &tracLink{kind: ticketCommentLink, source: match[0], ticketID: parseInt64(match[1]), commentNum: parseInt64(match[2])}, len(match[0])
+			}
+			return &tracLink{kind: ticketLink, source: match[0], ticketID: parseInt64(match[1])}, len(match[0])
+		}
+	case 'w':
+		if match := matchWithoutTrailingPunctuation(wikiLinkRegexp, s, unbracketed); match != nil {
+			return &tracLink{kind: wikiLink, source: s[:match[1]], target: s[match[2]:match[3]], anchor: submatch(s, match, 2)}, match[1]
+		}
+	}
+
+	// InterWiki prefixes can be CamelCase words so must be checked for before CamelCase links
+	if link, length := converter.matchInterWikiLink(s, unbracketed); link != nil {
+		return link, length
+	}
+
+	if match := matchWithoutTrailingPunctuation(wikiCamelCaseLinkRegexp, s, unbracketed); match != nil {
+		// a CamelCase word must not be immediately followed by further alphanumerics
+		if nextRune, _ := utf8.DecodeRuneInString(s[match[3]:]); isAlphanumeric(nextRune) {
+			return nil, 0
+		}
+		return &tracLink{kind: camelCaseLink, source: s[:match[1]], target: s[match[2]:match[3]], anchor: submatch(s, match, 2)}, match[1]
+	}
+
+	return nil, 0
+}
+
+// revisionLink creates the link for a single Trac revision or, if an end revision is provided, a range of revisions
+func revisionLink(source string, revision string, endRevision string) *tracLink {
+	if endRevision == "" {
+		return &tracLink{kind: changesetLink, source: source, target: revision}
+	}
+	return &tracLink{kind: revisionRangeLink, source: source, target: revision, endTarget: endRevision}
+}
+
+// submatch returns a given submatch of a string given the submatch indexes - returns an empty string if the submatch did not match
+func submatch(s string, match []int, submatchIndex int) string {
+	if match[2*submatchIndex] == -1 {
+		return ""
+	}
+	return s[match[2*submatchIndex]:match[2*submatchIndex+1]]
+}
+
+// matchWithoutTrailingPunctuation returns the submatch indexes of a regexp matching the start of a string.
+// If the match is for an unbracketed link, the match is shortened to exclude any trailing sentence punctuation.
+func matchWithoutTrailingPunctuation(linkRegexp *regexp.Regexp, s string, unbracketed bool) []int {
+	match := linkRegexp.FindStringSubmatchIndex(s)
+	if match == nil || !unbracketed {
+		return match
+	}
+
+	length := match[1]
+	for length > 0 && strings.IndexByte(trailingLinkPunctuation, s[length-1]) != -1 {
+		length--
+	}
+	if length == match[1] {
+		return match
+	}
+	return linkRegexp.FindStringSubmatchIndex(s[:length])
+}
+
+// parseLinkTarget parses the target of a bracketed Trac link or Image macro - the entire target must form a single Trac link
+func (converter *DefaultConverter) parseLinkTarget(target string) *tracLink {
+	if target == "" {
+		return nil
+	}
+	if match := pageAnchorLinkRegexp.FindStringSubmatch(target); match != nil {
+		return &tracLink{kind: pageAnchorLink, source: target, anchor: match[1]}
 	}
 
-	commentTicketIDStr := ticketCommentLinkRegexp.ReplaceAllString(link, `$3`)
-	var commentTicketID int64
-	if commentTicketIDStr != "" {
-		commentTicketID, err = strconv.ParseInt(commentTicketIDStr, 10, 64)
-		if err != nil {
-			log.Warn("found invalid Trac ticket id %s", commentTicketIDStr)
-			return link
+	link, length := converter.matchTracLink(target, false)
+	if link == nil || length != len(target) {
+		return nil
+	}
+	return link
+}
+
+// matchUnbracketedLink matches an unbracketed Trac link at the start of a string.
+// Unbracketed links must start at the beginning of a word so we need the character preceding the string (0 if none).
+func (parser *inlineParser) matchUnbracketedLink(s string, prevRune rune) (inline, int) {
+	if parser.inLinkText || isAlphanumeric(prevRune) {
+		return nil, 0
+	}
+
+	link, length := parser.converter.matchTracLink(s, true)
+	if link == nil {
+		return nil, 0
+	}
+
+	nonPrecedingChars := nonLinkPrecedingChars
+	switch link.kind {
+	case httpLink:
+		nonPrecedingChars = ""
+	case camelCaseLink:
+		nonPrecedingChars = nonCamelCaseLinkPrecedingChars
+	}
+	if prevRune != 0 && strings.ContainsRune(nonPrecedingChars, prevRune) {
+		return nil, 0
+	}
+
+	return &linkInline{link: link, source: s[:length]}, length
+}
+
+// matchBracket matches the various Trac constructs starting with a '['
+func (parser *inlineParser) matchBracket(s string) (inline, int) {
+	if strings.HasPrefix(s, "[[") {
+		return parser.matchDoubleBracket(s)
+	}
+	if strings.HasPrefix(s, "[=#") {
+		return parser.matchAnchor(s)
+	}
+	if parser.inLinkText {
+		return nil, 0
+	}
+	if match := revisionBracketLinkRegexp.FindStringSubmatch(s); match != nil {
+		if !isRevision(match[1]) || (match[2] != "" && !isRevision(match[2])) {
+			return nil, 0
 		}
-	} else {
+		return &linkInline{link: revisionLink(match[0], match[1], match[2]), source: match[0]}, len(match[0])
+	}
+
+	match := singleBracketLinkRegexp.FindStringSubmatch(s)
+	if match == nil {
+		return nil, 0
+	}
+	link := parser.converter.parseLinkTarget(match[1])
+	if link == nil {
+		return nil, 0
+	}
+
+	node := linkInline{link: link, source: match[0]}
+	if match[2] != "" {
+		node.text = parser.converter.parseLinkText(match[2])
+	}
+	return &node, len(match[0])
+}
+
+// matchDoubleBracket matches Trac '[[...]]' constructs - these are either macros or links
+func (parser *inlineParser) matchDoubleBracket(s string) (inline, int) {
+	match := doubleBracketRegexp.FindStringSubmatch(s)
+	if match == nil {
+		return nil, 0
+	}
+
+	if macro := parseMacro(match[1]); macro != nil {
+		return macro, len(match[0])
+	}
+	if parser.inLinkText {
+		return nil, 0
+	}
+
+	linkMatch := doubleBracketLinkRegexp.FindStringSubmatch(match[1])
+	if linkMatch == nil {
+		return nil, 0
+	}
+	link := parser.converter.parseLinkTarget(strings.TrimSpace(linkMatch[1]))
+	if link == nil {
+		return nil, 0
+	}
+
+	node := linkInline{link: link, source: match[0]}
+	if linkMatch[2] != "" {
+		node.text = parser.converter.parseLinkText(strings.TrimSpace(linkMatch[2]))
+	}
+	return &node, len(match[0])
+}
+
+// parseLinkText parses the text of a link - links cannot be nested so no links are recognised within this text
+func (converter *DefaultConverter) parseLinkText(text string) []inline {
+	parser := inlineParser{converter: converter, text: text, inLinkText: true}
+	parser.parse()
+	return nestFontStyles(parser.nodes)
+}
+
+// Link resolution functions:
+//	These are responsible for resolving a parsed Trac link into the URL of the equivalent Gitea item and a default text for the link.
+//	An empty default text means that the URL itself should be used as the text.
+//	Returns false if the link cannot be resolved in which case the original Trac text is retained.
+
+func (converter *DefaultConverter) resolveHtdocsLink(link *tracLink) (string, string, bool) {
+	// any htdocs file needs copying from trac htdocs directory to an equivalent wiki subdirectory
+	tracHtdocPath := converter.tracAccessor.GetFullPath("htdocs", link.target)
+	wikiHtdocRelPath := converter.giteaAccessor.GetWikiHtdocRelPath(link.target)
+	converter.giteaAccessor.CopyFileToWiki(tracHtdocPath, wikiHtdocRelPath)
+	wikiHtdocURL := converter.giteaAccessor.GetWikiFileURL(wikiHtdocRelPath)
+	return wikiHtdocURL, "", true
+}
+
+func (converter *DefaultConverter) resolveTicketCommentLink(ticketID int64, link *tracLink) (string, string, bool) {
+	commentTicketID := link.ticketID
+	if commentTicketID == trac.NullID {
 		// comment on current ticket
 		if ticketID == trac.NullID {
-			log.Warn("found Trac reference to comment %d of unknown ticket", commentNum)
-			return link
+			converter.warn(UnresolvedLinkDiagnostic, link.source, "found Trac reference to comment %d of unknown ticket", link.commentNum)
+			return "", "", false
 		}
 		commentTicketID = ticketID
 	}
 
-	issueID, err := converter.giteaAccessor.GetIssueID(commentTicketID)
+	commentIssueIndex := converter.issueIndex(commentTicketID)
+	issueID, err := converter.giteaAccessor.GetIssueID(commentIssueIndex)
 	if err != nil {
-		return link // not a recognised link - do not mark (error should already be logged)
+		return "", "", false // error should already be logged
 	}
 	if issueID == gitea.NullID {
-		log.Warn("cannot find Gitea issue for ticket %d referenced by Trac link \"%s\"", commentTicketID, link)
-		return link // not a recognised link - do not mark
+		converter.warn(UnresolvedLinkDiagnostic, link.source, "cannot find Gitea issue for ticket %d referenced by Trac link \"%s\"", commentTicketID, link.source)
+		return "", "", false
 	}
 
 	// find gitea ID for trac comment
-	timestamp, err := converter.tracAccessor.GetTicketCommentTime(commentTicketID, commentNum)
+	timestamp, err := converter.tracAccessor.GetTicketCommentTime(commentTicketID, link.commentNum)
 	if err != nil || timestamp == int64(0) {
-		return link // not a recognised link - do not mark (error should already be logged)
+		return "", "", false // error should already be logged
 	}
 	commentID, err := converter.giteaAccessor.GetIssueCommentIDByTime(issueID, timestamp)
 	if err != nil {
-		return link // not a recognised link - do not mark (error should already be logged)
+		return "", "", false // error should already be logged
 	}
-
-	commentURL := converter.giteaAccessor.GetIssueCommentURL(commentTicketID, commentID)
-
-	// unless already defined before, use a short link text instead of the full URL
-	if leadingChar != "]" {
-		return leadingChar + "[comment:" + strconv.FormatInt(commentID, 10) + "]" + markLink(commentURL)
+	if commentID == gitea.NullID {
+		converter.warn(UnresolvedLinkDiagnostic, link.source, "cannot find Gitea comment for comment %d of ticket %d referenced by Trac link \"%s\"", link.commentNum, commentTicketID, link.source)
+		return "", "", false
 	}
-	return leadingChar + markLink(commentURL)
+
+	commentURL := converter.giteaAccessor.GetIssueCommentURL(commentIssueIndex, commentID)
+	return commentURL, "comment:" + strconv.FormatInt(commentID, 10), true
 }
 
-func (converter *DefaultConverter) resolveMilestoneLink(link string) string {
-	leadingChar := milestoneLinkRegexp.ReplaceAllString(link, `$1`)
-	milestoneName := milestoneLinkRegexp.ReplaceAllString(link, `$2`)
-	milestoneID, err := converter.giteaAccessor.GetMilestoneID(milestoneName)
+func (converter *DefaultConverter) resolveMilestoneLink(link *tracLink) (string, string, bool) {
+	milestoneID, err := converter.giteaAccessor.GetMilestoneID(link.target)
 	if err != nil {
-		return link // not a recognised link - do not mark (error should already be logged)
+		return "", "", false // error should already be logged
 	}
 	if milestoneID == gitea.NullID {
-		log.Warn("cannot find milestone \"%s\" referenced by Trac link \"%s\"", milestoneName, link)
-		return link // not a recognised link - do not mark
+		converter.warn(UnresolvedLinkDiagnostic, link.source, "cannot find milestone \"%s\" referenced by Trac link \"%s\"", link.target, link.source)
+		return "", "", false
 	}
 
 	milestoneURL := converter.giteaAccessor.GetMilestoneURL(milestoneID)
-
-	// unless already defined before, use a short link text instead of the full URL
-	if leadingChar != "]" {
-		return leadingChar + "[milestone:" + milestoneName + "]" + markLink(milestoneURL)
-	}
-	return leadingChar + markLink(milestoneURL)
+	return milestoneURL, "milestone:" + link.target, true
 }
 
-func (converter *DefaultConverter) resolveTicketAttachmentLink(ticketID int64, attachmentName string, link string) string {
-	leadingChar := attachmentLinkRegexp.ReplaceAllString(link, `$1`)
-	issueID, err := converter.giteaAccessor.GetIssueID(ticketID)
+func (converter *DefaultConverter) resolveTicketAttachmentLink(ticketID int64, link *tracLink) (string, string, bool) {
+	issueID, err := converter.giteaAccessor.GetIssueID(converter.issueIndex(ticketID))
 	if err != nil {
-		return link // not a recognised link - do not mark
+		return "", "", false
 	}
 	if issueID == gitea.NullID {
-		log.Warn("cannot find Gitea issue for ticket %d for Trac link \"%s\"", ticketID, link)
-		return link // not a recognised link - do not mark
+		converter.warn(UnresolvedLinkDiagnostic, link.source, "cannot find Gitea issue for ticket %d for Trac link \"%s\"", ticketID, link.source)
+		return "", "", false
 	}
 
-	uuid, err := converter.giteaAccessor.GetIssueAttachmentUUID(issueID, attachmentName)
+	uuid, err := converter.giteaAccessor.GetIssueAttachmentUUID(issueID, link.target)
 	if err != nil {
-		return link // not a recognised link - do not mark
+		return "", "", false
 	}
 	if uuid == "" {
-		log.Warn("cannot find attachment \"%s\" for issue %d for Trac link \"%s\"", attachmentName, issueID, link)
-		return link // not a recognised link - do not mark
+		converter.warn(UnresolvedLinkDiagnostic, link.source, "cannot find attachment \"%s\" for issue %d for Trac link \"%s\"", link.target, issueID, link.source)
+		return "", "", false
 	}
 
 	attachmentURL := converter.giteaAccessor.GetIssueAttachmentURL(issueID, uuid)
-
-	// Keep original text unless already defined before
-	if leadingChar != "]" {
-		return leadingChar + "[attachment:" + attachmentName + "]" + markLink(attachmentURL)
-	}
-	return leadingChar + markLink(attachmentURL)
+	return attachmentURL, "attachment:" + link.target, true
 }
 
-func (converter *DefaultConverter) resolveWikiAttachmentLink(wikiPage string, attachmentName string, link string) string {
-	leadingChar := attachmentLinkRegexp.ReplaceAllString(link, `$1`)
-	attachmentWikiRelPath := converter.giteaAccessor.GetWikiAttachmentRelPath(wikiPage, attachmentName)
+func (converter *DefaultConverter) resolveWikiAttachmentLink(wikiPage string, link *tracLink) (string, string, bool) {
+	attachmentWikiRelPath := converter.giteaAccessor.GetWikiAttachmentRelPath(wikiPage, link.target)
 	attachmentURL := converter.giteaAccessor.GetWikiFileURL(attachmentWikiRelPath)
-
-	// Keep original text unless already defined before
-	if leadingChar != "]" {
-		return leadingChar + "[attachment:" + attachmentName + "]" + markLink(attachmentURL)
-	}
-	return leadingChar + markLink(attachmentURL)
+	return attachmentURL, "attachment:" + link.target, true
 }
 
-func (converter *DefaultConverter) resolveAttachmentLink(ticketID int64, wikiPage string, link string) string {
-	attachmentName := attachmentLinkRegexp.ReplaceAllString(link, `$2`)
-	attachmentWikiPage := attachmentLinkRegexp.ReplaceAllString(link, `$3`)
-	attachmentTicketIDStr := attachmentLinkRegexp.ReplaceAllString(link, `$4`)
-
+func (converter *DefaultConverter) resolveAttachmentLink(ticketID int64, wikiPage string, link *tracLink) (string, string, bool) {
 	// there are two types of attachment: ticket attachments and wiki attachments...
-	if attachmentTicketIDStr != "" {
-		var attachmentTicketID int64
-		attachmentTicketID, err := strconv.ParseInt(attachmentTicketIDStr, 10, 64)
-		if err != nil {
-			log.Warn("found invalid Trac ticket id %s", attachmentTicketIDStr)
-			return link
-		}
-
-		return converter.resolveTicketAttachmentLink(attachmentTicketID, attachmentName, link)
-	} else if attachmentWikiPage != "" {
-		return converter.resolveWikiAttachmentLink(attachmentWikiPage, attachmentName, link)
+	if link.ticketID != trac.NullID {
+		return converter.resolveTicketAttachmentLink(link.ticketID, link)
+	} else if link.wikiPage != "" {
+		return converter.resolveWikiAttachmentLink(link.wikiPage, link)
 	}
 
-	// no explicit ticket or wiki provided for attachment - use whichever of `ticketID` and `wiki` has been provided
+	// no explicit ticket or wiki provided for attachment - use whichever of `ticketID` and `wikiPage` has been provided
 	if ticketID != trac.NullID {
-		return converter.resolveTicketAttachmentLink(ticketID, attachmentName, link)
+		return converter.resolveTicketAttachmentLink(ticketID, link)
 	} else if wikiPage != "" {
-		return converter.resolveWikiAttachmentLink(wikiPage, attachmentName, link)
+		return converter.resolveWikiAttachmentLink(wikiPage, link)
 	}
 
-	log.Warn("Trac attachment link \"%s\" requires either ticket or wiki", link)
-	return link
+	converter.warn(UnresolvedLinkDiagnostic, link.source, "Trac attachment link \"%s\" requires either ticket or wiki", link.source)
+	return "", "", false
 }
 
-func (converter *DefaultConverter) resolveChangesetLink(link string) string {
-	changesetID := changesetLinkRegexp.ReplaceAllString(link, `$1`)
-	changesetURL := converter.giteaAccessor.GetCommitURL(changesetID)
-	return markLink(changesetURL)
-}
+func (converter *DefaultConverter) resolveChangesetLink(link *tracLink) (string, string, bool) {
+	commitID := converter.commitID(link.target)
+	if commitID == "" {
+		return "", "", false
+	}
 
-func (converter *DefaultConverter) resolveSourceLink(link string) string {
-	sourcePath := sourceLinkRegexp.ReplaceAllString(link, `$1`)
-	sourceURL := converter.giteaAccessor.GetSourceURL("master", sourcePath) // AFAICT Trac source URL does not include the git branch so we'll assume "master"
-	return markLink(sourceURL)
+	changesetURL := converter.giteaAccessor.GetCommitURL(commitID)
+	return changesetURL, revisionText(link.target), true
 }
 
-func (converter *DefaultConverter) resolveTicketLink(link string) string {
-	ticketIDStr := ticketLinkRegexp.ReplaceAllString(link, `$1`)
-	ticketID, err := strconv.ParseInt(ticketIDStr, 10, 64)
-	if err != nil {
-		log.Warn("found invalid Trac ticket reference %s" + link)
-		return link // not a recognised link - do not mark
+func (converter *DefaultConverter) resolveRevisionRangeLink(link *tracLink) (string, string, bool) {
+	fromCommitID := converter.commitID(link.target)
+	toCommitID := converter.commitID(link.endTarget)
+	if fromCommitID == "" || toCommitID == "" {
+		return "", "", false
 	}
 
+	compareURL := converter.giteaAccessor.GetCompareURL(fromCommitID, toCommitID)
+	return compareURL, revisionText(link.target) + ":" + revisionText(link.endTarget), true
+}
+
+func (converter *DefaultConverter) resolveTicketLink(link *tracLink) (string, string, bool) {
 	// validate ticket id
-	issueID, err := converter.giteaAccessor.GetIssueID(ticketID)
+	issueIndex := converter.issueIndex(link.ticketID)
+	issueID, err := converter.giteaAccessor.GetIssueID(issueIndex)
 	if err != nil {
-		return link // not a recognised link - do not mark (error already logged)
+		return "", "", false // error already logged
 	}
 	if issueID == gitea.NullID {
-		log.Warn("cannot find Gitea issue for ticket %d referenced by Trac link \"%s\"", ticketID, link)
-		return link // not a recognised link - do not mark
+		converter.warn(UnresolvedLinkDiagnostic, link.source, "cannot find Gitea issue for ticket %d referenced by Trac link \"%s\"", link.ticketID, link.source)
+		return "", "", false
 	}
 
-	issueURL := converter.giteaAccessor.GetIssueURL(issueID)
-	return markLink(issueURL)
+	issueURL := converter.giteaAccessor.GetIssueURL(issueIndex)
+	return issueURL, "", true
 }
 
-func (converter *DefaultConverter) resolveWikiLink(path string, link string) string {
-	wikiPageName := wikiLinkRegexp.ReplaceAllString(link, `$1`)
-	wikiPageAnchor := wikiLinkRegexp.ReplaceAllString(link, `$2`)
-	translatedPageName := converter.giteaAccessor.TranslateWikiPageName(wikiPageName)
-	var suffix string
-	if wikiPageAnchor == "" {
-		suffix = ""
-	} else {
-		suffix = "#" + wikiPageAnchor
-	}
-	return markLink(path + translatedPageName + suffix)
+// wikiPageWithAnchor returns the translation of a wiki page name with an optional anchor
+func (converter *DefaultConverter) wikiPageWithAnchor(ticketID int64, wikiPage string, link *tracLink) string {
+	translatedPageName := converter.giteaAccessor.TranslateWikiPageName(link.target)
+	if link.anchor == "" {
+		return translatedPageName
+	}
+	return translatedPageName + "#" + converter.convertAnchor(ticketID, wikiPage, link.target, link.anchor, link)
 }
 
-func (converter *DefaultConverter) resolveWikiCamelCaseLink(path string, link string) string {
-	leadingChar := wikiCamelCaseLinkRegexp.ReplaceAllString(link, `$1`)
-	wikiPageName := wikiCamelCaseLinkRegexp.ReplaceAllString(link, `$2`)
-	wikiPageAnchor := wikiCamelCaseLinkRegexp.ReplaceAllString(link, `$3`)
-	translatedPageName := converter.giteaAccessor.TranslateWikiPageName(wikiPageName)
-	var suffix string
-	if wikiPageAnchor == "" {
-		suffix = ""
-	} else {
-		suffix = "#" + wikiPageAnchor
-	}
-	// add accompagnying comment if there is not one already
-	if leadingChar != "]" {
-		return leadingChar + "[" + translatedPageName + suffix + "]" + markLink(path + translatedPageName + suffix)
-	}
-	return leadingChar + markLink(path + translatedPageName + suffix)
+func (converter *DefaultConverter) resolveWikiLink(ticketID int64, wikiPage string, path string, link *tracLink) (string, string, bool) {
+	return path + converter.wikiPageWithAnchor(ticketID, wikiPage, link), "", true
 }
 
-// convertBrackettedTracLinks converts the various forms of (square) bracketted Trac links into an unbracketted form.
-// The conversion performed here is partial: this method is solely responsible for disposing of the Trac bracketting
-// - any resolution of actual trac links is done later
-func (converter *DefaultConverter) convertBrackettedTracLinks(wikiPage string, in string) string {
-	out := in
-
-	out = doubleBracketImageLinkRegexp.ReplaceAllStringFunc(out, func(match string) string {
-		// convert Trac image links to markdown but leave the link unprocessed
-		// - it will get dealt with later
-		image := doubleBracketImageLinkRegexp.ReplaceAllString(match, "$1")
-		link := doubleBracketImageLinkRegexp.ReplaceAllString(match, "$2")
-
-		// If the image is just a local filename, we have to get its path, and mark that as a processed link
-		if localFileLinkRegexp.MatchString(image) {
-			image = markLink(converter.giteaAccessor.GetWikiAttachmentRelPath(wikiPage, image))
-		}
-
-		if link == "" {
-			return "![]" + image + zeroWidthSpace
-		}
-		return "[![]" + image + "]" + link + zeroWidthSpace
-	})
-
-	out = doubleBracketLinkRegexp.ReplaceAllStringFunc(out, func(match string) string {
-		// convert Trac double bracket links into Trac single bracket links
-		// - if we convert directly to markdown here, the "[<text>]" part of the markdown will get misinterpreted as a Trac single bracket link
-		link := doubleBracketLinkRegexp.ReplaceAllString(match, "$1")
-		text := doubleBracketLinkRegexp.ReplaceAllString(match, "$2")
-
-		if text == "" {
-			// '[[br]]' is a page break in Trac and is dealt with elsewhere
-			if strings.EqualFold(link, "br") {
-				return match
-			}
-			return "[" + link + "]"
-		}
-
-		return "[" + link + " " + text + "]"
-	})
-
-	out = singleBracketLinkRegexp.ReplaceAllStringFunc(out, func(match string) string {
-		// convert Trac single bracket links to markdown but leave the link unprocessed; it will get dealt with later
-		link := singleBracketLinkRegexp.ReplaceAllString(match, "$1")
-		text := singleBracketLinkRegexp.ReplaceAllString(match, "$2")
-
-		if text == "" {
-			// '[br]' can be assumed to be the inner section of a '[[br]]'
-			if strings.EqualFold(link, "br") {
-				return match
-			}
-			return zeroWidthSpace + link + zeroWidthSpace
-		}
-
-		return "[" + text + "]" + link + zeroWidthSpace
-	})
-
-	return out
+func (converter *DefaultConverter) resolvePageAnchorLink(wikiPage string, link *tracLink) (string, string, bool) {
+	if wikiPage == "" {
+		converter.warn(UnresolvedLinkDiagnostic, link.source, "Trac link \"%s\" to an anchor on the current page is only supported in wiki pages", link.source)
+		return "", "", false
+	}
+	return "#" + converter.convertAnchor(trac.NullID, wikiPage, wikiPage, link.anchor, link), "", true
 }
 
-// convertUnbrackettedTracLinks converts Trac-style links after any surrounding Trac bracketting and link texts have been processed
-func (converter *DefaultConverter) convertUnbrackettedTracLinks(ticketID int64, wikiPage string, in string) string {
-	out := in
-
-	out = httpLinkRegexp.ReplaceAllStringFunc(out, func(match string) string {
-		return converter.resolveHTTPLink(match)
-	})
-
-	out = htdocsLinkRegexp.ReplaceAllStringFunc(out, func(match string) string {
-		return converter.resolveHtdocsLink(match)
-	})
-
-	out = ticketCommentLinkRegexp.ReplaceAllStringFunc(out, func(match string) string {
-		return converter.resolveTicketCommentLink(ticketID, match)
-	})
-
-	out = milestoneLinkRegexp.ReplaceAllStringFunc(out, func(match string) string {
-		return converter.resolveMilestoneLink(match)
-	})
-
-	out = attachmentLinkRegexp.ReplaceAllStringFunc(out, func(match string) string {
-		return converter.resolveAttachmentLink(ticketID, wikiPage, match)
-	})
-
-	out = changesetLinkRegexp.ReplaceAllStringFunc(out, func(match string) string {
-		return converter.resolveChangesetLink(match)
-	})
-
-	out = sourceLinkRegexp.ReplaceAllStringFunc(out, func(match string) string {
-		return converter.resolveSourceLink(match)
-	})
-
-	// trac 'ticket:<ticketID>' and 'wiki:<pageName>' links can form suffixes to other trac links like attachments
-	// so only process a standalone ticket of wiki link after we have handled the suffix cases above
-	out = ticketLinkRegexp.ReplaceAllStringFunc(out, func(match string) string {
-		return converter.resolveTicketLink(match)
-	})
+func (converter *DefaultConverter) resolveWikiCamelCaseLink(ticketID int64, wikiPage string, path string, link *tracLink) (string, string, bool) {
+	pageWithAnchor := converter.wikiPageWithAnchor(ticketID, wikiPage, link)
+	return path + pageWithAnchor, pageWithAnchor, true
+}
 
+// resolveLink resolves a Trac link found in the text of a given ticket or wiki page, returning the link URL, its default text and whether the link could be resolved
+func (converter *DefaultConverter) resolveLink(ticketID int64, wikiPage string, link *tracLink) (string, string, bool) {
 	// add a 'wiki/' path for link from ticket
-	var path = ""
+	var wikiPath = ""
 	if ticketID != trac.NullID {
-		path = "wiki/"
+		wikiPath = "wiki/"
 	}
-	out = wikiLinkRegexp.ReplaceAllStringFunc(out, func(match string) string {
-		return converter.resolveWikiLink(path, match)
-	})
 
-	out = wikiCamelCaseLinkRegexp.ReplaceAllStringFunc(out, func(match string) string {
-		return converter.resolveWikiCamelCaseLink(path, match)
-	})
+	switch link.kind {
+	case httpLink:
+		return link.target, "", true
+	case htdocsLink:
+		return converter.resolveHtdocsLink(link)
+	case interTracTicketLink, interTracWikiLink:
+		return converter.resolveInterTracLink(link)
+	case interWikiLink:
+		return converter.resolveInterWikiLink(link)
+	case ticketCommentLink:
+		return converter.resolveTicketCommentLink(ticketID, link)
+	case milestoneLink:
+		return converter.resolveMilestoneLink(link)
+	case attachmentLink:
+		return converter.resolveAttachmentLink(ticketID, wikiPage, link)
+	case changesetLink:
+		return converter.resolveChangesetLink(link)
+	case revisionRangeLink:
+		return converter.resolveRevisionRangeLink(link)
+	case sourceLink, exportLink, sourceLogLink:
+		return converter.resolveSourceLink(link)
+	case queryLink:
+		return converter.resolveQueryLink(link)
+	case reportLink:
+		return converter.resolveReportLink(link)
+	case ticketLink:
+		return converter.resolveTicketLink(link)
+	case wikiLink:
+		return converter.resolveWikiLink(ticketID, wikiPage, wikiPath, link)
+	case camelCaseLink:
+		return converter.resolveWikiCamelCaseLink(ticketID, wikiPage, wikiPath, link)
+	case pageAnchorLink:
+		return converter.resolvePageAnchorLink(wikiPage, link)
+	}
 
-	return out
+	return "", "", false
 }
 
-// markLink marks the URL part of our links with a unique marker so that they can be picked up by later converters.
-// We cannot just convert to markdown at this stage because markdown's round brackets are insufficiently unique
-// and would result in the later converters misidentifying normal bracketted text as links.
-// Hence we put a marker in here and later convert that marker to the final markdown.
-func markLink(in string) string {
-	return "(@@" + in + "@@)"
+// resolveLink resolves a Trac link found in the text being rendered, returning the link URL, its default text and whether the link could be resolved
+// - a link which cannot be resolved is recorded as a diagnostic of the conversion, if the reason has not already been recorded
+func (renderer *renderer) resolveLink(link *tracLink) (string, string, bool) {
+	diagnosticCount := renderer.converter.diagnosticCount()
+	url, defaultText, resolved := renderer.converter.resolveLink(renderer.ticketID, renderer.wikiPage, link)
+	if !resolved && renderer.converter.diagnosticCount() == diagnosticCount {
+		renderer.converter.warn(UnresolvedLinkDiagnostic, link.source, "cannot convert Trac link \"%s\"", link.source)
+	}
+	return url, defaultText, resolved
 }
 
-// unmarkLinks removes the "marking" placed around links by markLinks and converts them into their final markdown format
-// after that, zero-width spaces can be removed
-func (converter *DefaultConverter) unmarkLinks(in string) string {
-	out := in
-	out = noTextMarkedLinkRegexp.ReplaceAllStringFunc(out, func(match string) string {
-		// first replace (marked) links with no accompanying comment
-		leadingChar := noTextMarkedLinkRegexp.ReplaceAllString(match, `$1`)
-		markdownURL := noTextMarkedLinkRegexp.ReplaceAllString(match, `$2`)
-
-		// if the link is a plain http(s) link, use a markdown automatic link
-		if httpLinkRegexp.MatchString(markdownURL) {
-			return leadingChar + "<" + markdownURL + ">"
+// resolveURL resolves a Trac link target (as used in e.g. an Image macro) into a URL - an unresolvable target is used as is
+func (renderer *renderer) resolveURL(target string) string {
+	if link := renderer.converter.parseLinkTarget(target); link != nil {
+		// the URL may be written into an HTML element, which Gitea does not resolve relative to the repository as it does a markdown link in an issue
+		if renderer.ticketID != trac.NullID && (link.kind == wikiLink || link.kind == camelCaseLink) {
+			pageWithAnchor := renderer.converter.wikiPageWithAnchor(renderer.ticketID, renderer.wikiPage, link)
+			return renderer.converter.giteaAccessor.GetWikiPageURL(pageWithAnchor)
 		}
-
-		// else, it is necessary to use a regular link, using the URL as accompanying comment
-		return leadingChar + "[" + markdownURL + "]" + "(" + markdownURL + ")"
-	})
-
-	out = markedLinkRegexp.ReplaceAllStringFunc(out, func(match string) string {
-		// any remaining (marked) links must have an accompanying comment so are converted into normal markdown links
-		markdownURL := markedLinkRegexp.ReplaceAllString(match, `$1`)
-		return "(" + markdownURL + ")"
-	})
-
-	out = strings.ReplaceAll(out, zeroWidthSpace, "")
-
-	return out
+		if url, _, resolved := renderer.resolveLink(link); resolved {
+			return url
+		}
+	}
+	return target
 }
 
-func (converter *DefaultConverter) convertLinks(ticketID int64, wikiPage string, in string) string {
-	out := in
+// writeLink writes a link, given the literal text immediately following it
+func (renderer *renderer) writeLink(builder *strings.Builder, node *linkInline, followingText string) {
+	if node.link.kind == queryLink {
+		renderer.writeQueryLink(builder, node)
+		return
+	}
 
-	// conversion occurs in three distinct phases with each phase dealing with one part of the link syntax
-	// and leaving the remainder for the next stage
-	out = converter.convertBrackettedTracLinks(wikiPage, out)
-	out = converter.convertUnbrackettedTracLinks(ticketID, wikiPage, out)
-	out = converter.unmarkLinks(out)
-	return out
-}
+	url, defaultText, resolved := renderer.resolveLink(node.link)
+	if !resolved {
+		builder.WriteString(node.source)
+		return
+	}
 
-var httpLinkDisguiseRegexp = regexp.MustCompile(`(https?)://`)
-var httpLinkUndisguiseRegexp = regexp.MustCompile(`(https?):@@`)
+	// links to tickets without explicit text use Gitea's own issue references - provided Gitea will recognise the reference in context
+	if node.text == nil && isIssueReferenceBoundary(builder.String(), followingText) {
+		if reference := renderer.converter.issueReference(node.link); reference != "" {
+			builder.WriteString(reference)
+			return
+		}
+	}
 
-// disguiseLinks temporarily disguises links into a format that doesn't interfere with other Trac -> markdown regexps
-// - in particular the '//' in 'http(s)://...' clashes with Trac's '//' italics marker
-func (converter *DefaultConverter) disguiseLinks(in string) string {
-	out := in
-	out = httpLinkDisguiseRegexp.ReplaceAllString(out, `$1:@@`)
-	return out
+	renderer.writeResolvedLink(builder, node, url, defaultText)
 }
 
-// undisguiseLink converts temporarily "disguised" links back to their correct format.
-func (converter *DefaultConverter) undisguiseLinks(in string) string {
-	out := in
-	out = httpLinkUndisguiseRegexp.ReplaceAllString(out, `$1://`)
-	return out
+// writeResolvedLink writes a link which has been resolved into a URL, using the link's own text or, failing that, its default text or URL
+func (renderer *renderer) writeResolvedLink(builder *strings.Builder, node *linkInline, url string, defaultText string) {
+	switch {
+	case node.text != nil:
+		builder.WriteString("[")
+		renderer.writeInlines(builder, node.text)
+		builder.WriteString("](" + url + ")")
+	case defaultText != "":
+		builder.WriteString("[" + defaultText + "](" + url + ")")
+	case httpLinkRegexp.MatchString(url):
+		// plain http(s) link - use a markdown automatic link
+		builder.WriteString("<" + url + ">")
+	default:
+		// otherwise it is necessary to use a regular link, using the URL as text
+		builder.WriteString("[" + url + "](" + url + ")")
+	}
 }
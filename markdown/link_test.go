@@ -58,11 +58,13 @@ func markdownImageWithLink(image string, link string) string {
 }
 
 func ticketConvert(tracText string) string {
-	return converter.TicketConvert(ticketID, tracText)
+	conversion, _ := converter.TicketConvert(ticketID, tracText)
+	return conversion
 }
 
 func wikiConvert(tracText string) string {
-	return converter.WikiConvert(wikiPage, tracText)
+	conversion, _ := converter.WikiConvert(wikiPage, tracText)
+	return conversion
 }
 
 // verifyLink verifies that the provided trac formatting for a link + text results in the corresponding markdown format
@@ -100,6 +102,21 @@ func verifyAllLinkTypes(
 	markdownLinkStr string,
 	extraOptions ...string) {
 
+	verifyAllTextLinkTypes(t, setUpFn, tearDownFn, convertFn, tracLinkStr, markdownLinkStr, extraOptions...)
+	verifyImageLinkTypes(t, setUpFn, tearDownFn, convertFn, tracLinkStr, markdownLinkStr)
+}
+
+// verifyAllTextLinkTypes verifies the conversion of a link in every form other than as the image of an Image macro
+// - this allows links which resolve differently when used as an image to be verified separately
+func verifyAllTextLinkTypes(
+	t *testing.T,
+	setUpFn func(t *testing.T),
+	tearDownFn func(t *testing.T),
+	convertFn func(tracText string) string,
+	tracLinkStr string,
+	markdownLinkStr string,
+	extraOptions ...string) {
+
 	// the markdown link text can be specified with the first extra option
 	markdownLinkText := markdownLinkStr
 	if len(extraOptions) > 0 && extraOptions[0] != "" {
@@ -121,16 +138,72 @@ func verifyAllLinkTypes(
 		verifyLink(t, setUpFn, tearDownFn, convertFn, tracDoubleBracketLink(tracLinkStr), markdownLinkWithText(markdownLinkStr, markdownLinkText), false)
 	}
 
+	verifyLabelledTextLinkTypes(t, setUpFn, tearDownFn, convertFn, tracLinkStr, markdownLinkStr)
+}
+
+// verifyAllTicketLinkTypes verifies the conversion of a link to a ticket: where Gitea can recognise it, a link without text is converted into a Gitea issue reference
+func verifyAllTicketLinkTypes(
+	t *testing.T,
+	setUpFn func(t *testing.T),
+	tearDownFn func(t *testing.T),
+	convertFn func(tracText string) string,
+	tracLinkStr string,
+	markdownLinkStr string,
+	markdownLinkText string,
+	issueReference string) {
+
+	verifyLink(t, setUpFn, tearDownFn, convertFn, tracPlainLink(tracLinkStr), issueReference, false)
+	verifyLink(t, setUpFn, tearDownFn, convertFn, tracSingleBracketLink(tracLinkStr), markdownLinkWithText(markdownLinkStr, markdownLinkText), true)
+	verifyLink(t, setUpFn, tearDownFn, convertFn, tracSingleBracketLink(tracLinkStr), issueReference, false)
+	verifyLink(t, setUpFn, tearDownFn, convertFn, tracDoubleBracketLink(tracLinkStr), markdownLinkWithText(markdownLinkStr, markdownLinkText), true)
+	verifyLink(t, setUpFn, tearDownFn, convertFn, tracDoubleBracketLink(tracLinkStr), issueReference, false)
+
+	verifyLabelledLinkTypes(t, setUpFn, tearDownFn, convertFn, tracLinkStr, markdownLinkStr)
+}
+
+// verifyLabelledLinkTypes verifies the conversion of a link with explicit text and of images using the link
+func verifyLabelledLinkTypes(
+	t *testing.T,
+	setUpFn func(t *testing.T),
+	tearDownFn func(t *testing.T),
+	convertFn func(tracText string) string,
+	tracLinkStr string,
+	markdownLinkStr string) {
+
+	verifyLabelledTextLinkTypes(t, setUpFn, tearDownFn, convertFn, tracLinkStr, markdownLinkStr)
+	verifyImageLinkTypes(t, setUpFn, tearDownFn, convertFn, tracLinkStr, markdownLinkStr)
+}
+
+// verifyLabelledTextLinkTypes verifies the conversion of a link with explicit text and of the link of an Image macro
+func verifyLabelledTextLinkTypes(
+	t *testing.T,
+	setUpFn func(t *testing.T),
+	tearDownFn func(t *testing.T),
+	convertFn func(tracText string) string,
+	tracLinkStr string,
+	markdownLinkStr string) {
+
 	verifyLink(t, setUpFn, tearDownFn, convertFn, tracSingleBracketLinkWithText(tracLinkStr, linkText), markdownLinkWithText(markdownLinkStr, linkText), true)
 	verifyLink(t, setUpFn, tearDownFn, convertFn, tracSingleBracketLinkWithText(tracLinkStr, linkText), markdownLinkWithText(markdownLinkStr, linkText), false)
 	verifyLink(t, setUpFn, tearDownFn, convertFn, tracDoubleBracketLinkWithText(tracLinkStr, linkText), markdownLinkWithText(markdownLinkStr, linkText), true)
 	verifyLink(t, setUpFn, tearDownFn, convertFn, tracDoubleBracketLinkWithText(tracLinkStr, linkText), markdownLinkWithText(markdownLinkStr, linkText), false)
+	verifyLink(t, setUpFn, tearDownFn, convertFn, tracImageWithLink(additionalImageLink, tracLinkStr), markdownImageWithLink(additionalImageLink, markdownLinkStr), true)
+	verifyLink(t, setUpFn, tearDownFn, convertFn, tracImageWithLink(additionalImageLink, tracLinkStr), markdownImageWithLink(additionalImageLink, markdownLinkStr), false)
+}
+
+// verifyImageLinkTypes verifies the conversion of a link used as the image of an Image macro
+func verifyImageLinkTypes(
+	t *testing.T,
+	setUpFn func(t *testing.T),
+	tearDownFn func(t *testing.T),
+	convertFn func(tracText string) string,
+	tracLinkStr string,
+	markdownLinkStr string) {
+
 	verifyLink(t, setUpFn, tearDownFn, convertFn, tracImage(tracLinkStr), markdownImage(markdownLinkStr), true)
 	verifyLink(t, setUpFn, tearDownFn, convertFn, tracImage(tracLinkStr), markdownImage(markdownLinkStr), false)
 	verifyLink(t, setUpFn, tearDownFn, convertFn, tracImageWithLink(tracLinkStr, additionalImageLink), markdownImageWithLink(markdownLinkStr, additionalImageLink), true)
 	verifyLink(t, setUpFn, tearDownFn, convertFn, tracImageWithLink(tracLinkStr, additionalImageLink), markdownImageWithLink(markdownLinkStr, additionalImageLink), false)
-	verifyLink(t, setUpFn, tearDownFn, convertFn, tracImageWithLink(additionalImageLink, tracLinkStr), markdownImageWithLink(additionalImageLink, markdownLinkStr), true)
-	verifyLink(t, setUpFn, tearDownFn, convertFn, tracImageWithLink(additionalImageLink, tracLinkStr), markdownImageWithLink(additionalImageLink, markdownLinkStr), false)
 }
 
 const httpLink = "http://www.example.com"
@@ -285,18 +358,20 @@ func setUpTicketOnlyLink(t *testing.T) {
 	// expect call to lookup gitea issue URL
 	mockGiteaAccessor.
 		EXPECT().
-		GetIssueURL(gomock.Eq(issueID)).
+		GetIssueURL(gomock.Eq(ticketID)).
 		Return(issueURL)
 }
 
 func TestTicketLink(t *testing.T) {
-	verifyAllLinkTypes(
+	verifyAllTicketLinkTypes(
 		t,
 		setUpTicketOnlyLink,
 		tearDown,
 		wikiConvert,
 		"ticket:"+ticketIDStr,
-		issueURL)
+		issueURL,
+		issueURL,
+		"#"+ticketIDStr)
 }
 
 const (
@@ -527,12 +602,144 @@ func TestChangesetLink(t *testing.T) {
 		tearDown,
 		wikiConvert,
 		"changeset:\""+commitID+"/repository-name\"",
-		commitURL)
+		commitURL,
+		"123abc4")
+}
+
+const (
+	svnRevision          = "4321"
+	svnRevisionCommit    = "fedcba9876543210fedcba9876543210fedcba98"
+	svnEndRevision       = "4333"
+	svnEndRevisionCommit = "0123456789abcdef0123456789abcdef01234567"
+	svnRevisionURL       = "url-of-revision-commit"
+	svnRevisionRangeURL  = "url-of-revision-range"
+)
+
+func setUpRevisionMap(t *testing.T) {
+	setUp(t)
+	converter.SetRevisionMap(map[string]string{"r" + svnRevision: svnRevisionCommit, "r" + svnEndRevision: svnEndRevisionCommit})
+}
+
+func setUpRevisionLink(t *testing.T) {
+	setUpRevisionMap(t)
+
+	// expect call to get URL of commit mapped from revision
+	mockGiteaAccessor.
+		EXPECT().
+		GetCommitURL(gomock.Eq(svnRevisionCommit)).
+		Return(svnRevisionURL)
+}
+
+func setUpRevisionRangeLink(t *testing.T) {
+	setUpRevisionMap(t)
+
+	// expect call to get URL comparing the commits mapped from the revisions
+	mockGiteaAccessor.
+		EXPECT().
+		GetCompareURL(gomock.Eq(svnRevisionCommit), gomock.Eq(svnEndRevisionCommit)).
+		Return(svnRevisionRangeURL)
+}
+
+func TestMappedChangesetLink(t *testing.T) {
+	verifyAllLinkTypes(
+		t,
+		setUpRevisionLink,
+		tearDown,
+		ticketConvert,
+		"changeset:"+svnRevision,
+		svnRevisionURL,
+		"r"+svnRevision)
+}
+
+func TestRevisionLink(t *testing.T) {
+	verifyAllLinkTypes(
+		t,
+		setUpRevisionLink,
+		tearDown,
+		wikiConvert,
+		"r"+svnRevision,
+		svnRevisionURL,
+		"r"+svnRevision)
+}
+
+func TestRevisionShorthandLink(t *testing.T) {
+	setUpRevisionLink(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.TicketConvert(ticketID, leadingText+" ["+svnRevision+"] "+trailingText)
+	assertEquals(t, conversion, leadingText+" [r"+svnRevision+"]("+svnRevisionURL+") "+trailingText)
+}
+
+func TestRevisionRangeLink(t *testing.T) {
+	verifyAllLinkTypes(
+		t,
+		setUpRevisionRangeLink,
+		tearDown,
+		wikiConvert,
+		"r"+svnRevision+":"+svnEndRevision,
+		svnRevisionRangeURL,
+		"r"+svnRevision+":r"+svnEndRevision)
+}
+
+func TestLogLink(t *testing.T) {
+	verifyAllLinkTypes(
+		t,
+		setUpRevisionRangeLink,
+		tearDown,
+		wikiConvert,
+		"log:@"+svnRevision+":"+svnEndRevision,
+		svnRevisionRangeURL,
+		"r"+svnRevision+":r"+svnEndRevision)
+}
+
+func TestDiffLink(t *testing.T) {
+	verifyAllLinkTypes(
+		t,
+		setUpRevisionRangeLink,
+		tearDown,
+		ticketConvert,
+		"diff:trunk@"+svnRevision+":"+svnEndRevision,
+		svnRevisionRangeURL,
+		"r"+svnRevision+":r"+svnEndRevision)
+}
+
+func TestUnmappedRevisionLink(t *testing.T) {
+	setUpRevisionMap(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.TicketConvert(ticketID, leadingText+" r999 and [999] "+trailingText)
+	assertEquals(t, conversion, leadingText+" r999 and [999] "+trailingText)
+}
+
+func TestRevisionLookalikesAreNotLinks(t *testing.T) {
+	setUpRevisionMap(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+" r"+svnRevision+"x and dir/r"+svnRevision+" and [effaced] "+trailingText)
+	assertEquals(t, conversion, leadingText+" r"+svnRevision+"x and dir/r"+svnRevision+" and [effaced] "+trailingText)
+}
+
+func TestRevisionLinkInCodeNotConverted(t *testing.T) {
+	setUpRevisionMap(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.TicketConvert(
+		ticketID,
+		leadingText+" {{{r"+svnRevision+"}}}\n"+
+			"{{{\n"+
+			"["+svnRevision+"]\n"+
+			"}}}\n")
+	assertEquals(t, conversion,
+		leadingText+" `r"+svnRevision+"`\n"+
+			"```\n"+
+			"["+svnRevision+"]\n"+
+			"```\n")
 }
 
 const (
-	sourcePath = "path/to/some/source/file"
-	sourceURL  = "url-of-source-file"
+	sourcePath   = "path/to/some/source/file"
+	sourceURL    = "url-of-source-file"
+	rawSourceURL = "raw-url-of-source-file"
 )
 
 func setUpSourceLink(t *testing.T) {
@@ -545,12 +752,143 @@ func setUpSourceLink(t *testing.T) {
 		Return(sourceURL)
 }
 
+func setUpRawSourceLink(t *testing.T) {
+	setUp(t)
+
+	// expect call to get raw URL of source file - as used for an image
+	mockGiteaAccessor.
+		EXPECT().
+		GetRawSourceURL(gomock.Eq("master"), gomock.Eq(sourcePath)).
+		Return(rawSourceURL)
+}
+
 func TestSourceLink(t *testing.T) {
+	tracLinkStr := "source:\"repo-name/" + sourcePath + "\""
+	verifyAllTextLinkTypes(t, setUpSourceLink, tearDown, wikiConvert, tracLinkStr, sourceURL)
+	verifyImageLinkTypes(t, setUpRawSourceLink, tearDown, wikiConvert, tracLinkStr, rawSourceURL)
+}
+
+func setUpBranchSourceLink(branch string) func(t *testing.T) {
+	return func(t *testing.T) {
+		setUp(t)
+		converter.SetBranchMap(map[string]string{"branches/1.x": "release-1.x"})
+
+		// expect call to get URL of source file on branch
+		mockGiteaAccessor.
+			EXPECT().
+			GetSourceURL(gomock.Eq(branch), gomock.Eq(sourcePath)).
+			Return(sourceURL)
+	}
+}
+
+func setUpRawBranchSourceLink(branch string) func(t *testing.T) {
+	return func(t *testing.T) {
+		setUp(t)
+		converter.SetBranchMap(map[string]string{"branches/1.x": "release-1.x"})
+
+		// expect call to get raw URL of source file on branch - as used for an image
+		mockGiteaAccessor.
+			EXPECT().
+			GetRawSourceURL(gomock.Eq(branch), gomock.Eq(sourcePath)).
+			Return(rawSourceURL)
+	}
+}
+
+func TestTrunkSourceLink(t *testing.T) {
+	tracLinkStr := "source:trunk/" + sourcePath
+	verifyAllTextLinkTypes(t, setUpBranchSourceLink("master"), tearDown, wikiConvert, tracLinkStr, sourceURL)
+	verifyImageLinkTypes(t, setUpRawBranchSourceLink("master"), tearDown, wikiConvert, tracLinkStr, rawSourceURL)
+}
+
+func TestBranchSourceLink(t *testing.T) {
+	tracLinkStr := "source:/branches/feature/" + sourcePath
+	verifyAllTextLinkTypes(t, setUpBranchSourceLink("feature"), tearDown, wikiConvert, tracLinkStr, sourceURL)
+	verifyImageLinkTypes(t, setUpRawBranchSourceLink("feature"), tearDown, wikiConvert, tracLinkStr, rawSourceURL)
+}
+
+func TestMappedBranchBrowserLink(t *testing.T) {
+	tracLinkStr := "browser:branches/1.x/" + sourcePath
+	verifyAllTextLinkTypes(t, setUpBranchSourceLink("release-1.x"), tearDown, ticketConvert, tracLinkStr, sourceURL)
+	verifyImageLinkTypes(t, setUpRawBranchSourceLink("release-1.x"), tearDown, ticketConvert, tracLinkStr, rawSourceURL)
+}
+
+func setUpRevisionSourceLink(t *testing.T) {
+	setUpRevisionMap(t)
+
+	// expect call to get URL of source file as of commit mapped from revision
+	mockGiteaAccessor.
+		EXPECT().
+		GetCommitSourceURL(gomock.Eq(svnRevisionCommit), gomock.Eq(sourcePath)).
+		Return(sourceURL)
+}
+
+func TestRevisionSourceLinkWithLineNumber(t *testing.T) {
+	// an image is taken from the raw file as of the revision, without the line number
+	tracLinkStr := "source:trunk/" + sourcePath + "@" + svnRevision + "#L45"
+	verifyAllTextLinkTypes(t, setUpRevisionSourceLink, tearDown, wikiConvert, tracLinkStr, sourceURL+"#L45")
+	verifyImageLinkTypes(t, setUpExportLink, tearDown, wikiConvert, tracLinkStr, sourceURL)
+}
+
+func setUpExportLink(t *testing.T) {
+	setUpRevisionMap(t)
+
+	// expect call to get raw URL of source file as of commit mapped from revision
+	mockGiteaAccessor.
+		EXPECT().
+		GetRawCommitSourceURL(gomock.Eq(svnRevisionCommit), gomock.Eq(sourcePath)).
+		Return(sourceURL)
+}
+
+func TestExportLink(t *testing.T) {
 	verifyAllLinkTypes(
 		t,
-		setUpSourceLink,
+		setUpExportLink,
 		tearDown,
 		wikiConvert,
-		"source:\"repo-name/"+sourcePath+"\"",
+		"export:trunk/"+sourcePath+"@"+svnRevision,
 		sourceURL)
 }
+
+func setUpSourceLogLink(t *testing.T) {
+	setUp(t)
+
+	// expect call to get URL of history of source path
+	mockGiteaAccessor.
+		EXPECT().
+		GetSourceLogURL(gomock.Eq("master"), gomock.Eq(sourcePath)).
+		Return(sourceURL)
+}
+
+func TestSourceLogLink(t *testing.T) {
+	verifyAllLinkTypes(
+		t,
+		setUpSourceLogLink,
+		tearDown,
+		ticketConvert,
+		"log:trunk/"+sourcePath,
+		sourceURL)
+}
+
+func TestExportLinkWithRevisionBeforePath(t *testing.T) {
+	verifyAllLinkTypes(
+		t,
+		setUpExportLink,
+		tearDown,
+		wikiConvert,
+		"export:"+svnRevision+":trunk/"+sourcePath,
+		sourceURL)
+}
+
+func TestQuotedSourceLinkWithSpaces(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	// each component of the path is percent-encoded so that it forms a valid markdown link destination
+	mockGiteaAccessor.
+		EXPECT().
+		GetSourceURL(gomock.Eq("master"), gomock.Eq("some%20dir/some%20file.c")).
+		Return(sourceURL)
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+" source:\"trunk/some dir/some file.c\" "+trailingText)
+	assertEquals(t, conversion, leadingText+" ["+sourceURL+"]("+sourceURL+") "+trailingText)
+}
@@ -5,47 +5,253 @@
 package markdown
 
 import (
-	"fmt"
 	"regexp"
+	"strconv"
+	"strings"
 )
 
 var romanNumerals = []string{"i", "ii", "iii", "iv", "v", "vi", "vii", "viii", "ix", "x", "xi", "xii", "xiii", "xiv", "xv", "xvi", "xvii", "xviii", "xix", "xx"}
 
-// Numbered and bulleted Trac lists translate directly to markdown without translation .
-// Therefore we only need to translate lettered lists ('a.', 'b.', ...) and roman-numbered lists ('i.', 'ii.', 'iv.' etc.).
-// Due to the vaguaries of regular expressions we only handle lettered lists from 'a.' to 'h.'
-// since 'i.' clashes with the roman-numbered case and is more likely to be the latter.
-// For roman lists we do not police the actual numerals in the regexp - too painful.
-
-// Regexp for lettered lists: $1=leading white space, $2=letter $3=trailing text
-var letteredListRegexp = regexp.MustCompile(`(?m)^([[:blank:]]*)([a-h])\.([^\n]+)$`)
-
-// Regexp for roman lists: $1=leading white space, $2=roman numerals $3=trailing text
-var romanNumberedListRegexp = regexp.MustCompile(`(?m)^([[:blank:]]*)([ivx]+)\.([^\n]+)$`)
-
-func (converter *DefaultConverter) convertLists(in string) string {
-	out := in
-
-	out = letteredListRegexp.ReplaceAllStringFunc(out, func(match string) string {
-		leadingSpace := letteredListRegexp.ReplaceAllString(match, `$1`)
-		letter := letteredListRegexp.ReplaceAllString(match, `$2`)
-		trailingText := letteredListRegexp.ReplaceAllString(match, `$3`)
-		letterNum := letter[0] - 'a' + 1 // 'a' => 1, 'b' => 2 etc
-		return fmt.Sprintf("%s%d.%s", leadingSpace, letterNum, trailingText)
-	})
-
-	out = romanNumberedListRegexp.ReplaceAllStringFunc(out, func(match string) string {
-		leadingSpace := romanNumberedListRegexp.ReplaceAllString(match, `$1`)
-		roman := romanNumberedListRegexp.ReplaceAllString(match, `$2`)
-		trailingText := romanNumberedListRegexp.ReplaceAllString(match, `$3`)
-		for romanIndex := 0; romanIndex < len(romanNumerals); romanIndex++ {
-			if romanNumerals[romanIndex] == roman {
-				return fmt.Sprintf("%s%d.%s", leadingSpace, romanIndex+1, trailingText)
+// Regexp for a Trac list item: $1=leading white space, $2=list marker, $3=trailing text
+// Trac lists may be bulleted ('*' or '-'), numbered ('1.'), lettered ('a.') or roman-numbered ('i.', 'iv.' etc.).
+var listItemRegexp = regexp.MustCompile(`^([[:blank:]]*)([*-]|[[:digit:]]+\.|[a-z]\.|[ivx]+\.)( .*)$`)
+
+// listKind is the kind of a Trac list, as determined by its list markers
+type listKind int
+
+const (
+	bulletList listKind = iota
+	numberedList
+	letteredList
+	romanList
+)
+
+// listItem is an item of a Trac list along with any nested items
+type listItem struct {
+	indentation string
+	marker      string
+	content     []inline
+
+	// continuation holds any subsequent (indented) lines continuing the text of the item
+	continuation []*listContinuationLine
+	children     []*listItem
+}
+
+// listContinuationLine is a line continuing the text of a list item
+type listContinuationLine struct {
+	// startsParagraph is true if the line follows a blank line and so starts a new paragraph of the item
+	startsParagraph bool
+	content         []inline
+}
+
+// listBlock is a Trac list
+type listBlock struct {
+	items []*listItem
+}
+
+func isListItem(line string) bool {
+	return listItemRegexp.MatchString(line)
+}
+
+// leadingWhitespace returns the leading whitespace of a line
+func leadingWhitespace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// contentColumn returns the column at which the text of a list item starts
+func (item *listItem) contentColumn() int {
+	return len(item.indentation) + len(item.marker) + 1
+}
+
+// continuationParagraphItem returns the index within the open list items of the item which a paragraph following a blank line continues, or -1 if there is none
+// - the paragraph must be indented at least as far as the text of the item it continues
+func (parser *blockParser) continuationParagraphItem(openItems []*listItem) int {
+	pos := parser.pos
+	for pos < len(parser.lines) && strings.TrimSpace(parser.lines[pos]) == "" {
+		pos++
+	}
+	if pos == parser.pos || pos == len(parser.lines) || isBlockStart(parser.lines[pos]) {
+		return -1
+	}
+
+	indentation := len(leadingWhitespace(parser.lines[pos]))
+	for index := len(openItems) - 1; index >= 0; index-- {
+		if indentation >= openItems[index].contentColumn() {
+			return index
+		}
+	}
+	return -1
+}
+
+// parseList parses consecutive list items and their continuation lines into a list, nesting items according to their indentation
+func (parser *blockParser) parseList() block {
+	list := listBlock{}
+
+	// stack of the most recent item at each level of nesting
+	var openItems []*listItem
+	startsParagraph := false
+	for line, ok := parser.currentLine(); ok; line, ok = parser.currentLine() {
+		if strings.TrimSpace(line) == "" {
+			itemIndex := parser.continuationParagraphItem(openItems)
+			if itemIndex == -1 {
+				break
+			}
+
+			openItems = openItems[:itemIndex+1]
+			for strings.TrimSpace(parser.lines[parser.pos]) == "" {
+				parser.pos++
+			}
+			startsParagraph = true
+			continue
+		}
+
+		match := listItemRegexp.FindStringSubmatch(line)
+		if match == nil {
+			// text indented beyond the current item continues it
+			if len(openItems) == 0 || isBlockStart(line) || len(leadingWhitespace(line)) <= len(openItems[len(openItems)-1].indentation) {
+				break
+			}
+
+			currentItem := openItems[len(openItems)-1]
+			currentItem.continuation = append(currentItem.continuation, &listContinuationLine{
+				startsParagraph: startsParagraph,
+				content:         parser.converter.parseInlines(strings.TrimLeft(line, " \t")),
+			})
+			startsParagraph = false
+			parser.pos++
+			continue
+		}
+
+		item := listItem{indentation: match[1], marker: match[2], content: parser.converter.parseInlines(strings.TrimLeft(match[3], " "))}
+		for len(openItems) > 0 && len(openItems[len(openItems)-1].indentation) >= len(item.indentation) {
+			openItems = openItems[:len(openItems)-1]
+		}
+		if len(openItems) == 0 {
+			list.items = append(list.items, &item)
+		} else {
+			parent := openItems[len(openItems)-1]
+			parent.children = append(parent.children, &item)
+		}
+		openItems = append(openItems, &item)
+		parser.pos++
+	}
+
+	return &list
+}
+
+// listMarkerKind returns the kind of list started or continued by a Trac list marker
+// - 'i.' is a roman numeral unless it continues a lettered list
+func listMarkerKind(marker string, continuesLetteredList bool) listKind {
+	number := strings.TrimSuffix(marker, ".")
+	switch {
+	case number == marker:
+		return bulletList
+	case number[0] >= '0' && number[0] <= '9':
+		return numberedList
+	case len(number) == 1 && continuesLetteredList:
+		return letteredList
+	case number == "i" || len(number) > 1:
+		return romanList
+	}
+	return letteredList
+}
+
+// listMarkerNumber returns the number of an item of an ordered Trac list given its list marker
+func listMarkerNumber(marker string, kind listKind) int {
+	number := strings.TrimSuffix(marker, ".")
+	switch kind {
+	case numberedList:
+		value, err := strconv.Atoi(number)
+		if err == nil {
+			return value
+		}
+	case letteredList:
+		return int(number[0]-'a') + 1 // 'a' => 1, 'b' => 2 etc
+	case romanList:
+		for romanIndex, romanNumeral := range romanNumerals {
+			if romanNumeral == number {
+				return romanIndex + 1
 			}
 		}
+	}
+	return 1
+}
+
+// alternateListDelimiter returns the alternative to a markdown list delimiter
+// - markdown merges adjacent lists using the same delimiter so a different one is needed to keep adjacent Trac lists separate
+func alternateListDelimiter(delimiter string) string {
+	switch delimiter {
+	case "*":
+		return "-"
+	case "-":
+		return "*"
+	case ".":
+		return ")"
+	}
+	return "."
+}
+
+// renderListItems renders list items at the given indentation, returning the markdown delimiter used for the last of them.
+// Consecutive items of the same kind form a single markdown list: items are numbered from the number of the first item
+// and nested items and continuation lines are indented to the start of the text of the item containing them.
+func (renderer *renderer) renderListItems(items []*listItem, indentation string, precedingDelimiter string) string {
+	delimiter := precedingDelimiter
+	for runStart := 0; runStart < len(items); {
+		kind := listMarkerKind(items[runStart].marker, false)
+		runEnd := runStart + 1
+		for runEnd < len(items) && listMarkerKind(items[runEnd].marker, kind == letteredList) == kind {
+			runEnd++
+		}
+
+		runDelimiter := "."
+		if kind == bulletList {
+			runDelimiter = items[runStart].marker
+		}
+		if runDelimiter == delimiter {
+			runDelimiter = alternateListDelimiter(runDelimiter)
+		}
+		delimiter = runDelimiter
+
+		// markdown only allows a list which does not start at 1 to follow a paragraph (including the text of an enclosing list item) if separated from it by a blank line
+		start := listMarkerNumber(items[runStart].marker, kind)
+		if kind != bulletList && start != 1 {
+			renderer.addBlankLineSeparator()
+		}
+
+		for index, item := range items[runStart:runEnd] {
+			marker := delimiter
+			if kind != bulletList {
+				marker = strconv.Itoa(start+index) + delimiter
+			}
+			renderer.renderListItem(item, indentation, marker)
+		}
+		runStart = runEnd
+	}
+
+	return delimiter
+}
+
+// renderListItem renders a list item with the given indentation and markdown list marker
+func (renderer *renderer) renderListItem(item *listItem, indentation string, marker string) {
+	renderer.addLine(strings.TrimRight(indentation+marker+" "+renderer.renderLine(item.content), " "))
+
+	contentIndentation := indentation + strings.Repeat(" ", len(marker)+1)
+	for _, continuation := range item.continuation {
+		if continuation.startsParagraph {
+			renderer.addLine("")
+		}
+		renderer.addLine(contentIndentation + renderer.renderLine(continuation.content))
+	}
+	renderer.renderListItems(item.children, contentIndentation, "")
+}
 
-		return match
-	})
+func (renderer *renderer) renderList(list *listBlock) {
+	// a list separated from a preceding list only by blank lines must use a different delimiter to remain a separate list
+	precedingDelimiter := ""
+	if renderer.listEnd > 0 && strings.TrimSpace(strings.Join(renderer.lines[renderer.listEnd:], "")) == "" {
+		precedingDelimiter = renderer.listDelimiter
+	}
 
-	return out
+	renderer.listDelimiter = renderer.renderListItems(list.items, "", precedingDelimiter)
+	renderer.listEnd = len(renderer.lines)
 }
@@ -22,7 +22,7 @@ func TestAsteriskBulletedLists(t *testing.T) {
 			"* " + listItem3 + "\n"
 	markdownList := tracList // asterisk bullets work in both trac and markdown
 
-	conversion := converter.WikiConvert(wikiPage, leadingText+"\n"+tracList+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n"+tracList+trailingText)
 	assertEquals(t, conversion, leadingText+"\n"+markdownList+trailingText)
 }
 
@@ -36,7 +36,7 @@ func TestHyphenBulletedLists(t *testing.T) {
 			"- " + listItem3 + "\n"
 	markdownList := tracList // hyphen bullets work in both trac and markdown
 
-	conversion := converter.WikiConvert(wikiPage, leadingText+"\n"+tracList+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n"+tracList+trailingText)
 	assertEquals(t, conversion, leadingText+"\n"+markdownList+trailingText)
 }
 
@@ -50,7 +50,7 @@ func TestNumberedBulletedLists(t *testing.T) {
 			"3. " + listItem3 + "\n"
 	markdownList := tracList // numbered bullets work in both trac and markdown
 
-	conversion := converter.WikiConvert(wikiPage, leadingText+"\n"+tracList+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n"+tracList+trailingText)
 	assertEquals(t, conversion, leadingText+"\n"+markdownList+trailingText)
 }
 
@@ -65,11 +65,12 @@ func TestLetteredBulletedLists(t *testing.T) {
 	markdownList :=
 		"1. " + listItem1 + "\n" +
 			"2. " + listItem2 + "\n" +
-			"6. " + listItem3 + "\n"
+			"3. " + listItem3 + "\n"
 
-	conversion := converter.WikiConvert(wikiPage, leadingText+"\n"+tracList+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n"+tracList+trailingText)
 	assertEquals(t, conversion, leadingText+"\n"+markdownList+trailingText)
 }
+
 func TestRomanBulletedLists(t *testing.T) {
 	setUp(t)
 	defer tearDown(t)
@@ -80,10 +81,10 @@ func TestRomanBulletedLists(t *testing.T) {
 			"xii. " + listItem3 + "\n"
 	markdownList :=
 		"1. " + listItem1 + "\n" +
-			"4. " + listItem2 + "\n" +
-			"12. " + listItem3 + "\n"
+			"2. " + listItem2 + "\n" +
+			"3. " + listItem3 + "\n"
 
-	conversion := converter.WikiConvert(wikiPage, leadingText+"\n"+tracList+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n"+tracList+trailingText)
 	assertEquals(t, conversion, leadingText+"\n"+markdownList+trailingText)
 }
 
@@ -97,9 +98,117 @@ func TestNestedLists(t *testing.T) {
 			"    * " + listItem3 + "\n"
 	markdownList :=
 		"1. " + listItem1 + "\n" +
-			"  4. " + listItem2 + "\n" +
-			"    * " + listItem3 + "\n"
+			"\n" +
+			"   4. " + listItem2 + "\n" +
+			"      * " + listItem3 + "\n"
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n"+tracList+trailingText)
+	assertEquals(t, conversion, leadingText+"\n"+markdownList+trailingText)
+}
+
+func TestListStartNumbers(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	tracList :=
+		"3. " + listItem1 + "\n" +
+			"4. " + listItem2 + "\n" +
+			"  c. " + listItem3 + "\n"
+	markdownList :=
+		"3. " + listItem1 + "\n" +
+			"4. " + listItem2 + "\n" +
+			"\n" +
+			"   3. " + listItem3 + "\n"
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n"+tracList+trailingText)
+	assertEquals(t, conversion, leadingText+"\n\n"+markdownList+trailingText)
+}
+
+func TestLetteredListContinuingPastH(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	tracList :=
+		"h. " + listItem1 + "\n" +
+			"i. " + listItem2 + "\n" +
+			"j. " + listItem3 + "\n"
+	markdownList :=
+		"8. " + listItem1 + "\n" +
+			"9. " + listItem2 + "\n" +
+			"10. " + listItem3 + "\n"
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n"+tracList+trailingText)
+	assertEquals(t, conversion, leadingText+"\n\n"+markdownList+trailingText)
+}
+
+func TestNestedListsReindented(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	tracList :=
+		" 1. " + listItem1 + "\n" +
+			"  * " + listItem2 + "\n" +
+			"       * " + listItem3 + "\n"
+	markdownList :=
+		"1. " + listItem1 + "\n" +
+			"   * " + listItem2 + "\n" +
+			"     * " + listItem3 + "\n"
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n"+tracList+trailingText)
+	assertEquals(t, conversion, leadingText+"\n"+markdownList+trailingText)
+}
+
+func TestMixedBulletedAndNumberedLists(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	tracList :=
+		"* " + listItem1 + "\n" +
+			"1. " + listItem2 + "\n" +
+			"a. " + listItem3 + "\n"
+	markdownList :=
+		"* " + listItem1 + "\n" +
+			"1. " + listItem2 + "\n" +
+			"1) " + listItem3 + "\n"
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n"+tracList+trailingText)
+	assertEquals(t, conversion, leadingText+"\n"+markdownList+trailingText)
+}
+
+func TestListItemContinuationParagraph(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	tracList :=
+		" * " + listItem1 + "\n" +
+			"   continued\n" +
+			"\n" +
+			"   another paragraph\n" +
+			" * " + listItem2 + "\n"
+	markdownList :=
+		"* " + listItem1 + "\n" +
+			"  continued\n" +
+			"\n" +
+			"  another paragraph\n" +
+			"* " + listItem2 + "\n"
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n"+tracList+trailingText)
+	assertEquals(t, conversion, leadingText+"\n"+markdownList+trailingText)
+}
+
+func TestAdjacentListsKeptSeparate(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	tracList :=
+		"1. " + listItem1 + "\n" +
+			"\n" +
+			"a. " + listItem2 + "\n"
+	markdownList :=
+		"1. " + listItem1 + "\n" +
+			"\n" +
+			"1) " + listItem2 + "\n"
 
-	conversion := converter.WikiConvert(wikiPage, leadingText+"\n"+tracList+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n"+tracList+trailingText)
 	assertEquals(t, conversion, leadingText+"\n"+markdownList+trailingText)
 }
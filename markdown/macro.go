@@ -0,0 +1,258 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+// regexp for contents of a trac '[[<macro>(<args>)]]': $1=macro name, $2=bracketed args, $3=args
+var macroRegexp = regexp.MustCompile(`^([[:alpha:]][[:alnum:]_]*)(\((.*)\))?$`)
+
+// regexp for a line consisting solely of a Trac macro: $1=contents of '[[...]]'
+var macroLineRegexp = regexp.MustCompile(`^\s*\[\[(.*)\]\]\s*$`)
+
+// regexp for a Trac macro keyword argument: $1=keyword, $2=value
+var macroKeywordArgRegexp = regexp.MustCompile(`^([[:alnum:]_-]+)=(.*)$`)
+
+// macroInline is a Trac macro
+type macroInline struct {
+	name    string
+	args    string
+	hasArgs bool
+}
+
+// macroBlock is a Trac macro appearing on a line of its own and which generates a whole block of markdown
+type macroBlock struct {
+	macro *macroInline
+}
+
+// macroKeywordArg is a Trac macro '<keyword>=<value>' argument
+type macroKeywordArg struct {
+	keyword string
+	value   string
+}
+
+// macroArgs are the parsed arguments of a Trac macro
+type macroArgs struct {
+	positional []string
+	keywords   []macroKeywordArg
+}
+
+// macroHandler converts a Trac macro into markdown.
+// Macros which convert into a block of markdown (e.g. a list) have a renderBlock function and are only converted when on a line of their own,
+// macros which convert into markdown text within a line have a writeInline function.
+type macroHandler struct {
+	writeInline func(renderer *renderer, builder *strings.Builder, macro *macroInline)
+	renderBlock func(renderer *renderer, macro *macroInline)
+}
+
+// macroHandlers are the handlers for the Trac macros we convert, indexed by macro name
+// - this is populated at initialisation because some handlers themselves convert Trac wiki text (and so may encounter macros)
+var macroHandlers map[string]macroHandler
+
+func init() {
+	macroHandlers = map[string]macroHandler{
+		"BR":            {writeInline: writePageBreakMacro},
+		"Image":         {writeInline: writeImageMacro},
+		"Include":       {renderBlock: renderIncludeMacro},
+		"PageOutline":   {renderBlock: renderPageOutlineMacro},
+		"RecentChanges": {renderBlock: renderRecentChangesMacro},
+		"Span":          {writeInline: writeSpanMacro},
+		"TicketQuery":   {writeInline: writeTicketQueryMacro, renderBlock: renderTicketQueryMacro},
+		"TitleIndex":    {renderBlock: renderTitleIndexMacro},
+		"TOC":           {writeInline: writeTOCMacro, renderBlock: renderTOCMacro},
+	}
+}
+
+// unconvertedTracMacros are the standard Trac macros for which there is no conversion
+// - any other '[[<name>]]' is treated as a link but these are flagged as unconverted macros
+var unconvertedTracMacros = []string{
+	"ChangeLog",
+	"InterTrac",
+	"InterWiki",
+	"KnownMimeTypes",
+	"MacroList",
+	"RepositoryIndex",
+	"Timestamp",
+	"TracAdminHelp",
+	"TracGuideToc",
+	"TracIni",
+	"ViewTicket",
+	"Workflow",
+}
+
+// lookupMacroHandler returns the handler for the Trac macro with a given name, or false if we do not convert that macro
+func lookupMacroHandler(name string) (macroHandler, bool) {
+	// Trac accepts '[[br]]' in any case
+	if strings.EqualFold(name, "br") {
+		name = "BR"
+	}
+
+	handler, found := macroHandlers[name]
+	return handler, found
+}
+
+// isTracMacro returns true if a name is that of a Trac macro, whether or not we convert it
+func isTracMacro(name string) bool {
+	if _, found := lookupMacroHandler(name); found {
+		return true
+	}
+
+	for _, unconvertedTracMacro := range unconvertedTracMacros {
+		if name == unconvertedTracMacro {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseMacro parses the contents of a '[[...]]' construct as a Trac macro, returning nil if this is not a macro.
+// Anything with bracketed arguments is taken to be a macro, otherwise the name must be that of a known Trac macro.
+func parseMacro(contents string) *macroInline {
+	match := macroRegexp.FindStringSubmatch(contents)
+	if match == nil {
+		return nil
+	}
+
+	hasArgs := match[2] != ""
+	if !hasArgs && !isTracMacro(match[1]) {
+		return nil
+	}
+
+	return &macroInline{name: match[1], args: match[3], hasArgs: hasArgs}
+}
+
+// parseBlockMacro parses a line consisting solely of a Trac macro which converts into a block of markdown, returning nil if the line is not such a macro
+func parseBlockMacro(line string) *macroInline {
+	match := macroLineRegexp.FindStringSubmatch(line)
+	if match == nil {
+		return nil
+	}
+
+	macro := parseMacro(match[1])
+	if macro == nil {
+		return nil
+	}
+
+	if handler, found := lookupMacroHandler(macro.name); !found || handler.renderBlock == nil {
+		return nil
+	}
+
+	return macro
+}
+
+// isBlockMacro returns true if a line consists solely of a Trac macro which converts into a block of markdown
+func isBlockMacro(line string) bool {
+	return parseBlockMacro(line) != nil
+}
+
+func (parser *blockParser) parseMacroBlock() block {
+	macro := parseBlockMacro(parser.lines[parser.pos])
+	parser.pos++
+	return &macroBlock{macro: macro}
+}
+
+// parseArgs parses the arguments of a Trac macro
+// - arguments are separated by commas (a comma can be escaped as '\,') and may be either positional or '<keyword>=<value>'
+func (macro *macroInline) parseArgs() *macroArgs {
+	args := macro.args
+	parsedArgs := macroArgs{}
+	if strings.TrimSpace(args) == "" {
+		return &parsedArgs
+	}
+
+	var builder strings.Builder
+	addArg := func() {
+		arg := strings.TrimSpace(builder.String())
+		builder.Reset()
+		if match := macroKeywordArgRegexp.FindStringSubmatch(arg); match != nil {
+			parsedArgs.keywords = append(parsedArgs.keywords, macroKeywordArg{keyword: match[1], value: strings.TrimSpace(match[2])})
+		} else {
+			parsedArgs.positional = append(parsedArgs.positional, arg)
+		}
+	}
+
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == '\\' && i+1 < len(args) && args[i+1] == ',':
+			builder.WriteByte(',')
+			i++
+		case args[i] == ',':
+			addArg()
+		default:
+			builder.WriteByte(args[i])
+		}
+	}
+	addArg()
+
+	return &parsedArgs
+}
+
+// arg returns a positional argument of a Trac macro, or "" if there is no such argument
+func (args *macroArgs) arg(index int) string {
+	if index >= len(args.positional) {
+		return ""
+	}
+	return args.positional[index]
+}
+
+// keyword returns the value of a keyword argument of a Trac macro, or false if there is no such argument
+func (args *macroArgs) keyword(keyword string) (string, bool) {
+	for _, keywordArg := range args.keywords {
+		if keywordArg.keyword == keyword {
+			return keywordArg.value, true
+		}
+	}
+	return "", false
+}
+
+// tracText returns the original Trac text of a macro
+func (macro *macroInline) tracText() string {
+	if !macro.hasArgs {
+		return "[[" + macro.name + "]]"
+	}
+	return "[[" + macro.name + "(" + macro.args + ")]]"
+}
+
+// writeUnconvertedMacro writes an HTML comment flagging a Trac macro which could not be converted
+func (renderer *renderer) writeUnconvertedMacro(builder *strings.Builder, macro *macroInline, reason string) {
+	renderer.converter.warn(UnconvertedMacroDiagnostic, macro.tracText(), "Trac macro %s %s", macro.tracText(), reason)
+
+	// '--' cannot appear in an HTML comment
+	macroText := strings.Replace(macro.tracText(), "--", "- -", -1)
+	builder.WriteString("<!-- Trac macro " + macroText + " " + reason + " -->")
+}
+
+func (renderer *renderer) writeMacro(builder *strings.Builder, node *macroInline) {
+	handler, found := lookupMacroHandler(node.name)
+	switch {
+	case !found:
+		renderer.writeUnconvertedMacro(builder, node, "not converted")
+	case handler.writeInline == nil:
+		renderer.writeUnconvertedMacro(builder, node, "not converted: it must be on a line of its own")
+	default:
+		handler.writeInline(renderer, builder, node)
+	}
+}
+
+// renderUnconvertedMacro renders a Trac macro which could not be converted as a line of its own
+func (renderer *renderer) renderUnconvertedMacro(macro *macroInline, reason string) {
+	var builder strings.Builder
+	renderer.writeUnconvertedMacro(&builder, macro, reason)
+	renderer.addLine(builder.String())
+}
+
+func (renderer *renderer) renderMacroBlock(node *macroBlock) {
+	handler, _ := lookupMacroHandler(node.macro.name)
+	handler.renderBlock(renderer, node.macro)
+}
+
+// writePageBreakMacro writes the markdown for a Trac '[[BR]]' macro
+func writePageBreakMacro(renderer *renderer, builder *strings.Builder, macro *macroInline) {
+	writePageBreak(builder)
+}
@@ -0,0 +1,171 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown_test
+
+import (
+	"testing"
+
+	"github.com/stevejefferson/trac2gitea/accessor/trac"
+	"go.uber.org/mock/gomock"
+)
+
+const (
+	includedPageName      = "IncludedPage"
+	otherPageName         = "OtherPage"
+	predefinedPageName    = "TracGuide"
+	giteaOtherPageName    = "GiteaOtherPage"
+	giteaIncludedPageName = "GiteaIncludedPage"
+)
+
+func expectTracToReturnWikiPages(t *testing.T, pages ...*trac.WikiPage) {
+	mockTracAccessor.
+		EXPECT().
+		GetWikiPages(gomock.Any()).
+		DoAndReturn(func(handlerFn func(page *trac.WikiPage) error) error {
+			for _, page := range pages {
+				handlerFn(page)
+			}
+			return nil
+		})
+}
+
+func expectToTestForPredefinedPage(t *testing.T, pageName string, isPredefined bool) {
+	mockTracAccessor.
+		EXPECT().
+		IsPredefinedPage(pageName).
+		Return(isPredefined)
+}
+
+func expectToTranslateWikiPageName(t *testing.T, pageName string, giteaPageName string) {
+	mockGiteaAccessor.
+		EXPECT().
+		TranslateWikiPageName(pageName).
+		Return(giteaPageName)
+}
+
+func TestUnknownMacro(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"[[SomeMacro(arg1, arg2)]]"+trailingText)
+	assertEquals(t, conversion, leadingText+"<!-- Trac macro [[SomeMacro(arg1, arg2)]] not converted -->"+trailingText)
+}
+
+func TestUnconvertedTracMacro(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"[[TracIni]]"+trailingText)
+	assertEquals(t, conversion, leadingText+"<!-- Trac macro [[TracIni]] not converted -->"+trailingText)
+}
+
+func TestBlockMacroWithinText(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"[[TitleIndex]]"+trailingText)
+	assertEquals(t, conversion, leadingText+"<!-- Trac macro [[TitleIndex]] not converted: it must be on a line of its own -->"+trailingText)
+}
+
+func TestSpanMacro(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"[[Span(some ''text'', title=important, style=color: red)]]"+trailingText)
+	assertEquals(t, conversion, leadingText+"<span title=\"important\" style=\"color: red\">some *text*</span>"+trailingText)
+}
+
+func TestSpanMacroDropsAttributesGiteaStrips(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"[[Span(some text, class=important, style=font-size: 90%)]]"+trailingText)
+	assertEquals(t, conversion, leadingText+"<span>some text</span>"+trailingText)
+}
+
+func TestPageOutlineMacro(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, "[[PageOutline]]\n= Heading One =\n== Heading Two == #anchor2\n")
+	assertEquals(t, conversion, "1. [Heading One](#heading-one)\n   1. [Heading Two](#heading-two)\n# Heading One\n## Heading Two\n")
+}
+
+func TestPageOutlineMacroWithLevels(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, "[[PageOutline(2-3,,unnumbered)]]\n= Heading One =\n== Heading Two ==\n=== Heading Three ===\n")
+	assertEquals(t, conversion, "* [Heading Two](#heading-two)\n  * [Heading Three](#heading-three)\n# Heading One\n## Heading Two\n### Heading Three\n")
+}
+
+func TestTitleIndexMacro(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	expectTracToReturnWikiPages(t,
+		&trac.WikiPage{Name: otherPageName, Version: 1},
+		&trac.WikiPage{Name: predefinedPageName, Version: 1},
+		&trac.WikiPage{Name: otherPageName, Version: 2})
+	expectToTestForPredefinedPage(t, otherPageName, false)
+	expectToTestForPredefinedPage(t, predefinedPageName, true)
+	expectToTranslateWikiPageName(t, otherPageName, giteaOtherPageName)
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n[[TitleIndex]]\n"+trailingText)
+	assertEquals(t, conversion, leadingText+"\n\n* ["+otherPageName+"]("+giteaOtherPageName+")\n"+trailingText)
+}
+
+func TestTitleIndexMacroWithPrefix(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	expectTracToReturnWikiPages(t,
+		&trac.WikiPage{Name: otherPageName, Version: 1},
+		&trac.WikiPage{Name: includedPageName, Version: 1})
+	expectToTestForPredefinedPage(t, otherPageName, false)
+	expectToTestForPredefinedPage(t, includedPageName, false)
+	expectToTranslateWikiPageName(t, includedPageName, giteaIncludedPageName)
+
+	conversion, _ := converter.WikiConvert(wikiPage, "[[TitleIndex(Incl)]]")
+	assertEquals(t, conversion, "* ["+includedPageName+"]("+giteaIncludedPageName+")")
+}
+
+func TestRecentChangesMacro(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	expectTracToReturnWikiPages(t,
+		&trac.WikiPage{Name: otherPageName, Version: 1, UpdateTime: 1577880000},
+		&trac.WikiPage{Name: includedPageName, Version: 1, UpdateTime: 1577966400})
+	expectToTestForPredefinedPage(t, otherPageName, false)
+	expectToTestForPredefinedPage(t, includedPageName, false)
+	expectToTranslateWikiPageName(t, otherPageName, giteaOtherPageName)
+	expectToTranslateWikiPageName(t, includedPageName, giteaIncludedPageName)
+
+	conversion, _ := converter.WikiConvert(wikiPage, "[[RecentChanges]]")
+	assertEquals(t, conversion,
+		"* 2020-01-02\n  * ["+includedPageName+"]("+giteaIncludedPageName+")\n"+
+			"* 2020-01-01\n  * ["+otherPageName+"]("+giteaOtherPageName+")")
+}
+
+func TestIncludeMacro(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	expectTracToReturnWikiPages(t, &trac.WikiPage{Name: includedPageName, Text: "included '''text'''", Version: 1})
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n[[Include("+includedPageName+")]]\n"+trailingText)
+	assertEquals(t, conversion, leadingText+"\n\nincluded **text**\n"+trailingText)
+}
+
+func TestIncludeMacroForMissingPage(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	expectTracToReturnWikiPages(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, "[[Include(wiki:"+includedPageName+")]]")
+	assertEquals(t, conversion, "<!-- Trac macro [[Include(wiki:"+includedPageName+")]] not converted: there is no such wiki page -->")
+}
@@ -0,0 +1,156 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/stevejefferson/trac2gitea/accessor/trac"
+)
+
+// regexp for a word which may be a Trac user name - internal '.'s and '-'s are allowed but sentence punctuation following the name is not part of it
+var userNameRegexp = regexp.MustCompile(`^[[:alnum:]](?:[[:alnum:]_.\-]*[[:alnum:]_])?`)
+
+// regexp for the preamble Trac adds to a reply to a ticket comment or description:
+// 'Replying to [comment:<commentNum> <user>]:' or 'Replying to [ticket:<ticketID> <user>]:' - $1=link target, $2=user
+var replyPreambleRegexp = regexp.MustCompile(`^Replying to \[((?:comment|ticket):[[:digit:]]+) ([^\]]+)\]:`)
+
+// regexp for the text preceding a user name which is to be mentioned: 'assigning to', 'assigned to', 'ping' or 'cc', optionally followed by a list of other users
+// - a user name elsewhere in prose is left alone as user names are often ordinary words ('admin', 'build', 'release' ...)
+var mentionContextRegexp = regexp.MustCompile(`(?i)\b(?:assign(?:ing|ed)?\s+to|ping|cc)(?:\s*:\s*|\s+)(?:[[:alnum:]][[:alnum:]_.\-]*(?:\s*,\s*(?:and\s+)?|\s+and\s+))*$`)
+
+// characters which, if preceding or following a user name, indicate that it is part of something else (an email address, a path etc.) rather than a mention
+const (
+	nonMentionPrecedingChars = "/:.@#-_~&=+\\"
+	nonMentionFollowingChars = "@/#-~&=+\\"
+)
+
+// mentionInline is a Trac user name in the text of a ticket description or comment, to be converted into a Gitea '@<user>' mention
+type mentionInline struct {
+	source    string
+	giteaUser string
+}
+
+// replyInline is the 'Replying to [<link> <user>]' part of the preamble of a reply to a ticket comment or description
+type replyInline struct {
+	link      *tracLink
+	source    string
+	user      string
+	giteaUser string
+}
+
+// mappedGiteaUser returns the Gitea user onto which a Trac user is mapped, or "" if the user is not mapped onto any Gitea user
+func (converter *DefaultConverter) mappedGiteaUser(tracUser string) string {
+	if converter.userMap == nil {
+		return ""
+	}
+	return converter.userMap[tracUser]
+}
+
+// matchMention matches the name of a Trac user with a Gitea mapping at a given position of the text being parsed, returning the mention and its length or a nil inline if there is none
+func (parser *inlineParser) matchMention(pos int, prevRune rune) (inline, int) {
+	if len(parser.converter.userMap) == 0 || parser.inLinkText || isAlphanumeric(prevRune) || (prevRune != 0 && strings.ContainsRune(nonMentionPrecedingChars, prevRune)) {
+		return nil, 0
+	}
+	// (an escaped user name is preceded by its '!')
+	if !mentionContextRegexp.MatchString(strings.TrimSuffix(parser.text[:pos], "!")) {
+		return nil, 0
+	}
+	s := parser.text[pos:]
+
+	userName := userNameRegexp.FindString(s)
+	giteaUser := parser.converter.mappedGiteaUser(userName)
+	if giteaUser == "" {
+		return nil, 0
+	}
+
+	// a user name immediately followed by an '@' etc. is part of an email address or some such
+	if len(userName) < len(s) && strings.IndexByte(nonMentionFollowingChars, s[len(userName)]) != -1 {
+		return nil, 0
+	}
+
+	return &mentionInline{source: userName, giteaUser: giteaUser}, len(userName)
+}
+
+// matchReplyPreamble matches the preamble Trac adds to the start of a reply to a ticket comment or description, returning the reply and its length or a nil inline if there is none
+// - the trailing ':' of the preamble is left as text
+func (parser *inlineParser) matchReplyPreamble(s string) (inline, int) {
+	match := replyPreambleRegexp.FindStringSubmatch(s)
+	if match == nil {
+		return nil, 0
+	}
+	link := parser.converter.parseLinkTarget(match[1])
+	if link == nil {
+		return nil, 0
+	}
+
+	user := strings.TrimSpace(match[2])
+	length := len(match[0]) - 1
+	return &replyInline{link: link, source: s[:length], user: user, giteaUser: parser.converter.mappedGiteaUser(user)}, length
+}
+
+// MentionedUsers returns the Gitea users mentioned in a comment/description string associated with a Trac ticket
+// - these are the users onto which Trac users named in the text are mapped.
+// Each user is returned once, in order of first mention.
+func (converter *DefaultConverter) MentionedUsers(ticketID int64, in string) []string {
+	blocks := converter.parse(converter.convertEOL(in))
+
+	users := []string{}
+	found := make(map[string]bool)
+	addUser := func(giteaUser string) {
+		if giteaUser != "" && !found[giteaUser] {
+			found[giteaUser] = true
+			users = append(users, giteaUser)
+		}
+	}
+	walkInlines(blocks, func(node inline) {
+		switch node := node.(type) {
+		case *mentionInline:
+			addUser(node.giteaUser)
+		case *replyInline:
+			addUser(node.giteaUser)
+		}
+	})
+	return users
+}
+
+// literalText returns the literal text of an inline which is rendered as literal text, or false if it is not rendered as such
+// - mentions only apply to tickets: in a wiki page the user name is just text
+func (renderer *renderer) literalText(node inline) (string, bool) {
+	switch node := node.(type) {
+	case *textInline:
+		return node.text, true
+	case *mentionInline:
+		if renderer.ticketID == trac.NullID {
+			return node.source, true
+		}
+	}
+	return "", false
+}
+
+// writeMention writes a Gitea '@<user>' mention
+func writeMention(builder *strings.Builder, node *mentionInline) {
+	builder.WriteString("@" + node.giteaUser)
+}
+
+// writeReply writes the preamble of a reply to a ticket comment or description as a link to the comment or description being replied to, mentioning its author
+func (renderer *renderer) writeReply(builder *strings.Builder, node *replyInline) {
+	url, _, resolved := renderer.resolveLink(node.link)
+	if !resolved {
+		builder.WriteString(escapeMarkdown(node.source))
+		return
+	}
+
+	replyTarget := "comment"
+	if node.link.kind == ticketLink {
+		replyTarget = "description"
+	}
+	user := escapeMarkdown(node.user)
+	if node.giteaUser != "" && renderer.ticketID != trac.NullID {
+		user = "@" + node.giteaUser
+	}
+	builder.WriteString("Replying to [" + replyTarget + "](" + url + ") by " + user)
+}
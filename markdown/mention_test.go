@@ -0,0 +1,129 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown_test
+
+import (
+	"testing"
+)
+
+const (
+	mentionedTracUser   = "jsmith"
+	mentionedGiteaUser  = "john.smith"
+	dottedTracUser      = "a.jones"
+	dottedGiteaUser     = "ann"
+	unmappedTracUser    = "bob"
+	mappedTracUserEmail = "jsmith@example.com"
+)
+
+func setUpMentions(t *testing.T) {
+	setUp(t)
+	setUpUserMap()
+}
+
+func setUpUserMap() {
+	converter.SetUserMap(map[string]string{
+		mentionedTracUser: mentionedGiteaUser,
+		dottedTracUser:    dottedGiteaUser,
+		unmappedTracUser:  "",
+	})
+}
+
+func verifyMention(t *testing.T, tracText string, markdownText string) {
+	setUpMentions(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.TicketConvert(ticketID, leadingText+" "+tracText+" "+trailingText)
+	assertEquals(t, conversion, leadingText+" "+markdownText+" "+trailingText)
+}
+
+func TestMention(t *testing.T) {
+	verifyMention(t, "assigning to "+mentionedTracUser, "assigning to @"+mentionedGiteaUser)
+}
+
+func TestMentionFollowedByPunctuation(t *testing.T) {
+	verifyMention(t, "ping "+dottedTracUser+".", "ping @"+dottedGiteaUser+".")
+	verifyMention(t, "cc: "+mentionedTracUser+";", "cc: @"+mentionedGiteaUser+";")
+}
+
+func TestMultipleMentions(t *testing.T) {
+	verifyMention(t, "cc "+mentionedTracUser+", "+dottedTracUser, "cc @"+mentionedGiteaUser+", @"+dottedGiteaUser)
+	verifyMention(t, "Assigned to "+dottedTracUser+" and "+mentionedTracUser, "Assigned to @"+dottedGiteaUser+" and @"+mentionedGiteaUser)
+}
+
+func TestUserNameInProseNotMentioned(t *testing.T) {
+	setUpMentions(t)
+	defer tearDown(t)
+
+	// user names which are ordinary words are only mentioned where the text is clearly addressing the user
+	converter.SetUserMap(map[string]string{"build": "build-bot", "release": "release-manager"})
+	tracText := "the build fails after release 2.0; ping release"
+	conversion, _ := converter.TicketConvert(ticketID, tracText)
+	assertEquals(t, conversion, "the build fails after release 2.0; ping @release-manager")
+
+	mentionedUsers := converter.MentionedUsers(ticketID, tracText)
+	assertEquals(t, len(mentionedUsers), 1)
+	assertEquals(t, mentionedUsers[0], "release-manager")
+}
+
+func TestUnmappedUserNotMentioned(t *testing.T) {
+	verifyMention(t, "ping "+unmappedTracUser, "ping "+unmappedTracUser)
+}
+
+func TestUserNameInWordNotMentioned(t *testing.T) {
+	verifyMention(t, mappedTracUserEmail, mappedTracUserEmail)
+	verifyMention(t, "xjsmith "+mentionedTracUser+"x", "xjsmith "+mentionedTracUser+"x")
+	verifyMention(t, "path/"+mentionedTracUser+" "+mentionedTracUser+"-thing", "path/"+mentionedTracUser+" "+mentionedTracUser+"-thing")
+}
+
+func TestEscapedUserNameNotMentioned(t *testing.T) {
+	verifyMention(t, "ping !"+mentionedTracUser, "ping "+mentionedTracUser)
+}
+
+func TestUserNameInLinkTextNotMentioned(t *testing.T) {
+	verifyMention(t, "[http://www.example.com "+mentionedTracUser+"]", "["+mentionedTracUser+"](http://www.example.com)")
+}
+
+func TestUserNameInWikiNotMentioned(t *testing.T) {
+	setUpMentions(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+" ping "+mentionedTracUser+" "+trailingText)
+	assertEquals(t, conversion, leadingText+" ping "+mentionedTracUser+" "+trailingText)
+}
+
+func TestMentionedUsers(t *testing.T) {
+	setUpMentions(t)
+	defer tearDown(t)
+
+	mentionedUsers := converter.MentionedUsers(ticketID, "ping "+dottedTracUser+" and "+unmappedTracUser+"\n * cc "+mentionedTracUser+", "+dottedTracUser)
+	assertEquals(t, len(mentionedUsers), 2)
+	assertEquals(t, mentionedUsers[0], dottedGiteaUser)
+	assertEquals(t, mentionedUsers[1], mentionedGiteaUser)
+}
+
+func setUpReply(t *testing.T) {
+	setUpTicketCommentLink(t, ticketID)
+	setUpUserMap()
+}
+
+func TestReplyPreamble(t *testing.T) {
+	setUpReply(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.TicketConvert(ticketID, "Replying to [comment:"+tracCommentNumStr+" "+mentionedTracUser+"]:\n> "+leadingText+"\n\n"+trailingText)
+	assertEquals(t, conversion, "Replying to [comment]("+commentURL+") by @"+mentionedGiteaUser+":\n> "+leadingText+"\n\n"+trailingText)
+
+	mentionedUsers := converter.MentionedUsers(ticketID, "Replying to [comment:"+tracCommentNumStr+" "+mentionedTracUser+"]:")
+	assertEquals(t, len(mentionedUsers), 1)
+	assertEquals(t, mentionedUsers[0], mentionedGiteaUser)
+}
+
+func TestReplyPreambleForUnmappedUser(t *testing.T) {
+	setUpReply(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.TicketConvert(ticketID, "Replying to [comment:"+tracCommentNumStr+" "+unmappedTracUser+"]:")
+	assertEquals(t, conversion, "Replying to [comment]("+commentURL+") by "+unmappedTracUser+":")
+}
@@ -4,12 +4,55 @@
 
 package markdown
 
-import "regexp"
+import (
+	"regexp"
+	"strings"
+)
 
-var pageBreakRegexp = regexp.MustCompile(`\[\[[Bb][Rr]\]\]`)
+// regexp for a Trac horizontal rule
+var horizontalRuleRegexp = regexp.MustCompile(`^\s*-{4,}\s*$`)
 
-func (converter *DefaultConverter) convertParagraphs(in string) string {
-	// convert trac page breaks to HTML <br>s
-	// - the alternative of "  \n" to force a newline doesn't work in the likes of table cells
-	return pageBreakRegexp.ReplaceAllString(in, "<br>")
+// paragraphBlock is a paragraph of ordinary text
+type paragraphBlock struct {
+	lines [][]inline
+}
+
+// horizontalRuleBlock is a Trac '----' horizontal rule
+type horizontalRuleBlock struct{}
+
+func isHorizontalRule(line string) bool {
+	return horizontalRuleRegexp.MatchString(line)
+}
+
+// parseParagraph parses consecutive lines of ordinary text into a paragraph
+func (parser *blockParser) parseParagraph() block {
+	paragraph := paragraphBlock{}
+	for line, ok := parser.currentLine(); ok; line, ok = parser.currentLine() {
+		if len(paragraph.lines) > 0 && (isBlockStart(line) || isIndented(line)) {
+			break
+		}
+
+		paragraph.lines = append(paragraph.lines, parser.converter.parseInlines(line))
+		parser.pos++
+	}
+
+	return &paragraph
+}
+
+func (renderer *renderer) renderParagraph(paragraph *paragraphBlock) {
+	for _, line := range paragraph.lines {
+		renderer.addLine(renderer.renderLine(line))
+	}
+}
+
+func (renderer *renderer) renderHorizontalRule(rule *horizontalRuleBlock) {
+	// a rule immediately following text would be interpreted by markdown as a heading underline
+	renderer.addBlankLineSeparator()
+	renderer.addLine("----")
+}
+
+// writePageBreak writes the markdown for a Trac '[[BR]]' page break
+func writePageBreak(builder *strings.Builder) {
+	// the alternative of "  \n" to force a newline doesn't work in the likes of table cells
+	builder.WriteString("<br>")
 }
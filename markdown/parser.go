@@ -0,0 +1,98 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"strings"
+)
+
+// blockParser parses lines of Trac wiki text into blocks
+type blockParser struct {
+	converter *DefaultConverter
+	lines     []string
+	pos       int
+}
+
+// parse parses Trac wiki text into a sequence of blocks
+func (converter *DefaultConverter) parse(in string) []block {
+	return converter.parseLines(strings.Split(in, "\n"))
+}
+
+// parseLines parses lines of Trac wiki text into a sequence of blocks
+func (converter *DefaultConverter) parseLines(lines []string) []block {
+	parser := blockParser{converter: converter, lines: lines}
+	return parser.parseBlocks()
+}
+
+func (parser *blockParser) parseBlocks() []block {
+	blocks := []block{}
+	for parser.pos < len(parser.lines) {
+		node := parser.parseBlock()
+
+		// the link to the commit replaces the 'In [<changeset>]:' line preceding a '#!CommitTicketReference' processor
+		if _, ok := node.(*commitTicketReferenceBlock); ok && len(blocks) > 0 && isCommitTicketReferenceIntro(blocks[len(blocks)-1]) {
+			blocks = blocks[:len(blocks)-1]
+		}
+		blocks = append(blocks, node)
+	}
+
+	return blocks
+}
+
+// parseBlock parses the block starting at the current line, leaving the parser positioned at the line after the block
+func (parser *blockParser) parseBlock() block {
+	line := parser.lines[parser.pos]
+	trimmedLine := strings.TrimSpace(line)
+	switch {
+	case trimmedLine == "":
+		parser.pos++
+		return &blankBlock{}
+	case isTableRow(line) || parser.tableProcessorName() != "":
+		return parser.parseTable()
+	case isCodeBlockStart(trimmedLine):
+		return parser.parseCodeBlock()
+	case isBlockMacro(line):
+		return parser.parseMacroBlock()
+	case isHeading(line):
+		return parser.parseHeading()
+	case isHorizontalRule(line):
+		parser.pos++
+		return &horizontalRuleBlock{}
+	case isListItem(line):
+		return parser.parseList()
+	case isDefinition(line):
+		return parser.parseDefinition()
+	case isIndented(line):
+		return parser.parseBlockQuote()
+	}
+
+	return parser.parseParagraph()
+}
+
+// isBlockStart returns true if a line starts a block other than a paragraph or block quote
+func isBlockStart(line string) bool {
+	trimmedLine := strings.TrimSpace(line)
+	return trimmedLine == "" ||
+		isCodeBlockStart(trimmedLine) ||
+		isBlockMacro(line) ||
+		isHeading(line) ||
+		isHorizontalRule(line) ||
+		isTableRow(line) ||
+		isListItem(line) ||
+		isDefinition(line)
+}
+
+// isIndented returns true if a line starts with whitespace
+func isIndented(line string) bool {
+	return line != "" && (line[0] == ' ' || line[0] == '\t')
+}
+
+// currentLine returns the current line of the parser, or false if there are no more lines
+func (parser *blockParser) currentLine() (string, bool) {
+	if parser.pos >= len(parser.lines) {
+		return "", false
+	}
+	return parser.lines[parser.pos], true
+}
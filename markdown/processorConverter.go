@@ -0,0 +1,45 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import ()
+
+// processorConverter converts the content of a Trac processor written in some other markup language into lines of markdown.
+// An error is returned if the content uses any construct which the converter does not support.
+type processorConverter func(renderer *renderer, content []string) ([]string, error)
+
+// processorConverters are the converters for each Trac processor whose content can be converted into markdown
+var processorConverters = map[string]processorConverter{}
+
+func init() {
+	processorConverters["rst"] = convertRST
+	processorConverters["html"] = convertHTML
+}
+
+// convertedProcessorBlock is the contents of a Trac processor which is converted into markdown by a processorConverter.
+// If the conversion fails, the fallback block is rendered instead.
+type convertedProcessorBlock struct {
+	processorName string
+	content       []string
+	fallback      block
+}
+
+func (renderer *renderer) renderConvertedProcessorBlock(processorBlock *convertedProcessorBlock) {
+	convertProcessor := processorConverters[processorBlock.processorName]
+	lines, err := convertProcessor(renderer, processorBlock.content)
+	if err != nil {
+		renderer.converter.warn(UnconvertedProcessorDiagnostic, "#!"+processorBlock.processorName,
+			"cannot convert content of Trac '#!%s' processor into markdown, leaving it unconverted: %v", processorBlock.processorName, err)
+		renderer.renderBlock(processorBlock.fallback)
+		return
+	}
+
+	// separate the converted markdown from any surrounding text
+	renderer.addBlankLineSeparator()
+	for _, line := range lines {
+		renderer.addLine(line)
+	}
+	renderer.addLine("")
+}
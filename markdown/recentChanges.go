@@ -0,0 +1,59 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// renderRecentChangesMacro renders a Trac '[[RecentChanges(<prefix>,<limit>,group=<date|none>)]]' macro as a list of links to the most recently modified wiki pages.
+// The list is static: it reflects the modifications made up to the time of the conversion.
+// As in Trac, the pages are grouped by the date of their last modification unless 'group=none' is specified.
+func renderRecentChangesMacro(renderer *renderer, macro *macroInline) {
+	args := macro.parseArgs()
+	prefix := args.arg(0)
+	limit, err := strconv.Atoi(args.arg(1))
+	if err != nil || limit <= 0 {
+		limit = -1
+	}
+	group, _ := args.keyword("group")
+	groupByDate := group != "none"
+
+	pages := renderer.converter.importedWikiPages()
+	sort.SliceStable(pages, func(i, j int) bool {
+		return pages[i].UpdateTime > pages[j].UpdateTime
+	})
+
+	renderer.addBlankLineSeparator()
+	lastDate := ""
+	for _, page := range pages {
+		if limit == 0 {
+			break
+		}
+		if !strings.HasPrefix(page.Name, prefix) {
+			continue
+		}
+		limit--
+
+		var builder strings.Builder
+		date := time.Unix(page.UpdateTime, 0).UTC().Format("2006-01-02")
+		if groupByDate {
+			if date != lastDate {
+				renderer.addLine("* " + date)
+				lastDate = date
+			}
+			builder.WriteString("  * ")
+			renderer.writeWikiPageLink(&builder, page.Name)
+		} else {
+			builder.WriteString("* ")
+			renderer.writeWikiPageLink(&builder, page.Name)
+			builder.WriteString(" (" + date + ")")
+		}
+		renderer.addLine(builder.String())
+	}
+}
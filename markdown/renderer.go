@@ -0,0 +1,149 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"strings"
+
+	"github.com/stevejefferson/trac2gitea/log"
+)
+
+// renderer renders the abstract syntax tree of some Trac wiki text as lines of markdown.
+// Links are resolved relative to either a ticket (in which case ticketID != NullID) or a wiki page (in which case wikiPage != "").
+type renderer struct {
+	converter     *DefaultConverter
+	ticketID      int64
+	wikiPage      string
+	headings      []*headingBlock
+	includedPages []string
+	lines         []string
+
+	// listEnd is the number of lines output as of the end of the most recent list, and listDelimiter the markdown delimiter of its last items
+	listEnd       int
+	listDelimiter string
+}
+
+// addLine adds a line of markdown to the output
+func (renderer *renderer) addLine(line string) {
+	renderer.lines = append(renderer.lines, line)
+}
+
+// addBlankLineSeparator adds a blank line to the output unless it is empty or already ends in a blank line
+// - markdown requires some constructs to be separated from any preceding text
+func (renderer *renderer) addBlankLineSeparator() {
+	if len(renderer.lines) > 0 && renderer.lines[len(renderer.lines)-1] != "" {
+		renderer.addLine("")
+	}
+}
+
+func (renderer *renderer) renderBlocks(blocks []block) {
+	for _, block := range blocks {
+		renderer.renderBlock(block)
+	}
+}
+
+func (renderer *renderer) renderBlock(node block) {
+	switch node := node.(type) {
+	case *blankBlock:
+		renderer.addLine("")
+	case *paragraphBlock:
+		renderer.renderParagraph(node)
+	case *horizontalRuleBlock:
+		renderer.renderHorizontalRule(node)
+	case *headingBlock:
+		renderer.renderHeading(node)
+	case *listBlock:
+		renderer.renderList(node)
+	case *definitionBlock:
+		renderer.renderDefinition(node)
+	case *blockQuoteBlock:
+		renderer.renderBlockQuote(node)
+	case *tableBlock:
+		renderer.renderTable(node)
+	case *codeBlock:
+		renderer.renderCodeBlock(node)
+	case *htmlBlock:
+		renderer.renderHTMLBlock(node)
+	case *commentBlock:
+		renderer.renderCommentBlock(node)
+	case *htmlTagBlock:
+		renderer.renderHTMLTagBlock(node)
+	case *htmlSpanBlock:
+		renderer.renderHTMLSpanBlock(node)
+	case *commitTicketReferenceBlock:
+		renderer.renderCommitTicketReference(node)
+	case *convertedProcessorBlock:
+		renderer.renderConvertedProcessorBlock(node)
+	case *macroBlock:
+		renderer.renderMacroBlock(node)
+	default:
+		log.Error("cannot render unknown wiki block %T", node)
+	}
+}
+
+func (renderer *renderer) renderInlines(inlines []inline) string {
+	var builder strings.Builder
+	renderer.writeInlines(&builder, inlines)
+	return builder.String()
+}
+
+// renderLine renders inlines forming the start of a line of markdown,
+// escaping any literal text at the start of the line which markdown would interpret as the start of a block
+func (renderer *renderer) renderLine(inlines []inline) string {
+	line := renderer.renderInlines(inlines)
+	if len(inlines) > 0 {
+		if _, ok := renderer.literalText(inlines[0]); ok {
+			return escapeLineStart(line)
+		}
+	}
+	return line
+}
+
+// followingText returns the literal text immediately following the inline at a given index, if any
+func followingText(inlines []inline, index int) string {
+	if index+1 < len(inlines) {
+		if textNode, ok := inlines[index+1].(*textInline); ok {
+			return textNode.text
+		}
+	}
+	return ""
+}
+
+func (renderer *renderer) writeInlines(builder *strings.Builder, inlines []inline) {
+	// adjacent spans of literal text are escaped together as whether markdown punctuation needs escaping depends on the surrounding text
+	text := ""
+	for index, node := range inlines {
+		if nodeText, ok := renderer.literalText(node); ok {
+			text = text + nodeText
+			if index+1 < len(inlines) {
+				if _, nextIsText := renderer.literalText(inlines[index+1]); nextIsText {
+					continue
+				}
+			}
+			builder.WriteString(escapeMarkdown(text))
+			text = ""
+			continue
+		}
+
+		switch node := node.(type) {
+		case *codeInline:
+			builder.WriteString(renderCodeInline(node))
+		case *styledInline:
+			renderer.writeStyledInline(builder, node)
+		case *anchorInline:
+			renderer.writeAnchor(builder, node)
+		case *linkInline:
+			renderer.writeLink(builder, node, followingText(inlines, index))
+		case *macroInline:
+			renderer.writeMacro(builder, node)
+		case *mentionInline:
+			writeMention(builder, node)
+		case *replyInline:
+			renderer.writeReply(builder, node)
+		default:
+			log.Error("cannot render unknown wiki inline %T", node)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"strings"
+)
+
+// ticket queries equivalent to those of Trac's default reports which have a Gitea issue list equivalent, indexed by report title
+// - reports are SQL so, unless a report is a saved ticket query, this is the only way we can convert it
+var defaultReportQueries = map[string]string{
+	"Active Tickets":                               "status!=closed",
+	"Active Tickets by Version":                    "status!=closed",
+	"Active Tickets by Milestone":                  "status!=closed",
+	"All Tickets By Milestone  (Including closed)": "",
+	"My Tickets":                                   "owner=$USER&status!=closed",
+	"Active Tickets, Mine first":                   "status!=closed",
+}
+
+// resolveReportLink resolves a Trac 'report:<reportID>' (or '{<reportID>}') link into a link to the equivalent Gitea issue list.
+// This is only possible for reports which are saved ticket queries or which are unchanged Trac default reports.
+func (converter *DefaultConverter) resolveReportLink(link *tracLink) (string, string, bool) {
+	report, err := converter.tracAccessor.GetReport(parseInt64(link.target))
+	if err != nil {
+		return "", "", false // error should already be logged
+	}
+	if report == nil {
+		converter.warn(UnresolvedLinkDiagnostic, link.source, "cannot find Trac report %s referenced by Trac link \"%s\"", link.target, link.source)
+		return "", "", false
+	}
+
+	queryText := ""
+	if strings.HasPrefix(report.Query, "query:") {
+		queryText = strings.TrimPrefix(report.Query, "query:")
+	} else if defaultQueryText, found := defaultReportQueries[report.Title]; found {
+		queryText = defaultQueryText
+	} else {
+		converter.warn(UnresolvedLinkDiagnostic, link.source, "cannot convert Trac link \"%s\": report \"%s\" is not a ticket query", link.source, report.Title)
+		return "", "", false
+	}
+
+	filter, reason := converter.giteaIssueFilter(parseTicketQuery(queryText))
+	if reason != "" {
+		converter.warn(UnresolvedLinkDiagnostic, link.source, "cannot convert Trac link \"%s\" to report \"%s\": %s", link.source, report.Title, reason)
+		return "", "", false
+	}
+
+	return converter.giteaAccessor.GetIssueListURL(filter), link.source, true
+}
+
+// matchReportShorthand matches a Trac '{<reportID>}' report link at the start of a string
+func (parser *inlineParser) matchReportShorthand(s string) (inline, int) {
+	if parser.inLinkText {
+		return nil, 0
+	}
+
+	match := reportShorthandLinkRegexp.FindStringSubmatch(s)
+	if match == nil {
+		return nil, 0
+	}
+
+	link := tracLink{kind: reportLink, source: match[0], target: match[1]}
+	return &linkInline{link: &link, source: match[0]}, len(match[0])
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+// regexp for a git commit ID (full or abbreviated)
+var gitCommitIDRegexp = regexp.MustCompile(`^[0-9a-fA-F]{4,40}$`)
+
+// minimum length of an abbreviated git commit ID which we recognise in a Trac '[<revision>]' link
+// - anything shorter is more likely to be a bracketed word or number
+const minAbbreviatedCommitIDLength = 7
+
+// length to which git commit IDs are abbreviated in link text
+const abbreviatedCommitIDLength = 7
+
+// regexp for a Subversion revision number
+var svnRevisionRegexp = regexp.MustCompile(`^r?(\d+)$`)
+
+// SetRevisionMap provides the converter with the map of Subversion revision ('r<number>') onto git commit ID used in the import.
+// This is used in the conversion of references to Trac revisions.
+func (converter *DefaultConverter) SetRevisionMap(revisionMap map[string]string) {
+	converter.revisionMap = revisionMap
+}
+
+// commitID returns the git commit ID corresponding to a Trac revision, or "" if there is none
+// - revisions of Subversion repositories are mapped through the revision map, revisions of git repositories are already commit IDs
+func (converter *DefaultConverter) commitID(revision string) string {
+	if match := svnRevisionRegexp.FindStringSubmatch(revision); match != nil {
+		if commitID, found := converter.revisionMap["r"+match[1]]; found && commitID != "" {
+			return commitID
+		}
+	}
+	if gitCommitIDRegexp.MatchString(revision) && !svnRevisionRegexp.MatchString(revision) {
+		return revision
+	}
+
+	converter.warn(UnresolvedLinkDiagnostic, revision, "no git commit found for Trac revision %s", revision)
+	return ""
+}
+
+// isRevision returns true if a string found in a Trac '[<revision>]' link is a Subversion revision number or an abbreviated git commit ID
+// - a git commit ID must contain both digits and letters to distinguish it from a bracketed word
+func isRevision(s string) bool {
+	if svnRevisionRegexp.MatchString(s) {
+		return true
+	}
+	return len(s) >= minAbbreviatedCommitIDLength &&
+		gitCommitIDRegexp.MatchString(s) &&
+		strings.ContainsAny(s, "0123456789") &&
+		strings.ContainsAny(strings.ToLower(s), "abcdef")
+}
+
+// revisionText returns the text used for a link to a Trac revision: 'r<number>' for a Subversion revision, an abbreviated commit ID for git
+func revisionText(revision string) string {
+	if match := svnRevisionRegexp.FindStringSubmatch(revision); match != nil {
+		return "r" + match[1]
+	}
+	if len(revision) > abbreviatedCommitIDLength {
+		return revision[:abbreviatedCommitIDLength]
+	}
+	return revision
+}
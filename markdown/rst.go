@@ -0,0 +1,706 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// reStructuredText constructs which we convert into markdown:
+// - sections (headings), transitions, paragraphs, block quotes and definition lists
+// - bullet and enumerated lists
+// - literal blocks ('::') and 'code', 'code-block' and 'sourcecode' directives
+// - admonition directives ('note', 'warning' etc.), 'image' directives and 'contents' directives (which are removed)
+// - simple and grid tables without spanned cells
+// - inline literals, emphasis, strong emphasis, hyperlinks (embedded, named and via targets) and ':trac:' links
+// Any other directive, and line blocks, cause the conversion to fail.
+
+// characters which can be used for reStructuredText section adornments and transitions
+const rstAdornmentCharacters = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+// regexp for a reStructuredText bullet list item
+var rstBulletItemRegexp = regexp.MustCompile(`^([-*+•])( +)\S`)
+
+// regexp for a reStructuredText enumerated list item
+var rstEnumeratedItemRegexp = regexp.MustCompile(`^(\(?)(\d+|#|[a-zA-Z]|[ivxlcdmIVXLCDM]+)([.)])( +)\S`)
+
+// regexp for a reStructuredText explicit markup block ('.. <something>')
+var rstExplicitMarkupRegexp = regexp.MustCompile(`^\.\.(?:\s|$)`)
+
+// regexp for a reStructuredText directive
+var rstDirectiveRegexp = regexp.MustCompile(`^\.\.\s+([\w-]+)::\s*(.*)$`)
+
+// regexp for a reStructuredText hyperlink target
+var rstTargetRegexp = regexp.MustCompile("^\\.\\.\\s+_(`[^`]+`|[^:]+):\\s*(.*)$")
+
+// regexp for a reStructuredText directive option
+var rstDirectiveOptionRegexp = regexp.MustCompile(`^:([\w-]+):\s*(.*)$`)
+
+// regexps for the borders of reStructuredText simple and grid tables
+var rstSimpleTableBorderRegexp = regexp.MustCompile(`^=+( +=+)+\s*$`)
+var rstGridTableBorderRegexp = regexp.MustCompile(`^\+([-=]+\+)+\s*$`)
+
+// regexp for reStructuredText inline markup
+var rstInlineRegexp = regexp.MustCompile("``(.+?)``" +
+	"|:([\\w-]+):`([^`]+)`" +
+	"|`([^`]+)`(__?|:[\\w-]+:)?" +
+	"|\\*\\*(\\S(?:.*?\\S)?)\\*\\*" +
+	"|\\*(\\S(?:[^*]*?\\S)?)\\*")
+
+// regexp for a reStructuredText simple reference name ('name_')
+var rstSimpleReferenceRegexp = regexp.MustCompile(`(^|[\s(])([A-Za-z0-9][\w.-]*[A-Za-z0-9]|[A-Za-z0-9])_($|[\s.,;:!?)])`)
+
+// regexp for a hyperlink reference with an embedded URI or alias
+var rstEmbeddedURIRegexp = regexp.MustCompile(`^(.*?)\s*<([^<>]+)>$`)
+
+// admonition directives, converted into block quotes headed by the admonition title
+var rstAdmonitions = map[string]string{
+	"attention": "Attention", "caution": "Caution", "danger": "Danger", "error": "Error", "hint": "Hint",
+	"important": "Important", "note": "Note", "tip": "Tip", "warning": "Warning", "seealso": "See also",
+}
+
+// rstConverter converts reStructuredText into markdown
+type rstConverter struct {
+	renderer       *renderer
+	targets        map[string]string
+	sectionStyles  []string
+	unsupportedErr error
+}
+
+// convertRST converts the content of a Trac '#!rst' processor from reStructuredText into markdown
+func convertRST(renderer *renderer, content []string) ([]string, error) {
+	converter := rstConverter{renderer: renderer, targets: make(map[string]string)}
+	lines := converter.collectTargets(expandTabs(content))
+	markdownLines := converter.convertBlocks(lines)
+	if converter.unsupportedErr != nil {
+		return nil, converter.unsupportedErr
+	}
+	return markdownLines, nil
+}
+
+// expandTabs replaces tabs in lines of text by spaces up to the next multiple of 8 characters
+func expandTabs(lines []string) []string {
+	expandedLines := []string{}
+	for _, line := range lines {
+		var builder strings.Builder
+		for _, char := range line {
+			if char == '\t' {
+				builder.WriteString(strings.Repeat(" ", 8-builder.Len()%8))
+			} else {
+				builder.WriteRune(char)
+			}
+		}
+		expandedLines = append(expandedLines, strings.TrimRight(builder.String(), " "))
+	}
+	return expandedLines
+}
+
+// normaliseRSTReferenceName normalises a reStructuredText reference name for lookup
+func normaliseRSTReferenceName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(strings.Trim(name, "`")), " "))
+}
+
+// collectTargets records the URLs of any hyperlink targets in some reStructuredText, returning the text with the targets removed
+func (converter *rstConverter) collectTargets(lines []string) []string {
+	remainingLines := []string{}
+	for _, line := range lines {
+		if match := rstTargetRegexp.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			converter.targets[normaliseRSTReferenceName(match[1])] = strings.TrimSpace(match[2])
+			continue
+		}
+		remainingLines = append(remainingLines, line)
+	}
+	return remainingLines
+}
+
+// unsupported records that some reStructuredText cannot be converted
+func (converter *rstConverter) unsupported(format string, args ...interface{}) {
+	if converter.unsupportedErr == nil {
+		converter.unsupportedErr = errors.Errorf("unsupported reStructuredText: "+format, args...)
+	}
+}
+
+// indentation returns the number of leading spaces of a line
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// dedent removes the common indentation from some lines
+func dedent(lines []string) []string {
+	minIndentation := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if lineIndentation := indentation(line); minIndentation == -1 || lineIndentation < minIndentation {
+			minIndentation = lineIndentation
+		}
+	}
+
+	dedentedLines := []string{}
+	for _, line := range lines {
+		if len(line) >= minIndentation && minIndentation > 0 {
+			line = line[minIndentation:]
+		}
+		dedentedLines = append(dedentedLines, line)
+	}
+	return dedentedLines
+}
+
+// indentedBlockEnd returns the index of the line after the block of lines starting at 'start' which are indented by at least minIndentation
+// - blank lines are included in the block if followed by further indented lines
+func indentedBlockEnd(lines []string, start int, minIndentation int) int {
+	end := start
+	for pos := start; pos < len(lines); pos++ {
+		if strings.TrimSpace(lines[pos]) == "" {
+			continue
+		}
+		if indentation(lines[pos]) < minIndentation {
+			break
+		}
+		end = pos + 1
+	}
+	return end
+}
+
+// isRSTAdornment returns true if a line is a reStructuredText section adornment or transition: a single punctuation character repeated at least twice
+func isRSTAdornment(line string) bool {
+	trimmedLine := strings.TrimRight(line, " ")
+	if len(trimmedLine) < 2 || !strings.ContainsRune(rstAdornmentCharacters, rune(trimmedLine[0])) {
+		return false
+	}
+	return strings.Count(trimmedLine, trimmedLine[:1]) == len(trimmedLine)
+}
+
+// isRSTBlank returns true if the line at a given position is blank or non-existent
+func isRSTBlank(lines []string, pos int) bool {
+	return pos < 0 || pos >= len(lines) || strings.TrimSpace(lines[pos]) == ""
+}
+
+// sectionLevel returns the heading level of a reStructuredText section with a given adornment style
+// - section levels are determined by the order in which adornment styles are first encountered
+func (converter *rstConverter) sectionLevel(style string) int {
+	level := 0
+	for index, sectionStyle := range converter.sectionStyles {
+		if sectionStyle == style {
+			level = index + 1
+		}
+	}
+	if level == 0 {
+		converter.sectionStyles = append(converter.sectionStyles, style)
+		level = len(converter.sectionStyles)
+	}
+	if level > 6 {
+		level = 6
+	}
+	return level
+}
+
+// appendBlock appends a block of markdown lines to some lines of markdown, separated from any preceding block by a blank line
+func appendBlock(markdownLines []string, block ...string) []string {
+	if len(markdownLines) > 0 && markdownLines[len(markdownLines)-1] != "" {
+		markdownLines = append(markdownLines, "")
+	}
+	return append(markdownLines, block...)
+}
+
+// prefixLines prefixes the first of some lines with one string and any subsequent non-blank lines with another
+func prefixLines(lines []string, firstPrefix string, prefix string) []string {
+	prefixedLines := []string{}
+	for index, line := range lines {
+		switch {
+		case index == 0:
+			prefixedLines = append(prefixedLines, firstPrefix+line)
+		case line == "":
+			prefixedLines = append(prefixedLines, "")
+		default:
+			prefixedLines = append(prefixedLines, prefix+line)
+		}
+	}
+	return prefixedLines
+}
+
+// convertBlocks converts a sequence of (dedented) reStructuredText body elements into markdown
+func (converter *rstConverter) convertBlocks(lines []string) []string {
+	markdownLines := []string{}
+	pos := 0
+	for pos < len(lines) {
+		line := lines[pos]
+		trimmedLine := strings.TrimSpace(line)
+		switch {
+		case trimmedLine == "":
+			pos++
+
+		case indentation(line) > 0:
+			// block quote
+			end := indentedBlockEnd(lines, pos, 1)
+			quoteLines := converter.convertBlocks(dedent(lines[pos:end]))
+			markdownLines = appendBlock(markdownLines, prefixLines(quoteLines, "> ", "> ")...)
+			pos = end
+
+		case isRSTAdornment(line) && pos+2 < len(lines) && !isRSTBlank(lines, pos+1) && strings.TrimSpace(lines[pos+2]) == trimmedLine:
+			// section title with overline
+			level := converter.sectionLevel(line[:1] + "overline")
+			markdownLines = appendBlock(markdownLines, strings.Repeat("#", level)+" "+converter.convertInline(strings.TrimSpace(lines[pos+1])))
+			pos += 3
+
+		case isRSTAdornment(line) && isRSTBlank(lines, pos-1) && isRSTBlank(lines, pos+1) && len(trimmedLine) >= 4:
+			// transition
+			markdownLines = appendBlock(markdownLines, "----")
+			pos++
+
+		case pos+1 < len(lines) && isRSTAdornment(lines[pos+1]) && len(strings.TrimSpace(lines[pos+1])) >= len([]rune(trimmedLine)):
+			// section title with underline
+			level := converter.sectionLevel(lines[pos+1][:1])
+			markdownLines = appendBlock(markdownLines, strings.Repeat("#", level)+" "+converter.convertInline(trimmedLine))
+			pos += 2
+
+		case rstSimpleTableBorderRegexp.MatchString(line):
+			var tableLines []string
+			tableLines, pos = converter.convertSimpleTable(lines, pos)
+			markdownLines = appendBlock(markdownLines, tableLines...)
+
+		case rstGridTableBorderRegexp.MatchString(line):
+			var tableLines []string
+			tableLines, pos = converter.convertGridTable(lines, pos)
+			markdownLines = appendBlock(markdownLines, tableLines...)
+
+		case rstBulletItemRegexp.MatchString(line) || rstEnumeratedItemRegexp.MatchString(line):
+			var listLines []string
+			listLines, pos = converter.convertList(lines, pos)
+			markdownLines = appendBlock(markdownLines, listLines...)
+
+		case rstExplicitMarkupRegexp.MatchString(line):
+			var directiveLines []string
+			directiveLines, pos = converter.convertExplicitMarkup(lines, pos)
+			if len(directiveLines) > 0 {
+				markdownLines = appendBlock(markdownLines, directiveLines...)
+			}
+
+		case strings.HasPrefix(line, "| ") || line == "|":
+			converter.unsupported("line block \"%s\"", line)
+			pos++
+
+		case pos+1 < len(lines) && indentation(lines[pos+1]) > 0 && strings.TrimSpace(lines[pos+1]) != "":
+			// definition list item
+			end := indentedBlockEnd(lines, pos+1, 1)
+			definitionLines := converter.convertBlocks(dedent(lines[pos+1 : end]))
+			markdownLines = appendBlock(markdownLines, "*"+converter.convertInline(trimmedLine)+"*  ")
+			markdownLines = append(markdownLines, definitionLines...)
+			pos = end
+
+		default:
+			var paragraphLines []string
+			paragraphLines, pos = converter.convertParagraph(lines, pos)
+			markdownLines = appendBlock(markdownLines, paragraphLines...)
+		}
+	}
+	return markdownLines
+}
+
+// convertParagraph converts a reStructuredText paragraph, including any literal block introduced by a trailing '::'
+func (converter *rstConverter) convertParagraph(lines []string, pos int) ([]string, int) {
+	paragraphLines := []string{}
+	for ; pos < len(lines) && !isRSTBlank(lines, pos) && indentation(lines[pos]) == 0; pos++ {
+		paragraphLines = append(paragraphLines, lines[pos])
+	}
+
+	// a paragraph ending in '::' introduces a literal block: a paragraph consisting solely of '::' is removed,
+	// a '::' preceded by whitespace is removed and any other '::' becomes ':'
+	hasLiteralBlock := false
+	lastLine := paragraphLines[len(paragraphLines)-1]
+	if strings.HasSuffix(lastLine, "::") {
+		hasLiteralBlock = true
+		switch {
+		case strings.TrimSpace(lastLine) == "::":
+			paragraphLines = paragraphLines[:len(paragraphLines)-1]
+		case strings.HasSuffix(lastLine, " ::"):
+			paragraphLines[len(paragraphLines)-1] = strings.TrimSuffix(lastLine, " ::")
+		default:
+			paragraphLines[len(paragraphLines)-1] = strings.TrimSuffix(lastLine, ":")
+		}
+	}
+
+	markdownLines := []string{}
+	for _, line := range paragraphLines {
+		markdownLines = append(markdownLines, converter.convertInline(line))
+	}
+
+	if hasLiteralBlock {
+		literalStart := pos
+		for literalStart < len(lines) && isRSTBlank(lines, literalStart) {
+			literalStart++
+		}
+		if literalStart < len(lines) && indentation(lines[literalStart]) > 0 {
+			end := indentedBlockEnd(lines, literalStart, 1)
+			markdownLines = appendBlock(markdownLines, converter.fencedCode("", dedent(lines[literalStart:end]))...)
+			pos = end
+		}
+	}
+
+	return markdownLines, pos
+}
+
+// fencedCode returns the markdown code block for some lines of code
+func (converter *rstConverter) fencedCode(lang string, code []string) []string {
+	fence := codeFence(code)
+	codeLines := []string{fence + lang}
+	codeLines = append(codeLines, code...)
+	return append(codeLines, fence)
+}
+
+// convertList converts a reStructuredText bullet or enumerated list
+func (converter *rstConverter) convertList(lines []string, pos int) ([]string, int) {
+	markdownLines := []string{}
+	itemNumber := 0
+	isBulletList := rstBulletItemRegexp.MatchString(lines[pos])
+	for pos < len(lines) {
+		line := lines[pos]
+		marker := ""
+		markerWidth := 0
+		if match := rstBulletItemRegexp.FindStringSubmatch(line); match != nil && isBulletList {
+			marker = "* "
+			markerWidth = len(match[1]) + len(match[2])
+		} else if match := rstEnumeratedItemRegexp.FindStringSubmatch(line); match != nil && !isBulletList {
+			itemNumber++
+			if number, err := strconv.Atoi(match[2]); err == nil && itemNumber == 1 {
+				itemNumber = number
+			}
+			marker = strconv.Itoa(itemNumber) + ". "
+			markerWidth = len(match[1]) + len(match[2]) + len(match[3]) + len(match[4])
+		} else {
+			break
+		}
+
+		// the item body is the text following the marker and any subsequent lines indented to the same level
+		end := indentedBlockEnd(lines, pos+1, markerWidth)
+		itemLines := append([]string{line[markerWidth:]}, dedent(lines[pos+1:end])...)
+		itemMarkdownLines := converter.convertBlocks(itemLines)
+		markdownLines = append(markdownLines, prefixLines(itemMarkdownLines, marker, strings.Repeat(" ", len(marker)))...)
+		pos = end
+
+		// list items can be separated by blank lines
+		next := pos
+		for next < len(lines) && isRSTBlank(lines, next) {
+			next++
+		}
+		if next < len(lines) && rstBulletItemRegexp.MatchString(lines[next]) == isBulletList && (isBulletList || rstEnumeratedItemRegexp.MatchString(lines[next])) {
+			pos = next
+		} else {
+			break
+		}
+	}
+	return markdownLines, pos
+}
+
+// convertExplicitMarkup converts a reStructuredText explicit markup block: a directive or a comment
+func (converter *rstConverter) convertExplicitMarkup(lines []string, pos int) ([]string, int) {
+	end := indentedBlockEnd(lines, pos+1, 1)
+	match := rstDirectiveRegexp.FindStringSubmatch(lines[pos])
+	if match == nil {
+		// a comment - dropped
+		return nil, end
+	}
+
+	directive := strings.ToLower(match[1])
+	argument := strings.TrimSpace(match[2])
+
+	// separate any directive options from the directive content
+	body := dedent(lines[pos+1 : end])
+	options := make(map[string]string)
+	for len(body) > 0 {
+		optionMatch := rstDirectiveOptionRegexp.FindStringSubmatch(strings.TrimSpace(body[0]))
+		if optionMatch == nil {
+			break
+		}
+		options[optionMatch[1]] = optionMatch[2]
+		body = body[1:]
+	}
+
+	switch {
+	case directive == "code" || directive == "code-block" || directive == "sourcecode":
+		for len(body) > 0 && strings.TrimSpace(body[0]) == "" {
+			body = body[1:]
+		}
+		return converter.fencedCode(argument, body), end
+
+	case directive == "image":
+		return []string{"![" + options["alt"] + "](" + argument + ")"}, end
+
+	case directive == "contents":
+		// Gitea provides its own table of contents for wiki pages
+		return nil, end
+
+	case rstAdmonitions[directive] != "":
+		admonitionLines := []string{}
+		if argument != "" {
+			admonitionLines = append(admonitionLines, argument)
+		}
+		admonitionLines = converter.convertBlocks(append(admonitionLines, body...))
+		title := "**" + rstAdmonitions[directive] + ":**"
+		if len(admonitionLines) > 0 {
+			admonitionLines[0] = title + " " + admonitionLines[0]
+		} else {
+			admonitionLines = []string{title}
+		}
+		return prefixLines(admonitionLines, "> ", "> "), end
+	}
+
+	converter.unsupported("directive \"%s\"", directive)
+	return nil, end
+}
+
+// tableRow returns the markdown for a table row
+func (converter *rstConverter) tableRow(cells []string) string {
+	row := "|"
+	for _, cell := range cells {
+		cellText := escapeTableCell(converter.convertInline(strings.TrimSpace(cell)))
+		if cellText == "" {
+			cellText = " "
+		}
+		row = row + cellText + "|"
+	}
+	return row
+}
+
+// markdownTable returns the markdown for a table with the given header and body rows - a blank header row is used if there is no header
+func (converter *rstConverter) markdownTable(header []string, rows [][]string, columnCount int) []string {
+	if header == nil {
+		header = make([]string, columnCount)
+	}
+	tableLines := []string{converter.tableRow(header), "|" + strings.Repeat("---|", columnCount)}
+	for _, row := range rows {
+		tableLines = append(tableLines, converter.tableRow(row))
+	}
+	return tableLines
+}
+
+// convertSimpleTable converts a reStructuredText simple table
+func (converter *rstConverter) convertSimpleTable(lines []string, pos int) ([]string, int) {
+	// the columns are delimited by the runs of '=' in the top border
+	border := lines[pos]
+	columns := [][2]int{}
+	for _, column := range regexp.MustCompile(`=+`).FindAllStringIndex(border, -1) {
+		columns = append(columns, [2]int{column[0], column[1]})
+	}
+
+	// split a line of text into the cell text for each column - the last column extends to the end of the line
+	splitRow := func(line string) []string {
+		cells := []string{}
+		for index, column := range columns {
+			start := column[0]
+			end := column[1]
+			if index == len(columns)-1 {
+				end = len(line)
+			}
+			if start >= len(line) {
+				cells = append(cells, "")
+				continue
+			}
+			if end > len(line) {
+				end = len(line)
+			}
+			cells = append(cells, line[start:end])
+		}
+		return cells
+	}
+
+	// collect the groups of rows between the borders
+	groups := [][][]string{}
+	group := [][]string{}
+	pos++
+	for pos < len(lines) {
+		line := lines[pos]
+		pos++
+		if rstSimpleTableBorderRegexp.MatchString(line) {
+			groups = append(groups, group)
+			group = [][]string{}
+			if isRSTBlank(lines, pos) {
+				break
+			}
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if regexp.MustCompile(`^[-\s]+$`).MatchString(line) {
+			converter.unsupported("simple table with column spans")
+			continue
+		}
+
+		cells := splitRow(line)
+		if strings.TrimSpace(cells[0]) == "" && len(group) > 0 {
+			// a row with a blank first column continues the previous row
+			previousRow := group[len(group)-1]
+			for index := range cells {
+				previousRow[index] = strings.TrimSpace(previousRow[index] + " " + strings.TrimSpace(cells[index]))
+			}
+			continue
+		}
+		group = append(group, cells)
+	}
+
+	var header []string
+	rows := [][]string{}
+	if len(groups) > 1 && len(groups[0]) > 0 {
+		header = groups[0][0]
+		groups = groups[1:]
+	}
+	for _, group := range groups {
+		rows = append(rows, group...)
+	}
+	return converter.markdownTable(header, rows, len(columns)), pos
+}
+
+// convertGridTable converts a reStructuredText grid table
+func (converter *rstConverter) convertGridTable(lines []string, pos int) ([]string, int) {
+	// the column boundaries are the '+' characters in the top border
+	border := lines[pos]
+	boundaries := []int{}
+	for index, char := range border {
+		if char == '+' {
+			boundaries = append(boundaries, index)
+		}
+	}
+	columnCount := len(boundaries) - 1
+
+	var header []string
+	rows := [][]string{}
+	row := make([]string, columnCount)
+	rowHasText := false
+	for pos++; pos < len(lines); pos++ {
+		line := strings.TrimRight(lines[pos], " ")
+		if rstGridTableBorderRegexp.MatchString(line) {
+			if line != border && strings.Replace(line, "=", "-", -1) != border {
+				converter.unsupported("grid table with spanned cells")
+			}
+			if rowHasText {
+				if strings.Contains(line, "=") && header == nil && len(rows) == 0 {
+					header = row
+				} else {
+					rows = append(rows, row)
+				}
+			}
+			row = make([]string, columnCount)
+			rowHasText = false
+			continue
+		}
+		if !strings.HasPrefix(line, "|") {
+			break
+		}
+
+		for column := 0; column < columnCount; column++ {
+			start := boundaries[column] + 1
+			end := boundaries[column+1]
+			if end >= len(line) || line[end] != '|' {
+				converter.unsupported("grid table with spanned cells")
+				break
+			}
+			if cellText := strings.TrimSpace(line[start:end]); cellText != "" {
+				row[column] = strings.TrimSpace(row[column] + " " + cellText)
+				rowHasText = true
+			}
+		}
+	}
+
+	return converter.markdownTable(header, rows, columnCount), pos
+}
+
+// convertInline converts reStructuredText inline markup into markdown
+func (converter *rstConverter) convertInline(text string) string {
+	var builder strings.Builder
+	lastEnd := 0
+	for _, match := range rstInlineRegexp.FindAllStringSubmatchIndex(text, -1) {
+		builder.WriteString(converter.convertReferences(text[lastEnd:match[0]]))
+		lastEnd = match[1]
+
+		group := func(index int) string {
+			if match[2*index] == -1 {
+				return ""
+			}
+			return text[match[2*index]:match[2*index+1]]
+		}
+
+		switch {
+		case match[2] != -1:
+			builder.WriteString(renderCodeInline(&codeInline{code: group(1)}))
+		case match[4] != -1:
+			builder.WriteString(converter.convertRole(group(2), group(3)))
+		case match[8] != -1 && strings.HasPrefix(group(5), "_"):
+			builder.WriteString(converter.convertHyperlinkReference(group(4)))
+		case match[8] != -1 && group(5) != "":
+			builder.WriteString(converter.convertRole(strings.Trim(group(5), ":"), group(4)))
+		case match[8] != -1:
+			// interpreted text in the default role
+			builder.WriteString("*" + group(4) + "*")
+		case match[12] != -1:
+			builder.WriteString("**" + group(6) + "**")
+		case match[14] != -1:
+			builder.WriteString("*" + group(7) + "*")
+		}
+	}
+	builder.WriteString(converter.convertReferences(text[lastEnd:]))
+	return builder.String()
+}
+
+// convertRole converts reStructuredText interpreted text with a given role
+func (converter *rstConverter) convertRole(role string, text string) string {
+	switch role {
+	case "trac":
+		// a Trac link, converted as if it were written in wiki text
+		return converter.renderer.renderInlines(converter.renderer.converter.parseInlines("[" + text + "]"))
+	case "code", "literal":
+		return renderCodeInline(&codeInline{code: text})
+	case "emphasis", "title-reference", "title", "t":
+		return "*" + text + "*"
+	case "strong":
+		return "**" + text + "**"
+	case "sub", "subscript":
+		return "<sub>" + text + "</sub>"
+	case "sup", "superscript":
+		return "<sup>" + text + "</sup>"
+	}
+	return text
+}
+
+// convertHyperlinkReference converts a reStructuredText hyperlink reference of the form '`text <url>`_' or '`text`_'
+func (converter *rstConverter) convertHyperlinkReference(reference string) string {
+	text := reference
+	url := ""
+	if match := rstEmbeddedURIRegexp.FindStringSubmatch(reference); match != nil {
+		text = match[1]
+		url = match[2]
+		if strings.HasSuffix(url, "_") {
+			// an alias for a named target
+			url = converter.targets[normaliseRSTReferenceName(strings.TrimSuffix(url, "_"))]
+		}
+		if text == "" {
+			text = url
+		}
+	} else {
+		url = converter.targets[normaliseRSTReferenceName(reference)]
+	}
+
+	if url == "" {
+		return text
+	}
+	return "[" + text + "](" + url + ")"
+}
+
+// convertReferences converts any reStructuredText simple references ('name_') in plain text into links to their targets
+func (converter *rstConverter) convertReferences(text string) string {
+	return rstSimpleReferenceRegexp.ReplaceAllStringFunc(text, func(reference string) string {
+		match := rstSimpleReferenceRegexp.FindStringSubmatch(reference)
+		url, found := converter.targets[normaliseRSTReferenceName(match[2])]
+		if !found {
+			return reference
+		}
+		return match[1] + "[" + match[2] + "](" + url + ")" + match[3]
+	})
+}
@@ -0,0 +1,153 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// regexp for trac 'source:', 'browser:', 'export:' and 'log:' links to a (possibly quoted) source path with optional revision and line number
+// - an 'export:' link may also give the revision before the path ('export:<revision>:<path>'):
+// $1=link type, $2=export revision, $3=quoted path, $4=quoted revision, $5=quoted line number, $6=path, $7=revision, $8=line number
+var sourcePathLinkRegexp = regexp.MustCompile(
+	`^(source|browser|log|export(?::([[:xdigit:]]+))?):` +
+		`(?:` +
+		`"([^"@#]*)(?:@([[:xdigit:]]+))?(?:#L([[:digit:]]+))?"|` +
+		`([[:alnum:]\-._~/%+]*)(?:@([[:xdigit:]]+))?(?:#L([[:digit:]]+))?` +
+		`)`)
+
+// defaultBranch is the git branch assumed for a source path which does not lie within a known Subversion branch
+const defaultBranch = "master"
+
+// svnTrunkPath and svnBranchesPath are the standard Subversion repository layout directories for the trunk and the other branches
+const svnTrunkPath = "trunk"
+const svnBranchesPath = "branches"
+
+// SetBranchMap provides the converter with a map of Subversion branch paths (e.g. 'branches/1.x') onto git branch names.
+// Paths not in the map are assumed to follow the standard Subversion layout: 'trunk' is the default git branch and 'branches/<name>' is git branch '<name>'.
+func (converter *DefaultConverter) SetBranchMap(branchMap map[string]string) {
+	converter.branchMap = branchMap
+}
+
+// sourceBranch splits a Trac source path into the git branch corresponding to the Subversion branch containing it and the path of the file within that branch.
+// Returns false if the path does not lie within a known branch.
+func (converter *DefaultConverter) sourceBranch(path string) (string, string, bool) {
+	path = strings.Trim(path, "/")
+
+	// look for the longest branch path in the branch map containing the source path
+	branch, branchPathLength := "", -1
+	for branchPath, branchName := range converter.branchMap {
+		branchPath = strings.Trim(branchPath, "/")
+		if len(branchPath) > branchPathLength && (path == branchPath || strings.HasPrefix(path, branchPath+"/")) {
+			branch, branchPathLength = branchName, len(branchPath)
+		}
+	}
+	if branchPathLength != -1 {
+		return branch, strings.TrimPrefix(path[branchPathLength:], "/"), true
+	}
+
+	pathComponents := strings.SplitN(path, "/", 3)
+	switch {
+	case pathComponents[0] == svnTrunkPath:
+		return defaultBranch, strings.TrimPrefix(path[len(svnTrunkPath):], "/"), true
+	case pathComponents[0] == svnBranchesPath && len(pathComponents) > 1 && pathComponents[1] != "":
+		if len(pathComponents) > 2 {
+			return pathComponents[1], pathComponents[2], true
+		}
+		return pathComponents[1], "", true
+	}
+
+	return defaultBranch, path, false
+}
+
+// matchSourceLink matches a Trac link to a source path at the start of a string, returning the link and its length or a nil link if there is no link
+func (converter *DefaultConverter) matchSourceLink(s string, unbracketed bool) (*tracLink, int) {
+	// the closing quote of a quoted path is not trailing punctuation
+	match := sourcePathLinkRegexp.FindStringSubmatchIndex(s)
+	if match == nil {
+		return nil, 0
+	}
+	quoted := match[6] != -1
+	if !quoted {
+		if match = matchWithoutTrailingPunctuation(sourcePathLinkRegexp, s, unbracketed); match == nil {
+			return nil, 0
+		}
+	}
+	path, revision, line := submatch(s, match, 6), submatch(s, match, 7), submatch(s, match, 8)
+	if quoted {
+		path, revision, line = submatch(s, match, 3), submatch(s, match, 4), submatch(s, match, 5)
+	} else if path == "" && revision == "" {
+		// a bare 'source:' or 'log:' is more likely to be part of a sentence than a link to the repository root
+		return nil, 0
+	}
+
+	link := tracLink{source: s[:match[1]], revision: revision}
+	switch linkType := submatch(s, match, 1); {
+	case linkType == "source" || linkType == "browser":
+		link.kind = sourceLink
+	case linkType == "log":
+		link.kind = sourceLogLink
+	default:
+		link.kind = exportLink
+		if exportRevision := submatch(s, match, 2); exportRevision != "" && revision == "" {
+			link.revision = exportRevision
+		}
+	}
+	if line != "" {
+		link.anchor = "L" + line
+	}
+
+	branch, filePath, found := converter.sourceBranch(path)
+	if !found && quoted {
+		// the first component of a quoted path outside any known branch is the name of the repository (as generated by Trac for git repositories)
+		if slashPos := strings.Index(filePath, "/"); slashPos != -1 {
+			filePath = filePath[slashPos+1:]
+		}
+	}
+	link.branch, link.target = escapeSourcePath(branch), escapeSourcePath(filePath)
+	return &link, match[1]
+}
+
+// escapeSourcePath percent-encodes each component of a source path for use in a URL.
+// Components of an unquoted Trac path may already be percent-encoded so are decoded first.
+func escapeSourcePath(path string) string {
+	components := strings.Split(path, "/")
+	for i, component := range components {
+		if unescaped, err := url.PathUnescape(component); err == nil {
+			component = unescaped
+		}
+		components[i] = url.PathEscape(component)
+	}
+	return strings.Join(components, "/")
+}
+
+func (converter *DefaultConverter) resolveSourceLink(link *tracLink) (string, string, bool) {
+	commitID := ""
+	if link.revision != "" {
+		// an unmapped revision is linked to the latest version of the file instead
+		commitID = converter.commitID(link.revision)
+	}
+
+	var sourceURL string
+	switch {
+	case link.kind == sourceLogLink:
+		sourceURL = converter.giteaAccessor.GetSourceLogURL(link.branch, link.target)
+	case link.kind == exportLink && commitID != "":
+		sourceURL = converter.giteaAccessor.GetRawCommitSourceURL(commitID, link.target)
+	case link.kind == exportLink:
+		sourceURL = converter.giteaAccessor.GetRawSourceURL(link.branch, link.target)
+	case commitID != "":
+		sourceURL = converter.giteaAccessor.GetCommitSourceURL(commitID, link.target)
+	default:
+		sourceURL = converter.giteaAccessor.GetSourceURL(link.branch, link.target)
+	}
+
+	if link.anchor != "" {
+		sourceURL = sourceURL + "#" + link.anchor
+	}
+	return sourceURL, "", true
+}
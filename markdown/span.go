@@ -0,0 +1,21 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import "strings"
+
+// writeSpanMacro writes the markdown for a Trac '[[Span(<text>,<attribute>=<value>,...)]]' macro as an HTML span.
+// The text is itself converted as wiki text and any keyword arguments which Gitea supports become attributes of the span.
+func writeSpanMacro(renderer *renderer, builder *strings.Builder, macro *macroInline) {
+	args := macro.parseArgs()
+
+	attributes := []htmlAttribute{}
+	for _, keywordArg := range args.keywords {
+		attributes = append(attributes, htmlAttribute{name: strings.ToLower(keywordArg.keyword), value: keywordArg.value})
+	}
+	builder.WriteString(htmlStartTag("span", attributes))
+	renderer.writeInlines(builder, renderer.converter.parseInlines(strings.Join(args.positional, ", ")))
+	builder.WriteString("</span>")
+}
@@ -0,0 +1,231 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stevejefferson/trac2gitea/accessor/trac"
+	"github.com/stevejefferson/trac2gitea/log"
+)
+
+// default columns of a static list of tickets (in addition to the ticket itself)
+var defaultStaticTicketQueryColumns = []string{"summary", "status", "owner", "type", "priority", "milestone"}
+
+// ticketFieldValue returns the value of a named field of a Trac ticket, or false if the field is not one we can retrieve
+func ticketFieldValue(ticket *trac.Ticket, field string) (string, bool) {
+	switch field {
+	case "id":
+		return strconv.FormatInt(ticket.TicketID, 10), true
+	case "summary":
+		return ticket.Summary, true
+	case "description":
+		return ticket.Description, true
+	case "owner":
+		return ticket.Owner, true
+	case "reporter":
+		return ticket.Reporter, true
+	case "milestone":
+		return ticket.MilestoneName, true
+	case "component":
+		return ticket.ComponentName, true
+	case "priority":
+		return ticket.PriorityName, true
+	case "resolution":
+		return ticket.ResolutionName, true
+	case "severity":
+		return ticket.SeverityName, true
+	case "type":
+		return ticket.TypeName, true
+	case "version":
+		return ticket.VersionName, true
+	case "status":
+		return ticket.Status, true
+	}
+	return "", false
+}
+
+// tracTickets returns all Trac tickets in ticket ID order
+// - the tickets are retrieved on first use so that we only incur the cost if the wiki text actually requires them
+func (converter *DefaultConverter) tracTickets() []*trac.Ticket {
+	if converter.tickets != nil {
+		return converter.tickets
+	}
+
+	converter.tickets = []*trac.Ticket{}
+	err := converter.tracAccessor.GetTickets(func(ticket *trac.Ticket) error {
+		converter.tickets = append(converter.tickets, ticket)
+		return nil
+	})
+	if err != nil {
+		log.Error("cannot retrieve Trac tickets: %+v", err)
+	}
+
+	sort.Slice(converter.tickets, func(i, j int) bool {
+		return converter.tickets[i].TicketID < converter.tickets[j].TicketID
+	})
+	return converter.tickets
+}
+
+// matchesTicketIDs returns true if a ticket ID is in a Trac ticket ID list such as '1,3,5-7'
+func matchesTicketIDs(ticketID int64, idList string) bool {
+	for _, idRange := range strings.Split(idList, ",") {
+		bounds := strings.SplitN(idRange, "-", 2)
+		lower, err := strconv.ParseInt(strings.TrimSpace(bounds[0]), 10, 64)
+		if err != nil {
+			continue
+		}
+		upper := lower
+		if len(bounds) == 2 {
+			if upper, err = strconv.ParseInt(strings.TrimSpace(bounds[1]), 10, 64); err != nil {
+				continue
+			}
+		}
+		if ticketID >= lower && ticketID <= upper {
+			return true
+		}
+	}
+	return false
+}
+
+// matches returns true if a Trac ticket satisfies a query clause
+func (clause *ticketQueryClause) matches(ticket *trac.Ticket) bool {
+	fieldValue, _ := ticketFieldValue(ticket, clause.field)
+	matched := false
+	for _, value := range clause.values {
+		switch {
+		case clause.field == "id":
+			matched = matchesTicketIDs(ticket.TicketID, value)
+		case clause.mode == containsMode:
+			matched = strings.Contains(strings.ToLower(fieldValue), strings.ToLower(value))
+		case clause.mode == startsWithMode:
+			matched = strings.HasPrefix(fieldValue, value)
+		case clause.mode == endsWithMode:
+			matched = strings.HasSuffix(fieldValue, value)
+		default:
+			matched = fieldValue == value
+		}
+		if matched {
+			break
+		}
+	}
+
+	return matched != clause.negated
+}
+
+// evaluateTicketQuery returns the Trac tickets matching a ticket query.
+// If the query cannot be evaluated, an explanation of why not is returned instead.
+func (converter *DefaultConverter) evaluateTicketQuery(query *ticketQuery) ([]*trac.Ticket, string) {
+	if query.hasOr {
+		return nil, "queries combined with 'or' cannot be evaluated"
+	}
+	for _, clause := range query.clauses {
+		if _, found := ticketFieldValue(&trac.Ticket{}, clause.field); !found {
+			return nil, fmt.Sprintf("Trac ticket field %s cannot be evaluated", clause.field)
+		}
+		for _, value := range clause.values {
+			if value == tracCurrentUser {
+				return nil, "the current Trac user cannot be evaluated"
+			}
+		}
+	}
+
+	tickets := []*trac.Ticket{}
+	for _, ticket := range converter.tracTickets() {
+		matched := true
+		for i := range query.clauses {
+			if !query.clauses[i].matches(ticket) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			tickets = append(tickets, ticket)
+		}
+	}
+
+	if query.order != "" {
+		if _, found := ticketFieldValue(&trac.Ticket{}, query.order); found {
+			sort.SliceStable(tickets, func(i, j int) bool {
+				iValue, _ := ticketFieldValue(tickets[i], query.order)
+				jValue, _ := ticketFieldValue(tickets[j], query.order)
+				return iValue < jValue
+			})
+		}
+	}
+	if query.desc {
+		for i, j := 0, len(tickets)-1; i < j; i, j = i+1, j-1 {
+			tickets[i], tickets[j] = tickets[j], tickets[i]
+		}
+	}
+	if query.max > 0 && len(tickets) > query.max {
+		tickets = tickets[:query.max]
+	}
+
+	return tickets, ""
+}
+
+// issueReferenceList returns a comma-separated list of Gitea references to the issues imported from a list of Trac tickets
+func (converter *DefaultConverter) issueReferenceList(tickets []*trac.Ticket) string {
+	if len(tickets) == 0 {
+		return "no matching issues"
+	}
+
+	references := []string{}
+	for _, ticket := range tickets {
+		references = append(references, "#"+strconv.FormatInt(converter.issueIndex(ticket.TicketID), 10))
+	}
+	return strings.Join(references, ", ")
+}
+
+// staticTicketFieldValue returns the value of a named field of a Trac ticket as shown in a static list of tickets
+// - Trac users are shown as the Gitea users onto which they are mapped, if any
+func (converter *DefaultConverter) staticTicketFieldValue(ticket *trac.Ticket, field string) string {
+	value, _ := ticketFieldValue(ticket, field)
+	if field == "owner" || field == "reporter" {
+		if giteaUser := converter.mappedGiteaUser(value); giteaUser != "" {
+			return giteaUser
+		}
+	}
+	return value
+}
+
+// renderStaticTicketQuery renders the Trac tickets matching a query as a markdown table of the corresponding Gitea issues,
+// preceded by a note explaining why the query could not be converted into a Gitea issue list
+func (renderer *renderer) renderStaticTicketQuery(query *ticketQuery, tickets []*trac.Ticket, reason string) {
+	columns := []string{}
+	for _, column := range query.columns {
+		if _, found := ticketFieldValue(&trac.Ticket{}, column); found && column != "id" {
+			columns = append(columns, column)
+		}
+	}
+	if len(columns) == 0 {
+		columns = defaultStaticTicketQueryColumns
+	}
+
+	renderer.addBlankLineSeparator()
+	renderer.addLine("*Issues matching Trac query `" + query.source + "` at the time of migration - this cannot be converted into a Gitea issue list because " + reason + ".*")
+	renderer.addLine("")
+
+	header := "|Issue|"
+	separator := "|---|"
+	for _, column := range columns {
+		header = header + strings.ToUpper(column[:1]) + column[1:] + "|"
+		separator = separator + "---|"
+	}
+	renderer.addLine(header)
+	renderer.addLine(separator)
+
+	for _, ticket := range tickets {
+		row := "|#" + strconv.FormatInt(renderer.converter.issueIndex(ticket.TicketID), 10) + "|"
+		for _, column := range columns {
+			row = row + escapeTableCell(renderer.converter.staticTicketFieldValue(ticket, column)) + "|"
+		}
+		renderer.addLine(row)
+	}
+}
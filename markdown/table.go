@@ -6,159 +6,393 @@ package markdown
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
 )
 
-var (
-	// regexp matching the first row of a trac table at the start of text
-	// $1=leading whitespace prior to first row, $2=first row starting from after first '||'
-	tableFirstRowStartOfTextRegexp = regexp.MustCompile(`(?m)\A([[:blank:]]*)\|\|([^\n]*)$`)
+// regexp for the separator between cells of a Trac table row - each additional '||' makes the following cell span a further column
+var tableCellSeparatorRegexp = regexp.MustCompile(`(?:\|\|)+`)
 
-	// regexp matching the first row of a trac table:
-	// $1=previous (non-table) line, $2=leading whitespace prior to first row, $3=first row starting from after first '||'
-	tableFirstRowRegexp = regexp.MustCompile(`(?m)^([[:blank:]]*(?:[^\|\n][^\n]*)?)$\n([[:blank:]]*)\|\|([^\n]*)$`)
+// regexp for a Trac '|----' line separating the rows of a table made up of '#!td' and '#!th' processors
+var tableRowSeparatorRegexp = regexp.MustCompile(`^\s*\|-+\s*$`)
 
-	// regexp matching entire trac table row including any intermediate cell separators:
-	// $1=leading whitespace, $2=row contents starting from after first '||'
-	tableRowRegexp = regexp.MustCompile(`(?m)([[:blank:]]*)\|\|(.*)$`)
+// alignments of a table cell
+const (
+	defaultAlignment = ""
+	leftAlignment    = "left"
+	rightAlignment   = "right"
+	centerAlignment  = "center"
 )
 
-// tracTableRowTransform is a general transformation function for identifying and processing cells in a row from a trac table
-func tracTableRowTransform(
-	tracRow string,
-	cellSeparator string,
-	actionIfCellIsHeader func(cell string) string,
-	actionIfCellNotHeader func(cell string) string) string {
-
-	// split table row into cells
-	// - input is always a trac-format row so always separated by '||'
-	// - remember that last one is the text between the terminating '||' and the end-of-line so should be skipped
-	cells := strings.Split(tracRow, "||")
-
-	result := cellSeparator
-	for i := 0; i < len(cells)-1; i++ {
-		cell := cells[i]
-		cellIsHeader := strings.HasPrefix(cell, "=") && strings.HasSuffix(cell, "=")
-		var transformedCell string
-		if cellIsHeader {
-			transformedCell = actionIfCellIsHeader(cell)
-		} else {
-			transformedCell = actionIfCellNotHeader(cell)
+// tableCell is a cell of a Trac table.
+// The content of a cell is either a line of text or, for cells defined using a '#!td' or '#!th' processor, a sequence of blocks.
+type tableCell struct {
+	header     bool
+	alignment  string
+	colspan    int
+	attributes []htmlAttribute
+	content    []inline
+	blocks     []block
+}
+
+// tableRow is a row of a Trac table
+type tableRow struct {
+	indentation string
+	cells       []*tableCell
+}
+
+// tableBlock is a Trac table
+type tableBlock struct {
+	attributes []htmlAttribute
+	rows       []*tableRow
+}
+
+func isTableRow(line string) bool {
+	return strings.HasPrefix(strings.TrimLeft(line, " \t"), "||")
+}
+
+func isTableRowSeparator(line string) bool {
+	return tableRowSeparatorRegexp.MatchString(line)
+}
+
+// tableProcessorName returns the name of the Trac processor if the current line starts a '#!td', '#!th' or '#!tr' table processor, otherwise ""
+func (parser *blockParser) tableProcessorName() string {
+	processorName := parser.codeBlockProcessorName()
+	switch processorName {
+	case "td", "th", "tr":
+		return processorName
+	}
+	return ""
+}
+
+// cellAlignment returns the alignment of a Trac table cell:
+// text sticking to just one side of the cell is aligned to that side and text with (at least) two spaces on either side is centered
+func cellAlignment(cellText string) string {
+	leadingSpace := strings.HasPrefix(cellText, " ")
+	trailingSpace := strings.HasSuffix(cellText, " ")
+	switch {
+	case !leadingSpace && trailingSpace:
+		return leftAlignment
+	case leadingSpace && !trailingSpace:
+		return rightAlignment
+	case strings.HasPrefix(cellText, "  ") && strings.HasSuffix(cellText, "  ") && len(cellText) >= 4:
+		return centerAlignment
+	}
+	return defaultAlignment
+}
+
+// parseTableCell parses the text of a cell of a Trac table row following a separator of the given length
+func (parser *blockParser) parseTableCell(cellText string, separatorLength int) *tableCell {
+	// a cell starting with '=' is a header, any '=' at the end of the cell is just the closing of the header delimiters
+	cellIsHeader := strings.HasPrefix(cellText, "=")
+	if cellIsHeader {
+		cellText = cellText[1:]
+	}
+	cellText = strings.TrimSuffix(cellText, "=")
+
+	return &tableCell{
+		header:    cellIsHeader,
+		alignment: cellAlignment(cellText),
+		colspan:   separatorLength / len("||"),
+		content:   parser.converter.parseInlines(strings.TrimSpace(cellText)),
+	}
+}
+
+// parseTableRow parses a line of a Trac table row into its cells.
+// Also returns whether the row is continued onto the next line by a trailing '\'.
+func (parser *blockParser) parseTableRow(line string) ([]*tableCell, bool) {
+	rowText := strings.TrimLeft(line, " \t")
+
+	// the text between the separators are the cells
+	cells := []*tableCell{}
+	separators := tableCellSeparatorRegexp.FindAllStringIndex(rowText, -1)
+	for separatorIndex := 0; separatorIndex < len(separators)-1; separatorIndex++ {
+		separator := separators[separatorIndex]
+		cells = append(cells, parser.parseTableCell(rowText[separator[1]:separators[separatorIndex+1][0]], separator[1]-separator[0]))
+	}
+
+	// the text after the last '||' is either a continuation marker or, as in Trac, a final cell with no closing '||'
+	lastSeparator := separators[len(separators)-1]
+	lastText := rowText[lastSeparator[1]:]
+	if strings.TrimSpace(lastText) == `\` {
+		return cells, true
+	}
+	if strings.TrimSpace(lastText) != "" {
+		// without a closing '||' there is nothing to align the text against
+		lastCell := parser.parseTableCell(lastText, lastSeparator[1]-lastSeparator[0])
+		lastCell.alignment = defaultAlignment
+		cells = append(cells, lastCell)
+	}
+	return cells, false
+}
+
+// parseTableCellProcessor parses a Trac '#!td' or '#!th' processor into a table cell containing further wiki text
+func (parser *blockParser) parseTableCellProcessor() *tableCell {
+	processor, content := parser.readCodeBlock()
+	return &tableCell{
+		header:     strings.HasPrefix(processor, "th"),
+		colspan:    1,
+		attributes: parseHTMLAttributes(processorParameters(processor)),
+		blocks:     parser.converter.parseLines(content),
+	}
+}
+
+// parseTable parses a Trac table made up of any combination of '||' rows and '#!td', '#!th' and '#!tr' processors
+func (parser *blockParser) parseTable() block {
+	table := tableBlock{}
+	parser.parseTableRows(&table, false)
+	return &table
+}
+
+// parseTableProcessor parses the content of a Trac '#!table' processor.
+// If the content is not made up entirely of table rows and cells it is treated as wiki text within an HTML table tag.
+func (converter *DefaultConverter) parseTableProcessor(processor string, content []string) block {
+	table := tableBlock{attributes: parseHTMLAttributes(processorParameters(processor))}
+	parser := blockParser{converter: converter, lines: content}
+	if !parser.parseTableRows(&table, true) {
+		return &htmlTagBlock{tag: "table", attributes: table.attributes, blocks: converter.parseLines(content), tableProcessor: processor}
+	}
+	return &table
+}
+
+// removeEmptyRows removes any rows without cells from a table (e.g. a '||' line on its own or a continued row with nothing following it)
+func (table *tableBlock) removeEmptyRows() {
+	rows := []*tableRow{}
+	for _, row := range table.rows {
+		if len(row.cells) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	table.rows = rows
+}
+
+// parseTableRows parses the rows of a Trac table into a table block.
+// Within a Trac '#!table' processor, blank lines and any other text which is not part of the table is skipped
+// (the return value indicates whether there was any such text), otherwise the table ends at the first such line.
+func (parser *blockParser) parseTableRows(table *tableBlock, inProcessor bool) bool {
+	defer table.removeEmptyRows()
+
+	onlyTableContent := true
+
+	// the row to which '#!td' cells and continuations of '||' rows are added
+	var openRow *tableRow
+
+	for line, ok := parser.currentLine(); ok; line, ok = parser.currentLine() {
+		processorName := parser.tableProcessorName()
+		switch {
+		case isTableRow(line):
+			cells, continued := parser.parseTableRow(line)
+			if openRow == nil {
+				openRow = &tableRow{indentation: leadingWhitespace(line)}
+				table.rows = append(table.rows, openRow)
+			}
+			openRow.cells = append(openRow.cells, cells...)
+			if !continued {
+				openRow = nil
+			}
+			parser.pos++
+		case processorName == "tr":
+			// the cells of a '#!tr' processor make up a row of their own
+			_, content := parser.readCodeBlock()
+			rowParser := blockParser{converter: parser.converter, lines: content}
+			rowTable := tableBlock{}
+			onlyTableContent = rowParser.parseTableRows(&rowTable, true) && onlyTableContent
+			row := tableRow{}
+			for _, rowTableRow := range rowTable.rows {
+				row.cells = append(row.cells, rowTableRow.cells...)
+			}
+			table.rows = append(table.rows, &row)
+			openRow = nil
+		case processorName != "":
+			if openRow == nil {
+				openRow = &tableRow{}
+				table.rows = append(table.rows, openRow)
+			}
+			openRow.cells = append(openRow.cells, parser.parseTableCellProcessor())
+		case isTableRowSeparator(line):
+			openRow = nil
+			parser.pos++
+		case inProcessor:
+			onlyTableContent = onlyTableContent && strings.TrimSpace(line) == ""
+			parser.pos++
+		default:
+			return onlyTableContent
 		}
+	}
+	return onlyTableContent
+}
 
-		result = result + transformedCell + cellSeparator
+// needsHTML returns true if a table cannot be represented as a markdown table
+func (table *tableBlock) needsHTML() bool {
+	if len(table.attributes) > 0 {
+		return true
 	}
-	return result
+	for _, row := range table.rows {
+		for _, cell := range row.cells {
+			if cell.colspan > 1 || cell.blocks != nil || len(cell.attributes) > 0 {
+				return true
+			}
+		}
+	}
+	return false
 }
 
-// makeFirstRowAHeader makes the first row of a trac table into a header either by making any non-header cells into header cells
-// or by inserting a blank header row if no cells are headers.
-// The result of this function is in trac table format.
-func makeFirstRowAHeader(leadingWhitespace string, tracRow string) string {
-	// determine whether any of the cells in the row are headers
-	haveAHeaderCell := false
-	tracTableRowTransform(tracRow, "||",
-		func(cell string) string {
-			haveAHeaderCell = true
-			return cell
-		},
-		func(cell string) string {
-			return cell
-		})
-
-	var header string
-	if haveAHeaderCell {
-		// first row contains at least one header: turn all cells in this row into header cells
-		header = tracTableRowTransform(tracRow, "||",
-			func(cell string) string {
-				return cell
-			},
-			func(cell string) string {
-				return "=" + cell + "=" // make non-header into a header
-			})
-	} else {
-		// first row does not contain any headers: prepend a blank header row to the table
-		header = tracTableRowTransform(tracRow, "||",
-			func(cell string) string {
-				return "= ="
-			},
-			func(cell string) string {
-				return "= ="
-			})
+// columnCount returns the number of columns in a table
+func (table *tableBlock) columnCount() int {
+	columnCount := 0
+	for _, row := range table.rows {
+		rowColumnCount := 0
+		for _, cell := range row.cells {
+			rowColumnCount += cell.colspan
+		}
+		if rowColumnCount > columnCount {
+			columnCount = rowColumnCount
+		}
+	}
+	return columnCount
+}
 
-		header = header + "\n" + leadingWhitespace + "||" + tracRow
+// columnAlignment returns the alignment of a column of a markdown table: the alignment of its non-header cells if they all agree
+func columnAlignment(rows []*tableRow, column int) string {
+	alignment := defaultAlignment
+	haveACell := false
+	for _, row := range rows {
+		if column >= len(row.cells) || row.cells[column].header || len(row.cells[column].content) == 0 {
+			continue
+		}
+		if haveACell && row.cells[column].alignment != alignment {
+			return defaultAlignment
+		}
+		alignment = row.cells[column].alignment
+		haveACell = true
 	}
+	return alignment
+}
 
-	return leadingWhitespace + header
+// alignmentMarker returns the markdown table separator for a column with the given alignment
+func alignmentMarker(alignment string) string {
+	switch alignment {
+	case leftAlignment:
+		return ":---"
+	case rightAlignment:
+		return "---:"
+	case centerAlignment:
+		return ":---:"
+	}
+	return "---"
 }
 
-func tracRowToMarkdown(leadingWhitespace string, tracRow string) string {
-	// build up markdown-format cell row plus an optional header row if any Trac '||=...=||' cells are found
-	haveAHeaderCell := false
-	cellRow := tracTableRowTransform(tracRow, "|",
-		func(cell string) string {
-			haveAHeaderCell = true
-			return cell[1 : len(cell)-1] // strip trac '=' delimiters off cell
-		},
-		func(cell string) string {
-			return cell
-		})
-	result := cellRow
+// renderTableRow renders a row of a markdown table padded out to the given number of columns.
+// If isHeaderRow is not set, any header cells are rendered in bold as markdown only supports a single header row.
+func (renderer *renderer) renderTableRow(row *tableRow, columnCount int, isHeaderRow bool) {
+	cellRow := "|"
+	for column := 0; column < columnCount; column++ {
+		cellText := " "
+		if column < len(row.cells) {
+			cell := row.cells[column]
+			cellText = escapeTableCell(renderer.renderInlines(cell.content))
+			if cell.header && !isHeaderRow && cellText != "" && !strings.HasPrefix(cellText, "**") {
+				cellText = "**" + cellText + "**"
+			}
+		}
+		cellRow = cellRow + cellText + "|"
+	}
+
+	renderer.addLine(row.indentation + cellRow)
+}
 
+// renderMarkdownTable renders a table as a markdown table
+func (renderer *renderer) renderMarkdownTable(table *tableBlock) {
+	columnCount := table.columnCount()
+
+	// the first row of the table needs to be a header for the table to render in markdown:
+	// if it contains any header cells, make all its cells into header cells otherwise prepend a blank header row to the table
+	firstRow := table.rows[0]
+	haveAHeaderCell := false
+	for _, cell := range firstRow.cells {
+		haveAHeaderCell = haveAHeaderCell || cell.header
+	}
+	bodyRows := table.rows
 	if haveAHeaderCell {
-		headerRow := tracTableRowTransform(tracRow, "|",
-			func(cell string) string {
-				return "---"
-			},
-			func(cell string) string {
-				return ""
-			})
+		renderer.renderTableRow(firstRow, columnCount, true)
+		bodyRows = table.rows[1:]
+	} else {
+		renderer.renderTableRow(&tableRow{indentation: firstRow.indentation}, columnCount, true)
+	}
 
-		result = result + "\n" + headerRow
+	separatorRow := "|"
+	for column := 0; column < columnCount; column++ {
+		separatorRow = separatorRow + alignmentMarker(columnAlignment(bodyRows, column)) + "|"
 	}
+	renderer.addLine(firstRow.indentation + separatorRow)
 
-	result = leadingWhitespace + result
-	return result
+	for _, row := range bodyRows {
+		renderer.renderTableRow(row, columnCount, false)
+	}
 }
 
-func (converter *DefaultConverter) convertTables(in string) string {
-	out := in
+// renderHTMLTableCell renders a cell of a table as HTML
+func (renderer *renderer) renderHTMLTableCell(cell *tableCell) {
+	tag := "td"
+	if cell.header {
+		tag = "th"
+	}
 
-	// First pass: examine first row of table - this will need converting to be a header for the table to render in markdown.
-	// Due to the way we phrase our regexps we need to do this in two parts - one where the first row is at the start of text
-	// and one where it is identified by a preceeding non-table line.
-	// Note: in both cases, the output of this pass is a trac-format table, conversion to markdown happens in second pass
-	out = tableFirstRowStartOfTextRegexp.ReplaceAllStringFunc(out, func(match string) string {
-		leadingWhitespace := tableFirstRowStartOfTextRegexp.ReplaceAllString(match, `$1`)
-		rowContents := tableFirstRowStartOfTextRegexp.ReplaceAllString(match, `$2`)
+	attributes := append([]htmlAttribute{}, cell.attributes...)
+	if cell.colspan > 1 {
+		attributes = append(attributes, htmlAttribute{name: "colspan", value: strconv.Itoa(cell.colspan)})
+	}
+	if cell.alignment != defaultAlignment {
+		attributes = append(attributes, htmlAttribute{name: "align", value: cell.alignment})
+	}
+	startTag := htmlStartTag(tag, attributes)
 
-		rowWithHeader := makeFirstRowAHeader(leadingWhitespace, rowContents)
-		return rowWithHeader
-	})
+	if cell.blocks == nil && len(cell.content) == 0 {
+		renderer.addLine(startTag + "</" + tag + ">")
+		return
+	}
 
-	out = tableFirstRowRegexp.ReplaceAllStringFunc(out, func(match string) string {
-		prevLine := tableFirstRowRegexp.ReplaceAllString(match, `$1`)
-		leadingWhitespace := tableFirstRowRegexp.ReplaceAllString(match, `$2`)
-		rowContents := tableFirstRowRegexp.ReplaceAllString(match, `$3`)
+	// markdown is only recognised inside HTML tags if separated from them by empty lines
+	renderer.addLine(startTag)
+	renderer.addLine("")
+	if cell.blocks != nil {
+		renderer.renderBlocks(cell.blocks)
+	} else {
+		renderer.addLine(renderer.renderLine(cell.content))
+	}
+	renderer.addLine("")
+	renderer.addLine("</" + tag + ">")
+}
 
-		// if line prior to table is non-empty, insert a newline because markdown needs the table to be separate for preceeding content
-		if prevLine != "" {
-			prevLine = prevLine + "\n"
+// renderHTMLTable renders a table as HTML - used for tables with features such as spanned cells which markdown cannot represent
+func (renderer *renderer) renderHTMLTable(table *tableBlock) {
+	renderer.addLine(htmlStartTag("table", table.attributes))
+	for _, row := range table.rows {
+		renderer.addLine("<tr>")
+		for _, cell := range row.cells {
+			renderer.renderHTMLTableCell(cell)
 		}
+		renderer.addLine("</tr>")
+	}
+	renderer.addLine("</table>")
+}
+
+func (renderer *renderer) renderTable(table *tableBlock) {
+	if len(table.rows) == 0 {
+		return
+	}
+
+	if table.needsHTML() {
+		renderer.renderHTMLTable(table)
+		return
+	}
+
+	// markdown needs the table to be separate from preceding content
+	renderer.addBlankLineSeparator()
+	renderer.renderMarkdownTable(table)
+}
 
-		rowWithHeader := makeFirstRowAHeader(leadingWhitespace, rowContents)
-		result := prevLine + "\n" + rowWithHeader
-		return result
-	})
-
-	// second pass: convert all trac table rows to markdown
-	out = tableRowRegexp.ReplaceAllStringFunc(out, func(match string) string {
-		// split row into cells
-		leadingWhitespace := tableRowRegexp.ReplaceAllString(match, `$1`)
-		rowContents := tableRowRegexp.ReplaceAllString(match, `$2`)
-		markdownRow := tracRowToMarkdown(leadingWhitespace, rowContents)
-		return markdownRow
-	})
-
-	return out
+// escapeTableCell escapes text for inclusion in a markdown table cell
+func escapeTableCell(text string) string {
+	return strings.ReplaceAll(text, "|", `\|`)
 }
@@ -31,7 +31,7 @@ func TestSingleNonHeaderRowTableHasHeaderRowPrepended(t *testing.T) {
 		"| | | |\n" +
 		"|---|---|---|\n" +
 		"|" + row1Cell1 + "|" + row1Cell2 + "|" + row1Cell3 + "|\n"
-	conversion := converter.WikiConvert(wikiPage, leadingText+"\n\n"+tracTable+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n\n"+tracTable+trailingText)
 	assertEquals(t, conversion, leadingText+"\n"+markdownTable+trailingText)
 }
 
@@ -44,7 +44,7 @@ func TestSingleNonHeaderRowTableAtStartOfTextHasHeaderRowPrepended(t *testing.T)
 		"| | | |\n" +
 			"|---|---|---|\n" +
 			"|" + row1Cell1 + "|" + row1Cell2 + "|" + row1Cell3 + "|\n"
-	conversion := converter.WikiConvert(wikiPage, tracTable+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, tracTable+trailingText)
 	assertEquals(t, conversion, markdownTable+trailingText)
 }
 
@@ -56,7 +56,7 @@ func TestSinglePartialHeaderRowTableBecomesAllHeaderRow(t *testing.T) {
 	markdownTable := "\n" +
 		"|" + row1Cell1 + "|" + row1Cell2 + "|" + row1Cell3 + "|\n" +
 		"|---|---|---|\n"
-	conversion := converter.WikiConvert(wikiPage, leadingText+"\n\n"+tracTable+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n\n"+tracTable+trailingText)
 	assertEquals(t, conversion, leadingText+"\n"+markdownTable+trailingText)
 }
 
@@ -68,7 +68,7 @@ func TestSingleAllHeaderRowTableRemainsAllHeaderRow(t *testing.T) {
 	markdownTable := "\n" +
 		"|" + row1Cell1 + "|" + row1Cell2 + "|" + row1Cell3 + "|\n" +
 		"|---|---|---|\n"
-	conversion := converter.WikiConvert(wikiPage, leadingText+"\n\n"+tracTable+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n\n"+tracTable+trailingText)
 	assertEquals(t, conversion, leadingText+"\n"+markdownTable+trailingText)
 }
 
@@ -82,7 +82,7 @@ func TestBlankLineInsertedBetweenPrevLineAndTable(t *testing.T) {
 		"|---|---|---|\n"
 
 	// note omission of "\n" in text to convert compared to prev test
-	conversion := converter.WikiConvert(wikiPage, leadingText+"\n"+tracTable+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n"+tracTable+trailingText)
 	assertEquals(t, conversion, leadingText+"\n"+markdownTable+trailingText)
 }
 
@@ -102,7 +102,7 @@ func TestMultiRowTableWithNoHeader(t *testing.T) {
 		"|" + row2Cell1 + "|" + row2Cell2 + "|" + row2Cell3 + "|\n" +
 		"|" + row3Cell1 + "|" + row3Cell2 + "|" + row3Cell3 + "|\n"
 
-	conversion := converter.WikiConvert(wikiPage, leadingText+"\n"+tracTable+"\n"+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n"+tracTable+"\n"+trailingText)
 	assertEquals(t, conversion, leadingText+"\n"+markdownTable+"\n"+trailingText)
 }
 
@@ -121,7 +121,7 @@ func TestMultiRowTableWithPartialHeader(t *testing.T) {
 		"|" + row2Cell1 + "|" + row2Cell2 + "|" + row2Cell3 + "|\n" +
 		"|" + row3Cell1 + "|" + row3Cell2 + "|" + row3Cell3 + "|\n"
 
-	conversion := converter.WikiConvert(wikiPage, leadingText+"\n"+tracTable+"\n"+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n"+tracTable+"\n"+trailingText)
 	assertEquals(t, conversion, leadingText+"\n"+markdownTable+"\n"+trailingText)
 }
 
@@ -141,7 +141,7 @@ func TestMultiRowTableWithAllHeaders(t *testing.T) {
 		"|" + row2Cell1 + "|" + row2Cell2 + "|" + row2Cell3 + "|\n" +
 		"|" + row3Cell1 + "|" + row3Cell2 + "|" + row3Cell3 + "|\n"
 
-	conversion := converter.WikiConvert(wikiPage, leadingText+"\n"+tracTable+"\n"+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n"+tracTable+"\n"+trailingText)
 	assertEquals(t, conversion, leadingText+"\n"+markdownTable+"\n"+trailingText)
 }
 
@@ -161,7 +161,7 @@ func TestTableAtStartOfText(t *testing.T) {
 		"|" + row2Cell1 + "|" + row2Cell2 + "|" + row2Cell3 + "|\n" +
 		"|" + row3Cell1 + "|" + row3Cell2 + "|" + row3Cell3 + "|"
 
-	conversion := converter.WikiConvert(wikiPage, leadingText+"\n"+tracTable)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n"+tracTable)
 	assertEquals(t, conversion, leadingText+"\n"+markdownTable)
 }
 
@@ -181,7 +181,7 @@ func TestTableAtEndOfText(t *testing.T) {
 		"|" + row2Cell1 + "|" + row2Cell2 + "|" + row2Cell3 + "|\n" +
 		"|" + row3Cell1 + "|" + row3Cell2 + "|" + row3Cell3 + "|"
 
-	conversion := converter.WikiConvert(wikiPage, leadingText+"\n"+tracTable)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n"+tracTable)
 	assertEquals(t, conversion, leadingText+"\n"+markdownTable)
 }
 
@@ -194,15 +194,38 @@ func TestMultiRowTableWithCrazyHeadings(t *testing.T) {
 			"||" + row2Cell1 + "||" + row2Cell2 + "||=" + row2Cell3 + "=||\n" +
 			"||" + row3Cell1 + "||=" + row3Cell2 + "=||" + row3Cell3 + "||\n"
 
-	// expect insertion of extra newline and for first row to be all headings regardless of input
+	// expect insertion of extra newline, for first row to be all headings regardless of input
+	// and for header cells in subsequent rows to be emboldened
 	markdownTable := "\n" +
 		"|" + row1Cell1 + "|" + row1Cell2 + "|" + row1Cell3 + "|\n" +
 		"|---|---|---|\n" +
-		"|" + row2Cell1 + "|" + row2Cell2 + "|" + row2Cell3 + "|\n" +
-		"|||---|\n" +
-		"|" + row3Cell1 + "|" + row3Cell2 + "|" + row3Cell3 + "|\n" +
-		"||---||\n"
+		"|" + row2Cell1 + "|" + row2Cell2 + "|**" + row2Cell3 + "**|\n" +
+		"|" + row3Cell1 + "|**" + row3Cell2 + "**|" + row3Cell3 + "|\n"
 
-	conversion := converter.WikiConvert(wikiPage, leadingText+"\n"+tracTable+"\n"+trailingText)
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n"+tracTable+"\n"+trailingText)
 	assertEquals(t, conversion, leadingText+"\n"+markdownTable+"\n"+trailingText)
 }
+
+func TestTableRowWithUnclosedLastCell(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	// as in Trac, the text after the last '||' is a cell even without a closing '||'
+	tracTable := "||" + row1Cell1 + "||" + row1Cell2 + "\n" +
+		"||" + row2Cell1 + "||\n"
+	markdownTable := "\n" +
+		"| | |\n" +
+		"|---|---|\n" +
+		"|" + row1Cell1 + "|" + row1Cell2 + "|\n" +
+		"|" + row2Cell1 + "| |\n"
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n\n"+tracTable+trailingText)
+	assertEquals(t, conversion, leadingText+"\n"+markdownTable+trailingText)
+}
+
+func TestTableWithoutCellsIsDropped(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+"\n\n||\n||\\\n"+trailingText)
+	assertEquals(t, conversion, leadingText+"\n\n"+trailingText)
+}
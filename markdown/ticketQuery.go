@@ -0,0 +1,472 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/stevejefferson/trac2gitea/accessor/gitea"
+)
+
+// Trac ticket query modes: the comparison made between a ticket field and a query value
+const (
+	equalsMode     = ""
+	containsMode   = "~"
+	startsWithMode = "^"
+	endsWithMode   = "$"
+)
+
+// tracCurrentUser is the Trac query value denoting the user viewing the query results
+const tracCurrentUser = "$USER"
+
+// Trac ticket statuses of open tickets in the default Trac workflow - any other status is taken to be closed
+var tracOpenStatuses = []string{"new", "assigned", "accepted", "reopened"}
+
+// Trac ticket fields which are imported as Gitea labels
+var labelTicketFields = []string{"component", "priority", "resolution", "severity", "type", "version"}
+
+// Trac ticket query parameters which control the presentation of the query results rather than selecting tickets
+var ticketQueryPresentationParams = []string{"col", "compact", "count", "desc", "format", "group", "groupdesc", "max", "order", "page", "report", "row", "rows", "verbose"}
+
+// ticketQueryClause is a single constraint of a Trac ticket query: '<field>[!][<mode>]=<value>|<value>...'
+type ticketQueryClause struct {
+	field   string
+	negated bool
+	mode    string
+	values  []string
+}
+
+// ticketQuery is a parsed Trac ticket query - either the arguments of a '[[TicketQuery]]' macro or the target of a 'query:' link
+type ticketQuery struct {
+	// source is the text of the query, excluding presentation parameters
+	source string
+
+	clauses []ticketQueryClause
+
+	// hasOr is true if the query consists of several sets of clauses combined with 'or'
+	hasOr bool
+
+	// presentation parameters
+	format  string
+	columns []string
+	order   string
+	desc    bool
+	max     int
+}
+
+// SetUserMap provides the converter with the map of Trac user onto Gitea user used in the import.
+// This is used in the conversion of Trac ticket queries referencing ticket owners and reporters.
+func (converter *DefaultConverter) SetUserMap(userMap map[string]string) {
+	converter.userMap = userMap
+}
+
+// SetLabelMaps provides the converter with the maps of Trac ticket components, priorities, resolutions, severities, types and versions onto Gitea labels used in the import.
+// This is used in the conversion of Trac ticket queries referencing those ticket fields.
+func (converter *DefaultConverter) SetLabelMaps(componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap map[string]string) {
+	converter.labelMaps = map[string]map[string]string{
+		"component":  componentMap,
+		"priority":   priorityMap,
+		"resolution": resolutionMap,
+		"severity":   severityMap,
+		"type":       typeMap,
+		"version":    versionMap,
+	}
+}
+
+func isPresentationParam(name string) bool {
+	for _, presentationParam := range ticketQueryPresentationParams {
+		if name == presentationParam {
+			return true
+		}
+	}
+	return false
+}
+
+func isOpenStatus(status string) bool {
+	for _, openStatus := range tracOpenStatuses {
+		if status == openStatus {
+			return true
+		}
+	}
+	return false
+}
+
+func isLabelTicketField(field string) bool {
+	for _, labelTicketField := range labelTicketFields {
+		if field == labelTicketField {
+			return true
+		}
+	}
+	return false
+}
+
+// unescapeQueryText decodes any URL encoding in the text of a Trac query - the text is used as is if it is not validly encoded
+func unescapeQueryText(text string) string {
+	if unescaped, err := url.QueryUnescape(text); err == nil {
+		return unescaped
+	}
+	return text
+}
+
+// parseTicketQueryClause parses a single '<name>=<value>' element of a Trac ticket query.
+// The negation and mode of a clause can either be part of the name ('status!=closed', as in a TicketQuery macro)
+// or prefix the value ('status=!closed', as in a query URL).
+func parseTicketQueryClause(name string, value string) ticketQueryClause {
+	clause := ticketQueryClause{}
+
+	modifiers := ""
+	for len(name) > 0 && strings.IndexByte("!~^$", name[len(name)-1]) != -1 {
+		modifiers = name[len(name)-1:] + modifiers
+		name = name[:len(name)-1]
+	}
+	if modifiers == "" && !strings.HasPrefix(value, tracCurrentUser) {
+		for len(value) > 0 && strings.IndexByte("!~^$", value[0]) != -1 && len(modifiers) < 2 {
+			modifiers = modifiers + value[:1]
+			value = value[1:]
+		}
+	}
+	if strings.HasPrefix(modifiers, "!") {
+		clause.negated = true
+		modifiers = modifiers[1:]
+	}
+
+	clause.field = strings.ToLower(strings.TrimSpace(name))
+	clause.mode = modifiers
+	clause.values = strings.Split(value, "|")
+	return clause
+}
+
+// parseTicketQuery parses the text of a Trac ticket query: a sequence of '<name>=<value>' elements separated by '&'
+func parseTicketQuery(text string) *ticketQuery {
+	query := ticketQuery{}
+	sourceElements := []string{}
+	for _, element := range strings.Split(strings.TrimPrefix(text, "?"), "&") {
+		element = strings.TrimSpace(element)
+		if element == "" {
+			continue
+		}
+		if element == "or" {
+			query.hasOr = true
+			sourceElements = append(sourceElements, element)
+			continue
+		}
+
+		name, value := element, ""
+		if equalsIndex := strings.Index(element, "="); equalsIndex != -1 {
+			name, value = element[:equalsIndex], element[equalsIndex+1:]
+		}
+		name = unescapeQueryText(name)
+		value = unescapeQueryText(value)
+
+		switch strings.ToLower(name) {
+		case "format":
+			query.format = value
+		case "col":
+			query.columns = append(query.columns, strings.Split(value, "|")...)
+		case "order":
+			query.order = value
+		case "desc":
+			query.desc = value == "1" || value == "true"
+		case "max":
+			query.max, _ = strconv.Atoi(value)
+		default:
+			if !isPresentationParam(strings.ToLower(name)) {
+				query.clauses = append(query.clauses, parseTicketQueryClause(name, value))
+				sourceElements = append(sourceElements, element)
+			}
+		}
+	}
+
+	query.source = strings.Join(sourceElements, "&")
+	return &query
+}
+
+// parseTicketQueryMacroArgs parses the arguments of a Trac '[[TicketQuery(<query>,<param>=<value>...)]]' macro as a ticket query.
+// Any argument other than a presentation parameter is part of the query itself.
+func parseTicketQueryMacroArgs(macro *macroInline) *ticketQuery {
+	args := macro.parseArgs()
+	elements := append([]string{}, args.positional...)
+	for _, keywordArg := range args.keywords {
+		elements = append(elements, keywordArg.keyword+"="+keywordArg.value)
+	}
+	return parseTicketQuery(strings.Join(elements, "&"))
+}
+
+// singleValue returns the single value of a query clause selecting tickets whose field equals that value,
+// or an explanation of why the clause cannot be expressed as a single value
+func (clause *ticketQueryClause) singleValue() (string, string) {
+	switch {
+	case clause.negated:
+		return "", fmt.Sprintf("Gitea cannot exclude issues by %s", clause.field)
+	case clause.mode != equalsMode:
+		return "", fmt.Sprintf("Gitea can only filter issues on an exact %s", clause.field)
+	case len(clause.values) != 1:
+		return "", fmt.Sprintf("Gitea cannot filter issues by more than one %s", clause.field)
+	case clause.values[0] == "":
+		return "", fmt.Sprintf("Gitea cannot filter issues with no %s", clause.field)
+	}
+	return clause.values[0], ""
+}
+
+// issueState returns the state ("open" or "closed") of the Gitea issues selected by a query clause on the Trac ticket status,
+// or an explanation of why the clause cannot be expressed as an issue state.
+// Gitea only distinguishes open and closed issues so a clause selecting some of the open Trac statuses selects all open issues.
+func (clause *ticketQueryClause) issueState() (string, string) {
+	if clause.mode != equalsMode {
+		return "", "Gitea can only filter issues on an exact status"
+	}
+
+	openCount := 0
+	for _, value := range clause.values {
+		if isOpenStatus(value) {
+			openCount++
+		}
+	}
+
+	state := ""
+	switch {
+	case openCount == len(clause.values):
+		state = "open"
+	case openCount == 0:
+		state = "closed"
+	default:
+		return "", "Gitea issues can only be filtered on whether they are open or closed, not on a mixture of open and closed Trac statuses"
+	}
+
+	if clause.negated {
+		if state == "open" {
+			return "", "Gitea cannot exclude open issues with particular Trac statuses"
+		}
+		state = "open"
+	}
+	return state, ""
+}
+
+// giteaLabelID returns the ID of the Gitea label onto which a value of a Trac ticket field is mapped, or gitea.NullID if there is no such label
+func (converter *DefaultConverter) giteaLabelID(field string, value string) int64 {
+	labelName := value
+	if labelMap := converter.labelMaps[field]; labelMap != nil {
+		labelName = labelMap[value]
+	}
+	if labelName == "" {
+		return gitea.NullID
+	}
+
+	labelID, err := converter.giteaAccessor.GetLabelID(labelName)
+	if err != nil {
+		return gitea.NullID // error should already be logged
+	}
+	return labelID
+}
+
+// giteaUserID returns the ID of the Gitea user onto which a Trac user is mapped, or gitea.NullID if there is no such user
+func (converter *DefaultConverter) giteaUserID(tracUser string) int64 {
+	giteaUser := tracUser
+	if converter.userMap != nil {
+		giteaUser = converter.userMap[tracUser]
+	}
+	if giteaUser == "" {
+		return gitea.NullID
+	}
+
+	userID, err := converter.giteaAccessor.GetUserID(giteaUser)
+	if err != nil {
+		return gitea.NullID // error should already be logged
+	}
+	return userID
+}
+
+// giteaIssueFilter translates a Trac ticket query into the equivalent (URL-encoded) Gitea issue list filter.
+// If the query cannot be expressed as a Gitea filter, an explanation of why not is returned instead.
+func (converter *DefaultConverter) giteaIssueFilter(query *ticketQuery) (string, string) {
+	if query.hasOr {
+		return "", "Gitea cannot combine issue filters with 'or'"
+	}
+
+	filter := url.Values{}
+	labelIDs := []string{}
+	for _, clause := range query.clauses {
+		switch {
+		case clause.field == "status":
+			state, reason := clause.issueState()
+			if reason != "" {
+				return "", reason
+			}
+			if filter.Get("state") != "" && filter.Get("state") != state {
+				return "", "the query contains contradictory status filters"
+			}
+			filter.Set("state", state)
+
+		case clause.field == "milestone":
+			milestoneName, reason := clause.singleValue()
+			if reason != "" {
+				return "", reason
+			}
+			if filter.Get("milestone") != "" {
+				return "", "Gitea cannot filter issues by more than one milestone"
+			}
+			milestoneID, err := converter.giteaAccessor.GetMilestoneID(milestoneName)
+			if err != nil || milestoneID == gitea.NullID {
+				return "", fmt.Sprintf("there is no Gitea milestone %s", milestoneName)
+			}
+			filter.Set("milestone", strconv.FormatInt(milestoneID, 10))
+
+		case isLabelTicketField(clause.field):
+			value, reason := clause.singleValue()
+			if reason != "" {
+				return "", reason
+			}
+			labelID := converter.giteaLabelID(clause.field, value)
+			if labelID == gitea.NullID {
+				return "", fmt.Sprintf("there is no Gitea label for %s %s", clause.field, value)
+			}
+			labelIDs = append(labelIDs, strconv.FormatInt(labelID, 10))
+
+		case clause.field == "owner" || clause.field == "reporter":
+			tracUser, reason := clause.singleValue()
+			if reason != "" {
+				return "", reason
+			}
+
+			// the issue list filter for the current user is determined by the type of filter, otherwise the user is given explicitly
+			filterType, userParam := "assigned", "assignee"
+			if clause.field == "reporter" {
+				filterType, userParam = "created_by", "poster"
+			}
+			if tracUser == tracCurrentUser {
+				if filter.Get("type") != "" {
+					return "", "Gitea cannot filter issues on both those assigned to and created by the current user"
+				}
+				filter.Set("type", filterType)
+				continue
+			}
+			userID := converter.giteaUserID(tracUser)
+			if userID == gitea.NullID {
+				return "", fmt.Sprintf("there is no Gitea user for Trac user %s", tracUser)
+			}
+			filter.Set(userParam, strconv.FormatInt(userID, 10))
+
+		default:
+			return "", fmt.Sprintf("Gitea cannot filter issues by %s", clause.field)
+		}
+	}
+
+	if len(labelIDs) > 0 {
+		filter.Set("labels", strings.Join(labelIDs, ","))
+	}
+
+	// Trac queries include closed tickets unless told otherwise, Gitea issue lists do not
+	if filter.Get("state") == "" {
+		filter.Set("state", "all")
+	}
+
+	return filter.Encode(), ""
+}
+
+// resolveQueryLink resolves a Trac 'query:...' link into a link to the equivalent Gitea issue list
+func (converter *DefaultConverter) resolveQueryLink(link *tracLink) (string, string, bool) {
+	query := parseTicketQuery(link.target)
+	filter, reason := converter.giteaIssueFilter(query)
+	if reason != "" {
+		converter.warn(UnresolvedLinkDiagnostic, link.source, "cannot convert Trac ticket query link \"%s\": %s", link.source, reason)
+		return "", "", false
+	}
+
+	return converter.giteaAccessor.GetIssueListURL(filter), link.source, true
+}
+
+// writeTicketQueryMacro writes the markdown for a Trac '[[TicketQuery]]' macro within a line of text.
+// This is a link to the equivalent Gitea issue list or, for a query that only counts tickets, the number of tickets if there is no such list.
+func writeTicketQueryMacro(renderer *renderer, builder *strings.Builder, macro *macroInline) {
+	query := parseTicketQueryMacroArgs(macro)
+	filter, reason := renderer.converter.giteaIssueFilter(query)
+	if reason == "" {
+		builder.WriteString(renderer.converter.ticketQueryLink(query, filter))
+		return
+	}
+
+	if query.format != "count" {
+		renderer.writeUnconvertedMacro(builder, macro, "not converted: "+reason+" so the query results can only be listed when the macro is on a line of its own")
+		return
+	}
+
+	tickets, evaluationReason := renderer.converter.evaluateTicketQuery(query)
+	if evaluationReason != "" {
+		renderer.writeUnconvertedMacro(builder, macro, "not converted: "+reason+" and "+evaluationReason)
+		return
+	}
+	renderer.converter.warn(StaticTicketQueryDiagnostic, macro.tracText(), "Trac ticket query %s converted into a static count of tickets: %s", macro.tracText(), reason)
+	builder.WriteString(strconv.Itoa(len(tickets)))
+}
+
+// renderTicketQueryMacro renders a Trac '[[TicketQuery]]' macro on a line of its own.
+// This is a link to the equivalent Gitea issue list or, if there is no such list, a static table of the tickets matching the query at the time of conversion.
+func renderTicketQueryMacro(renderer *renderer, macro *macroInline) {
+	query := parseTicketQueryMacroArgs(macro)
+	filter, reason := renderer.converter.giteaIssueFilter(query)
+	if reason == "" {
+		renderer.addLine(renderer.converter.ticketQueryLink(query, filter))
+		return
+	}
+
+	tickets, evaluationReason := renderer.converter.evaluateTicketQuery(query)
+	if evaluationReason != "" {
+		renderer.renderUnconvertedMacro(macro, "not converted: "+reason+" and "+evaluationReason)
+		return
+	}
+
+	renderer.converter.warn(StaticTicketQueryDiagnostic, macro.tracText(), "Trac ticket query %s converted into a static list of tickets: %s", macro.tracText(), reason)
+	renderer.renderStaticTicketQuery(query, tickets, reason)
+}
+
+// writeQueryLink writes a Trac 'query:...' link within a line of text.
+// This is a link to the equivalent Gitea issue list or, if there is no such list, the link text followed by references to the issues matching the query at the time of conversion.
+func (renderer *renderer) writeQueryLink(builder *strings.Builder, node *linkInline) {
+	query := parseTicketQuery(node.link.target)
+	filter, reason := renderer.converter.giteaIssueFilter(query)
+	if reason == "" {
+		renderer.writeResolvedLink(builder, node, renderer.converter.giteaAccessor.GetIssueListURL(filter), node.link.source)
+		return
+	}
+
+	tickets, evaluationReason := renderer.converter.evaluateTicketQuery(query)
+	if evaluationReason != "" {
+		renderer.converter.warn(UnresolvedLinkDiagnostic, node.link.source, "cannot convert Trac ticket query link \"%s\": %s and %s", node.link.source, reason, evaluationReason)
+		builder.WriteString(node.source)
+		return
+	}
+
+	renderer.converter.warn(StaticTicketQueryDiagnostic, node.link.source, "Trac ticket query link \"%s\" converted into a static list of issues: %s", node.link.source, reason)
+	if node.text != nil {
+		renderer.writeInlines(builder, node.text)
+	} else {
+		builder.WriteString(ticketQueryDescription(query))
+	}
+	builder.WriteString(" (" + renderer.converter.issueReferenceList(tickets) + ")")
+}
+
+// ticketQueryDescription returns a description of the issues matching a Trac ticket query
+func ticketQueryDescription(query *ticketQuery) string {
+	if query.source == "" {
+		return "all issues"
+	}
+	return "issues matching `" + query.source + "`"
+}
+
+// ticketQueryLink returns a markdown link to the Gitea issue list equivalent to a Trac ticket query given the Gitea issue list filter.
+// The link text describes the query or, for a query that only counts tickets, is the number of matching tickets at the time of conversion.
+func (converter *DefaultConverter) ticketQueryLink(query *ticketQuery, filter string) string {
+	linkText := ticketQueryDescription(query)
+	if query.format == "count" {
+		if tickets, evaluationReason := converter.evaluateTicketQuery(query); evaluationReason == "" {
+			linkText = strconv.Itoa(len(tickets))
+		}
+	}
+
+	return "[" + linkText + "](" + converter.giteaAccessor.GetIssueListURL(filter) + ")"
+}
@@ -0,0 +1,210 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown_test
+
+import (
+	"testing"
+
+	"github.com/stevejefferson/trac2gitea/accessor/trac"
+	"github.com/stevejefferson/trac2gitea/markdown"
+	"go.uber.org/mock/gomock"
+)
+
+const (
+	queryMilestoneName = "milestone1"
+	queryMilestoneID   = int64(33)
+	queryLabelName     = "label1"
+	queryLabelID       = int64(44)
+	issueListURL       = "http://example.com/issues"
+)
+
+func expectToRetrieveMilestoneID(t *testing.T, milestoneName string, milestoneID int64) {
+	mockGiteaAccessor.
+		EXPECT().
+		GetMilestoneID(milestoneName).
+		Return(milestoneID, nil)
+}
+
+func expectToRetrieveLabelID(t *testing.T, labelName string, labelID int64) {
+	mockGiteaAccessor.
+		EXPECT().
+		GetLabelID(labelName).
+		Return(labelID, nil)
+}
+
+func expectToRetrieveIssueListURL(t *testing.T, query string) {
+	mockGiteaAccessor.
+		EXPECT().
+		GetIssueListURL(query).
+		Return(issueListURL + "?" + query)
+}
+
+func expectTracToReturnTickets(t *testing.T, tickets ...*trac.Ticket) {
+	mockTracAccessor.
+		EXPECT().
+		GetTickets(gomock.Any()).
+		DoAndReturn(func(handlerFn func(ticket *trac.Ticket) error) error {
+			for _, ticket := range tickets {
+				handlerFn(ticket)
+			}
+			return nil
+		})
+}
+
+func expectTracToReturnReport(t *testing.T, reportID int64, report *trac.Report) {
+	mockTracAccessor.
+		EXPECT().
+		GetReport(reportID).
+		Return(report, nil)
+}
+
+func TestTicketQueryMacro(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	expectToRetrieveMilestoneID(t, queryMilestoneName, queryMilestoneID)
+	expectToRetrieveIssueListURL(t, "milestone=33&state=open")
+
+	conversion, _ := converter.WikiConvert(wikiPage, "[[TicketQuery(milestone="+queryMilestoneName+"&status!=closed,format=table)]]")
+	assertEquals(t, conversion, "[issues matching `milestone="+queryMilestoneName+"&status!=closed`]("+issueListURL+"?milestone=33&state=open)")
+}
+
+func TestTicketQueryMacroWithLabelField(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	converter.SetLabelMaps(map[string]string{"ui": queryLabelName}, nil, nil, nil, nil, nil)
+	expectToRetrieveLabelID(t, queryLabelName, queryLabelID)
+	expectToRetrieveIssueListURL(t, "labels=44&state=all")
+
+	conversion, _ := converter.WikiConvert(wikiPage, "[[TicketQuery(component=ui)]]")
+	assertEquals(t, conversion, "[issues matching `component=ui`]("+issueListURL+"?labels=44&state=all)")
+}
+
+func TestComplexTicketQueryMacro(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	converter.SetUserMap(map[string]string{"bob": "robert"})
+	expectTracToReturnTickets(t,
+		&trac.Ticket{TicketID: 2, Summary: "summary 2", Owner: "alice", Status: "new"},
+		&trac.Ticket{TicketID: 1, Summary: "summary 1", Owner: "bob", Status: "assigned"},
+		&trac.Ticket{TicketID: 3, Summary: "summary 3", Owner: "bob", Status: "closed"})
+	converter.SetIssueIndexMap(map[int64]int64{1: 101, 2: 102, 3: 103})
+
+	conversion, _ := converter.WikiConvert(wikiPage, "[[TicketQuery(status=new|assigned&summary~=summary,col=summary|owner)]]")
+	assertEquals(t, conversion,
+		"*Issues matching Trac query `status=new|assigned&summary~=summary` at the time of migration - "+
+			"this cannot be converted into a Gitea issue list because Gitea cannot filter issues by summary.*\n"+
+			"\n"+
+			"|Issue|Summary|Owner|\n"+
+			"|---|---|---|\n"+
+			"|#101|summary 1|robert|\n"+
+			"|#102|summary 2|alice|")
+}
+
+func TestOpenStatusTicketQueryMacro(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	expectToRetrieveIssueListURL(t, "state=open")
+
+	conversion, _ := converter.WikiConvert(wikiPage, "[[TicketQuery(status=new|assigned|reopened)]]")
+	assertEquals(t, conversion, "[issues matching `status=new|assigned|reopened`]("+issueListURL+"?state=open)")
+}
+
+func TestMixedStatusTicketQueryMacro(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, "[[TicketQuery(status=new|closed&keywords~=fast)]]")
+	assertEquals(t, conversion, "<!-- Trac macro [[TicketQuery(status=new|closed&keywords~=fast)]] not converted: "+
+		"Gitea issues can only be filtered on whether they are open or closed, not on a mixture of open and closed Trac statuses and Trac ticket field keywords cannot be evaluated -->")
+}
+
+func TestUnevaluableTicketQueryMacro(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, "[[TicketQuery(keywords~=fast)]]")
+	assertEquals(t, conversion, "<!-- Trac macro [[TicketQuery(keywords~=fast)]] not converted: Gitea cannot filter issues by keywords and Trac ticket field keywords cannot be evaluated -->")
+}
+
+func TestQueryLink(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	expectToRetrieveIssueListURL(t, "state=closed&type=assigned")
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+" query:?status=closed&owner=$USER. "+trailingText)
+	assertEquals(t, conversion, leadingText+" [query:?status=closed&owner=$USER]("+issueListURL+"?state=closed&type=assigned). "+trailingText)
+}
+
+func TestOpenStatusQueryLink(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	converter.SetLabelMaps(map[string]string{"ui": "user-interface"}, nil, nil, nil, nil, nil)
+	expectToRetrieveLabelID(t, "user-interface", 44)
+	expectToRetrieveIssueListURL(t, "labels=44&state=open")
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+" query:?status=new&component=ui "+trailingText)
+	assertEquals(t, conversion, leadingText+" [query:?status=new&component=ui]("+issueListURL+"?labels=44&state=open) "+trailingText)
+}
+
+func TestStaticQueryLink(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	expectTracToReturnTickets(t,
+		&trac.Ticket{TicketID: 1, Summary: "fast start"},
+		&trac.Ticket{TicketID: 2, Summary: "slow start"},
+		&trac.Ticket{TicketID: 3, Summary: "faster rendering"})
+
+	conversion, diagnostics := converter.WikiConvert(wikiPage, leadingText+" query:?summary~=fast and [query:?summary~=none no tickets] "+trailingText)
+	assertEquals(t, conversion, leadingText+" issues matching `summary~=fast` (#1, #3) and no tickets (no matching issues) "+trailingText)
+	assertEquals(t, len(diagnostics), 2)
+	assertEquals(t, diagnostics[0].Kind, markdown.StaticTicketQueryDiagnostic)
+}
+
+func TestUnconvertibleQueryLink(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+" query:?keywords~=fast "+trailingText)
+	assertEquals(t, conversion, leadingText+" query:?keywords~=fast "+trailingText)
+}
+
+func TestReportLinkToSavedQuery(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	expectTracToReturnReport(t, 9, &trac.Report{ReportID: 9, Title: "Closed", Query: "query:?status=closed"})
+	expectToRetrieveIssueListURL(t, "state=closed")
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+" [report:9 closed tickets] "+trailingText)
+	assertEquals(t, conversion, leadingText+" [closed tickets]("+issueListURL+"?state=closed) "+trailingText)
+}
+
+func TestReportShorthandLinkToDefaultReport(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	expectTracToReturnReport(t, 1, &trac.Report{ReportID: 1, Title: "Active Tickets", Query: "SELECT ..."})
+	expectToRetrieveIssueListURL(t, "state=open")
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+" {1} "+trailingText)
+	assertEquals(t, conversion, leadingText+" [{1}]("+issueListURL+"?state=open) "+trailingText)
+}
+
+func TestReportLinkToSQLReport(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	expectTracToReturnReport(t, 12, &trac.Report{ReportID: 12, Title: "Custom", Query: "SELECT ..."})
+
+	conversion, _ := converter.WikiConvert(wikiPage, leadingText+" report:12 "+trailingText)
+	assertEquals(t, conversion, leadingText+" report:12 "+trailingText)
+}
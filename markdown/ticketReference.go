@@ -0,0 +1,52 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Gitea only recognises issue references delimited by whitespace, certain brackets or punctuation (markdown emphasis delimiters do not appear in the rendered text)
+var (
+	issueReferenceStartRegexp = regexp.MustCompile(`(?:^|[\s(\[*_~])$`)
+	issueReferenceEndRegexp   = regexp.MustCompile(`^(?:$|[\s)\]*_~]|[:;,.?!](?:$|\s))`)
+)
+
+// TicketReferences returns the IDs of the Trac tickets referenced by ticket links in a comment/description string associated with a Trac ticket.
+// Each ticket is returned once, in order of first reference, and references by the ticket to itself are omitted.
+func (converter *DefaultConverter) TicketReferences(ticketID int64, in string) []int64 {
+	blocks := converter.parse(converter.convertEOL(in))
+
+	references := []int64{}
+	found := make(map[int64]bool)
+	found[ticketID] = true
+	walkInlines(blocks, func(node inline) {
+		if linkNode, ok := node.(*linkInline); ok && linkNode.link.kind == ticketLink && !found[linkNode.link.ticketID] {
+			found[linkNode.link.ticketID] = true
+			references = append(references, linkNode.link.ticketID)
+		}
+	})
+	return references
+}
+
+// issueReference returns the Gitea-native reference ("#N" or "owner/repo#N") for a resolved Trac ticket link, or "" if the link is not to a ticket.
+// Gitea renders such references as links itself, which keeps them valid if the repository is renamed or transferred.
+func (converter *DefaultConverter) issueReference(link *tracLink) string {
+	switch link.kind {
+	case ticketLink:
+		return "#" + strconv.FormatInt(converter.issueIndex(link.ticketID), 10)
+	case interTracTicketLink:
+		repoName := converter.interTracRepos[strings.ToLower(link.interTracPrefix)]
+		return converter.giteaAccessor.GetRepoIssueReference(repoName, converter.interTracIssueIndex(repoName, link.ticketID))
+	}
+	return ""
+}
+
+// isIssueReferenceBoundary returns whether a Gitea issue reference written between two pieces of text would be recognised as such
+func isIssueReferenceBoundary(precedingText string, followingText string) bool {
+	return issueReferenceStartRegexp.MatchString(precedingText) && issueReferenceEndRegexp.MatchString(followingText)
+}
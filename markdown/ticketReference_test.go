@@ -0,0 +1,44 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown_test
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTicketLinkAsIssueReference(t *testing.T) {
+	setUpTicketOnlyLink(t)
+	defer tearDown(t)
+
+	// Gitea recognises references delimited by brackets and followed by punctuation
+	conversion := ticketConvert("See (ticket:" + ticketIDStr + "), fixed.")
+	assertEquals(t, conversion, "See (#"+ticketIDStr+"), fixed.")
+}
+
+func TestTicketLinkNotRecognisableAsIssueReference(t *testing.T) {
+	setUpTicketOnlyLink(t)
+	defer tearDown(t)
+
+	// a reference followed by other text would not be recognised by Gitea so an ordinary link is used
+	conversion := ticketConvert("See [ticket:" + ticketIDStr + "]s")
+	assertEquals(t, conversion, "See ["+issueURL+"]("+issueURL+")s")
+}
+
+func TestTicketReferences(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	references := converter.TicketReferences(ticketID, "See ticket:12, [ticket:34 ticket] and '''ticket:12'''.\n * ticket:56 and ticket:"+ticketIDStr)
+	assertEquals(t, fmt.Sprint(references), "[12 34 56]")
+}
+
+func TestTicketReferencesIgnoresCode(t *testing.T) {
+	setUp(t)
+	defer tearDown(t)
+
+	references := converter.TicketReferences(ticketID, "Not `ticket:12` or\n{{{\nticket:34\n}}}\nor !ticket:56")
+	assertEquals(t, len(references), 0)
+}
@@ -0,0 +1,25 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import "strings"
+
+// renderTitleIndexMacro renders a Trac '[[TitleIndex(<prefix>)]]' macro as a list of links to the imported wiki pages whose names start with the prefix
+// - the list is static: it only contains those pages present at the time of the conversion
+func renderTitleIndexMacro(renderer *renderer, macro *macroInline) {
+	prefix := macro.parseArgs().arg(0)
+
+	renderer.addBlankLineSeparator()
+	for _, page := range renderer.converter.importedWikiPages() {
+		if !strings.HasPrefix(page.Name, prefix) {
+			continue
+		}
+
+		var builder strings.Builder
+		builder.WriteString("* ")
+		renderer.writeWikiPageLink(&builder, page.Name)
+		renderer.addLine(builder.String())
+	}
+}
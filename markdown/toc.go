@@ -4,11 +4,117 @@
 
 package markdown
 
-import "regexp"
+import (
+	"strconv"
+	"strings"
+)
 
-var tocRegexp = regexp.MustCompile(`\[\[TOC(?:\(.*\))?\]\]\s*`)
+// writeTOCMacro writes the markdown for a Trac '[[TOC]]' macro
+// - there is no markdown equivalent and Gitea provides its own table of contents for wiki pages so the macro is just removed
+func writeTOCMacro(renderer *renderer, builder *strings.Builder, macro *macroInline) {
+}
+
+// renderTOCMacro renders a Trac '[[TOC]]' macro on a line of its own - the line is removed altogether
+func renderTOCMacro(renderer *renderer, macro *macroInline) {
+}
+
+// collectHeadings returns all headings in a sequence of blocks, including those nested inside other blocks
+func collectHeadings(blocks []block) []*headingBlock {
+	headings := []*headingBlock{}
+	for _, node := range blocks {
+		switch node := node.(type) {
+		case *headingBlock:
+			headings = append(headings, node)
+		case *htmlTagBlock:
+			headings = append(headings, collectHeadings(node.blocks)...)
+		case *tableBlock:
+			for _, row := range node.rows {
+				for _, cell := range row.cells {
+					headings = append(headings, collectHeadings(cell.blocks)...)
+				}
+			}
+		}
+	}
+	return headings
+}
+
+// plainText returns the text of a sequence of inlines without any formatting
+func plainText(inlines []inline) string {
+	var builder strings.Builder
+	for _, node := range inlines {
+		switch node := node.(type) {
+		case *textInline:
+			builder.WriteString(node.text)
+		case *codeInline:
+			builder.WriteString(node.code)
+		case *styledInline:
+			builder.WriteString(plainText(node.content))
+		case *anchorInline:
+			builder.WriteString(plainText(node.label))
+		case *mentionInline:
+			builder.WriteString(node.source)
+		case *replyInline:
+			builder.WriteString(node.source)
+		case *linkInline:
+			if node.text != nil {
+				builder.WriteString(plainText(node.text))
+			} else {
+				builder.WriteString(node.source)
+			}
+		}
+	}
+	return builder.String()
+}
+
+// parsePageOutlineDepth parses the heading levels argument of a Trac '[[PageOutline]]' macro: either '<level>' or '<min level>-<max level>'
+func parsePageOutlineDepth(depth string) (int, int) {
+	minLevel, maxLevel := 1, 6
+	if depth == "" {
+		return minLevel, maxLevel
+	}
+
+	minDepth, maxDepth := depth, depth
+	if dashIndex := strings.Index(depth, "-"); dashIndex != -1 {
+		minDepth, maxDepth = depth[:dashIndex], depth[dashIndex+1:]
+	}
+	if level, err := strconv.Atoi(strings.TrimSpace(minDepth)); err == nil && level >= 1 && level <= 6 {
+		minLevel = level
+	}
+	if level, err := strconv.Atoi(strings.TrimSpace(maxDepth)); err == nil && level >= minLevel && level <= 6 {
+		maxLevel = level
+	}
+	return minLevel, maxLevel
+}
+
+// renderPageOutlineMacro renders a Trac '[[PageOutline(<depth>,<title>,<style>)]]' macro as a list of links to the headings of the page
+func renderPageOutlineMacro(renderer *renderer, macro *macroInline) {
+	args := macro.parseArgs()
+	minLevel, maxLevel := parsePageOutlineDepth(args.arg(0))
+	title := args.arg(1)
+	numbered := args.arg(2) != "unnumbered"
+
+	renderer.addBlankLineSeparator()
+	if title != "" {
+		renderer.addLine("**" + title + "**")
+		renderer.addLine("")
+	}
+
+	anchors := markdownHeadingAnchors(renderer.headings)
+	for index, heading := range renderer.headings {
+		if heading.level < minLevel || heading.level > maxLevel {
+			continue
+		}
+
+		text := plainText(heading.content)
+		anchor := anchors[index]
 
-func (converter *DefaultConverter) removeTOC(in string) string {
-	// Remove [[TOC]] special mark
-	return tocRegexp.ReplaceAllString(in, "")
+		// nested ordered list items must be indented further than their parent's list marker
+		indentation := strings.Repeat("  ", heading.level-minLevel)
+		marker := "*"
+		if numbered {
+			indentation = strings.Repeat("   ", heading.level-minLevel)
+			marker = "1."
+		}
+		renderer.addLine(indentation + marker + " [" + text + "](#" + anchor + ")")
+	}
 }
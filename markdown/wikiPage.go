@@ -0,0 +1,61 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/stevejefferson/trac2gitea/accessor/trac"
+	"github.com/stevejefferson/trac2gitea/log"
+)
+
+// SetConvertPredefineds tells the converter whether Trac's predefined wiki pages are being converted.
+// If not, the predefined pages are omitted from any lists of wiki pages generated by the conversion of Trac macros.
+func (converter *DefaultConverter) SetConvertPredefineds(convertPredefineds bool) {
+	converter.convertPredefineds = convertPredefineds
+}
+
+// latestWikiPages returns the latest version of each Trac wiki page, indexed by page name
+// - the pages are retrieved on first use so that we only incur the cost if the wiki text actually requires them
+func (converter *DefaultConverter) latestWikiPages() map[string]*trac.WikiPage {
+	if converter.wikiPages != nil {
+		return converter.wikiPages
+	}
+
+	converter.wikiPages = make(map[string]*trac.WikiPage)
+	err := converter.tracAccessor.GetWikiPages(func(page *trac.WikiPage) error {
+		// pages are retrieved in time order so any later version replaces an earlier one
+		converter.wikiPages[page.Name] = page
+		return nil
+	})
+	if err != nil {
+		log.Error("cannot retrieve Trac wiki pages: %+v", err)
+	}
+
+	return converter.wikiPages
+}
+
+// importedWikiPages returns the latest version of each Trac wiki page which is imported into Gitea, in page name order
+func (converter *DefaultConverter) importedWikiPages() []*trac.WikiPage {
+	pages := []*trac.WikiPage{}
+	for pageName, page := range converter.latestWikiPages() {
+		if !converter.convertPredefineds && converter.tracAccessor.IsPredefinedPage(pageName) {
+			continue
+		}
+		pages = append(pages, page)
+	}
+
+	sort.Slice(pages, func(i, j int) bool {
+		return pages[i].Name < pages[j].Name
+	})
+	return pages
+}
+
+// writeWikiPageLink writes a link to a wiki page, using the Trac page name as the link text
+func (renderer *renderer) writeWikiPageLink(builder *strings.Builder, pageName string) {
+	link := tracLink{kind: wikiLink, source: "wiki:" + pageName, target: pageName}
+	renderer.writeLink(builder, &linkInline{link: &link, text: []inline{&textInline{text: pageName}}, source: link.source}, "")
+}
@@ -0,0 +1,359 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"github.com/stevejefferson/trac2gitea/accessor/gitea"
+	"github.com/stevejefferson/trac2gitea/accessor/trac"
+	"github.com/stevejefferson/trac2gitea/importer"
+	"github.com/stevejefferson/trac2gitea/log"
+	"github.com/stevejefferson/trac2gitea/markdown"
+)
+
+// previewCommand is the subcommand previewing the conversion of Trac wiki text into markdown
+const previewCommand = "preview"
+
+// previewPageName is the name of the wiki page as which text read from stdin is converted
+const previewPageName = "WikiStart"
+
+// previewSection is a piece of Trac wiki text (a wiki page, ticket description or ticket comment) along with its conversion into markdown
+type previewSection struct {
+	Title        string
+	TracText     string
+	MarkdownText string
+	Diagnostics  []markdown.Diagnostic
+}
+
+// createPreviewSection creates a preview section from a piece of Trac wiki text and its conversion
+func createPreviewSection(title string, tracText string, markdownText string, diagnostics []markdown.Diagnostic) *previewSection {
+	return &previewSection{Title: title, TracText: tracText, MarkdownText: markdownText, Diagnostics: diagnostics}
+}
+
+// previewHTMLDiffTemplate is the template of the HTML page showing each piece of Trac wiki text side by side with its markdown conversion
+var previewHTMLDiffTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>trac2gitea preview</title>
+<style>
+table { border-collapse: collapse; width: 100%; table-layout: fixed; }
+th, td { border: 1px solid #ccc; padding: 4px; vertical-align: top; }
+th.section { background: #eee; text-align: left; }
+pre { margin: 0; white-space: pre-wrap; word-wrap: break-word; }
+</style>
+</head>
+<body>
+<table>
+<tr><th>Trac</th><th>Markdown</th></tr>
+{{- range .}}
+<tr><th class="section" colspan="2">{{.Title}}</th></tr>
+<tr><td><pre>{{.TracText}}</pre></td><td><pre>{{.MarkdownText}}</pre></td></tr>
+{{- if .Diagnostics}}
+<tr><td colspan="2"><ul>
+{{- range .Diagnostics}}
+<li><b>{{.Kind}}</b>: {{.Message}}</li>
+{{- end}}
+</ul></td></tr>
+{{- end}}
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// previewUsage prints the usage of the preview subcommand
+func previewUsage(flags *pflag.FlagSet) {
+	fmt.Fprintf(os.Stderr,
+		"Usage: %s %s [options] [<trac-root>] [<file>]\n"+
+			"Converts Trac wiki text into markdown without accessing Gitea, printing the markdown.\n"+
+			"The text is read from <file> (or stdin if no file or '-' is given) unless --wiki-page or --ticket is used to read it from the Trac environment at <trac-root>.\n"+
+			"<trac-root> is optional when converting a file or stdin: without it there are no InterWiki or InterTrac prefixes and no ticket lookups.\n"+
+			"Any Trac markup which cannot be converted is reported on stderr.\n",
+		os.Args[0], previewCommand)
+	fmt.Fprintf(os.Stderr, "Options:\n")
+	flags.PrintDefaults()
+}
+
+// runPreview runs the preview subcommand with the given arguments
+func runPreview(args []string) error {
+	flags := pflag.NewFlagSet(previewCommand, pflag.ExitOnError)
+	wikiPageParam := flags.String("wiki-page", "",
+		"convert the latest version of the named Trac wiki page")
+	ticketParam := flags.Int64("ticket", trac.NullID,
+		"convert the description and comments of the Trac ticket with this ID")
+	giteaOrgParam := flags.String("gitea-org", "org",
+		"Gitea user or organisation used in the placeholder URLs of the converted links")
+	giteaRepoParam := flags.String("gitea-repo", "repo",
+		"Gitea repository used in the placeholder URLs of the converted links")
+	userMapParam := flags.String("user-map", "",
+		"file mapping Trac users onto Gitea users - by default no Trac user is mapped")
+	labelMapParam := flags.String("label-map", "",
+		"file mapping Trac component, priority, resolution, severity, type and version names onto Gitea labels - by default each maps onto a label of the same name")
+	revisionMapParam := flags.String("revision-map", "",
+		"file mapping Subversion revisions onto git commits")
+	branchMapParam := flags.String("branch-map", "",
+		"file mapping Subversion branch paths onto git branch names")
+	interTracMapParam := flags.String("intertrac-map", "",
+		"file mapping Trac InterTrac environment names onto Gitea repositories")
+	issueMapParam := flags.String("issue-map", "",
+		"file mapping Trac ticket IDs onto Gitea issue indexes")
+	outputParam := flags.String("output", "",
+		"file into which to write the markdown - defaults to stdout")
+	htmlDiffParam := flags.String("html-diff", "",
+		"file into which to write an HTML page showing the Trac text side by side with its markdown conversion")
+	verboseParam := flags.Bool("verbose", false,
+		"verbose output (log messages are written to stdout so use --output to keep them separate from the markdown)")
+	flags.Usage = func() { previewUsage(flags) }
+	flags.Parse(args)
+
+	if *wikiPageParam != "" && *ticketParam != trac.NullID {
+		return errors.New("cannot preview both a wiki page AND a ticket!")
+	}
+	readFromTrac := *wikiPageParam != "" || *ticketParam != trac.NullID
+	if flags.NArg() > 2 || (readFromTrac && flags.NArg() != 1) {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	log.SetLevel(log.ERROR)
+	if *verboseParam {
+		log.SetLevel(log.TRACE)
+	}
+
+	// a single argument is the Trac root if it is a directory (or the text is read from Trac), otherwise it is the file to convert
+	tracRootDir, fileName := flags.Arg(0), flags.Arg(1)
+	if flags.NArg() == 1 && !readFromTrac {
+		if stat, err := os.Stat(tracRootDir); err != nil || !stat.IsDir() {
+			tracRootDir, fileName = "", flags.Arg(0)
+		}
+	}
+
+	var tracAccessor trac.Accessor = trac.CreateEmptyAccessor()
+	if tracRootDir != "" {
+		defaultAccessor, err := trac.CreateDefaultAccessor(tracRootDir)
+		if err != nil {
+			return err
+		}
+		tracAccessor = defaultAccessor
+	}
+	markdownConverter, err := createPreviewConverter(tracAccessor, *giteaOrgParam, *giteaRepoParam,
+		*userMapParam, *labelMapParam, *revisionMapParam, *branchMapParam, *interTracMapParam, *issueMapParam)
+	if err != nil {
+		return err
+	}
+
+	var sections []*previewSection
+	switch {
+	case *wikiPageParam != "":
+		sections, err = previewWikiPage(tracAccessor, markdownConverter, *wikiPageParam)
+	case *ticketParam != trac.NullID:
+		sections, err = previewTicket(tracAccessor, markdownConverter, *ticketParam)
+	default:
+		sections, err = previewFile(markdownConverter, fileName)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = writePreviewMarkdown(*outputParam, sections); err != nil {
+		return err
+	}
+	writePreviewDiagnostics(sections)
+	if *htmlDiffParam != "" {
+		return writePreviewHTMLDiff(*htmlDiffParam, sections)
+	}
+	return nil
+}
+
+// createPreviewConverter creates a markdown converter which uses a Gitea preview accessor in place of a real Gitea instance, configured with the provided map files
+func createPreviewConverter(tracAccessor trac.Accessor, giteaOrg, giteaRepo,
+	userMapFile, labelMapFile, revisionMapFile, branchMapFile, interTracMapFile, issueMapFile string) (*markdown.DefaultConverter, error) {
+	giteaAccessor := gitea.CreatePreviewAccessor(giteaOrg, giteaRepo)
+	markdownConverter := markdown.CreateDefaultConverter(tracAccessor, giteaAccessor)
+	markdownConverter.SetConvertPredefineds(true)
+	if err := markdownConverter.LoadInterWikiMap(); err != nil {
+		return nil, err
+	}
+
+	// the importer is only needed for the default label maps
+	dataImporter, err := importer.CreateImporter(tracAccessor, giteaAccessor, markdownConverter, giteaOrg, true)
+	if err != nil {
+		return nil, err
+	}
+	// without a real Gitea no Trac user can be matched to a Gitea user, so there is no default user map
+	userMap := map[string]string{}
+	if userMapFile != "" {
+		userMap, err = readUserMap(userMapFile, dataImporter)
+		if err != nil {
+			return nil, err
+		}
+	}
+	componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap, err := readLabelMaps(labelMapFile, dataImporter)
+	if err != nil {
+		return nil, err
+	}
+	markdownConverter.SetUserMap(userMap)
+	markdownConverter.SetLabelMaps(componentMap, priorityMap, resolutionMap, severityMap, typeMap, versionMap)
+
+	interTracMap, _, err := readInterTracMap(interTracMapFile)
+	if err != nil {
+		return nil, err
+	}
+	if interTracMap != nil {
+		markdownConverter.SetInterTracMap(interTracMap)
+	}
+
+	issueMap, err := readIssueMap(issueMapFile)
+	if err != nil {
+		return nil, err
+	}
+	markdownConverter.SetIssueIndexMap(issueMap)
+
+	revisionMap, err := readRevisionMap(revisionMapFile)
+	if err != nil {
+		return nil, err
+	}
+	markdownConverter.SetRevisionMap(revisionMap)
+
+	branchMap, err := readBranchMap(branchMapFile)
+	if err != nil {
+		return nil, err
+	}
+	markdownConverter.SetBranchMap(branchMap)
+
+	return markdownConverter, nil
+}
+
+// previewFile converts the Trac wiki text in a file (or stdin if the file is "" or "-") as the text of a wiki page named after the file
+func previewFile(markdownConverter *markdown.DefaultConverter, fileName string) ([]*previewSection, error) {
+	var text []byte
+	var err error
+	pageName := previewPageName
+	if fileName == "" || fileName == "-" {
+		text, err = io.ReadAll(os.Stdin)
+	} else {
+		text, err = os.ReadFile(fileName)
+		pageName = strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading Trac wiki text to preview")
+	}
+
+	tracText := string(text)
+	markdownText, diagnostics := markdownConverter.WikiConvert(pageName, tracText)
+	return []*previewSection{createPreviewSection(pageName, tracText, markdownText, diagnostics)}, nil
+}
+
+// previewWikiPage converts the latest version of a Trac wiki page
+func previewWikiPage(tracAccessor trac.Accessor, markdownConverter *markdown.DefaultConverter, pageName string) ([]*previewSection, error) {
+	var latestPage *trac.WikiPage
+	err := tracAccessor.GetWikiPages(func(page *trac.WikiPage) error {
+		if page.Name == pageName && (latestPage == nil || page.Version > latestPage.Version) {
+			latestPage = page
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if latestPage == nil {
+		return nil, errors.Errorf("cannot find Trac wiki page %s", pageName)
+	}
+
+	title := fmt.Sprintf("wiki page %s (version %d)", pageName, latestPage.Version)
+	markdownText, diagnostics := markdownConverter.WikiConvert(pageName, latestPage.Text)
+	return []*previewSection{createPreviewSection(title, latestPage.Text, markdownText, diagnostics)}, nil
+}
+
+// previewTicket converts the description and comments of a Trac ticket
+func previewTicket(tracAccessor trac.Accessor, markdownConverter *markdown.DefaultConverter, ticketID int64) ([]*previewSection, error) {
+	var sections []*previewSection
+	err := tracAccessor.GetTickets(func(ticket *trac.Ticket) error {
+		if ticket.TicketID == ticketID {
+			title := fmt.Sprintf("ticket %d description", ticketID)
+			markdownText, diagnostics := markdownConverter.TicketConvert(ticketID, ticket.Description)
+			sections = append(sections, createPreviewSection(title, ticket.Description, markdownText, diagnostics))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(sections) == 0 {
+		return nil, errors.Errorf("cannot find Trac ticket %d", ticketID)
+	}
+
+	err = tracAccessor.GetTicketChanges(ticketID, func(change *trac.TicketChange) error {
+		if change.ChangeType != trac.TicketCommentChange {
+			return nil
+		}
+
+		// Trac records the comment number as the old value of the change: '<number>' or, for a reply, '<replied-to-number>.<number>'
+		commentNum := change.OldValue[strings.LastIndex(change.OldValue, ".")+1:]
+		title := fmt.Sprintf("ticket %d comment %s by %s", ticketID, commentNum, change.Author)
+		markdownText, diagnostics := markdownConverter.TicketConvert(ticketID, change.NewValue)
+		sections = append(sections, createPreviewSection(title, change.NewValue, markdownText, diagnostics))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sections, nil
+}
+
+// writePreviewMarkdown writes the markdown of the previewed sections to the provided file or, if no file is provided, to stdout
+// - where there are several sections, each is introduced by an HTML comment giving its title
+func writePreviewMarkdown(outputFile string, sections []*previewSection) error {
+	var builder strings.Builder
+	for index, section := range sections {
+		if len(sections) > 1 {
+			if index > 0 {
+				builder.WriteString("\n")
+			}
+			builder.WriteString("<!-- " + section.Title + " -->\n")
+		}
+		builder.WriteString(strings.TrimRight(section.MarkdownText, "\n") + "\n")
+	}
+
+	if outputFile == "" {
+		_, err := os.Stdout.WriteString(builder.String())
+		return err
+	}
+	return os.WriteFile(outputFile, []byte(builder.String()), 0644)
+}
+
+// writePreviewDiagnostics writes the diagnostics of any Trac markup in the previewed sections which could not be converted to stderr
+func writePreviewDiagnostics(sections []*previewSection) {
+	for _, section := range sections {
+		for _, diagnostic := range section.Diagnostics {
+			fmt.Fprintf(os.Stderr, "%s: %s: %s\n", section.Title, diagnostic.Kind, diagnostic.Message)
+		}
+	}
+}
+
+// writePreviewHTMLDiff writes an HTML page showing the Trac text of each previewed section side by side with its markdown
+func writePreviewHTMLDiff(htmlFile string, sections []*previewSection) error {
+	fd, err := os.Create(htmlFile)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	if err = previewHTMLDiffTemplate.Execute(fd, sections); err != nil {
+		return errors.Wrapf(err, "writing HTML preview %s", htmlFile)
+	}
+	log.Info("wrote HTML preview to %s", htmlFile)
+	return nil
+}
@@ -0,0 +1,160 @@
+// Copyright 2020 Steve Jefferson. All rights reserved.
+// Use of this source code is governed by a GPL-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/stevejefferson/trac2gitea/importer"
+	"github.com/stevejefferson/trac2gitea/log"
+)
+
+// supported redirect map formats
+const (
+	nginxRedirectFormat  = "nginx"
+	apacheRedirectFormat = "apache"
+	csvRedirectFormat    = "csv"
+)
+
+// isValidRedirectFormat returns true if the provided redirect map format is supported
+func isValidRedirectFormat(format string) bool {
+	return format == nginxRedirectFormat || format == apacheRedirectFormat || format == csvRedirectFormat
+}
+
+// redirectWriter writes redirects to a redirect map file in a given format
+type redirectWriter interface {
+	writeRedirect(redirect *importer.Redirect) error
+	close() error
+}
+
+// nginxRedirectWriter writes redirects as the entries of an nginx 'map' block
+type nginxRedirectWriter struct {
+	out io.Writer
+}
+
+func (writer *nginxRedirectWriter) writeRedirect(redirect *importer.Redirect) error {
+	_, err := fmt.Fprintf(writer.out, "%s %s;\n", quoteNginxString(redirect.TracPath), quoteNginxString(redirect.GiteaURL))
+	return err
+}
+
+func (writer *nginxRedirectWriter) close() error {
+	return nil
+}
+
+// quoteNginxString returns a string quoted for use in an nginx configuration file
+func quoteNginxString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// apacheRedirectWriter writes redirects as an Apache RewriteMap text file
+type apacheRedirectWriter struct {
+	out io.Writer
+}
+
+func (writer *apacheRedirectWriter) writeRedirect(redirect *importer.Redirect) error {
+	if strings.ContainsAny(redirect.TracPath, " \t") {
+		log.Warn("Trac path %s contains whitespace which cannot be represented in an Apache RewriteMap - no redirect written", redirect.TracPath)
+		return nil
+	}
+
+	_, err := fmt.Fprintf(writer.out, "%s %s\n", redirect.TracPath, redirect.GiteaURL)
+	return err
+}
+
+func (writer *apacheRedirectWriter) close() error {
+	return nil
+}
+
+// csvRedirectWriter writes redirects as CSV
+type csvRedirectWriter struct {
+	out *csv.Writer
+}
+
+func (writer *csvRedirectWriter) writeRedirect(redirect *importer.Redirect) error {
+	return writer.out.Write([]string{redirect.TracPath, redirect.GiteaURL})
+}
+
+func (writer *csvRedirectWriter) close() error {
+	writer.out.Flush()
+	return writer.out.Error()
+}
+
+// createRedirectWriter creates a writer for the provided redirect map format, writing any header required by the format
+func createRedirectWriter(out io.Writer, format string) (redirectWriter, error) {
+	switch format {
+	case nginxRedirectFormat:
+		_, err := fmt.Fprint(out,
+			"# nginx map of Trac paths to Gitea URLs generated by trac2gitea - use with e.g.\n"+
+				"#   map $uri $trac_redirect { include <this-file>; }\n"+
+				"#   if ($trac_redirect) { return 301 $trac_redirect; }\n")
+		return &nginxRedirectWriter{out: out}, err
+
+	case apacheRedirectFormat:
+		_, err := fmt.Fprint(out,
+			"# Apache RewriteMap of Trac paths to Gitea URLs generated by trac2gitea - use with e.g.\n"+
+				"#   RewriteMap tracmap txt:<this-file>\n"+
+				"#   RewriteCond ${tracmap:%{REQUEST_URI}} !=\"\"\n"+
+				"#   RewriteRule ^ ${tracmap:%{REQUEST_URI}} [R=301,L]\n")
+		return &apacheRedirectWriter{out: out}, err
+
+	case csvRedirectFormat:
+		csvWriter := csv.NewWriter(out)
+		err := csvWriter.Write([]string{"trac_path", "gitea_url"})
+		return &csvRedirectWriter{out: csvWriter}, err
+	}
+
+	return nil, fmt.Errorf("unsupported redirect map format %s", format)
+}
+
+// writeRedirectMapToFile writes redirects from the Trac URLs of the imported data to their Gitea URLs into the provided file in the provided format.
+// URL fragments are not sent to web servers so redirects to ticket comments are only written in CSV format.
+func writeRedirectMapToFile(mapFile string, format string, dataImporter *importer.Importer) error {
+	fd, err := os.Create(mapFile)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	writer, err := createRedirectWriter(fd, format)
+	if err != nil {
+		return err
+	}
+
+	redirectCount := 0
+	handlerFn := func(redirect *importer.Redirect) error {
+		if format != csvRedirectFormat && strings.Contains(redirect.TracPath, "#") {
+			return nil
+		}
+		redirectCount++
+		return writer.writeRedirect(redirect)
+	}
+
+	if !wikiOnly {
+		if err = dataImporter.GenerateTicketRedirects(handlerFn); err != nil {
+			return err
+		}
+		if err = dataImporter.GenerateMilestoneRedirects(handlerFn); err != nil {
+			return err
+		}
+	}
+	if !dbOnly {
+		if err = dataImporter.GenerateWikiRedirects(handlerFn); err != nil {
+			return err
+		}
+	}
+
+	if err = writer.close(); err != nil {
+		return err
+	}
+
+	log.Info("wrote %d redirects to %s", redirectCount, mapFile)
+	return nil
+}